@@ -0,0 +1,184 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/signer"
+)
+
+// emptyPayloadHash 为空请求体的 SHA-256，MinIO 管理接口要求签名包含请求体的哈希
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// adminClient 调用 MinIO 管理接口（mc admin 使用的 /minio/admin/v3），需要凭证具有 admin:DataUsageInfo 和 admin:StorageInfo 权限
+type adminClient struct {
+	endpoint string
+	region   string
+	creds    *credentials.Credentials
+	client   *http.Client
+}
+
+// dataUsageInfo 为管理接口返回的数据用量，由 MinIO 的后台扫描定期更新，不是实时统计
+type dataUsageInfo struct {
+	LastUpdate   time.Time `json:"lastUpdate"`
+	BucketsUsage map[string]struct {
+		Size         int64 `json:"size"`
+		ObjectsCount int64 `json:"objectsCount"`
+	} `json:"bucketsUsageInfo"`
+}
+
+// newAdminClient 为开启 adminAPI 的集群创建管理接口客户端，使用与对象操作相同的凭证和传输层配置
+func newAdminClient(cfg *config.Config, creds *credentials.Credentials) (*adminClient, error) {
+	tr, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if cfg.Minio.UseSSL {
+		scheme = "https"
+	}
+	return &adminClient{
+		endpoint: scheme + "://" + cfg.Minio.Endpoint,
+		region:   cmp.Or(cfg.Minio.Region, "us-east-1"),
+		creds:    creds,
+		client:   &http.Client{Transport: tr},
+	}, nil
+}
+
+// get 以 GET 请求调用管理接口，将 JSON 响应解析到 v。MinIO 返回的错误转换为 minio.ErrorResponse
+func (a *adminClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.endpoint+"/minio/admin/v3/"+path, nil)
+	if err != nil {
+		return err
+	}
+	value, err := a.creds.Get()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	req = signer.SignV4(*req, value.AccessKeyID, value.SecretAccessKey, value.SessionToken, a.region)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		e := minio.ErrorResponse{StatusCode: resp.StatusCode}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Code == "" {
+			e.Code, e.Message = http.StatusText(resp.StatusCode), resp.Status
+		}
+		return e
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// dataUsage 查询集群上各存储桶的数据用量
+func (a *adminClient) dataUsage(ctx context.Context) (*dataUsageInfo, error) {
+	var info dataUsageInfo
+	if err := a.get(ctx, "datausageinfo", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// freeSpace 查询集群所有磁盘的剩余空间之和。这是磁盘上的原始空间，纠删码的校验块也占用其中一部分
+func (a *adminClient) freeSpace(ctx context.Context) (int64, error) {
+	var info struct {
+		Disks []struct {
+			AvailableSpace int64 `json:"availspace"`
+		} `json:"disks"`
+	}
+	if err := a.get(ctx, "storageinfo", &info); err != nil {
+		return 0, err
+	}
+	var free int64
+	for _, d := range info.Disks {
+		free += d.AvailableSpace
+	}
+	return free, nil
+}
+
+// prioritizeTargets 返回按存储桶大小从大到小排列的清理目标，占用空间最多的存储桶最先清理。
+// 存储桶大小来自开启 adminAPI 的集群的数据用量，其余目标和查询失败的集群上的目标按原来的顺序排在后面
+func prioritizeTargets(ctx context.Context, targets []Target) []Target {
+	sizes := make(map[*config.Config]int64, len(targets))
+	queried := make(map[string]bool)
+	for _, t := range targets {
+		cluster := t.cfg.Minio.Name
+		if t.admin == nil || queried[cluster] {
+			continue
+		}
+		queried[cluster] = true
+		var usage *dataUsageInfo
+		err := withTimeout(ctx, "admin", t.cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			usage, err = t.admin.dataUsage(ctx)
+			return err
+		})
+		if err != nil {
+			slog.Warn(tr(t.cfg, msgAdminUsageFailed, cluster, err), "cluster", cluster, "action", "admin", "error", err)
+			continue
+		}
+		var ranked []Target
+		for _, o := range targets {
+			if u, ok := usage.BucketsUsage[o.cfg.Minio.Bucket]; ok && o.cfg.Minio.Name == cluster {
+				sizes[o.cfg] = u.Size
+				ranked = append(ranked, o)
+			}
+		}
+		slices.SortStableFunc(ranked, func(x, y Target) int { return cmp.Compare(sizes[y.cfg], sizes[x.cfg]) })
+		names := make([]string, len(ranked))
+		for i, o := range ranked {
+			names[i] = fmt.Sprintf("%s (%.2f GB)", o.cfg.Minio.Bucket, float64(sizes[o.cfg])/1024/1024/1024)
+		}
+		slog.Info(tr(t.cfg, msgAdminUsage, cluster, usage.LastUpdate.Format(time.DateTime), strings.Join(names, ", ")),
+			"cluster", cluster, "action", "admin", "lastUpdate", usage.LastUpdate)
+	}
+	if len(sizes) == 0 {
+		return targets
+	}
+	ordered := slices.Clone(targets)
+	slices.SortStableFunc(ordered, func(x, y Target) int {
+		sx, okx := sizes[x.cfg]
+		sy, oky := sizes[y.cfg]
+		switch {
+		case okx && oky:
+			return cmp.Compare(sy, sx)
+		case okx:
+			return -1
+		case oky:
+			return 1
+		}
+		return 0
+	})
+	return ordered
+}
+
+// freeSpace 查询清理前后集群的剩余空间，没有开启 adminAPI 或查询失败时返回 false，查询失败只记录警告
+func (c *cleaner) freeSpace(ctx context.Context) (int64, bool) {
+	if c.admin == nil {
+		return 0, false
+	}
+	cfg := c.cfg
+	var free int64
+	err := withTimeout(ctx, "admin", cfg.Timeouts.Stat, func(ctx context.Context) error {
+		var err error
+		free, err = c.admin.freeSpace(ctx)
+		return err
+	})
+	if err != nil {
+		slog.Warn(tr(cfg, msgAdminSpaceFailed, cfg.Minio.Name, err), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "admin", "error", err)
+		return 0, false
+	}
+	return free, true
+}
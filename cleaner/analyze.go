@@ -0,0 +1,267 @@
+package cleaner
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// ageBucket 描述一个文件年龄区间，maxDays 为 0 表示不设上限
+type ageBucket struct {
+	label   string
+	maxDays int
+}
+
+// ageBuckets 为分析报告中使用的文件年龄区间，按从新到旧排列
+var ageBuckets = []ageBucket{
+	{"<7d", 7},
+	{"7-30d", 30},
+	{"30-90d", 90},
+	{"90-365d", 365},
+	{">365d", 0},
+}
+
+// ageBucketIndex 返回对象年龄所在的区间序号
+func ageBucketIndex(lastModified, now time.Time) int {
+	age := now.Sub(lastModified)
+	for i, b := range ageBuckets {
+		if b.maxDays > 0 && age < time.Duration(b.maxDays)*24*time.Hour {
+			return i
+		}
+	}
+	return len(ageBuckets) - 1
+}
+
+// ageHistogramWidth 为控制台输出中年龄分布直方图的最大宽度
+const ageHistogramWidth = 30
+
+// ageHistogramBar 按字节数占最大区间的比例生成直方图条
+func ageHistogramBar(bytes, maxBytes int64) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	return strings.Repeat("#", int(bytes*ageHistogramWidth/maxBytes))
+}
+
+// analysisReport 是 analyze 命令输出的存储桶构成报告
+type analysisReport struct {
+	Bucket         string          `json:"bucket"`
+	StartTime      time.Time       `json:"startTime"`
+	EndTime        time.Time       `json:"endTime"`
+	TotalFiles     int64           `json:"totalFiles"`
+	TotalBytes     int64           `json:"totalBytes"`
+	ErrorCount     int64           `json:"errorCount"`
+	Prefixes       []report.Prefix `json:"prefixes"`
+	Extensions     []groupReport   `json:"extensions"`
+	Ages           []report.Age    `json:"ages"`
+	LargestObjects []report.Object `json:"largestObjects"`
+	OldestObjects  []report.Object `json:"oldestObjects"`
+}
+
+// groupReport 是按某一维度分组的文件数和字节数
+type groupReport struct {
+	Name  string `json:"name"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// oldestHeap 是按修改时间排序的堆，堆顶为最新的对象，用于保留最旧的若干个对象
+type oldestHeap struct{ objectHeap }
+
+func (h oldestHeap) Less(i, j int) bool {
+	return h.objectHeap[i].LastModified.After(h.objectHeap[j].LastModified)
+}
+
+// offer 加入一个对象，堆中超过 limit 个对象时淘汰最新的一个
+func (h *oldestHeap) offer(o report.Object, limit int) {
+	if h.Len() < limit {
+		heap.Push(h, o)
+		return
+	}
+	if o.LastModified.Before(h.objectHeap[0].LastModified) {
+		h.objectHeap[0] = o
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted 返回按修改时间从旧到新排列的对象列表
+func (h oldestHeap) sorted() []report.Object {
+	out := make([]report.Object, len(h.objectHeap))
+	copy(out, h.objectHeap)
+	sort.Slice(out, func(i, j int) bool { return out[i].LastModified.Before(out[j].LastModified) })
+	return out
+}
+
+// objectExtension 返回对象名的扩展名（小写，含 .），没有扩展名时返回空字符串
+func objectExtension(key string) string {
+	return strings.ToLower(path.Ext(path.Base(key)))
+}
+
+// sortGroups 将分组按字节数从大到小排序，并只保留前 limit 个
+func sortGroups(m map[string]*prefixStats, limit int) []groupReport {
+	groups := make([]groupReport, 0, len(m))
+	for name, s := range m {
+		groups = append(groups, groupReport{Name: name, Files: s.files, Bytes: s.bytes})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Bytes > groups[j].Bytes })
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+	return groups
+}
+
+// addGroup 将对象计入 m 中 name 对应的分组
+func addGroup(m map[string]*prefixStats, name string, size int64) {
+	s := groupEntry(m, name)
+	s.files++
+	s.bytes += size
+}
+
+// runAnalyze 列举存储桶中的所有对象并统计其构成，不会删除任何文件。
+// top 为各排行列出的条数
+func runAnalyze(ctx context.Context, cfg *config.Config, store objectStore, top int) error {
+	bucket := cfg.Minio.Bucket
+	startTime := time.Now()
+	slog.Info(tr(cfg, msgAnalyzeStart, bucket), "bucket", bucket, "action", "analyze")
+
+	rep := &analysisReport{
+		Bucket:    bucket,
+		StartTime: startTime,
+	}
+	prefixes := make(map[string]*prefixStats)
+	extensions := make(map[string]*prefixStats)
+	ages := make([]report.Age, len(ageBuckets))
+	for i, b := range ageBuckets {
+		ages[i].Age = b.label
+	}
+	var largest objectHeap
+	var oldest oldestHeap
+
+	lastProgress := time.Now()
+	listObjects(ctx, store, bucket, minio.ListObjectsOptions{Recursive: true}, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		if obj.Err != nil {
+			rep.ErrorCount++
+			slog.Error(tr(cfg, msgListError, obj.Err), "bucket", bucket, "action", "list", "error", obj.Err)
+			return
+		}
+		rep.TotalFiles++
+		rep.TotalBytes += obj.Size
+
+		addGroup(prefixes, topPrefix(obj.Key), obj.Size)
+		addGroup(extensions, objectExtension(obj.Key), obj.Size)
+		a := &ages[ageBucketIndex(obj.LastModified, startTime)]
+		a.Files++
+		a.Bytes += obj.Size
+
+		o := report.Object{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified}
+		largest.offer(o, top)
+		oldest.offer(o, top)
+
+		if time.Since(lastProgress) >= 10*time.Second {
+			lastProgress = time.Now()
+			slog.Info(tr(cfg, msgAnalyzeProgress, rep.TotalFiles, float64(rep.TotalBytes)/1024/1024),
+				"bucket", bucket, "action", "progress", "processed", rep.TotalFiles, "size", rep.TotalBytes)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rep.EndTime = time.Now()
+	for _, g := range sortGroups(prefixes, top) {
+		rep.Prefixes = append(rep.Prefixes, report.Prefix{Prefix: g.Name, Files: g.Files, Bytes: g.Bytes})
+	}
+	rep.Extensions = sortGroups(extensions, top)
+	rep.Ages = ages
+	rep.LargestObjects = largest.sorted()
+	rep.OldestObjects = oldest.sorted()
+
+	slog.Info(tr(cfg, msgAnalyzeFinish, rep.TotalFiles, float64(rep.TotalBytes)/1024/1024),
+		"bucket", bucket, "action", "finish", "total", rep.TotalFiles, "size", rep.TotalBytes)
+
+	printAnalysis(cfg, os.Stdout, rep)
+
+	if cfg.Report.AnalyzeFile != "" {
+		path := expandReportName(cfg.Report.AnalyzeFile, startTime)
+		data, err := json.MarshalIndent(rep, "", "  ")
+		if err == nil {
+			err = report.WriteFile(path, data)
+		}
+		if err != nil {
+			slog.Error(tr(cfg, msgReportFailed, err), "action", "report", "error", err)
+		} else {
+			slog.Info(tr(cfg, msgReportWritten, path), "action", "report")
+		}
+	}
+	return nil
+}
+
+// printAnalysis 以表格形式输出分析报告，使用 cfg 设置的语言
+func printAnalysis(cfg *config.Config, out io.Writer, r *analysisReport) {
+	percent := func(n int64) float64 {
+		if r.TotalBytes == 0 {
+			return 0
+		}
+		return float64(n) / float64(r.TotalBytes) * 100
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	printGroups := func(title string, groups []groupReport) {
+		fmt.Fprintf(w, "\n%s\n", title)
+		fmt.Fprintln(w, tr(cfg, msgAnalyzeGroupHeader))
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s\t%d\t%.2f MB\t%.1f%%\n", g.Name, g.Files, float64(g.Bytes)/1024/1024, percent(g.Bytes))
+		}
+	}
+	printObjects := func(title string, objects []report.Object) {
+		fmt.Fprintf(w, "\n%s\n", title)
+		fmt.Fprintln(w, tr(cfg, msgAnalyzeObjectHeader))
+		for _, o := range objects {
+			fmt.Fprintf(w, "%s\t%.2f MB\t%s\n", o.Key, float64(o.Size)/1024/1024, o.LastModified.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	fmt.Fprintln(w, tr(cfg, msgAnalyzeTotal, r.Bucket, r.TotalFiles, float64(r.TotalBytes)/1024/1024))
+
+	prefixes := make([]groupReport, len(r.Prefixes))
+	for i, p := range r.Prefixes {
+		prefixes[i] = groupReport{Name: p.Prefix, Files: p.Files, Bytes: p.Bytes}
+		if p.Prefix == "" {
+			prefixes[i].Name = "/"
+		}
+	}
+	printGroups(tr(cfg, msgAnalyzeByPrefix), prefixes)
+
+	extensions := make([]groupReport, len(r.Extensions))
+	copy(extensions, r.Extensions)
+	for i := range extensions {
+		if extensions[i].Name == "" {
+			extensions[i].Name = tr(cfg, msgAnalyzeNoExt)
+		}
+	}
+	printGroups(tr(cfg, msgAnalyzeByExt), extensions)
+
+	fmt.Fprintf(w, "\n%s\n", tr(cfg, msgAnalyzeByAge))
+	fmt.Fprintln(w, tr(cfg, msgAnalyzeAgeHeader))
+	maxBytes := report.MaxAgeBytes(r.Ages)
+	for _, a := range r.Ages {
+		fmt.Fprintf(w, "%s\t%d\t%.2f MB\t%.1f%%\t%s\n", a.Age, a.Files, float64(a.Bytes)/1024/1024, percent(a.Bytes),
+			ageHistogramBar(a.Bytes, maxBytes))
+	}
+
+	printObjects(tr(cfg, msgAnalyzeLargest), r.LargestObjects)
+	printObjects(tr(cfg, msgAnalyzeOldest), r.OldestObjects)
+	w.Flush()
+}
@@ -0,0 +1,101 @@
+// Package cleaner 按配置清理 MinIO 及兼容 S3 的对象存储中的过期文件。
+// 其他 Go 服务可以通过 LoadConfig 和 Run 直接使用，无需调用命令行程序
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// LoadConfig 读取并严格解析配置文件（YAML、JSON 或 TOML），profile 不为空时合并 profiles 中的同名配置，
+// 为未配置的项设置默认值并检查配置，有问题时返回的错误列出全部问题。overrides 为对配置项的覆盖（如命令行参数），
+// 优先于配置文件；与命令行程序相同，环境变量 MINIO_CLEANER_* 的覆盖同样生效，并优先于 overrides
+func LoadConfig(path, profile string, overrides ...config.Override) (*config.Config, error) {
+	cfg, err := config.Load(path, config.ProfileName(profile), slices.Concat(overrides, config.EnvOverrides())...)
+	if err != nil {
+		return nil, err
+	}
+	// 与 validate 命令的检查相同，配置有问题时不开始运行
+	if problems := validateConfig(cfg); len(problems) > 0 {
+		return nil, errors.New(tr(cfg, msgConfigInvalid, len(problems), strings.Join(problems, "\n  - ")))
+	}
+	return cfg, nil
+}
+
+// Validate 检查配置项的取值和相互之间的冲突，不连接存储服务，返回发现的全部问题，与 validate 命令相同
+func Validate(cfg *config.Config) []string {
+	c := cfg.Clone()
+	config.SetDefaults(c)
+	return validateConfig(c)
+}
+
+// Run 按配置清理全部目标（各集群中的存储桶），相当于命令行程序的 run -yes：不提示确认，
+// 删除比例检查、计划删除清单、外部审批、金丝雀删除等安全措施照常生效。
+// 每个目标的运行结果按目标顺序记录在返回的 Report 中；任一目标出错时返回的错误汇总全部出错目标的错误，
+// 其余目标的结果照常返回。
+//
+// 日志输出到 slog.Default()，由调用方配置。日志和错误信息使用 cfg 的 cleanup.language 设置的语言（默认中文），
+// 语言只对本次调用生效，同时进行的 Run 调用可以使用不同的语言。
+// 未经 LoadConfig 加载的配置在运行前设置默认值，cfg 本身不会被修改；配置有 Validate 报告的问题时不运行并返回错误。
+// 守护模式、交互式审查和需要在终端中确认的 canary.confirm 只能在命令行程序中使用
+func Run(ctx context.Context, cfg *config.Config) (Report, error) {
+	cfg = cfg.Clone()
+	config.SetDefaults(cfg)
+	if problems := validateConfig(cfg); len(problems) > 0 {
+		return Report{}, errors.New(tr(cfg, msgConfigInvalid, len(problems), strings.Join(problems, "\n  - ")))
+	}
+	if cfg.Canary.Confirm && cfg.Canary.Size > 0 && !cfg.Cleanup.DryRun {
+		return Report{}, errors.New(tr(cfg, msgCanaryNoTerminal))
+	}
+	targets, err := Targets(ctx, cfg)
+	if err != nil {
+		return Report{}, err
+	}
+	return RunTargets(ctx, cfg, targets, nil)
+}
+
+// RunTargets 清理 targets 中的目标，cfg 为生成目标的配置，需已设置默认值并通过检查。
+// 与 Run 不同，不检查配置，也不拒绝 canary.confirm；删除前的确认（如 ConfirmTargets）由调用方完成。
+// objects 不为 nil 时逐个输出待清理或已删除的对象
+func RunTargets(ctx context.Context, cfg *config.Config, targets []Target, objects *ObjectOutput) (Report, error) {
+	results, err := runAllTargets(ctx, cfg, targets, nil, objects)
+	return newReport(results), err
+}
+
+// Diff 以预览模式逐个清理目标，并与上一次预览的待清理对象列表比较，输出新增匹配和不再匹配的对象，与 diff 命令相同
+func Diff(ctx context.Context, targets []Target) error {
+	return runTargets(ctx, targets, 1, func(t Target) error {
+		return runDiff(ctx, t.cfg, t.store)
+	})
+}
+
+// Analyze 逐个列举目标存储桶中的所有对象并统计其构成，不会删除任何文件，与 analyze 命令相同。
+// top 为各排行列出的条数
+func Analyze(ctx context.Context, targets []Target, top int) error {
+	return runTargets(ctx, targets, 1, func(t Target) error {
+		return runAnalyze(ctx, t.cfg, t.store, top)
+	})
+}
+
+// ListBuckets 按 cfg 中 minio 的连接配置列举存储桶，返回按名称排序的存储桶名称，可用于检查地址和访问密钥。
+// cfg 需已设置默认值
+func ListBuckets(ctx context.Context, cfg *config.Config) ([]string, error) {
+	store, _, err := newObjectStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := store.listBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(buckets))
+	for i, b := range buckets {
+		names[i] = b.Name
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,66 @@
+package cleaner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+func TestAPIKeepsConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(cfg *config.Config)
+	}{
+		{name: "Validate", call: func(cfg *config.Config) { Validate(cfg) }},
+		// 配置缺少 endpoint，Run 在设置默认值和检查配置之后返回错误
+		{name: "Run", call: func(cfg *config.Config) { Run(context.Background(), cfg) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Rules: make([]rules.Rule, 1, 2)}
+			cfg.Daemon.API.Tenants = []config.JobsTenant{{Team: "a"}}
+			want := &config.Config{Rules: make([]rules.Rule, 1, 2)}
+			want.Daemon.API.Tenants = []config.JobsTenant{{Team: "a"}}
+			tt.call(cfg)
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("%s modified the config: tenants = %+v", tt.name, cfg.Daemon.API.Tenants)
+			}
+		})
+	}
+}
+
+func TestRunLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "", want: "配置有"},
+		{lang: "zh", want: "配置有"},
+		{lang: "en", want: "configuration has"},
+		{lang: "en-US", want: "configuration has"},
+	}
+	// 同时进行的 Run 调用各自使用配置的语言
+	errs := make([]error, len(tests))
+	var wg sync.WaitGroup
+	for i, tt := range tests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 配置缺少 endpoint，Run 在检查配置后返回错误
+			cfg := &config.Config{}
+			cfg.Cleanup.Language = tt.lang
+			_, errs[i] = Run(context.Background(), cfg)
+		}()
+	}
+	wg.Wait()
+	for i, tt := range tests {
+		if errs[i] == nil || !strings.Contains(errs[i].Error(), tt.want) {
+			t.Errorf("Run(language=%q) error = %v, want it to contain %q", tt.lang, errs[i], tt.want)
+		}
+	}
+}
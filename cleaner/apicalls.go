@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// API 请求的类别，按 AWS S3 的计费方式区分
+const (
+	apiList = iota
+	apiHead
+	apiGet
+	apiDelete
+	apiOther
+	apiKinds
+)
+
+// apiCallKind 返回请求的类别。列举请求包括 S3 的 ListObjectsV2 和 ListObjectVersions、
+// Azure 的 List Blobs 和 GCS 的 objects.list，其余 GET 请求（读取对象、标签等）为 GET
+func apiCallKind(req *http.Request) int {
+	switch req.Method {
+	case http.MethodHead:
+		return apiHead
+	case http.MethodDelete:
+		return apiDelete
+	case http.MethodGet:
+		q := req.URL.Query()
+		if q.Has("list-type") || q.Has("versions") || q.Get("comp") == "list" || strings.HasSuffix(req.URL.Path, "/o") {
+			return apiList
+		}
+		return apiGet
+	}
+	return apiOther
+}
+
+// apiCalls 统计一次运行发出的 API 请求数，总数超过 cleanup.maxAPICalls 时中止运行并拒绝之后的请求
+type apiCalls struct {
+	counts [apiKinds]int64
+	total  int64
+	limit  int64
+	bucket string
+	lang   string
+
+	once   sync.Once
+	cancel context.CancelCauseFunc
+
+	mu sync.Mutex
+	// stop 为流水线运行期间中止运行的方法，为 nil 时直接取消运行的 context
+	stop func(reason string)
+}
+
+func newAPICalls(bucket, lang string, limit int64, cancel context.CancelCauseFunc) *apiCalls {
+	return &apiCalls{bucket: bucket, lang: lang, limit: limit, cancel: cancel}
+}
+
+// apiCallsKey 为请求上下文中保存 *apiCalls 的键
+type apiCallsKey struct{}
+
+// withAPICalls 返回统计 API 请求的 context，通过它发出的请求都计入 calls
+func withAPICalls(ctx context.Context, calls *apiCalls) context.Context {
+	return context.WithValue(ctx, apiCallsKey{}, calls)
+}
+
+// apiCallsFrom 返回 ctx 中的请求统计，没有时返回 nil
+func apiCallsFrom(ctx context.Context) *apiCalls {
+	calls, _ := ctx.Value(apiCallsKey{}).(*apiCalls)
+	return calls
+}
+
+// setStop 设置超过限制时中止运行的方法，流水线结束时设置为 nil
+func (c *apiCalls) setStop(stop func(reason string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stop = stop
+}
+
+// add 记录一个请求。超过限制时不计数并返回中止的原因，第一次超过时中止运行
+func (c *apiCalls) add(kind int) error {
+	if n := atomic.AddInt64(&c.total, 1); c.limit > 0 && n > c.limit {
+		atomic.AddInt64(&c.total, -1)
+		reason := trLang(c.lang, msgAbortAPICalls, c.limit)
+		c.once.Do(func() {
+			c.mu.Lock()
+			stop := c.stop
+			c.mu.Unlock()
+			if stop != nil {
+				stop(reason)
+				return
+			}
+			slog.Error(reason, "bucket", c.bucket, "action", "abort", "apiCalls", c.limit)
+			c.cancel(&abortError{reason})
+		})
+		return &abortError{reason}
+	}
+	atomic.AddInt64(&c.counts[kind], 1)
+	return nil
+}
+
+// count 返回某一类别的请求数
+func (c *apiCalls) count(kind int) int64 {
+	return atomic.LoadInt64(&c.counts[kind])
+}
+
+// report 返回目前的请求数
+func (c *apiCalls) report() report.APICalls {
+	r := report.APICalls{
+		List:   c.count(apiList),
+		Head:   c.count(apiHead),
+		Get:    c.count(apiGet),
+		Delete: c.count(apiDelete),
+		Other:  c.count(apiOther),
+	}
+	r.Total = r.List + r.Head + r.Get + r.Delete + r.Other
+	return r
+}
+
+// apiCallTransport 将通过它发出的请求计入请求上下文中的 apiCalls，超过限制时不发出请求
+type apiCallTransport struct {
+	http.RoundTripper
+}
+
+func (t apiCallTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if calls := apiCallsFrom(req.Context()); calls != nil {
+		if err := calls.add(apiCallKind(req)); err != nil {
+			return nil, err
+		}
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// reportAPICalls 将运行发出的请求数写入汇总报告并输出日志。预览模式下同时估算实际删除时的请求数，
+// 超过 cleanup.maxAPICalls 时警告。listCalls 为清理过程中列举存储桶的请求数
+func (c *cleaner) reportAPICalls(rep *report.RunReport, calls *apiCalls, listCalls int64) {
+	cfg, bucket := c.cfg, c.cfg.Minio.Bucket
+	currency := cmp.Or(cfg.Cost.Currency, config.DefaultCurrency)
+	log := func(msg msgID, r report.APICalls) {
+		slog.Info(tr(cfg, msg, r.List, r.Head, r.Get, r.Delete, r.Other, r.Total),
+			"bucket", bucket, "action", "apiCalls", "list", r.List, "head", r.Head, "get", r.Get, "delete", r.Delete, "other", r.Other, "total", r.Total)
+		if cfg.Cost.Requests.Enabled() {
+			slog.Info(tr(cfg, msgAPICallsCost, r.Cost, currency), "bucket", bucket, "action", "apiCalls", "cost", r.Cost, "currency", currency)
+		}
+	}
+
+	rep.APICalls = calls.report().Priced(&cfg.Cost.Requests)
+	log(msgAPICalls, rep.APICalls)
+	if !cfg.Cleanup.DryRun {
+		return
+	}
+	var matched int64
+	for _, r := range rep.Rules {
+		matched += r.MatchedFiles
+	}
+	estimate := estimateAPICalls(cfg, rep.APICalls, listCalls, matched).Priced(&cfg.Cost.Requests)
+	rep.EstimatedAPICalls = &estimate
+	log(msgAPICallsEstimate, estimate)
+	if limit := cfg.Cleanup.MaxAPICalls; limit > 0 && estimate.Total > limit {
+		slog.Warn(tr(cfg, msgAPIBudgetEstimate, estimate.Total, limit), "bucket", bucket, "action", "apiCalls", "total", estimate.Total, "limit", limit)
+	}
+}
+
+// estimateAPICalls 根据预览运行的请求数估算实际删除时的请求数：实际删除前的安全检查再列举一次存储桶，
+// 每个待清理对象发出一个 DELETE 请求，开启删除前重新查询时再发出一个 HEAD 请求。
+// lists 为预览运行列举存储桶的请求数，matched 为待清理的对象数
+func estimateAPICalls(cfg *config.Config, actual report.APICalls, lists, matched int64) report.APICalls {
+	r := actual
+	if cfg.ScansBeforeDelete() {
+		r.List += lists
+	}
+	r.Delete += matched
+	if cfg.VerifiesBeforeDelete() || cfg.Cleanup.SkipUnreplicated {
+		r.Head += matched
+	}
+	r.Total = r.List + r.Head + r.Get + r.Delete + r.Other
+	return r
+}
@@ -0,0 +1,146 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// 审批服务返回的审批状态
+const (
+	approvalApproved = "approved" // 批准，开始删除
+	approvalDenied   = "denied"   // 拒绝，不删除任何对象
+	approvalPending  = "pending"  // 等待审批，稍后查询 statusUrl
+)
+
+// approvalRequired 判断本次删除是否需要审批
+func approvalRequired(a *config.ApprovalConfig, pre *preflightResult) bool {
+	return a.Enabled() && pre.files > 0 && pre.bytes > int64(a.MinBytes)
+}
+
+// approvalRequest 为提交给审批服务的删除计划
+type approvalRequest struct {
+	RunID      string      `json:"runId"`
+	Cluster    string      `json:"cluster,omitempty"`
+	Bucket     string      `json:"bucket"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Files      int64       `json:"files"`
+	Bytes      int64       `json:"bytes"`
+	PlanObject string      `json:"planObject,omitempty"`
+	Objects    []planEntry `json:"objects"`
+	// Truncated 为 true 表示 objects 只列出了前 approval.maxObjects 个对象
+	Truncated bool `json:"truncated"`
+}
+
+// approvalResponse 为审批服务返回的审批状态，status 为 pending 时需要给出 statusUrl，之后用 GET 查询
+type approvalResponse struct {
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+	StatusURL string `json:"statusUrl"`
+}
+
+// requestApproval 将删除计划提交给审批服务并等待审批结果，批准时返回 nil。
+// 拒绝、超时、提交失败或无法解析审批结果时返回错误，不删除任何对象
+func requestApproval(ctx context.Context, cfg *config.Config, runID, planObject string, pre *preflightResult) error {
+	a, bucket := &cfg.Approval, cfg.Minio.Bucket
+	req := approvalRequest{
+		RunID:      runID,
+		Bucket:     bucket,
+		Timestamp:  time.Now(),
+		Files:      pre.files,
+		Bytes:      pre.bytes,
+		PlanObject: planObject,
+		Objects:    pre.objects,
+		Truncated:  pre.files > int64(len(pre.objects)),
+	}
+	if len(cfg.Clusters) > 0 {
+		req.Cluster = cfg.Minio.Name
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.New(tr(cfg, msgApprovalFailed, bucket, err))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.Timeout)
+	defer cancel()
+	resp, err := callApproval(ctx, a, http.MethodPost, a.URL, body)
+	if err != nil {
+		return errors.New(tr(cfg, msgApprovalFailed, bucket, err))
+	}
+	slog.Info(tr(cfg, msgApprovalWaiting, bucket, pre.files, float64(pre.bytes)/1024/1024, a.Timeout),
+		"bucket", bucket, "action", "approval", "files", pre.files, "size", pre.bytes)
+
+	for {
+		switch resp.Status {
+		case approvalApproved:
+			slog.Info(tr(cfg, msgApprovalApproved, bucket, resp.Reason), "bucket", bucket, "action", "approval", "reason", resp.Reason)
+			return nil
+		case approvalDenied:
+			return errors.New(tr(cfg, msgApprovalDenied, bucket, resp.Reason))
+		case approvalPending:
+		default:
+			return errors.New(tr(cfg, msgApprovalFailed, bucket, fmt.Errorf("unknown status %q", resp.Status)))
+		}
+		if resp.StatusURL == "" {
+			return errors.New(tr(cfg, msgApprovalFailed, bucket, errors.New("pending without statusUrl")))
+		}
+		statusURL, err := resolveURL(a.URL, resp.StatusURL)
+		if err != nil {
+			return errors.New(tr(cfg, msgApprovalFailed, bucket, err))
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.New(tr(cfg, msgApprovalTimeout, bucket, a.Timeout))
+			}
+			return ctx.Err()
+		case <-time.After(a.PollInterval):
+		}
+		// 查询失败时保留上一次的状态，下次继续查询，直到超时
+		next, err := callApproval(ctx, a, http.MethodGet, statusURL, nil)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn(tr(cfg, msgApprovalPollFailed, bucket, err), "bucket", bucket, "action", "approval", "error", err)
+			}
+			continue
+		}
+		if next.StatusURL == "" {
+			next.StatusURL = resp.StatusURL
+		}
+		resp = next
+	}
+}
+
+// callApproval 向审批服务发送请求并解析审批状态
+func callApproval(ctx context.Context, a *config.ApprovalConfig, method, target string, body []byte) (*approvalResponse, error) {
+	out, err := requestJSON(ctx, method, target, a.Headers, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp approvalResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, truncate(string(out), 200))
+	}
+	return &resp, nil
+}
+
+// resolveURL 将审批服务返回的 statusUrl 解析为完整地址，相对路径相对于 approval.url
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(r).String(), nil
+}
@@ -0,0 +1,244 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	minio "github.com/minio/minio-go/v7"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// 审计记录类型
+const (
+	auditRunStart = "runStart"
+	auditDelete   = "delete"
+	auditRunEnd   = "runEnd"
+)
+
+// auditRecord 为审计日志中的一条记录
+type auditRecord struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	RunID     string    `json:"runId"`
+	Bucket    string    `json:"bucket"`
+	User      string    `json:"user,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+	DryRun    *bool     `json:"dryRun,omitempty"`
+	ConfigSum string    `json:"configHash,omitempty"`
+	Config    any       `json:"config,omitempty"`
+
+	Key          string    `json:"key,omitempty"`
+	Size         int64     `json:"size,omitempty"`
+	LastModified time.Time `json:"lastModified,omitzero"`
+	VersionID    string    `json:"versionId,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	Rule         string    `json:"rule,omitempty"`
+	Outcome      string    `json:"outcome,omitempty"`
+	Error        string    `json:"error,omitempty"`
+
+	DeletedFiles *int64 `json:"deletedFiles,omitempty"`
+	DeletedBytes *int64 `json:"deletedBytes,omitempty"`
+	ErrorCount   *int64 `json:"errorCount,omitempty"`
+}
+
+// auditLog 以只追加方式写入审计记录，可被多个工作协程并发调用
+type auditLog struct {
+	mu     sync.Mutex
+	f      *os.File
+	path   string
+	offset int64
+	runID  string
+	bucket string
+}
+
+func openAuditLog(path, runID, bucket string) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &auditLog{f: f, path: path, offset: fi.Size(), runID: runID, bucket: bucket}, nil
+}
+
+func (a *auditLog) write(r auditRecord) error {
+	r.Time = time.Now()
+	r.RunID = a.runID
+	r.Bucket = a.bucket
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// 每条记录单独写入，程序中途退出时已写入的记录保持完整
+	_, err = a.f.Write(append(data, '\n'))
+	return err
+}
+
+// runStart 记录运行者、主机和使用的配置（敏感字段已脱敏）
+func (a *auditLog) runStart(cfg *config.Config) error {
+	dryRun := cfg.Cleanup.DryRun
+	r := auditRecord{
+		Type:      auditRunStart,
+		PID:       os.Getpid(),
+		DryRun:    &dryRun,
+		ConfigSum: config.Hash(cfg),
+		Config:    redactedConfig(cfg),
+	}
+	if u, err := user.Current(); err == nil {
+		r.User = u.Username
+	}
+	if h, err := os.Hostname(); err == nil {
+		r.Host = h
+	}
+	return a.write(r)
+}
+
+// deleted 记录一次删除操作及其结果
+func (a *auditLog) deleted(obj minio.ObjectInfo, rule string, deleteErr error) error {
+	r := auditRecord{
+		Type:         auditDelete,
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		VersionID:    obj.VersionID,
+		ETag:         obj.ETag,
+		Rule:         rule,
+		Outcome:      "success",
+	}
+	if deleteErr != nil {
+		r.Outcome = "failure"
+		r.Error = deleteErr.Error()
+	}
+	return a.write(r)
+}
+
+func (a *auditLog) runEnd(report *report.RunReport) error {
+	return a.write(auditRecord{
+		Type:         auditRunEnd,
+		DeletedFiles: &report.DeletedFiles,
+		DeletedBytes: &report.DeletedBytes,
+		ErrorCount:   &report.ErrorCount,
+	})
+}
+
+// upload 将本次运行写入的审计记录上传到审计存储桶
+func (a *auditLog) upload(ctx context.Context, store objectStore, cfg *config.AuditConfig, start time.Time) (string, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Seek(a.offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	key := path.Join(cfg.ObjectPrefix, a.bucket, start.Format("20060102-150405")+"-"+a.runID+".jsonl")
+	err = store.put(ctx, cfg.Bucket, key, bytes.NewReader(data), int64(len(data)), "application/x-ndjson")
+	return key, err
+}
+
+func (a *auditLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.f.Close()
+}
+
+// finishAudit 写入运行结束记录，并按配置上传本次的审计记录
+func (c *cleaner) finishAudit(ctx context.Context, audit *auditLog, report *report.RunReport) {
+	if err := audit.runEnd(report); err != nil {
+		slog.Error(tr(c.cfg, msgAuditWriteFailed, err), "bucket", report.Bucket, "action", "audit", "error", err)
+	}
+	if c.cfg.Audit.Bucket == "" {
+		return
+	}
+	key, err := audit.upload(ctx, c.store, &c.cfg.Audit, report.StartTime)
+	if err != nil {
+		slog.Error(tr(c.cfg, msgAuditUploadFailed, err), "bucket", c.cfg.Audit.Bucket, "action", "audit", "error", err)
+		return
+	}
+	slog.Info(tr(c.cfg, msgAuditUploaded, c.cfg.Audit.Bucket, key), "bucket", c.cfg.Audit.Bucket, "key", key, "action", "audit")
+}
+
+// newRunID 生成运行 ID，由开始时间和随机后缀组成
+func newRunID(start time.Time) string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return start.Format("20060102150405") + "-" + hex.EncodeToString(b)
+}
+
+// sensitiveKeys 为配置中需要脱敏的字段名片段（不区分大小写）
+var sensitiveKeys = []string{"secret", "password", "token", "authorization"}
+
+// redactedConfig 返回将密钥、密码等敏感字段替换为 *** 后的配置
+func redactedConfig(cfg *config.Config) any {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+	var v any
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	return redactValue(v)
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSensitiveKey(k) {
+				if s, ok := val.(string); !ok || s != "" {
+					t[k] = "***"
+				}
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
+
+func isSensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	// secretAccessKeyFile 等字段为密钥文件路径，本身不是敏感信息
+	if strings.HasSuffix(k, "file") {
+		return false
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package cleaner
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// autoTuneInterval 为自动调整并发数的评估周期
+const autoTuneInterval = 5 * time.Second
+
+// autoTuneMaxErrorRate 为一个周期内允许的删除错误比例，超过时减少并发数
+const autoTuneMaxErrorRate = 0.05
+
+// workerGate 限制同时执行删除的工作协程数，上限可在运行中调整
+type workerGate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newWorkerGate(limit int) *workerGate {
+	g := &workerGate{limit: limit}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+func (g *workerGate) acquire() {
+	g.mu.Lock()
+	for g.active >= g.limit {
+		g.cond.Wait()
+	}
+	g.active++
+	g.mu.Unlock()
+}
+
+func (g *workerGate) release() {
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	g.cond.Signal()
+}
+
+func (g *workerGate) setLimit(n int) {
+	g.mu.Lock()
+	g.limit = n
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// autoTuner 根据删除延迟和错误率调整并发数：
+// 开始时只使用 1 个工作协程，延迟稳定时先成倍增加（慢启动），
+// 第一次退避后改为逐个增加；出现 503/SlowDown 或错误率过高时减半，延迟明显升高时减一
+type autoTuner struct {
+	gate       *workerGate
+	maxWorkers int
+	bucket     string
+	lang       string
+
+	mu        sync.Mutex
+	count     int
+	errors    int
+	throttled int
+	latency   time.Duration
+
+	// 以下字段只在 tune 中访问
+	workers   int
+	baseline  time.Duration
+	slowStart bool
+}
+
+func newAutoTuner(maxWorkers int, bucket, lang string) *autoTuner {
+	return &autoTuner{
+		gate:       newWorkerGate(1),
+		maxWorkers: maxWorkers,
+		bucket:     bucket,
+		lang:       lang,
+		workers:    1,
+		slowStart:  true,
+	}
+}
+
+// isThrottled 判断错误是否表示服务端过载
+func isThrottled(err error) bool {
+	resp := minio.ToErrorResponse(err)
+	return resp.Code == "SlowDown" || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// observe 记录一次删除请求的耗时和结果
+func (t *autoTuner) observe(latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count++
+	t.latency += latency
+	if err != nil {
+		t.errors++
+		if isThrottled(err) {
+			t.throttled++
+		}
+	}
+}
+
+// run 周期性调整并发数，直到 stop 被关闭
+func (t *autoTuner) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(autoTuneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.tune()
+		}
+	}
+}
+
+func (t *autoTuner) tune() {
+	t.mu.Lock()
+	count, errs, throttled, total := t.count, t.errors, t.throttled, t.latency
+	t.count, t.errors, t.throttled, t.latency = 0, 0, 0, 0
+	t.mu.Unlock()
+	if count == 0 {
+		return
+	}
+
+	avg := total / time.Duration(count)
+	errorRate := float64(errs) / float64(count)
+	workers := t.workers
+	switch {
+	case throttled > 0 || errorRate > autoTuneMaxErrorRate:
+		workers /= 2
+		t.slowStart = false
+	case t.baseline > 0 && avg > t.baseline*2:
+		workers--
+		t.slowStart = false
+	case t.slowStart:
+		workers *= 2
+	default:
+		workers++
+	}
+	workers = max(1, min(workers, t.maxWorkers))
+
+	// 基准延迟取观察到的最小平均延迟
+	if t.baseline == 0 || avg < t.baseline {
+		t.baseline = avg
+	}
+	if workers == t.workers {
+		return
+	}
+	t.workers = workers
+	t.gate.setLimit(workers)
+	slog.Info(trLang(t.lang, msgAutoTune, workers, avg.Round(time.Millisecond), errorRate*100, throttled),
+		"bucket", t.bucket, "action", "autotune", "workers", workers, "latency", avg, "errorRate", errorRate, "throttled", throttled)
+}
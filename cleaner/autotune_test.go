@@ -0,0 +1,140 @@
+package cleaner
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestAutoTuner(t *testing.T) {
+	// step 为一个评估周期内观察到的删除请求，want 为周期结束后的并发数
+	type step struct {
+		ok, failed, throttled int
+		latency               time.Duration
+		want                  int
+	}
+	ms := time.Millisecond
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		// 慢启动时成倍增加，不超过 maxWorkers
+		{name: "slow start", steps: []step{
+			{ok: 10, latency: 10 * ms, want: 2},
+			{ok: 10, latency: 10 * ms, want: 4},
+			{ok: 10, latency: 10 * ms, want: 8},
+			{ok: 10, latency: 10 * ms, want: 10},
+			{ok: 10, latency: 10 * ms, want: 10},
+		}},
+		{name: "no requests", steps: []step{
+			{want: 1},
+			{ok: 10, latency: 10 * ms, want: 2},
+			{want: 2},
+		}},
+		// 服务端过载时减半，之后逐个增加
+		{name: "throttled", steps: []step{
+			{ok: 10, latency: 10 * ms, want: 2},
+			{ok: 10, latency: 10 * ms, want: 4},
+			{ok: 10, latency: 10 * ms, want: 8},
+			{ok: 99, throttled: 1, latency: 10 * ms, want: 4},
+			{ok: 10, latency: 10 * ms, want: 5},
+			{ok: 10, latency: 10 * ms, want: 6},
+		}},
+		{name: "error rate", steps: []step{
+			{ok: 10, latency: 10 * ms, want: 2},
+			{ok: 10, latency: 10 * ms, want: 4},
+			// 错误比例不超过 5% 时不减少
+			{ok: 95, failed: 5, latency: 10 * ms, want: 8},
+			{ok: 90, failed: 10, latency: 10 * ms, want: 4},
+			{ok: 10, latency: 10 * ms, want: 5},
+		}},
+		// 延迟超过最小平均延迟的两倍时减一
+		{name: "latency", steps: []step{
+			{ok: 10, latency: 10 * ms, want: 2},
+			{ok: 10, latency: 10 * ms, want: 4},
+			{ok: 10, latency: 30 * ms, want: 3},
+			{ok: 10, latency: 15 * ms, want: 4},
+		}},
+		{name: "at least one", steps: []step{
+			{ok: 1, throttled: 1, latency: 10 * ms, want: 1},
+			{ok: 1, failed: 1, latency: 10 * ms, want: 1},
+			{ok: 10, latency: 10 * ms, want: 2},
+		}},
+	}
+	slowDown := minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAutoTuner(10, "b", "")
+			for i, s := range tt.steps {
+				for range s.ok {
+					a.observe(s.latency, nil)
+				}
+				for range s.failed {
+					a.observe(s.latency, errors.New("boom"))
+				}
+				for range s.throttled {
+					a.observe(s.latency, slowDown)
+				}
+				a.tune()
+				if a.workers != s.want {
+					t.Fatalf("step %d: workers = %d, want %d", i, a.workers, s.want)
+				}
+				a.gate.mu.Lock()
+				limit := a.gate.limit
+				a.gate.mu.Unlock()
+				if limit != s.want {
+					t.Fatalf("step %d: gate limit = %d, want %d", i, limit, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerGate(t *testing.T) {
+	g := newWorkerGate(2)
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	done := make(chan struct{})
+	for range 4 {
+		go func() {
+			g.acquire()
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			active.Add(-1)
+			g.release()
+			done <- struct{}{}
+		}()
+	}
+	waitActive := func(want int32) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for active.Load() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("active = %d, want %d", active.Load(), want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitActive(2)
+	time.Sleep(20 * time.Millisecond)
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("peak = %d, want 2", got)
+	}
+	// 提高上限后等待中的工作协程立即开始
+	g.setLimit(4)
+	waitActive(4)
+	close(release)
+	for range 4 {
+		<-done
+	}
+}
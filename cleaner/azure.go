@@ -0,0 +1,314 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// azureStore 通过官方 SDK（azblob）访问 Azure Blob 存储，存储桶对应容器。
+// 使用账户密钥（Shared Key）签名请求，或在请求中附带 SAS
+type azureStore struct {
+	client *azblob.Client
+}
+
+// newAzureStore 创建 Azure Blob 客户端。未配置 SAS 和账户密钥时使用
+// AZURE_STORAGE_ACCOUNT 和 AZURE_STORAGE_KEY 环境变量
+func newAzureStore(cfg *config.Config) (*azureStore, *credentials.Credentials, error) {
+	m := &cfg.Minio
+	tr, err := newTransport(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	httpClient := &http.Client{Transport: apiCallTransport{tr}}
+	scheme := "http://"
+	if m.UseSSL {
+		scheme = "https://"
+	}
+	serviceURL := scheme + strings.TrimSuffix(m.Endpoint, "/") + "/"
+	opts := &azblob.ClientOptions{ClientOptions: policy.ClientOptions{
+		Transport: httpClient,
+		// 重试由调用方按 cleanup.retry 统一处理
+		Retry: policy.RetryOptions{MaxRetries: -1},
+	}}
+	if m.Azure.SASToken != "" {
+		client, err := azblob.NewClientWithNoCredential(serviceURL+"?"+strings.TrimPrefix(m.Azure.SASToken, "?"), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &azureStore{client: client}, nil, nil
+	}
+
+	var creds *credentials.Credentials
+	if m.AccessKeyID == "" && !m.SecretsFromFile() && m.Vault.Path == "" {
+		creds = credentials.NewStaticV4(os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_KEY"), "")
+	} else if creds, err = newCredentials(m, httpClient, cfg.Cleanup.Language); err != nil {
+		return nil, nil, err
+	}
+	v, err := creds.Get()
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := azblob.NewSharedKeyCredential(v.AccessKeyID, v.SecretAccessKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 签名前重新读取账户密钥，使 reloadSecrets 和 Vault 轮换后的密钥生效
+	opts.PerRetryPolicies = append(opts.PerRetryPolicies, azureKeyPolicy{creds: creds, key: key})
+	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &azureStore{client: client}, creds, nil
+}
+
+// azureKeyPolicy 在每次发送请求前将 creds 中的最新密钥设置到签名使用的 key。账户名不会变化
+type azureKeyPolicy struct {
+	creds *credentials.Credentials
+	key   *azblob.SharedKeyCredential
+}
+
+func (p azureKeyPolicy) Do(req *policy.Request) (*http.Response, error) {
+	v, err := p.creds.Get()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.key.SetAccountKey(v.SecretAccessKey); err != nil {
+		return nil, err
+	}
+	return req.Next()
+}
+
+// azureError 将 SDK 返回的错误转换为 S3 错误，key 为空时请求的是容器本身
+func azureError(err error, bucket, key string) error {
+	var re *azcore.ResponseError
+	if errors.As(err, &re) && re.RawResponse != nil {
+		return httpError(re.RawResponse, bucket, key, re.ErrorCode)
+	}
+	return err
+}
+
+// deref 返回 SDK 响应中可选字段的值，未返回时为零值
+func deref[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+	return *p
+}
+
+func (s *azureStore) blob(bucket, key string) *blob.Client {
+	return s.client.ServiceClient().NewContainerClient(bucket).NewBlobClient(key)
+}
+
+// azureObjectInfo 转换列举结果中的一个 Blob
+func azureObjectInfo(item *container.BlobItem) minio.ObjectInfo {
+	obj := minio.ObjectInfo{Key: deref(item.Name)}
+	if p := item.Properties; p != nil {
+		obj.Size = deref(p.ContentLength)
+		obj.LastModified = deref(p.LastModified)
+		obj.ETag = strings.Trim(string(deref(p.ETag)), `"`)
+		obj.ContentType = deref(p.ContentType)
+	}
+	return obj
+}
+
+func (s *azureStore) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo, 1)
+	go func() {
+		defer close(ch)
+		c := s.client.ServiceClient().NewContainerClient(bucket)
+		var prefix *string
+		if opts.Prefix != "" {
+			prefix = &opts.Prefix
+		}
+		maxResults := to.Ptr[int32](5000)
+		if opts.Recursive {
+			pager := c.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: prefix, MaxResults: maxResults})
+			for pager.More() {
+				page, err := pager.NextPage(ctx)
+				if err != nil {
+					sendObject(ctx, ch, minio.ObjectInfo{Err: azureError(err, bucket, "")})
+					return
+				}
+				objects := make([]minio.ObjectInfo, len(page.Segment.BlobItems))
+				for i, item := range page.Segment.BlobItems {
+					objects[i] = azureObjectInfo(item)
+				}
+				if !sendListPage(ctx, ch, objects, nil) {
+					return
+				}
+			}
+			return
+		}
+		pager := c.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: prefix, MaxResults: maxResults})
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				sendObject(ctx, ch, minio.ObjectInfo{Err: azureError(err, bucket, "")})
+				return
+			}
+			objects := make([]minio.ObjectInfo, len(page.Segment.BlobItems))
+			for i, item := range page.Segment.BlobItems {
+				objects[i] = azureObjectInfo(item)
+			}
+			prefixes := make([]string, len(page.Segment.BlobPrefixes))
+			for i, p := range page.Segment.BlobPrefixes {
+				prefixes[i] = deref(p.Name)
+			}
+			if !sendListPage(ctx, ch, objects, prefixes) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *azureStore) stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error) {
+	props, err := s.blob(bucket, key).GetProperties(ctx, nil)
+	if err != nil {
+		return minio.ObjectInfo{}, azureError(err, bucket, key)
+	}
+	metadata := make(minio.StringMap, len(props.Metadata))
+	for name, v := range props.Metadata {
+		metadata[name] = deref(v)
+	}
+	return minio.ObjectInfo{
+		Key:          key,
+		Size:         deref(props.ContentLength),
+		LastModified: deref(props.LastModified),
+		ETag:         strings.Trim(string(deref(props.ETag)), `"`),
+		ContentType:  deref(props.ContentType),
+		VersionID:    deref(props.VersionID),
+		UserMetadata: metadata,
+	}, nil
+}
+
+func (s *azureStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	resp, err := s.client.DownloadStream(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, azureError(err, bucket, key)
+	}
+	return resp.Body, nil
+}
+
+func (s *azureStore) remove(ctx context.Context, bucket, key string) (string, error) {
+	// 同时删除 Blob 的快照，否则存在快照的 Blob 无法删除。
+	// 开启 Blob 版本控制时被删除的版本保留为历史版本，删除不产生删除标记
+	_, err := s.blob(bucket, key).Delete(ctx, &blob.DeleteOptions{DeleteSnapshots: to.Ptr(blob.DeleteSnapshotsOptionTypeInclude)})
+	if err != nil {
+		return "", azureError(err, bucket, key)
+	}
+	return "", nil
+}
+
+func (s *azureStore) copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	dst := s.blob(dstBucket, dstKey)
+	resp, err := dst.StartCopyFromURL(ctx, s.blob(srcBucket, srcKey).URL(), nil)
+	if err != nil {
+		return azureError(err, dstBucket, dstKey)
+	}
+	status := deref(resp.CopyStatus)
+	var description string
+
+	// 跨账户等情况下复制为异步执行，等待复制完成
+	for status == blob.CopyStatusTypePending {
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		props, err := dst.GetProperties(ctx, nil)
+		if err != nil {
+			return azureError(err, dstBucket, dstKey)
+		}
+		status, description = deref(props.CopyStatus), deref(props.CopyStatusDescription)
+	}
+	if status != "" && status != blob.CopyStatusTypeSuccess {
+		return minio.ErrorResponse{Code: "CopyFailed", Message: description, BucketName: dstBucket, Key: dstKey}
+	}
+	return nil
+}
+
+func (s *azureStore) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.UploadStream(ctx, bucket, key, r, &azblob.UploadStreamOptions{
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	return azureError(err, bucket, key)
+}
+
+func (s *azureStore) bucketExists(ctx context.Context, bucket string) (bool, error) {
+	_, err := s.client.ServiceClient().NewContainerClient(bucket).GetProperties(ctx, nil)
+	if err != nil {
+		err = azureError(err, bucket, "")
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// removeBucket 不支持：Azure 删除容器时会一并删除其中的 Blob，无法保证只删除空的容器
+func (s *azureStore) removeBucket(ctx context.Context, bucket string) error {
+	return errors.New(trLang(contextLanguage(ctx), msgBucketRemoveAzure))
+}
+
+// versioning 无法按容器查询：Azure 的 Blob 版本控制在存储账户上配置
+func (s *azureStore) versioning(ctx context.Context, bucket string) (bool, error) {
+	return false, errVersioningUnknown
+}
+
+func (s *azureStore) objectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	resp, err := s.blob(bucket, key).GetTags(ctx, nil)
+	if err != nil {
+		return nil, azureError(err, bucket, key)
+	}
+	tags := make(map[string]string, len(resp.BlobTagSet))
+	for _, t := range resp.BlobTagSet {
+		tags[deref(t.Key)] = deref(t.Value)
+	}
+	return tags, nil
+}
+
+func (s *azureStore) bucketTags(ctx context.Context, bucket string) (map[string]string, error) {
+	props, err := s.client.ServiceClient().NewContainerClient(bucket).GetProperties(ctx, nil)
+	if err != nil {
+		return nil, azureError(err, bucket, "")
+	}
+	tags := make(map[string]string, len(props.Metadata))
+	for name, v := range props.Metadata {
+		tags[strings.ToLower(name)] = deref(v)
+	}
+	return tags, nil
+}
+
+// listBuckets 列举容器。容器只有最后修改时间而没有创建时间，返回的创建时间为零值
+func (s *azureStore) listBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	var buckets []minio.BucketInfo
+	pager := s.client.NewListContainersPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, azureError(err, "", "")
+		}
+		for _, c := range page.ContainerItems {
+			buckets = append(buckets, minio.BucketInfo{Name: deref(c.Name)})
+		}
+	}
+	return buckets, nil
+}
@@ -0,0 +1,225 @@
+package cleaner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Azurite 模拟器公开的开发账户和密钥
+const (
+	testAzureAccount = "devstoreaccount1"
+	testAzureKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// azureTestPages 为容器 logs 分两页返回的列举结果，非递归列举时 dir/ 为公共前缀
+var azureTestPages = map[string]string{
+	"": `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="logs"><Blobs>` +
+		`<Blob><Name>a.log</Name><Properties><Last-Modified>Mon, 10 Jun 2024 00:00:00 GMT</Last-Modified>` +
+		`<Etag>"0x1"</Etag><Content-Length>5</Content-Length><Content-Type>text/plain</Content-Type></Properties></Blob>` +
+		`<BlobPrefix><Name>dir/</Name></BlobPrefix></Blobs><NextMarker>m1</NextMarker></EnumerationResults>`,
+	"m1": `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="logs"><Blobs>` +
+		`<Blob><Name>z.log</Name><Properties><Content-Length>7</Content-Length></Properties></Blob>` +
+		`</Blobs><NextMarker/></EnumerationResults>`,
+}
+
+// azureTestServer 为模拟 Blob 服务的假服务器，记录收到的请求
+type azureTestServer struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (s *azureTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	s.mu.Unlock()
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && q.Get("comp") == "list" && r.URL.Path == "/"+testAzureAccount+"/logs":
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(azureTestPages[q.Get("marker")]))
+	case r.Method == http.MethodDelete && r.URL.Path == "/"+testAzureAccount+"/logs/a.log":
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodGet && q.Get("restype") == "container" && r.URL.Path == "/"+testAzureAccount+"/logs":
+		w.Header().Set("x-ms-meta-Team", "ops")
+	default:
+		code := "BlobNotFound"
+		if q.Get("restype") == "container" {
+			code = "ContainerNotFound"
+		}
+		w.Header().Set("x-ms-error-code", code)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// writeTestFile 写入测试文件
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestAzureStore 返回连接到假服务器的存储，sas 为空时使用密钥文件中的账户密钥，同时返回凭证和密钥文件路径
+func newTestAzureStore(t *testing.T, sas string) (*azureStore, *credentials.Credentials, *azureTestServer, string) {
+	t.Helper()
+	fake := &azureTestServer{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.Minio.Type = storageAzure
+	cfg.Minio.Endpoint = strings.TrimPrefix(srv.URL, "http://") + "/" + testAzureAccount
+	cfg.Minio.Azure.SASToken = sas
+	cfg.Minio.AccessKeyID = testAzureAccount
+	cfg.Minio.SecretAccessKeyFile = filepath.Join(dir, "key")
+	writeTestFile(t, cfg.Minio.SecretAccessKeyFile, testAzureKey)
+	s, creds, err := newAzureStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, creds, fake, cfg.Minio.SecretAccessKeyFile
+}
+
+func TestAzureStore(t *testing.T) {
+	tests := []struct {
+		name string
+		sas  string
+		call func(ctx context.Context, s *azureStore) (any, error)
+		want any
+		// code 为期望的 S3 错误码
+		code string
+	}{
+		{
+			name: "list recursive",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return listKeys(ctx, s, true) },
+			want: []string{"a.log", "z.log"},
+		},
+		// 非递归列举时公共前缀与对象按名称顺序返回
+		{
+			name: "list with prefixes",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return listKeys(ctx, s, false) },
+			want: []string{"a.log", "dir/", "z.log"},
+		},
+		{
+			name: "stat missing blob",
+			call: func(ctx context.Context, s *azureStore) (any, error) {
+				_, err := s.stat(ctx, "logs", "missing")
+				return nil, err
+			},
+			code: "NoSuchKey",
+		},
+		{
+			name: "list missing container",
+			call: func(ctx context.Context, s *azureStore) (any, error) {
+				for obj := range s.list(ctx, "other", minio.ListObjectsOptions{Recursive: true}) {
+					return nil, obj.Err
+				}
+				return nil, nil
+			},
+			code: "NoSuchBucket",
+		},
+		{
+			name: "container exists",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return s.bucketExists(ctx, "logs") },
+			want: true,
+		},
+		{
+			name: "container missing",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return s.bucketExists(ctx, "other") },
+			want: false,
+		},
+		// 容器元数据的名称转换为小写
+		{
+			name: "container metadata",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return s.bucketTags(ctx, "logs") },
+			want: map[string]string{"team": "ops"},
+		},
+		{
+			name: "remove",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return s.remove(ctx, "logs", "a.log") },
+			want: "",
+		},
+		{
+			name: "list with SAS",
+			sas:  "?sv=2024-08-04&sig=abc",
+			call: func(ctx context.Context, s *azureStore) (any, error) { return listKeys(ctx, s, true) },
+			want: []string{"a.log", "z.log"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, fake, _ := newTestAzureStore(t, tt.sas)
+			got, err := tt.call(context.Background(), s)
+			if code := minio.ToErrorResponse(err).Code; code != tt.code || (err != nil) != (tt.code != "") {
+				t.Fatalf("error = %v (code %q), want code %q", err, code, tt.code)
+			}
+			if tt.code == "" && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, r := range fake.requests {
+				auth := r.Header.Get("Authorization")
+				if tt.sas != "" && (auth != "" || r.URL.Query().Get("sig") != "abc") {
+					t.Errorf("%s %s: Authorization = %q, want SAS only", r.Method, r.URL, auth)
+				}
+				if tt.sas == "" && !strings.HasPrefix(auth, "SharedKey "+testAzureAccount+":") {
+					t.Errorf("%s %s: Authorization = %q, want Shared Key", r.Method, r.URL, auth)
+				}
+				// 删除 Blob 时同时删除快照
+				if r.Method == http.MethodDelete && r.Header.Get("x-ms-delete-snapshots") != "include" {
+					t.Errorf("DELETE x-ms-delete-snapshots = %q, want include", r.Header.Get("x-ms-delete-snapshots"))
+				}
+			}
+		})
+	}
+}
+
+// listKeys 列举容器 logs，返回对象名和公共前缀
+func listKeys(ctx context.Context, s *azureStore, recursive bool) ([]string, error) {
+	var keys []string
+	for obj := range s.list(ctx, "logs", minio.ListObjectsOptions{Recursive: recursive}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+func TestAzureKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	s, creds, _, keyFile := newTestAzureStore(t, "")
+	tests := []struct {
+		name string
+		key  string
+		// expire 为是否使读取的密钥过期（reloadSecrets）
+		expire  bool
+		wantErr bool
+	}{
+		{name: "initial key"},
+		// 密钥文件更新后，在 reloadSecrets 之前仍使用已读取的密钥
+		{name: "before reload", key: "not base64!"},
+		{name: "invalid key after reload", key: "not base64!", expire: true, wantErr: true},
+		{name: "rotated key", key: "bmV3LWtleQ==", expire: true},
+	}
+	for _, tt := range tests {
+		if tt.key != "" {
+			writeTestFile(t, keyFile, tt.key)
+		}
+		if tt.expire {
+			creds.Expire()
+		}
+		if _, err := s.bucketExists(ctx, "logs"); (err != nil) != tt.wantErr {
+			t.Errorf("%s: bucketExists() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package cleaner
+
+import (
+	"context"
+	"io"
+)
+
+// bandwidthReadChunk 为限速时每次读取的最大字节数，使等待均匀分布，而不是一次读取大量数据后长时间等待
+const bandwidthReadChunk = 32 * 1024
+
+// bandwidthStore 限制对象内容传输的带宽：读取（open）、上传（put）和服务端复制（copy）。
+// global 为全部目标共用的限速器，perWorker 为每个传输单独的速率（字节/秒），
+// 工作协程同一时间只进行一个传输，因此也就是每个工作协程的带宽
+type bandwidthStore struct {
+	objectStore
+	global    *rateLimiter
+	perWorker float64
+}
+
+// withBandwidthLimit 返回限制传输带宽的 store，global 为 nil 且 perWorker 为 0 时返回 store 本身
+func withBandwidthLimit(store objectStore, global *rateLimiter, perWorker float64) objectStore {
+	if global == nil && perWorker <= 0 {
+		return store
+	}
+	return bandwidthStore{objectStore: store, global: global, perWorker: perWorker}
+}
+
+// limiters 返回一次传输使用的限速器，每次传输有单独的 perWorker 限速器
+func (s bandwidthStore) limiters() []*rateLimiter {
+	var limiters []*rateLimiter
+	if s.global != nil {
+		limiters = append(limiters, s.global)
+	}
+	if s.perWorker > 0 {
+		limiters = append(limiters, newRateLimiter(s.perWorker))
+	}
+	return limiters
+}
+
+func (s bandwidthStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	r, err := s.objectStore.open(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&bandwidthReader{ctx: ctx, r: r, limiters: s.limiters()}, r}, nil
+}
+
+func (s bandwidthStore) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	return s.objectStore.put(ctx, bucket, key, &bandwidthReader{ctx: ctx, r: r, limiters: s.limiters()}, size, contentType)
+}
+
+// copy 在复制前按源对象的大小等待配额。服务端复制不经过本机，但同样占用存储服务的带宽，
+// 复制到归档集群时还占用集群之间的链路
+func (s bandwidthStore) copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	obj, err := s.objectStore.stat(ctx, srcBucket, srcKey)
+	if err != nil {
+		return err
+	}
+	if err := waitBandwidth(ctx, s.limiters(), obj.Size); err != nil {
+		return err
+	}
+	return s.objectStore.copy(ctx, srcBucket, srcKey, dstBucket, dstKey)
+}
+
+// waitBandwidth 从每个限速器取得 n 字节的配额
+func waitBandwidth(ctx context.Context, limiters []*rateLimiter, n int64) error {
+	for _, l := range limiters {
+		if err := l.waitN(ctx, float64(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bandwidthReader 每次读取后按读取的字节数等待配额
+type bandwidthReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*rateLimiter
+}
+
+func (b *bandwidthReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthReadChunk {
+		p = p[:bandwidthReadChunk]
+	}
+	n, err := b.r.Read(p)
+	if n > 0 {
+		if werr := waitBandwidth(b.ctx, b.limiters, int64(n)); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,99 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// transferStore 在 memStore 的基础上实现读取、上传和复制，内容均为 size 字节
+type transferStore struct {
+	memStore
+	size int64
+}
+
+func (s *transferStore) stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error) {
+	return minio.ObjectInfo{Key: key, Size: s.size}, nil
+}
+
+func (s *transferStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(make([]byte, s.size))), nil
+}
+
+func (s *transferStore) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (s *transferStore) copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	return nil
+}
+
+func TestBandwidthStore(t *testing.T) {
+	const size = 120 * 1024
+	transfers := map[string]func(objectStore) error{
+		"open": func(s objectStore) error {
+			r, err := s.open(context.Background(), "b", "k")
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			_, err = io.Copy(io.Discard, r)
+			return err
+		},
+		"put": func(s objectStore) error {
+			return s.put(context.Background(), "b", "k", bytes.NewReader(make([]byte, size)), size, "")
+		},
+		"copy": func(s objectStore) error {
+			return s.copy(context.Background(), "b", "k", "b2", "k")
+		},
+	}
+	tests := []struct {
+		name      string
+		global    float64
+		perWorker float64
+		// 桶容量为一秒的配额，超出的 20KiB 按 100KiB/s 约需 200ms
+		minElapsed time.Duration
+	}{
+		{name: "unlimited"},
+		{name: "global", global: 100 * 1024, minElapsed: 150 * time.Millisecond},
+		{name: "per worker", perWorker: 100 * 1024, minElapsed: 150 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for op, transfer := range transfers {
+			t.Run(tt.name+"/"+op, func(t *testing.T) {
+				var global *rateLimiter
+				if tt.global > 0 {
+					global = newRateLimiter(tt.global)
+				}
+				store := withBandwidthLimit(&transferStore{size: size}, global, tt.perWorker)
+				start := time.Now()
+				if err := transfer(store); err != nil {
+					t.Fatal(err)
+				}
+				elapsed := time.Since(start)
+				if elapsed < tt.minElapsed {
+					t.Errorf("elapsed = %v, want at least %v", elapsed, tt.minElapsed)
+				}
+				if tt.minElapsed == 0 && elapsed > 100*time.Millisecond {
+					t.Errorf("elapsed = %v, want no throttling", elapsed)
+				}
+			})
+		}
+	}
+}
+
+func TestBandwidthStoreCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	store := withBandwidthLimit(&transferStore{size: 10 * 1024}, nil, 1024)
+	err := store.put(ctx, "b", "k", strings.NewReader(strings.Repeat("x", 10*1024)), 10*1024, "")
+	if err != context.Canceled {
+		t.Errorf("put() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,114 @@
+package cleaner
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// stageTimer 累计流水线中一个阶段处理的对象数和实际工作时间
+type stageTimer struct {
+	count int64
+	busy  int64 // 纳秒
+}
+
+func (t *stageTimer) add(d time.Duration) {
+	atomic.AddInt64(&t.count, 1)
+	atomic.AddInt64(&t.busy, int64(d))
+}
+
+// benchStats 在基准模式下分别统计列举、筛选和执行阶段的吞吐量。
+// 各阶段的工作时间不含等待上下游的时间，吞吐量表示该阶段不被其他阶段拖慢时每秒能处理的对象数
+type benchStats struct {
+	listers int
+	workers int
+
+	list    stageTimer
+	filter  stageTimer
+	execute stageTimer
+
+	// listWait 为列举协程等待筛选阶段接收对象的累计时间
+	listWait int64
+}
+
+func newBenchStats(listers, workers int) *benchStats {
+	return &benchStats{listers: max(1, listers), workers: workers}
+}
+
+// listed 记录列举阶段向下游发送一个对象及等待的时间
+func (b *benchStats) listed(wait time.Duration) {
+	if b == nil {
+		return
+	}
+	atomic.AddInt64(&b.list.count, 1)
+	atomic.AddInt64(&b.listWait, int64(wait))
+}
+
+// listDone 记录列举阶段的总耗时，工作时间为总耗时减去各列举协程平均等待下游的时间
+func (b *benchStats) listDone(elapsed time.Duration) {
+	if b == nil {
+		return
+	}
+	busy := elapsed - time.Duration(atomic.LoadInt64(&b.listWait)/int64(b.listers))
+	atomic.StoreInt64(&b.list.busy, int64(max(busy, 0)))
+}
+
+func (b *benchStats) filtered(d time.Duration) {
+	if b != nil {
+		b.filter.add(d)
+	}
+}
+
+func (b *benchStats) executed(d time.Duration) {
+	if b != nil {
+		b.execute.add(d)
+	}
+}
+
+// throughput 返回 goroutines 个协程并行时每秒处理的对象数
+func (t *stageTimer) throughput(goroutines int) float64 {
+	if t.busy <= 0 {
+		return 0
+	}
+	return float64(t.count) / time.Duration(t.busy).Seconds() * float64(goroutines)
+}
+
+// log 输出各阶段的吞吐量和最慢的阶段，lang 为日志使用的语言
+func (b *benchStats) log(bucket, lang string, elapsed time.Duration, dryRun bool) {
+	type stage struct {
+		name       string
+		label      msgID
+		timer      *stageTimer
+		goroutines int
+	}
+	executeLabel := msgBenchStageDelete
+	if dryRun {
+		executeLabel = msgBenchStageDryRun
+	}
+	stages := []stage{
+		{"list", msgBenchStageList, &b.list, 1},
+		{"filter", msgBenchStageFilter, &b.filter, 1},
+		{"execute", executeLabel, &b.execute, b.workers},
+	}
+
+	var slowest *stage
+	var slowestRate float64
+	for i := range stages {
+		s := &stages[i]
+		busy := time.Duration(s.timer.busy)
+		rate := s.timer.throughput(s.goroutines)
+		slog.Info(trLang(lang, msgBenchStage, trLang(lang, s.label), s.timer.count, busy.Round(time.Millisecond), s.goroutines, rate),
+			"bucket", bucket, "action", "bench", "stage", s.name, "count", s.timer.count, "busy", busy, "goroutines", s.goroutines, "throughput", rate)
+		if s.timer.count > 0 && (slowest == nil || rate < slowestRate) {
+			slowest, slowestRate = s, rate
+		}
+	}
+
+	overall := float64(b.list.count) / elapsed.Seconds()
+	slog.Info(trLang(lang, msgBenchTotal, b.list.count, elapsed.Round(time.Millisecond), overall),
+		"bucket", bucket, "action", "bench", "count", b.list.count, "elapsed", elapsed, "throughput", overall)
+	if slowest != nil {
+		slog.Info(trLang(lang, msgBenchBottleneck, trLang(lang, slowest.label), slowestRate),
+			"bucket", bucket, "action", "bench", "bottleneck", slowest.name, "throughput", slowestRate)
+	}
+}
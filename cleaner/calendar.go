@@ -0,0 +1,109 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// calendarSeries 为一条规则按一种日历周期划分的对象
+type calendarSeries struct {
+	rule   int
+	period rules.Period
+}
+
+// calendarPeriods 返回规则按哪些日历周期保留对象，以及各保留最近多少个周期，0 表示保留所有周期
+func calendarPeriods(r *rules.Rule) map[rules.Period]int {
+	limits := make(map[rules.Period]int)
+	for p, n := range map[rules.Period]int{rules.PeriodDay: r.GFS.Daily, rules.PeriodWeek: r.GFS.Weekly, rules.PeriodMonth: r.GFS.Monthly} {
+		if n > 0 {
+			limits[p] = n
+		}
+	}
+	if r.KeepLastOf != "" {
+		limits[r.KeepLastOf] = 0
+	}
+	return limits
+}
+
+// calendarObject 为日历周期中目前最新的对象
+type calendarObject struct {
+	key          string
+	lastModified time.Time
+}
+
+// newer 返回 obj 是否比 o 新，修改时间相同时对象名较大的为新，多次运行的结果一致
+func (o calendarObject) newer(obj minio.ObjectInfo) bool {
+	if !obj.LastModified.Equal(o.lastModified) {
+		return obj.LastModified.After(o.lastModified)
+	}
+	return obj.Key > o.key
+}
+
+// calendarIndex 记录规则按日历周期保留的对象，这些对象即使超过 maxAge 也不清理
+type calendarIndex struct {
+	keep map[string]bool
+}
+
+// buildCalendarIndex 列举存储桶，找出设置了 keepLastOf 或 gfs 的规则在每个日历周期中最新的对象。
+// 周期的边界按 now 的时区（cleanup.timezone）计算。列举出错时返回错误，不清理任何对象
+func buildCalendarIndex(ctx context.Context, cfg *config.Config, store objectStore, now time.Time) (*calendarIndex, error) {
+	compiled := rules.Compile(effectiveRules(cfg), now, cfg.Safety.MinObjectAge)
+	limits := make([]map[rules.Period]int, len(compiled))
+	for i, r := range compiled {
+		limits[i] = calendarPeriods(&r.Rule)
+	}
+	loc := now.Location()
+	latest := make(map[calendarSeries]map[time.Time]calendarObject)
+	var mu sync.Mutex
+	var listErr error
+	bucket := cfg.Minio.Bucket
+	listBucket(ctx, store, bucket, cfg.Cleanup.Listers, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		idx, r := rules.Match(compiled, obj.Key)
+		if r == nil || len(limits[idx]) == 0 {
+			return
+		}
+		modified := obj.LastModified.In(loc)
+		mu.Lock()
+		defer mu.Unlock()
+		for p := range limits[idx] {
+			series := calendarSeries{rule: idx, period: p}
+			if latest[series] == nil {
+				latest[series] = make(map[time.Time]calendarObject)
+			}
+			start := p.Start(modified)
+			if cur, ok := latest[series][start]; !ok || cur.newer(obj) {
+				latest[series][start] = calendarObject{key: obj.Key, lastModified: obj.LastModified}
+			}
+		}
+	}, func(err error) {
+		mu.Lock()
+		listErr = err
+		mu.Unlock()
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if listErr != nil {
+		return nil, errors.New(tr(cfg, msgCalendarIndexFailed, bucket, listErr))
+	}
+	c := &calendarIndex{keep: make(map[string]bool)}
+	for series, slots := range latest {
+		// 保留最近的若干个有对象的周期，没有对象的周期不计数
+		starts := slices.SortedFunc(maps.Keys(slots), func(a, b time.Time) int { return b.Compare(a) })
+		if n := limits[series.rule][series.period]; n > 0 && len(starts) > n {
+			starts = starts[:n]
+		}
+		for _, start := range starts {
+			c.keep[slots[start].key] = true
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,96 @@
+package cleaner
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestBuildCalendarIndex(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	at := func(m time.Month, d, h int) time.Time { return time.Date(2024, m, d, h, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name    string
+		rule    rules.Rule
+		objects []minio.ObjectInfo
+		want    []string
+	}{
+		{
+			name: "keepLastOf month",
+			rule: rules.Rule{Prefix: "exports/", MaxAge: rules.Retention(rules.Day), KeepLastOf: rules.PeriodMonth},
+			objects: []minio.ObjectInfo{
+				testObject("exports/a", at(4, 10, 0), 1),
+				testObject("exports/b", at(4, 25, 0), 1),
+				testObject("exports/c", at(5, 2, 0), 1),
+				testObject("other/d", at(5, 3, 0), 1),
+			},
+			want: []string{"exports/b", "exports/c"},
+		},
+		{
+			name: "same time keeps the larger key",
+			rule: rules.Rule{MaxAge: rules.Retention(rules.Day), KeepLastOf: rules.PeriodDay},
+			objects: []minio.ObjectInfo{
+				testObject("b", at(5, 2, 8), 1),
+				testObject("a", at(5, 2, 8), 1),
+				testObject("c", at(5, 2, 7), 1),
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "gfs keeps the latest periods with objects",
+			rule: rules.Rule{MaxAge: rules.Retention(rules.Day), GFS: rules.GFSConfig{Daily: 2}},
+			objects: []minio.ObjectInfo{
+				testObject("1", at(6, 1, 10), 1),
+				testObject("2", at(6, 1, 11), 1),
+				testObject("3", at(6, 5, 9), 1),
+				testObject("4", at(6, 9, 9), 1),
+				testObject("5", at(6, 9, 8), 1),
+			},
+			want: []string{"3", "4"},
+		},
+		{
+			name: "gfs daily and weekly",
+			rule: rules.Rule{MaxAge: rules.Retention(rules.Day), GFS: rules.GFSConfig{Daily: 1, Weekly: 2}},
+			objects: []minio.ObjectInfo{
+				testObject("mon", at(6, 3, 9), 1),
+				testObject("fri", at(6, 7, 9), 1),
+				testObject("prev-sun", at(6, 2, 9), 1),
+				testObject("prev-prev", at(5, 26, 9), 1),
+			},
+			want: []string{"fri", "prev-sun"},
+		},
+		{
+			name: "no calendar rule",
+			rule: rules.Rule{MaxAge: rules.Retention(rules.Day)},
+			objects: []minio.ObjectInfo{
+				testObject("a", at(6, 1, 0), 1),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(tt.rule)
+			c, err := buildCalendarIndex(context.Background(), cfg, &memStore{objects: tt.objects}, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Sorted(maps.Keys(c.keep)); !slices.Equal(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildCalendarIndexListError(t *testing.T) {
+	cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day), KeepLastOf: rules.PeriodMonth})
+	store := &memStore{listErr: context.DeadlineExceeded}
+	if _, err := buildCalendarIndex(context.Background(), cfg, store, time.Now()); err == nil {
+		t.Error("buildCalendarIndex() error = nil, want the listing error")
+	}
+}
@@ -0,0 +1,87 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	rand "math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// reservoir 从数量未知的对象中等概率随机抽取至多 size 个，size 为负数时保留全部对象，可被并发调用
+type reservoir struct {
+	mu    sync.Mutex
+	size  int
+	seen  int
+	items []minio.ObjectInfo
+}
+
+func newReservoir(size int) *reservoir {
+	return &reservoir{size: size}
+}
+
+func (r *reservoir) offer(obj minio.ObjectInfo) {
+	if r == nil || r.size == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen++
+	if r.size < 0 || len(r.items) < r.size {
+		r.items = append(r.items, obj)
+	} else if i := rand.IntN(r.seen); i < r.size {
+		r.items[i] = obj
+	}
+}
+
+// canaryPromptMu 保证并行清理多个目标时逐个询问是否继续
+var canaryPromptMu sync.Mutex
+
+// runCanary 删除抽取的金丝雀对象，作为一次单独的运行输出报告、清单和运行历史，
+// 之后等待 canary.delay 或操作者确认。金丝雀删除出错或操作者未确认时返回错误，不再删除其余对象
+func (c *cleaner) runCanary(ctx context.Context, sample []minio.ObjectInfo) error {
+	cfg := c.cfg
+	bucket := cfg.Minio.Bucket
+	slog.Info(tr(cfg, msgCanaryStart, bucket, len(sample)), "bucket", bucket, "action", "canary", "files", len(sample))
+
+	canary := &cleaner{cfg: cfg, store: c.store, sharedLimiter: c.sharedLimiter, onProgress: c.onProgress, objects: c.objects, preset: sample}
+	report, err := canary.run(ctx)
+	recordHistory(cfg, report, err)
+	if err != nil {
+		return errors.New(tr(cfg, msgCanaryFailed, bucket, err))
+	}
+	if report.ErrorCount > 0 {
+		return errors.New(tr(cfg, msgCanaryErrors, bucket, report.ErrorCount))
+	}
+	if report.ManifestFile != "" {
+		slog.Info(tr(cfg, msgCanaryDone, bucket, report.DeletedFiles, report.ManifestFile), "bucket", bucket, "action", "canary")
+	} else {
+		slog.Info(tr(cfg, msgCanaryDeleted, bucket, report.DeletedFiles), "bucket", bucket, "action", "canary")
+	}
+
+	if cfg.Canary.Confirm {
+		canaryPromptMu.Lock()
+		defer canaryPromptMu.Unlock()
+		slog.Info(tr(cfg, msgCanaryPrompt, bucket), "bucket", bucket, "action", "canary")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+			return errors.New(tr(cfg, msgCanaryCancelled, bucket))
+		}
+		return nil
+	}
+	if cfg.Canary.Delay > 0 {
+		slog.Info(tr(cfg, msgCanaryWaiting, bucket, cfg.Canary.Delay), "bucket", bucket, "action", "canary")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(cfg.Canary.Delay):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// candidateEntry 记录预览模式下一个待清理的对象
+type candidateEntry struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	Rule         string    `json:"rule"`
+}
+
+// candidateWriter 将待清理对象列表写入临时文件，运行成功结束后替换上一次的列表
+type candidateWriter struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	enc  *json.Encoder
+}
+
+// previousCandidatesPath 返回上一次运行的待清理对象列表路径
+func previousCandidatesPath(path string) string {
+	return path + ".prev"
+}
+
+func openCandidates(path string) (*candidateWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &candidateWriter{path: path, f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func (c *candidateWriter) write(e candidateEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Encode(e)
+}
+
+// commit 关闭临时文件，将当前列表保存为上一次的列表，并用本次结果替换当前列表
+func (c *candidateWriter) commit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.w.Flush(); err != nil {
+		c.f.Close()
+		return err
+	}
+	if err := c.f.Close(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(c.path); err == nil {
+		if err := os.Rename(c.path, previousCandidatesPath(c.path)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(c.path+".tmp", c.path)
+}
+
+// discard 放弃本次写入的列表，保留上一次的结果
+func (c *candidateWriter) discard() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.f.Close()
+	os.Remove(c.path + ".tmp")
+}
+
+// defaultCandidatesFile 为 diff 命令在未配置 report.candidatesFile 时使用的路径
+const defaultCandidatesFile = "candidates.jsonl"
+
+// runDiff 以预览模式运行一次清理，并与上一次预览的待清理对象列表比较，
+// 输出新增匹配和不再匹配的对象
+func runDiff(ctx context.Context, cfg *config.Config, store objectStore) error {
+	cfg.Cleanup.DryRun = true
+	if cfg.Report.CandidatesFile == "" {
+		cfg.Report.CandidatesFile = defaultCandidatesFile
+	}
+
+	c := &cleaner{cfg: cfg, store: store}
+	report, err := c.run(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := report.CandidatesFile
+	previousSorted, err := sortCandidates(previousCandidatesPath(path))
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	defer os.Remove(previousSorted)
+	currentSorted, err := sortCandidates(path)
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	defer os.Remove(currentSorted)
+
+	previous, err := openCandidateReader(previousSorted)
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	defer previous.close()
+	current, err := openCandidateReader(currentSorted)
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	defer current.close()
+
+	// 两个列表均已按 Key 排序，逐条归并比较，按对象名顺序输出差异
+	bucket := cfg.Minio.Bucket
+	var previousCount, currentCount, added, removed int
+	prev, prevOK, err := previous.next()
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	cur, curOK, err := current.next()
+	if err != nil {
+		return errors.New(tr(cfg, msgDiffReadFailed, err))
+	}
+	for prevOK || curOK {
+		switch {
+		case curOK && (!prevOK || cur.Key < prev.Key):
+			added++
+			currentCount++
+			slog.Info(tr(cfg, msgDiffAdded, cur.Key, float64(cur.Size)/1024/1024, cur.Rule),
+				"bucket", bucket, "key", cur.Key, "size", cur.Size, "rule", cur.Rule, "action", "diff", "change", "added")
+			cur, curOK, err = current.next()
+		case prevOK && (!curOK || prev.Key < cur.Key):
+			removed++
+			previousCount++
+			slog.Info(tr(cfg, msgDiffRemoved, prev.Key, float64(prev.Size)/1024/1024, prev.Rule),
+				"bucket", bucket, "key", prev.Key, "size", prev.Size, "rule", prev.Rule, "action", "diff", "change", "removed")
+			prev, prevOK, err = previous.next()
+		default:
+			previousCount++
+			currentCount++
+			if prev, prevOK, err = previous.next(); err == nil {
+				cur, curOK, err = current.next()
+			}
+		}
+		if err != nil {
+			return errors.New(tr(cfg, msgDiffReadFailed, err))
+		}
+	}
+	slog.Info(tr(cfg, msgDiffSummary, previousCount, currentCount, added, removed),
+		"bucket", bucket, "action", "diff", "previous", previousCount, "current", currentCount, "added", added, "removed", removed)
+	return nil
+}
@@ -0,0 +1,232 @@
+package cleaner
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+)
+
+// sortChunkSize 为外部排序时一次在内存中排序的条目数，决定 diff 命令的内存占用
+const sortChunkSize = 100000
+
+// sortMergeFanIn 为每轮归并同时打开的临时文件数上限，避免超出文件描述符限制
+const sortMergeFanIn = 64
+
+// candidateReader 顺序读取待清理对象列表，跳过与上一条 Key 相同的重复条目
+type candidateReader struct {
+	f    *os.File
+	dec  *json.Decoder
+	last string
+	read bool
+}
+
+// openCandidateReader 打开待清理对象列表，文件不存在时视为空列表
+func openCandidateReader(path string) (*candidateReader, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &candidateReader{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &candidateReader{f: f, dec: json.NewDecoder(bufio.NewReader(f))}, nil
+}
+
+// next 返回下一条记录，读完时 ok 为 false
+func (r *candidateReader) next() (e candidateEntry, ok bool, err error) {
+	for r.dec != nil && r.dec.More() {
+		if err := r.dec.Decode(&e); err != nil {
+			return e, false, err
+		}
+		if r.read && e.Key == r.last {
+			continue
+		}
+		r.last, r.read = e.Key, true
+		return e, true, nil
+	}
+	return e, false, nil
+}
+
+func (r *candidateReader) close() {
+	if r.f != nil {
+		r.f.Close()
+	}
+}
+
+// sortCandidates 将待清理对象列表按 Key 排序后写入临时文件，返回其路径，调用方负责删除。
+// 列表按 sortChunkSize 条分段在内存中排序并写入临时文件，再多路归并，
+// 内存占用与列表长度无关。临时文件位于 TMPDIR 下
+func sortCandidates(path string) (string, error) {
+	r, err := openCandidateReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.close()
+
+	var chunks []string
+	cleanup := func() {
+		for _, c := range chunks {
+			os.Remove(c)
+		}
+	}
+	chunk := make([]candidateEntry, 0, sortChunkSize)
+	for {
+		e, ok, err := r.next()
+		if err != nil {
+			cleanup()
+			return "", err
+		}
+		if ok {
+			chunk = append(chunk, e)
+		}
+		if len(chunk) == sortChunkSize || (!ok && (len(chunk) > 0 || len(chunks) == 0)) {
+			sort.Slice(chunk, func(i, j int) bool { return chunk[i].Key < chunk[j].Key })
+			name, err := writeCandidateChunk(chunk)
+			if err != nil {
+				cleanup()
+				return "", err
+			}
+			chunks = append(chunks, name)
+			chunk = chunk[:0]
+		}
+		if !ok {
+			break
+		}
+	}
+
+	// 每轮最多归并 sortMergeFanIn 个文件，直到只剩一个
+	for len(chunks) > 1 {
+		var merged []string
+		for i := 0; i < len(chunks); i += sortMergeFanIn {
+			group := chunks[i:min(i+sortMergeFanIn, len(chunks))]
+			name, err := mergeCandidateChunks(group)
+			if err != nil {
+				chunks = append(merged, chunks[i:]...)
+				cleanup()
+				return "", err
+			}
+			for _, c := range group {
+				os.Remove(c)
+			}
+			merged = append(merged, name)
+		}
+		chunks = merged
+	}
+	return chunks[0], nil
+}
+
+// writeCandidateChunk 将一段已排序的记录写入新的临时文件
+func writeCandidateChunk(entries []candidateEntry) (string, error) {
+	f, err := os.CreateTemp("", "minio-cleaner-sort-*.jsonl")
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return "", err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// mergeItem 为多路归并中某个输入文件的当前记录
+type mergeItem struct {
+	entry  candidateEntry
+	reader *candidateReader
+}
+
+// mergeHeap 按 Key 排列各输入文件的当前记录
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool { return h[i].entry.Key < h[j].entry.Key }
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(mergeItem)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// mergeCandidateChunks 将多个已排序的临时文件归并为一个新的临时文件
+func mergeCandidateChunks(paths []string) (string, error) {
+	var h mergeHeap
+	var readers []*candidateReader
+	defer func() {
+		for _, r := range readers {
+			r.close()
+		}
+	}()
+	for _, p := range paths {
+		r, err := openCandidateReader(p)
+		if err != nil {
+			return "", err
+		}
+		readers = append(readers, r)
+		e, ok, err := r.next()
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			h = append(h, mergeItem{entry: e, reader: r})
+		}
+	}
+	heap.Init(&h)
+
+	f, err := os.CreateTemp("", "minio-cleaner-sort-*.jsonl")
+	if err != nil {
+		return "", err
+	}
+	fail := func(err error) (string, error) {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for h.Len() > 0 {
+		item := h[0]
+		if err := enc.Encode(item.entry); err != nil {
+			return fail(err)
+		}
+		e, ok, err := item.reader.next()
+		if err != nil {
+			return fail(err)
+		}
+		if ok {
+			h[0].entry = e
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,168 @@
+package cleaner
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// chatMessageData 为消息模板可用的数据
+type chatMessageData struct {
+	Event   string
+	Title   string
+	Bucket  string
+	Summary string
+	Report  *report.RunReport
+	Error   error
+}
+
+// chatEvents 返回本次运行对该通知触发的事件
+func chatEvents(c *config.ChatConfig, report *report.RunReport) []string {
+	var events []string
+	if c.Wants(config.ChatEventCompletion) {
+		events = append(events, config.ChatEventCompletion)
+	}
+	if report == nil {
+		return events
+	}
+	if c.Wants(config.ChatEventThreshold) &&
+		((c.DeletedFiles > 0 && report.DeletedFiles > c.DeletedFiles) ||
+			(c.DeletedBytes > 0 && report.DeletedBytes > int64(c.DeletedBytes))) {
+		events = append(events, config.ChatEventThreshold)
+	}
+	if c.Wants(config.ChatEventErrorRate) && c.ErrorRate > 0 && report.ProcessedFiles > 0 &&
+		float64(report.ErrorCount)/float64(report.ProcessedFiles) > c.ErrorRate {
+		events = append(events, config.ChatEventErrorRate)
+	}
+	return events
+}
+
+// notifyChat 向配置的聊天平台发送运行结果通知，发送失败只记录日志
+func notifyChat(cfg *config.Config, report *report.RunReport, runErr error) {
+	for i := range cfg.Notify.Chat {
+		chat := &cfg.Notify.Chat[i]
+		for _, event := range chatEvents(chat, report) {
+			data := chatMessageData{
+				Event:   event,
+				Title:   chatTitle(cfg, event, report, runErr),
+				Bucket:  cfg.Minio.Bucket,
+				Summary: summaryText(cfg, report, runErr),
+				Report:  report,
+				Error:   runErr,
+			}
+			if err := sendChat(cfg, chat, data); err != nil {
+				slog.Error(tr(cfg, msgChatFailed, chat.Type, err), "bucket", cfg.Minio.Bucket, "action", "notify", "error", err)
+				continue
+			}
+			slog.Info(tr(cfg, msgChatSent, chat.Type, event), "bucket", cfg.Minio.Bucket, "action", "notify")
+		}
+	}
+}
+
+func chatTitle(cfg *config.Config, event string, report *report.RunReport, runErr error) string {
+	switch event {
+	case config.ChatEventThreshold:
+		return tr(cfg, msgChatTitleThreshold, cfg.Minio.Bucket)
+	case config.ChatEventErrorRate:
+		return tr(cfg, msgChatTitleErrorRate, cfg.Minio.Bucket)
+	}
+	if runFailed(report, runErr) {
+		return tr(cfg, msgEmailSubjectFail, cfg.Minio.Bucket)
+	}
+	return tr(cfg, msgEmailSubjectOK, cfg.Minio.Bucket)
+}
+
+// renderChatMessage 使用配置的模板或默认格式生成消息正文
+func renderChatMessage(chat *config.ChatConfig, data chatMessageData) (string, error) {
+	if chat.Template == "" {
+		return data.Title + "\n" + data.Summary, nil
+	}
+	tmpl, err := template.New("chat").Parse(chat.Template)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sendChat 按平台格式构造请求并发送，错误信息使用 cfg 设置的语言
+func sendChat(cfg *config.Config, chat *config.ChatConfig, data chatMessageData) error {
+	text, err := renderChatMessage(chat, data)
+	if err != nil {
+		return err
+	}
+
+	target := chat.URL
+	var payload any
+	switch strings.ToLower(chat.Type) {
+	case "slack":
+		payload = map[string]any{"text": text}
+	case "teams":
+		// Teams 的 MessageCard 文本按 Markdown 渲染，需要空行才能换行
+		payload = map[string]any{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  data.Title,
+			"text":     strings.ReplaceAll(text, "\n", "\n\n"),
+		}
+	case "dingtalk":
+		payload = map[string]any{
+			"msgtype": "text",
+			"text":    map[string]string{"content": text},
+		}
+		if chat.Secret != "" {
+			target, err = signDingTalkURL(target, chat.Secret, time.Now())
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New(tr(cfg, msgChatBadType, chat.Type))
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := notifyHTTPClient.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signDingTalkURL 按钉钉机器人加签规则为地址附加 timestamp 和 sign 参数
+func signDingTalkURL(rawURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	q := u.Query()
+	q.Set("timestamp", timestamp)
+	q.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
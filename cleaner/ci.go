@@ -0,0 +1,130 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// ciBranch 为一个分支的构建：keepFrom 为保留的最早构建号，latest 为最近一次构建的修改时间
+type ciBranch struct {
+	keepFrom int64
+	latest   time.Time
+}
+
+type ciPreset struct {
+	cfg    *config.Config
+	preset *config.CIPresetConfig
+	layout *regexp.Regexp
+
+	// branches 由 prepare 根据列举结果生成，之后只读；为 nil 时保留所有符合布局的对象
+	branches map[string]ciBranch
+}
+
+func newGitLabPreset(cfg *config.Config) appPreset {
+	return newCIPreset(cfg, &cfg.Presets.GitLab, config.DefaultGitLabLayout)
+}
+
+func newJenkinsPreset(cfg *config.Config) appPreset {
+	return newCIPreset(cfg, &cfg.Presets.Jenkins, config.DefaultJenkinsLayout)
+}
+
+func newCIPreset(cfg *config.Config, preset *config.CIPresetConfig, layout string) appPreset {
+	// layout 已在 validate 中检查，无法编译时不放行任何对象
+	re, _ := regexp.Compile(preset.LayoutPattern(layout))
+	return &ciPreset{cfg: cfg, preset: preset, layout: re}
+}
+
+func (c *ciPreset) rules() []rules.Rule {
+	return []rules.Rule{{Name: c.cfg.Minio.Preset + "-artifacts", MaxAge: c.preset.MaxAge}}
+}
+
+// parse 按布局解析对象名中的分支和构建号，不符合布局或构建号不是整数时返回 false
+func (c *ciPreset) parse(key string) (string, int64, bool) {
+	if c.layout == nil {
+		return "", 0, false
+	}
+	m := c.layout.FindStringSubmatch(key)
+	if m == nil {
+		return "", 0, false
+	}
+	build, err := strconv.ParseInt(m[c.layout.SubexpIndex("build")], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return m[c.layout.SubexpIndex("branch")], build, true
+}
+
+// prepare 列举存储桶，找出每个分支最近 keepLatest 次构建。列举出错时不清理，避免把未列举到的构建当作不存在
+func (c *ciPreset) prepare(ctx context.Context, store objectStore, _ time.Time) error {
+	type builds struct {
+		numbers map[int64]bool
+		latest  time.Time
+	}
+	var mu sync.Mutex
+	seen := make(map[string]*builds)
+	var listErr error
+	bucket := c.cfg.Minio.Bucket
+	listBucket(ctx, store, bucket, c.cfg.Cleanup.Listers, c.cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		branch, build, ok := c.parse(obj.Key)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		b := seen[branch]
+		if b == nil {
+			b = &builds{numbers: make(map[int64]bool)}
+			seen[branch] = b
+		}
+		b.numbers[build] = true
+		if obj.LastModified.After(b.latest) {
+			b.latest = obj.LastModified
+		}
+	}, func(err error) {
+		mu.Lock()
+		listErr = err
+		mu.Unlock()
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if listErr != nil {
+		return errors.New(tr(c.cfg, msgCIIndexFailed, bucket, listErr))
+	}
+	c.branches = make(map[string]ciBranch, len(seen))
+	for branch, b := range seen {
+		numbers := slices.Sorted(maps.Keys(b.numbers))
+		keep := min(c.preset.KeepLatest, len(numbers))
+		c.branches[branch] = ciBranch{keepFrom: numbers[len(numbers)-keep], latest: b.latest}
+	}
+	return nil
+}
+
+// keep 保留不符合布局的对象和每个分支最近的构建；分支长期没有新构建时，最近的构建也可以清理
+func (c *ciPreset) keep(obj rules.ObjectInfo) bool {
+	branch, build, ok := c.parse(obj.Key)
+	if !ok || c.branches == nil {
+		return true
+	}
+	b, ok := c.branches[branch]
+	// 列举之后才上传的构建不在索引中，是最新的构建
+	if !ok || build >= b.keepFrom {
+		return !c.stale(b)
+	}
+	return false
+}
+
+// stale 返回分支最近一次构建是否早于 staleBranchAge
+func (c *ciPreset) stale(b ciBranch) bool {
+	return c.preset.StaleBranchAge > 0 && !b.latest.IsZero() && b.latest.Before(c.preset.StaleBranchAge.Before(time.Now()))
+}
@@ -0,0 +1,97 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestCIKeep(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-60 * rules.Day)
+	objects := []minio.ObjectInfo{
+		testObject("group/app/main/10/app.zip", old, 1),
+		testObject("group/app/main/11/app.zip", old, 1),
+		testObject("group/app/main/12/app.zip", now.Add(-rules.Day), 1),
+		testObject("group/app/feature/5/app.zip", old, 1),
+		testObject("group/app/feature/6/app.zip", old.Add(rules.Day), 1),
+		testObject("group/app/README.md", old, 1),
+		testObject("folder/job/main/3/artifacts/app.jar", old, 1),
+		testObject("folder/job/main/4/artifacts/app.jar", now.Add(-rules.Day), 1),
+		testObject("folder/job/main/4/log", old, 1),
+	}
+	tests := []struct {
+		name   string
+		preset string
+		key    string
+		want   bool
+	}{
+		{name: "gitlab old build", preset: "gitlab", key: "group/app/main/10/app.zip", want: false},
+		// 每个分支最近 keepLatest 次构建保留
+		{name: "gitlab latest builds", preset: "gitlab", key: "group/app/main/11/app.zip", want: true},
+		{name: "gitlab newest build", preset: "gitlab", key: "group/app/main/12/app.zip", want: true},
+		// 列举之后才上传的构建是最新的构建
+		{name: "gitlab new build", preset: "gitlab", key: "group/app/main/13/app.zip", want: true},
+		{name: "gitlab new branch", preset: "gitlab", key: "group/app/hotfix/1/app.zip", want: true},
+		// 分支长期没有新构建时，最近的构建也可以清理
+		{name: "gitlab stale branch", preset: "gitlab", key: "group/app/feature/6/app.zip", want: false},
+		{name: "gitlab not a build", preset: "gitlab", key: "group/app/README.md", want: true},
+		{name: "jenkins old build", preset: "jenkins", key: "folder/job/main/3/artifacts/app.jar", want: false},
+		{name: "jenkins latest build", preset: "jenkins", key: "folder/job/main/4/artifacts/app.jar", want: true},
+		{name: "jenkins log", preset: "jenkins", key: "folder/job/main/4/log", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Minio.Preset = tt.preset
+			preset := config.CIPresetConfig{KeepLatest: 2, MaxAge: rules.Retention(30 * rules.Day), StaleBranchAge: rules.Retention(30 * rules.Day)}
+			cfg.Presets.GitLab, cfg.Presets.Jenkins = preset, preset
+			cfg.Presets.Jenkins.KeepLatest = 1
+			p, err := newAppPreset(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.prepare(context.Background(), &memStore{objects: objects}, now); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.keep(testObject(tt.key, old, 1)); got != tt.want {
+				t.Errorf("keep(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIPrepareFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+	}{
+		{name: "default layout"},
+		// 布局无法编译时不放行任何对象
+		{name: "invalid layout", layout: "("},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			cfg := testConfig()
+			cfg.Minio.Preset = "gitlab"
+			cfg.Presets.GitLab = config.CIPresetConfig{Layout: tt.layout, KeepLatest: 1, MaxAge: rules.Retention(rules.Day)}
+			c := newGitLabPreset(cfg)
+			obj := testObject("app/main/1/app.zip", now.Add(-10*rules.Day), 1)
+			store := &memStore{objects: []minio.ObjectInfo{obj, testObject("app/main/2/app.zip", now, 1)}, listErr: errors.New("boom")}
+			if err := c.prepare(context.Background(), store, now); err == nil || !strings.Contains(err.Error(), "boom") {
+				t.Fatalf("prepare() = %v, want list error", err)
+			}
+			// 列举出错时保留所有构建
+			if !c.keep(obj) {
+				t.Error("keep() = false after a failed prepare, want true")
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// authErrorCodes 为表示凭证或权限失效的 S3 错误码
+var authErrorCodes = map[string]bool{
+	"AccessDenied":          true,
+	"InvalidAccessKeyId":    true,
+	"SignatureDoesNotMatch": true,
+	"ExpiredToken":          true,
+	"InvalidToken":          true,
+}
+
+// isEndpointError 判断错误是否表示 endpoint 整体不可用：无法连接或认证失败，
+// 而非单个对象的问题
+func isEndpointError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	resp := minio.ToErrorResponse(err)
+	if authErrorCodes[resp.Code] || resp.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// circuitBreaker 统计连续的 endpoint 错误，达到阈值后断开：
+// 所有删除在 wait 中阻塞，由探测协程定期检查 endpoint，探测成功后恢复
+type circuitBreaker struct {
+	threshold int
+	interval  time.Duration
+	bucket    string
+	lang      string
+	probe     func(ctx context.Context) error
+
+	mu       sync.Mutex
+	failures int
+	// ready 在熔断器闭合时为已关闭的通道，断开时替换为新通道，恢复时关闭
+	ready    chan struct{}
+	open     bool
+	openedAt time.Time
+}
+
+func newCircuitBreaker(cfg *config.CircuitBreakerConfig, bucket, lang string, probe func(ctx context.Context) error) *circuitBreaker {
+	ready := make(chan struct{})
+	close(ready)
+	return &circuitBreaker{
+		threshold: cfg.FailureThreshold,
+		interval:  cfg.ProbeInterval,
+		bucket:    bucket,
+		lang:      lang,
+		probe:     probe,
+		ready:     ready,
+	}
+}
+
+// wait 在熔断器断开时阻塞，直到 endpoint 恢复或 ctx 结束
+func (b *circuitBreaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	ready := b.ready
+	b.mu.Unlock()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// record 记录一次请求的结果，连续 endpoint 错误达到阈值时断开熔断器并开始探测
+func (b *circuitBreaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !isEndpointError(err) {
+		if err == nil {
+			b.failures = 0
+		}
+		return
+	}
+	b.failures++
+	if b.open || b.failures < b.threshold {
+		return
+	}
+	b.open = true
+	b.openedAt = time.Now()
+	b.ready = make(chan struct{})
+	slog.Error(trLang(b.lang, msgCircuitOpen, b.failures, b.interval, err),
+		"bucket", b.bucket, "action", "circuitOpen", "failures", b.failures, "error", err)
+	go b.probeLoop(ctx)
+}
+
+// probeLoop 每隔 interval 探测一次 endpoint，探测成功后闭合熔断器
+func (b *circuitBreaker) probeLoop(ctx context.Context) {
+	defer ReportPanic()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := b.probe(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn(trLang(b.lang, msgCircuitProbeFailed, err), "bucket", b.bucket, "action", "circuitProbe", "error", err)
+			continue
+		}
+
+		b.mu.Lock()
+		paused := time.Since(b.openedAt)
+		b.open = false
+		b.failures = 0
+		close(b.ready)
+		b.mu.Unlock()
+		slog.Info(trLang(b.lang, msgCircuitClosed, paused.Round(time.Second)),
+			"bucket", b.bucket, "action", "circuitClose", "paused", paused)
+		return
+	}
+}
@@ -0,0 +1,119 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestIsEndpointError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: fmt.Errorf("delete: %w", context.DeadlineExceeded), want: false},
+		{name: "access denied", err: minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, want: true},
+		{name: "expired token", err: minio.ErrorResponse{Code: "ExpiredToken", StatusCode: http.StatusBadRequest}, want: true},
+		{name: "unauthorized", err: minio.ErrorResponse{StatusCode: http.StatusUnauthorized}, want: true},
+		// 单个对象的错误和服务端过载不表示 endpoint 不可用
+		{name: "no such key", err: minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound}, want: false},
+		{name: "slow down", err: minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}, want: false},
+		{name: "dial", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}, want: true},
+		{name: "connection refused", err: fmt.Errorf("remove: %w", syscall.ECONNREFUSED), want: true},
+		{name: "connection reset", err: fmt.Errorf("remove: %w", syscall.ECONNRESET), want: true},
+		{name: "other", err: errors.New("boom"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEndpointError(tt.err); got != tt.want {
+				t.Errorf("isEndpointError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerOpen(t *testing.T) {
+	endpoint := syscall.ECONNREFUSED
+	object := minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound}
+	tests := []struct {
+		name     string
+		errs     []error
+		wantOpen bool
+	}{
+		{name: "below threshold", errs: []error{endpoint, endpoint}, wantOpen: false},
+		{name: "threshold", errs: []error{endpoint, endpoint, endpoint}, wantOpen: true},
+		// 成功的请求使连续错误数归零
+		{name: "reset by success", errs: []error{endpoint, endpoint, nil, endpoint, endpoint}, wantOpen: false},
+		// 单个对象的错误既不计数也不归零
+		{name: "object errors", errs: []error{endpoint, object, endpoint, object, endpoint}, wantOpen: true},
+		{name: "object errors only", errs: []error{object, object, object}, wantOpen: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var probes atomic.Int32
+			b := newCircuitBreaker(&config.CircuitBreakerConfig{FailureThreshold: 3, ProbeInterval: 10 * time.Millisecond}, "b", "", func(ctx context.Context) error {
+				probes.Add(1)
+				return endpoint
+			})
+			for _, err := range tt.errs {
+				b.record(ctx, err)
+			}
+			waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+			defer waitCancel()
+			err := b.wait(waitCtx)
+			if open := err != nil; open != tt.wantOpen {
+				t.Errorf("wait() = %v, want open %v", err, tt.wantOpen)
+			}
+			// 断开时探测失败不会闭合熔断器；闭合时不探测
+			if tt.wantOpen && probes.Load() == 0 {
+				t.Error("open breaker did not probe the endpoint")
+			}
+			if !tt.wantOpen && probes.Load() != 0 {
+				t.Errorf("closed breaker probed the endpoint %d times", probes.Load())
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRecover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var probes atomic.Int32
+	b := newCircuitBreaker(&config.CircuitBreakerConfig{FailureThreshold: 1, ProbeInterval: 10 * time.Millisecond}, "b", "", func(ctx context.Context) error {
+		if probes.Add(1) < 3 {
+			return syscall.ECONNREFUSED
+		}
+		return nil
+	})
+	b.record(ctx, syscall.ECONNREFUSED)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	if err := b.wait(waitCtx); err != nil {
+		t.Fatalf("wait() = %v, want the breaker to close after a successful probe", err)
+	}
+	if got := probes.Load(); got != 3 {
+		t.Errorf("%d probes, want 3", got)
+	}
+	// 闭合后重新计数，再次达到阈值时重新断开
+	b.record(ctx, syscall.ECONNREFUSED)
+	b.mu.Lock()
+	open := b.open
+	b.mu.Unlock()
+	if !open {
+		t.Error("breaker did not open again after recovering")
+	}
+}
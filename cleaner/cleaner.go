@@ -0,0 +1,430 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// runStats 记录单次清理过程的计数器
+type runStats struct {
+	// totalFiles 为已列举出的文件数，listed 为 1 后即为总文件数
+	totalFiles     int64
+	listed         int32
+	processedFiles int64
+	processedSize  int64
+	deletedFiles   int64
+	deletedSize    int64
+	failedFiles    int64
+	failedSize     int64
+	changedFiles   int64
+	unreplicated   int64
+	pluginKept     int64
+	ttlKept        int64
+	hookVetoed     int64
+	errorCount     int64
+	retries        int64
+
+	// rules 与本次运行的规则一一对应
+	rules []*ruleStats
+	// filters 与本次运行的过滤器（集成预设和 Config.Filters）一一对应
+	filters []*filterStats
+
+	// ages 为预览模式下所有已处理对象按年龄区间的分布，与 ageBuckets 一一对应
+	ages []ageStats
+
+	// prefixDepth 为 breakdown 分组使用的前缀层级
+	prefixDepth int
+
+	mu        sync.Mutex
+	errors    []string
+	prefixes  map[string]*prefixStats
+	breakdown map[string]*report.PrefixBreakdown
+	largest   objectHeap
+}
+
+// prefixStats 记录某个顶级前缀下已清理（预览模式下为待清理）的文件数和字节数
+type prefixStats struct {
+	files int64
+	bytes int64
+}
+
+// maxLargestObjects 为报告中保留的最大对象条数
+const maxLargestObjects = 20
+
+// recordReclaimed 记录一个已清理（预览模式下为待清理）的对象，用于按前缀统计和最大对象排行
+func (s *runStats) recordReclaimed(obj minio.ObjectInfo, rule string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.prefixes == nil {
+		s.prefixes = make(map[string]*prefixStats)
+	}
+	ps := groupEntry(s.prefixes, topPrefix(obj.Key))
+	ps.files++
+	ps.bytes += obj.Size
+
+	s.largest.offer(report.Object{
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		Rule:         rule,
+	}, maxLargestObjects)
+}
+
+// ageStats 记录单个年龄区间的文件数和字节数
+type ageStats struct {
+	files int64
+	bytes int64
+}
+
+// recordAge 将对象计入所在的年龄区间
+func (s *runStats) recordAge(obj minio.ObjectInfo, now time.Time) {
+	a := &s.ages[ageBucketIndex(obj.LastModified, now)]
+	atomic.AddInt64(&a.files, 1)
+	atomic.AddInt64(&a.bytes, obj.Size)
+}
+
+// countPrefix 在对象所在前缀的统计上执行 update
+func (s *runStats) countPrefix(key string, update func(b *report.PrefixBreakdown)) {
+	prefix := prefixAt(key, s.prefixDepth)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.breakdown == nil {
+		s.breakdown = make(map[string]*report.PrefixBreakdown)
+	}
+	update(groupEntry(s.breakdown, prefix))
+}
+
+// ruleStats 记录单条规则的匹配和删除计数
+type ruleStats struct {
+	matchedFiles int64
+	matchedSize  int64
+	deletedFiles int64
+	deletedSize  int64
+}
+
+// maxReportErrors 为报告中保留的错误信息条数上限
+const maxReportErrors = 100
+
+// recordError 累计错误次数，并保留前 maxReportErrors 条错误信息用于报告
+func (s *runStats) recordError(msg string) {
+	atomic.AddInt64(&s.errorCount, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errors) < maxReportErrors {
+		s.errors = append(s.errors, msg)
+	}
+}
+
+// runStatsSnapshot 是 runStats 在某一时刻的只读副本
+type runStatsSnapshot struct {
+	total         int64
+	listed        bool
+	processed     int64
+	processedSize int64
+	deleted       int64
+	deletedSize   int64
+}
+
+func (s *runStats) snapshot() runStatsSnapshot {
+	return runStatsSnapshot{
+		total:         atomic.LoadInt64(&s.totalFiles),
+		listed:        atomic.LoadInt32(&s.listed) == 1,
+		processed:     atomic.LoadInt64(&s.processedFiles),
+		processedSize: atomic.LoadInt64(&s.processedSize),
+		deleted:       atomic.LoadInt64(&s.deletedFiles),
+		deletedSize:   atomic.LoadInt64(&s.deletedSize),
+	}
+}
+
+// cleaner 负责对配置的存储桶执行一次清理过程
+type cleaner struct {
+	cfg   *config.Config
+	store objectStore
+
+	// approved 为交互式审查或 tui 中批准清理的对象，为 nil 时不限制
+	approved map[string]bool
+
+	// sharedLimiter 为与其他清理过程共用的删除限速器，为 nil 时不限制
+	sharedLimiter *rateLimiter
+
+	// onProgress 在每处理完一个对象后调用，可为 nil
+	onProgress func()
+
+	// objects 为 -output jsonl 的对象输出，为 nil 时不输出
+	objects *ObjectOutput
+
+	// preset 不为 nil 时只清理这些对象而不列举存储桶，用于金丝雀删除
+	preset []minio.ObjectInfo
+
+	// admin 不为 nil 时在清理前后查询集群的剩余空间
+	admin *adminClient
+
+	// removable 为 true 时清理后存储桶为空则删除存储桶，created 为存储桶的创建时间，未知时为零值
+	removable bool
+	created   time.Time
+}
+
+// run 清理存储桶，统计运行发出的 API 请求，请求数超过 cleanup.maxAPICalls 时中止运行
+func (c *cleaner) run(ctx context.Context) (*report.RunReport, error) {
+	ctx = withLanguage(ctx, c.cfg.Cleanup.Language)
+	// 金丝雀删除与所属的运行共用请求统计和限制
+	if apiCallsFrom(ctx) != nil {
+		return c.clean(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	ctx = withAPICalls(ctx, newAPICalls(c.cfg.Minio.Bucket, c.cfg.Cleanup.Language, c.cfg.Cleanup.MaxAPICalls, cancel))
+	report, err := c.clean(ctx)
+	// 开始清理之前超过限制时，返回中止的原因而不是被拒绝的请求的错误
+	if cause := abortCause(ctx); cause != nil && report == nil {
+		err = cause
+	}
+	return report, err
+}
+
+func (c *cleaner) clean(ctx context.Context) (*report.RunReport, error) {
+	cfg := c.cfg
+	startTime := cfg.Now()
+	runID := newRunID(startTime)
+	bucket := cfg.Minio.Bucket
+
+	// 排除的存储桶在生成清理目标时已跳过，这里再检查一次，避免任何途径清理受保护的存储桶
+	if cfg.Excluded(&cfg.Minio, bucket) {
+		return nil, errors.New(tr(cfg, msgBucketProtected, bucket))
+	}
+	if err := rules.Check(effectiveRules(cfg), cfg.Cleanup.Language); err != nil {
+		return nil, err
+	}
+	// 紧急停止开关打开时不清理，同样在打开清单和审计日志之前检查
+	if err := checkKillSwitch(ctx, cfg, c.store); err != nil {
+		return nil, err
+	}
+	// 集成预设检查应用的状态，如 Harbor 正在垃圾回收时不清理
+	filters, err := runFilters(ctx, cfg, c.store, startTime, c.approved)
+	if err != nil {
+		return nil, err
+	}
+
+	// 开启 adminAPI 时记录清理前集群的剩余空间，金丝雀删除释放的空间也计入
+	freeBefore, hasFree := c.freeSpace(ctx)
+
+	// 删除比例超过安全限制时不删除任何对象，在打开清单和审计日志之前检查，被拒绝的运行不留下记录。
+	// 配置了计划删除清单时先上传清单，上传失败不删除任何对象；删除的总大小超过 approval.minBytes 时等待外部审批；
+	// 配置了金丝雀删除时先删除抽取的对象，成功并等待之后再继续
+	var planObject string
+	if c.preset == nil {
+		warnUnversioned(ctx, cfg, c.store)
+		plan, err := openPlan(cfg)
+		if err != nil {
+			return nil, errors.New(tr(cfg, msgPlanFailed, err))
+		}
+		if plan != nil {
+			defer plan.close()
+		}
+		pre, err := preflight(ctx, cfg, c.store, startTime, filters, c.approved, plan)
+		if err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			planBucket, planKey, err := plan.upload(ctx, cfg, c.store, startTime)
+			if err != nil {
+				return nil, errors.New(tr(cfg, msgPlanFailed, err))
+			}
+			planObject = planBucket + "/" + planKey
+		}
+		if approvalRequired(&cfg.Approval, pre) {
+			if err := requestApproval(ctx, cfg, runID, planObject, pre); err != nil {
+				return nil, err
+			}
+		}
+		if len(pre.sample) > 0 {
+			if err := c.runCanary(ctx, pre.sample); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 打开已删除对象清单，无法记录时不执行删除
+	var manifest *manifestWriter
+	var manifestPath string
+	if cfg.Report.ManifestFile != "" && !cfg.Cleanup.DryRun {
+		manifestPath = expandReportName(cfg.Report.ManifestFile, startTime)
+		var err error
+		manifest, err = openManifest(manifestPath, cfg.Report.ManifestFormat, cfg.Cleanup.Language)
+		if err != nil {
+			return nil, errors.New(tr(cfg, msgManifestOpenFailed, err))
+		}
+		defer manifest.close()
+	}
+
+	// 打开审计日志，无法记录时不执行清理
+	var audit *auditLog
+	if cfg.Audit.File != "" {
+		var err error
+		audit, err = openAuditLog(cfg.Audit.File, runID, bucket)
+		if err != nil {
+			return nil, errors.New(tr(cfg, msgAuditOpenFailed, err))
+		}
+		defer audit.close()
+		if err := audit.runStart(cfg); err != nil {
+			return nil, errors.New(tr(cfg, msgAuditWriteFailed, err))
+		}
+	}
+
+	// 预览模式下保存待清理对象列表，供 diff 命令与上一次运行比较
+	var candidates *candidateWriter
+	if cfg.Report.CandidatesFile != "" && cfg.Cleanup.DryRun {
+		var err error
+		candidates, err = openCandidates(cfg.Report.CandidatesFile)
+		if err != nil {
+			return nil, errors.New(tr(cfg, msgCandidatesFailed, err))
+		}
+	}
+
+	// 设置各规则的清理时间阈值
+	rules := rules.Compile(effectiveRules(cfg), startTime, cfg.Safety.MinObjectAge)
+	stats := &runStats{rules: make([]*ruleStats, len(rules)), filters: newFilterStats(filters), prefixDepth: cfg.Report.PrefixDepth}
+	for i := range rules {
+		stats.rules[i] = &ruleStats{}
+	}
+	if cfg.Cleanup.DryRun {
+		stats.ages = make([]ageStats, len(ageBuckets))
+	}
+
+	// 开始清理过程
+	if len(cfg.Rules) == 0 {
+		slog.Info(tr(cfg, msgRunStart, rules[0].Threshold, float64(rules[0].MinSize)/1024/1024),
+			"bucket", bucket, "action", "start")
+	} else {
+		slog.Info(tr(cfg, msgRunStartRules, len(rules)), "bucket", bucket, "action", "start")
+		for _, r := range rules {
+			slog.Info(tr(cfg, msgRuleInfo, r.Name, r.Prefix, r.Threshold, float64(r.MinSize)/1024/1024),
+				"bucket", bucket, "rule", r.Name, "action", "start")
+		}
+	}
+	for _, r := range rules {
+		if r.Suspended {
+			slog.Warn(tr(cfg, msgRuleSuspended, r.Name, r.Prefix, r.SuspendUntil),
+				"bucket", bucket, "rule", r.Name, "action", "start", "suspendUntil", r.SuspendUntil)
+		}
+	}
+	for _, r := range shortRules(effectiveRules(cfg), cfg.Safety.MinObjectAge) {
+		slog.Warn(tr(cfg, msgSafetyShortRule, r.Name, r.MaxAge, cfg.Safety.MinObjectAge),
+			"bucket", bucket, "rule", r.Name, "action", "start")
+	}
+	if cfg.Cleanup.DryRun {
+		slog.Info(tr(cfg, msgRunDryRun), "bucket", bucket)
+	}
+
+	hooks := newWebhookDispatcher(cfg)
+	if hooks != nil {
+		hooks.start()
+	}
+
+	p := &pipeline{
+		cfg:        cfg,
+		store:      c.store,
+		bucket:     bucket,
+		startTime:  startTime,
+		rules:      rules,
+		stats:      stats,
+		manifest:   manifest,
+		audit:      audit,
+		candidates: candidates,
+		hooks:      hooks,
+		approved:   c.approved,
+		onProgress: c.onProgress,
+		shared:     c.sharedLimiter,
+		objects:    c.objects,
+		preset:     c.preset,
+		samples:    newVerificationSamples(cfg),
+
+		deletedBatch: newDeletedBatch(cfg),
+		events:       newEventBatch(cfg),
+	}
+	// 限制整次运行的时长，超时后停止列举和删除，照常汇总已完成的部分
+	runCtx := ctx
+	if cfg.Cleanup.MaxRuntime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeoutCause(ctx, cfg.Cleanup.MaxRuntime, &runtimeExceededError{maxRuntime: cfg.Cleanup.MaxRuntime, lang: cfg.Cleanup.Language})
+		defer cancel()
+	}
+	calls := apiCallsFrom(ctx)
+	listCalls := calls.count(apiList)
+	p.run(runCtx)
+	runErr := p.aborted
+	listCalls = calls.count(apiList) - listCalls
+	timedOut := runtimeExceeded(runCtx)
+	if timedOut {
+		slog.Warn(tr(cfg, msgRunTimedOut, cfg.Cleanup.MaxRuntime), "bucket", bucket, "action", "timeout")
+	}
+
+	total := atomic.LoadInt64(&stats.totalFiles)
+	processedCount := atomic.LoadInt64(&stats.processedFiles)
+	deleted := atomic.LoadInt64(&stats.deletedFiles)
+	size := atomic.LoadInt64(&stats.deletedSize)
+	slog.Info(tr(cfg, msgRunFinish,
+		total, processedCount, deleted, float64(size)/1024/1024),
+		"bucket", bucket, "action", "finish", "total", total, "processed", processedCount, "deleted", deleted, "size", size)
+
+	report := newRunReport(cfg, rules, stats, startTime, cfg.Now())
+	logPrefixBreakdown(cfg, report.Prefixes)
+	logAgeHistogram(cfg, report.AgeHistogram)
+	report.RunID = runID
+	report.ManifestFile = manifestPath
+	report.TimedOut = timedOut
+	report.PlanObject = planObject
+	if report.EstimatedSavings = estimateSavings(&cfg.Cost, report); report.EstimatedSavings != nil {
+		s := report.EstimatedSavings
+		slog.Info(tr(cfg, msgCostSavings, s.Monthly, s.Currency, float64(s.Bytes)/1024/1024/1024),
+			"bucket", bucket, "action", "cost", "bytes", s.Bytes, "monthly", s.Monthly, "currency", s.Currency)
+	}
+	if hasFree {
+		if freeAfter, ok := c.freeSpace(ctx); ok {
+			report.FreeSpaceBefore, report.FreeSpaceAfter = freeBefore, freeAfter
+			slog.Info(tr(cfg, msgAdminSpace, cfg.Minio.Name, float64(freeBefore)/1024/1024/1024, float64(freeAfter)/1024/1024/1024,
+				float64(freeAfter-freeBefore)/1024/1024/1024),
+				"cluster", cfg.Minio.Name, "bucket", bucket, "action", "admin", "freeBefore", freeBefore, "freeAfter", freeAfter)
+		}
+	}
+	// 运行被中断或中止时不复查，超过 maxRuntime 的运行照常复查已完成的部分
+	if p.samples != nil && ctx.Err() == nil && runErr == nil {
+		report.Verification = verifyRun(ctx, cfg, c.store, p.samples)
+	}
+	if candidates != nil {
+		// 运行被中断或中止时列表不完整，保留上一次的结果
+		if runCtx.Err() != nil || runErr != nil {
+			candidates.discard()
+		} else if err := candidates.commit(); err != nil {
+			msg := tr(cfg, msgCandidatesFailed, err)
+			stats.recordError(msg)
+			slog.Error(msg, "bucket", bucket, "action", "candidates", "error", err)
+		} else {
+			report.CandidatesFile = cfg.Report.CandidatesFile
+		}
+	}
+	c.reportAPICalls(report, calls, listCalls)
+	if audit != nil {
+		c.finishAudit(ctx, audit, report)
+	}
+	// 汇总报告在删除空的存储桶之前写入，summaryObject 上传到清理的存储桶中，存储桶因此不为空而保留
+	c.writeReports(ctx, report)
+	// 只在完整、没有错误并且删除了对象的运行之后删除空的存储桶，本来就为空（如刚创建、等待第一次上传）的存储桶不删除
+	if c.removable && !cfg.Cleanup.DryRun && runCtx.Err() == nil && runErr == nil && report.ErrorCount == 0 && report.DeletedFiles > 0 {
+		report.BucketRemoved = c.removeEmptyBucket(ctx)
+	}
+	if hooks != nil {
+		hooks.finish(report, runErr)
+	}
+	return report, runErr
+}
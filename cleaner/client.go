@@ -0,0 +1,266 @@
+package cleaner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// proxyDirect 表示不使用代理，忽略代理环境变量
+const proxyDirect = "direct"
+
+// defaultMaxIdleConnsPerHost 为 MinIO 客户端默认的每主机空闲连接数
+const defaultMaxIdleConnsPerHost = 16
+
+// newTransport 在 MinIO 客户端默认传输层的基础上应用 transport 配置。
+// 未配置 maxIdleConnsPerHost 时按并发协程数放大，避免工作协程较多时连接无法复用而频繁新建
+func newTransport(cfg *config.Config) (*http.Transport, error) {
+	t := &cfg.Minio.Transport
+	tr, err := minio.DefaultTransport(cfg.Minio.UseSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if t.DialTimeout > 0 {
+		dialer.Timeout = t.DialTimeout
+	}
+	if t.KeepAlive != 0 {
+		dialer.KeepAlive = t.KeepAlive
+	}
+	tr.DialContext = dialer.DialContext
+
+	if t.MaxIdleConns > 0 {
+		tr.MaxIdleConns = t.MaxIdleConns
+	}
+	tr.MaxIdleConnsPerHost = max(defaultMaxIdleConnsPerHost, cfg.Cleanup.Workers+cfg.Cleanup.Listers)
+	if t.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	// 空闲连接总数不能小于每主机的空闲连接数，否则后者不起作用
+	tr.MaxIdleConns = max(tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	tr.MaxConnsPerHost = t.MaxConnsPerHost
+	if t.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = t.IdleConnTimeout
+	}
+	if t.TLSHandshakeTimeout > 0 {
+		tr.TLSHandshakeTimeout = t.TLSHandshakeTimeout
+	}
+	if t.ResponseHeaderTimeout > 0 {
+		tr.ResponseHeaderTimeout = t.ResponseHeaderTimeout
+	}
+	if t.ExpectContinueTimeout > 0 {
+		tr.ExpectContinueTimeout = t.ExpectContinueTimeout
+	}
+	tr.DisableKeepAlives = t.DisableKeepAlives
+	if t.Proxy != "" {
+		if tr.Proxy, err = proxyFunc(t.Proxy, cfg.Cleanup.Language); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Minio.UseSSL {
+		if err := applyTLSConfig(tr.TLSClientConfig, &cfg.Minio, cfg.Cleanup.Language); err != nil {
+			return nil, err
+		}
+	}
+	return tr, nil
+}
+
+// proxyFunc 返回使用指定代理的 Proxy 函数，direct 表示不使用代理。
+// 未配置 proxy 时默认传输层已按 HTTP_PROXY、HTTPS_PROXY 和 NO_PROXY 环境变量选择代理。错误信息使用语言 lang
+func proxyFunc(proxy, lang string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == proxyDirect {
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, errors.New(trLang(lang, msgProxyInvalid, proxy))
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return http.ProxyURL(u), nil
+	}
+	return nil, errors.New(trLang(lang, msgProxyInvalid, proxy))
+}
+
+// applyTLSConfig 将 tls 配置应用到 MinIO 客户端默认的 TLS 配置上。
+// 自定义 CA 追加到系统信任的 CA 之后，不影响公共 CA 签发的证书。日志和错误信息使用语言 lang
+func applyTLSConfig(c *tls.Config, m *config.MinioConfig, lang string) error {
+	t := &m.TLS
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return err
+		}
+		pool := c.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New(trLang(lang, msgTLSNoCert, t.CAFile))
+		}
+		c.RootCAs = pool
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New(trLang(lang, msgTLSPartialCert))
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return err
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	c.ServerName = t.ServerName
+	if t.InsecureSkipVerify {
+		slog.Warn(trLang(lang, msgTLSInsecure, m.Name), "cluster", m.Name, "action", "tls")
+		c.InsecureSkipVerify = true
+	}
+	return nil
+}
+
+// newCredentials 返回集群使用的凭证。配置了 accessKeyIdFile 或 secretAccessKeyFile 时从文件读取密钥，
+// 配置了 accessKeyId 时使用静态密钥，配置了 vault 时从 Vault 读取，配置了 endpointAlias 时使用 mc 别名的密钥，否则依次尝试环境变量（MINIO_ACCESS_KEY 等、AWS_ACCESS_KEY_ID 等）、
+// AWS 凭证文件、mc 配置文件和 IAM 角色（EC2 实例角色、ECS 任务角色、EKS IRSA），使用第一个可用的凭证。
+// 配置了 sts 时以上述凭证调用 STS，使用返回的临时凭证。获取凭证的日志和错误信息使用语言 lang
+func newCredentials(m *config.MinioConfig, client *http.Client, lang string) (*credentials.Credentials, error) {
+	var creds *credentials.Credentials
+	if m.SecretsFromFile() {
+		creds = credentials.New(&fileSecretProvider{
+			accessKeyID:         m.AccessKeyID,
+			accessKeyIDFile:     m.AccessKeyIDFile,
+			secretAccessKey:     m.SecretAccessKey,
+			secretAccessKeyFile: m.SecretAccessKeyFile,
+		})
+	} else if m.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(m.AccessKeyID, m.SecretAccessKey, "")
+	} else if m.Vault.Path != "" {
+		var err error
+		if creds, err = newVaultCredentials(m, lang); err != nil {
+			return nil, err
+		}
+	} else if accessKey, secretKey, token, ok := m.AliasCredentials(); ok {
+		creds = credentials.NewStaticV4(accessKey, secretKey, token)
+	} else {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvMinio{},
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{Filename: m.CredentialsFile, Profile: m.Profile},
+			&credentials.FileMinioClient{},
+			&credentials.IAM{Endpoint: m.IAMEndpoint},
+		})
+	}
+	if m.STS.Type == "" {
+		return creds, nil
+	}
+	return newSTSCredentials(m, creds, client, lang)
+}
+
+// bucketLookups 为 addressing 配置对应的存储桶寻址方式。
+// auto 时 AWS S3 和阿里云 OSS 使用虚拟主机方式（bucket.endpoint），其他服务使用路径方式（endpoint/bucket）
+var bucketLookups = map[string]minio.BucketLookupType{
+	"":            minio.BucketLookupAuto,
+	"auto":        minio.BucketLookupAuto,
+	"path":        minio.BucketLookupPath,
+	"virtualHost": minio.BucketLookupDNS,
+}
+
+// newMinioClient 按配置创建 MinIO 客户端，同时返回客户端使用的凭证
+func newMinioClient(cfg *config.Config) (*minio.Client, *credentials.Credentials, error) {
+	lookup, ok := bucketLookups[cfg.Minio.Addressing]
+	if !ok {
+		return nil, nil, errors.New(tr(cfg, msgAddressingInvalid, cfg.Minio.Addressing))
+	}
+	tr, err := newTransport(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, err := newCredentials(&cfg.Minio, &http.Client{Transport: tr}, cfg.Cleanup.Language)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := minio.New(cfg.Minio.Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       cfg.Minio.UseSSL,
+		Transport:    apiCallTransport{deleteMarkerTransport{tr}},
+		Region:       cfg.Minio.Region,
+		BucketLookup: lookup,
+	})
+	return client, creds, err
+}
+
+// deleteMarkerKey 为删除请求上下文中保存删除标记版本 ID 的 *string 的键
+type deleteMarkerKey struct{}
+
+// deleteMarkerTransport 在删除请求产生删除标记时，将响应头中的版本 ID 写入请求上下文中的 deleteMarkerKey
+type deleteMarkerTransport struct {
+	http.RoundTripper
+}
+
+func (t deleteMarkerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil || req.Method != http.MethodDelete || resp.Header.Get("x-amz-delete-marker") != "true" {
+		return resp, err
+	}
+	if versionID, ok := req.Context().Value(deleteMarkerKey{}).(*string); ok {
+		*versionID = resp.Header.Get("x-amz-version-id")
+	}
+	return resp, err
+}
+
+// fileSecretProvider 从挂载的密钥文件（如 Kubernetes Secret、Vault Agent 渲染的文件）读取访问密钥，
+// 未配置文件的一项使用配置中的值。读取结果一直有效，直到 Credentials.Expire 要求重新读取
+type fileSecretProvider struct {
+	accessKeyID         string
+	accessKeyIDFile     string
+	secretAccessKey     string
+	secretAccessKeyFile string
+	loaded              bool
+}
+
+// readSecretFile 读取密钥文件，去掉首尾空白（密钥文件通常以换行结尾）
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (p *fileSecretProvider) Retrieve() (credentials.Value, error) {
+	return p.RetrieveWithCredContext(nil)
+}
+
+func (p *fileSecretProvider) RetrieveWithCredContext(*credentials.CredContext) (credentials.Value, error) {
+	v := credentials.Value{AccessKeyID: p.accessKeyID, SecretAccessKey: p.secretAccessKey, SignerType: credentials.SignatureV4}
+	var err error
+	if p.accessKeyIDFile != "" {
+		if v.AccessKeyID, err = readSecretFile(p.accessKeyIDFile); err != nil {
+			return credentials.Value{}, err
+		}
+	}
+	if p.secretAccessKeyFile != "" {
+		if v.SecretAccessKey, err = readSecretFile(p.secretAccessKeyFile); err != nil {
+			return credentials.Value{}, err
+		}
+	}
+	p.loaded = true
+	return v, nil
+}
+
+func (p *fileSecretProvider) IsExpired() bool {
+	return !p.loaded
+}
@@ -0,0 +1,157 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// confirmTopPrefixes 为删除前确认时列出的前缀数
+const confirmTopPrefixes = 10
+
+// deletionSummary 为删除前确认时展示的待删除对象统计
+type deletionSummary struct {
+	files    int64
+	bytes    int64
+	errors   int64
+	prefixes map[string]*prefixStats
+}
+
+// scanCandidates 按规则列举并筛选目标存储桶中的对象，将待清理的对象交给 emit，返回列举的对象数和列举错误数。
+// 规则配置了 ttl 或配置了过滤插件时，与流水线一样逐个查询对象（过滤插件因此会被调用两次），查询失败的对象不清理。
+// 只读取列举结果，不删除对象，也不写入报告、审计日志和运行历史。emit 会被并发调用
+func scanCandidates(ctx context.Context, cfg *config.Config, store objectStore, emit func(obj minio.ObjectInfo, rule *rules.Compiled)) (scanned, failures int64) {
+	now := cfg.Now()
+	preset, err := runFilters(ctx, cfg, store, now, nil)
+	if err != nil {
+		slog.Error(err.Error(), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "preset", "error", err)
+		return 0, 1
+	}
+	return scanFiltered(ctx, cfg, store, now, preset, emit)
+}
+
+// scanFiltered 与 scanCandidates 相同，但使用已由 runFilters 准备好的过滤器 preset，now 为准备过滤器时判断规则的时间
+func scanFiltered(ctx context.Context, cfg *config.Config, store objectStore, now time.Time, preset []rules.Filter, emit func(obj minio.ObjectInfo, rule *rules.Compiled)) (scanned, failures int64) {
+	rules := rules.Compile(effectiveRules(cfg), now, cfg.Safety.MinObjectAge)
+	filters := newFilterStats(preset)
+	e := &enricher{
+		cfg:    cfg,
+		store:  store,
+		bucket: cfg.Minio.Bucket,
+		now:    now,
+		fail: func(msg string, attrs ...any) {
+			slog.Error(msg, attrs...)
+		},
+	}
+
+	// 需要逐个查询对象时使用 workers 个协程并发查询
+	var candidates chan candidate
+	var wg sync.WaitGroup
+	if e.needed(rules) {
+		candidates = make(chan candidate, max(cfg.Cleanup.Workers, 1)*2)
+		for range max(cfg.Cleanup.Workers, 1) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c := range candidates {
+					if ctx.Err() == nil && e.remove(ctx, c) {
+						emit(c.obj, c.rule)
+					}
+				}
+			}()
+		}
+	}
+	listSource(ctx, cfg, store, func(obj minio.ObjectInfo) {
+		atomic.AddInt64(&scanned, 1)
+		c, reason := selectCandidate(rules, obj)
+		if reason != "" || keptByFilter(filters, obj) != nil {
+			return
+		}
+		if candidates != nil {
+			candidates <- c
+			return
+		}
+		emit(obj, c.rule)
+	}, func(err error) {
+		atomic.AddInt64(&failures, 1)
+		slog.Error(tr(cfg, msgListError, err), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "list", "error", err)
+	})
+	if candidates != nil {
+		close(candidates)
+		wg.Wait()
+	}
+	return scanned, failures
+}
+
+// previewDeletion 统计目标存储桶中将被删除的对象
+func previewDeletion(ctx context.Context, cfg *config.Config, store objectStore) *deletionSummary {
+	summary := &deletionSummary{prefixes: make(map[string]*prefixStats)}
+	var mu sync.Mutex
+	_, summary.errors = scanCandidates(ctx, cfg, store, func(obj minio.ObjectInfo, _ *rules.Compiled) {
+		mu.Lock()
+		defer mu.Unlock()
+		summary.files++
+		summary.bytes += obj.Size
+		ps := groupEntry(summary.prefixes, topPrefix(obj.Key))
+		ps.files++
+		ps.bytes += obj.Size
+	})
+	return summary
+}
+
+// ConfirmTargets 在实际删除前逐个统计各目标将被删除的对象，要求操作者输入存储桶名称确认，
+// 返回确认过的目标。没有待删除对象的目标无需确认；统计时出现列举错误的目标不清理
+func ConfirmTargets(ctx context.Context, targets []Target, in io.Reader, out io.Writer) []Target {
+	reader := bufio.NewReader(in)
+	var confirmed []Target
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			return nil
+		}
+		cluster, bucket := t.cfg.Minio.Name, t.cfg.Minio.Bucket
+		fmt.Fprintln(out, tr(t.cfg, msgConfirmScanning, cluster, bucket))
+		summary := previewDeletion(ctx, t.cfg, t.store)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if summary.errors > 0 {
+			slog.Warn(tr(t.cfg, msgConfirmListFailed, cluster, bucket, summary.errors), "cluster", cluster, "bucket", bucket, "action", "confirm")
+			continue
+		}
+		if summary.files == 0 {
+			fmt.Fprintln(out, tr(t.cfg, msgConfirmNothing, cluster, bucket))
+			confirmed = append(confirmed, t)
+			continue
+		}
+
+		fmt.Fprintln(out, tr(t.cfg, msgConfirmSummary, cluster, bucket, summary.files, float64(summary.bytes)/1024/1024/1024))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, g := range sortGroups(summary.prefixes, confirmTopPrefixes) {
+			fmt.Fprintf(w, "  %s\t%d\t%.2f GB\n", g.Name, g.Files, float64(g.Bytes)/1024/1024/1024)
+		}
+		w.Flush()
+		fmt.Fprint(out, tr(t.cfg, msgConfirmPrompt, bucket))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// 输入在回车之前结束，换行后再输出取消的日志
+			fmt.Fprintln(out)
+		}
+		if strings.TrimSpace(line) != bucket {
+			slog.Warn(tr(t.cfg, msgConfirmCancelled, cluster, bucket), "cluster", cluster, "bucket", bucket, "action", "confirm")
+			continue
+		}
+		confirmed = append(confirmed, t)
+	}
+	return confirmed
+}
@@ -0,0 +1,64 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestConfirmTargets(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	expired := []minio.ObjectInfo{testObject("a", old, 10), testObject("b", old, 10)}
+	fresh := []minio.ObjectInfo{testObject("a", time.Now(), 10)}
+	newTarget := func(bucket string, objects []minio.ObjectInfo, listErr error) Target {
+		cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+		cfg.Minio.Bucket = bucket
+		return Target{cfg: cfg, store: &memStore{objects: objects, listErr: listErr}}
+	}
+	tests := []struct {
+		name    string
+		targets []Target
+		input   string
+		want    []string
+	}{
+		{name: "bucket name typed", targets: []Target{newTarget("logs", expired, nil)}, input: "logs\n", want: []string{"logs"}},
+		{name: "surrounding spaces ignored", targets: []Target{newTarget("logs", expired, nil)}, input: "  logs \n", want: []string{"logs"}},
+		{name: "wrong name", targets: []Target{newTarget("logs", expired, nil)}, input: "yes\n", want: nil},
+		{name: "input ends without newline", targets: []Target{newTarget("logs", expired, nil)}, input: "lo", want: nil},
+		{name: "no input", targets: []Target{newTarget("logs", expired, nil)}, input: "", want: nil},
+		// 没有待删除对象的目标不需要确认，也不读取输入
+		{name: "nothing to delete", targets: []Target{newTarget("logs", fresh, nil), newTarget("tmp", expired, nil)}, input: "tmp\n", want: []string{"logs", "tmp"}},
+		// 统计不完整的目标不清理，也不读取输入
+		{name: "listing failed", targets: []Target{newTarget("logs", expired, errors.New("boom")), newTarget("tmp", expired, nil)}, input: "tmp\n", want: []string{"tmp"}},
+		{name: "each target confirmed separately", targets: []Target{newTarget("logs", expired, nil), newTarget("tmp", expired, nil)}, input: "nope\ntmp\n", want: []string{"tmp"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			confirmed := ConfirmTargets(context.Background(), tt.targets, strings.NewReader(tt.input), io.Discard)
+			var got []string
+			for _, c := range confirmed {
+				got = append(got, c.cfg.Minio.Bucket)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("confirmTargets() confirmed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfirmTargetsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+	targets := []Target{{cfg: cfg, store: &memStore{}}}
+	if got := ConfirmTargets(ctx, targets, strings.NewReader("b\n"), io.Discard); got != nil {
+		t.Errorf("confirmTargets() with a cancelled context = %v, want nil", got)
+	}
+}
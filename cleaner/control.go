@@ -0,0 +1,539 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// 运行状态
+const (
+	runStateQueued    = "queued" // 通过 REST 接口提交，等待执行
+	runStateRunning   = "running"
+	runStateSucceeded = "succeeded"
+	runStateFailed    = "failed"
+	runStateCanceled  = "canceled"
+)
+
+// 运行的触发方式
+const (
+	runTriggerSchedule = "schedule" // 守护模式按 daemon.interval 定时运行
+	runTriggerGRPC     = "grpc"     // 通过 gRPC 控制服务启动
+	runTriggerAPI      = "api"      // 通过 REST 接口提交的任务
+)
+
+// maxControlRuns 为守护模式下保留状态的最近运行数，排队和进行中的运行不计入淘汰
+const maxControlRuns = 20
+
+var (
+	// errRunInProgress 表示同时进行的运行已达到上限，控制接口启动的运行不排队等待
+	errRunInProgress = errors.New("run in progress")
+	// errRunFinished 表示要取消的运行已经结束
+	errRunFinished = errors.New("run finished")
+	// errQueueFull 表示团队排队的任务数已达到 daemon.api.queueSize
+	errQueueFull = errors.New("job queue full")
+)
+
+// controlRun 为守护模式下的一次运行（定时触发或通过控制接口启动），记录状态和进度，供控制接口查询、取消和订阅
+type controlRun struct {
+	id        string
+	trigger   string
+	dryRun    bool
+	submitted time.Time
+	// team、cluster 和 bucket 为通过 REST 接口提交的任务的提交团队和目标存储桶
+	team    string
+	cluster string
+	bucket  string
+	// ctx 在 CancelRun 或守护进程退出时取消
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	processed    atomic.Int64
+	matched      atomic.Int64
+	deleted      atomic.Int64
+	deletedBytes atomic.Int64
+	failed       atomic.Int64
+
+	mu       sync.Mutex
+	state    string
+	started  time.Time
+	finished time.Time
+	err      string
+	report   *Report
+	subs     map[chan objectEvent]struct{}
+}
+
+// runStatus 为运行状态的快照
+type runStatus struct {
+	ID           string
+	State        string
+	Trigger      string
+	DryRun       bool
+	Team         string
+	Cluster      string
+	Bucket       string
+	SubmittedAt  time.Time
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Processed    int64
+	Matched      int64
+	Deleted      int64
+	DeletedBytes int64
+	Failed       int64
+	Error        string
+	Report       *Report
+}
+
+func (r *controlRun) status() runStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return runStatus{
+		ID:           r.id,
+		State:        r.state,
+		Trigger:      r.trigger,
+		DryRun:       r.dryRun,
+		Team:         r.team,
+		Cluster:      r.cluster,
+		Bucket:       r.bucket,
+		SubmittedAt:  r.submitted,
+		StartedAt:    r.started,
+		FinishedAt:   r.finished,
+		Processed:    r.processed.Load(),
+		Matched:      r.matched.Load(),
+		Deleted:      r.deleted.Load(),
+		DeletedBytes: r.deletedBytes.Load(),
+		Failed:       r.failed.Load(),
+		Error:        r.err,
+		Report:       r.report,
+	}
+}
+
+// begin 将排队的运行标记为开始执行，运行已在排队时取消时返回 false
+func (r *controlRun) begin() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state != runStateQueued {
+		return false
+	}
+	r.state = runStateRunning
+	r.started = time.Now()
+	return true
+}
+
+// cancelQueued 取消尚未开始执行的运行，运行已开始或已结束时返回 false
+func (r *controlRun) cancelQueued() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state != runStateQueued {
+		return false
+	}
+	r.state = runStateCanceled
+	r.finished = time.Now()
+	r.cancel()
+	close(r.done)
+	return true
+}
+
+// ended 返回运行是否已结束
+func (r *controlRun) ended() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// observe 按处理完的对象更新进度，并转发给订阅者。订阅者来不及接收时丢弃该对象，不阻塞删除
+func (r *controlRun) observe(e objectEvent) {
+	switch {
+	case e.Action == "match":
+		r.matched.Add(1)
+	case e.Error != "":
+		r.failed.Add(1)
+	default:
+		r.deleted.Add(1)
+		r.deletedBytes.Add(e.Size)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ch := range r.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// subscribe 订阅运行处理的对象，运行结束后通道不再有新对象，调用方需通过 done 判断运行结束
+func (r *controlRun) subscribe() (<-chan objectEvent, func()) {
+	ch := make(chan objectEvent, 256)
+	r.mu.Lock()
+	if r.subs == nil {
+		r.subs = make(map[chan objectEvent]struct{})
+	}
+	r.subs[ch] = struct{}{}
+	r.mu.Unlock()
+	return ch, func() {
+		r.mu.Lock()
+		delete(r.subs, ch)
+		r.mu.Unlock()
+	}
+}
+
+// runManager 限制守护模式下同时进行的运行数（daemon.maxConcurrentRuns），并保留最近运行的状态
+type runManager struct {
+	// ctx 为守护进程的 context，退出时取消所有运行
+	ctx     context.Context
+	cfg     *config.Config
+	health  *healthState
+	objects *ObjectOutput
+	// slot 为运行许可，定时运行和排队的任务等待许可，gRPC 启动的运行在没有空闲许可时直接失败
+	slot chan struct{}
+	// jobs 为通过 REST 接口提交、等待执行的任务
+	jobs *jobQueue
+
+	mu      sync.Mutex
+	targets []Target
+	runs    []*controlRun
+}
+
+func newRunManager(ctx context.Context, cfg *config.Config, h *healthState, objects *ObjectOutput) *runManager {
+	m := &runManager{ctx: ctx, cfg: cfg, health: h, objects: objects, slot: make(chan struct{}, cfg.Daemon.MaxConcurrentRuns),
+		jobs: newJobQueue(&cfg.Daemon.API), targets: h.currentTargets()}
+	go m.processQueue()
+	return m
+}
+
+// setTargets 更新清理目标，在按 bucketPattern 重新发现存储桶后调用
+func (m *runManager) setTargets(targets []Target) {
+	m.mu.Lock()
+	m.targets = targets
+	m.mu.Unlock()
+	m.health.setTargets(targets)
+}
+
+func (m *runManager) currentTargets() []Target {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.targets
+}
+
+// runScheduled 等待空闲的运行许可后执行一次定时运行，守护进程退出时返回
+func (m *runManager) runScheduled() {
+	select {
+	case m.slot <- struct{}{}:
+	case <-m.ctx.Done():
+		return
+	}
+	run := m.newRun(runTriggerSchedule, m.cfg.Cleanup.DryRun)
+	run.begin()
+	m.execute(run, m.currentTargets())
+}
+
+// start 在后台启动一次运行，没有空闲的运行许可时返回 errRunInProgress。
+// dryRun 为 true 时以预览模式运行，buckets 不为空时只清理其中的存储桶，均不改变配置文件中的设置。
+// 不同集群上可能有同名的存储桶，buckets 的每项需写成 <集群名称>/<存储桶>
+func (m *runManager) start(trigger string, dryRun bool, buckets []string) (*controlRun, error) {
+	targets := m.currentTargets()
+	if len(buckets) > 0 {
+		var selected []Target
+		for _, b := range buckets {
+			cluster, bucket, ok := strings.Cut(b, "/")
+			if !ok || cluster == "" || bucket == "" {
+				return nil, errors.New(tr(m.cfg, msgControlNoCluster, b))
+			}
+			i := slices.IndexFunc(targets, func(t Target) bool { return t.cfg.Minio.Name == cluster && t.cfg.Minio.Bucket == bucket })
+			if i < 0 {
+				return nil, errors.New(tr(m.cfg, msgControlNoBucket, b))
+			}
+			selected = append(selected, targets[i])
+		}
+		targets = selected
+	}
+	select {
+	case m.slot <- struct{}{}:
+	default:
+		return nil, errRunInProgress
+	}
+	dryRun = dryRun || m.cfg.Cleanup.DryRun
+	if dryRun && !m.cfg.Cleanup.DryRun {
+		targets = withDryRun(targets)
+	}
+	run := m.newRun(trigger, dryRun)
+	run.begin()
+	go m.execute(run, targets)
+	return run, nil
+}
+
+// processQueue 在有空闲的运行许可时执行排队的任务，守护进程退出时取消仍在排队的任务
+func (m *runManager) processQueue() {
+	for {
+		select {
+		case <-m.jobs.wake:
+		case <-m.ctx.Done():
+			m.jobs.cancelAll()
+			return
+		}
+		for {
+			select {
+			case m.slot <- struct{}{}:
+			case <-m.ctx.Done():
+				m.jobs.cancelAll()
+				return
+			}
+			q, ok := m.jobs.pick()
+			if !ok {
+				<-m.slot
+				break
+			}
+			go func() {
+				defer m.jobs.done(q.run.team)
+				m.execute(q.run, q.targets)
+			}()
+		}
+	}
+}
+
+// cancel 取消排队或正在进行的运行，运行已结束时返回 errRunFinished
+func (m *runManager) cancel(run *controlRun) error {
+	if run.cancelQueued() {
+		return nil
+	}
+	if run.ended() {
+		return errRunFinished
+	}
+	run.cancel()
+	return nil
+}
+
+// withDryRun 返回以预览模式运行的目标副本
+func withDryRun(targets []Target) []Target {
+	out := make([]Target, len(targets))
+	for i, t := range targets {
+		cfg := *t.cfg
+		cfg.Cleanup.DryRun = true
+		t.cfg = &cfg
+		out[i] = t
+	}
+	return out
+}
+
+// newRun 创建一次排队状态的运行并记录，调用 begin 后开始执行
+func (m *runManager) newRun(trigger string, dryRun bool) *controlRun {
+	return m.track(m.prepareRun(trigger, dryRun))
+}
+
+// prepareRun 创建一次排队状态的运行但不记录，调用方设置任务信息后通过 track 记录
+func (m *runManager) prepareRun(trigger string, dryRun bool) *controlRun {
+	now := time.Now()
+	runCtx, cancel := context.WithCancel(m.ctx)
+	return &controlRun{
+		id:        newRunID(now),
+		trigger:   trigger,
+		dryRun:    dryRun,
+		submitted: now,
+		state:     runStateQueued,
+		ctx:       runCtx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+}
+
+// track 记录运行，超过 maxControlRuns 时淘汰最早结束的运行
+func (m *runManager) track(run *controlRun) *controlRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, run)
+	for len(m.runs) > maxControlRuns {
+		i := slices.IndexFunc(m.runs, (*controlRun).ended)
+		if i < 0 {
+			break
+		}
+		m.runs = slices.Delete(m.runs, i, i+1)
+	}
+	return run
+}
+
+// execute 执行一次运行并记录结果，结束后释放运行许可并唤醒排队的任务
+func (m *runManager) execute(run *controlRun, targets []Target) {
+	defer func() {
+		<-m.slot
+		m.jobs.notify()
+	}()
+	defer close(run.done)
+	defer run.cancel()
+
+	objects := newObjectFunc(func(e objectEvent) error {
+		run.observe(e)
+		if m.objects != nil {
+			return m.objects.write(e)
+		}
+		return nil
+	})
+	progress := func() {
+		run.processed.Add(1)
+		m.health.progress()
+	}
+
+	m.health.start()
+	results, err := runAllTargets(run.ctx, m.cfg, withListProgress(targets, m.health.progress), progress, objects)
+	m.health.finish()
+	if err != nil && len(targets) == 1 {
+		slog.Error(err.Error(), "bucket", targets[0].cfg.Minio.Bucket, "error", err)
+	}
+
+	report := newReport(results)
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.finished = time.Now()
+	run.report = &report
+	switch {
+	case run.ctx.Err() != nil:
+		run.state = runStateCanceled
+	case err != nil || slices.ContainsFunc(results, func(r targetResult) bool { return r.report != nil && r.report.ErrorCount > 0 }):
+		run.state = runStateFailed
+	default:
+		run.state = runStateSucceeded
+	}
+	if err != nil {
+		run.err = err.Error()
+	}
+}
+
+// wait 等待正在进行的运行结束，之后不再开始新的运行
+func (m *runManager) wait() {
+	for range cap(m.slot) {
+		m.slot <- struct{}{}
+	}
+}
+
+// list 返回保留状态的全部运行，按提交顺序排列
+func (m *runManager) list() []*controlRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.runs)
+}
+
+// find 返回指定的运行，id 为空时返回最近一次运行
+func (m *runManager) find(id string) *controlRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		if id == "" || m.runs[i].id == id {
+			return m.runs[i]
+		}
+	}
+	return nil
+}
+
+// queuedRun 为排队等待执行的任务及其清理目标
+type queuedRun struct {
+	run     *controlRun
+	targets []Target
+}
+
+// jobQueue 按团队排队通过 REST 接口提交的任务。各团队轮流取出任务，
+// 团队同时执行的任务数不超过 maxConcurrent，一个团队提交大量任务不会让其他团队一直等待
+type jobQueue struct {
+	cfg *config.JobsAPIConfig
+	// wake 在有任务入队或结束时通知 processQueue
+	wake chan struct{}
+
+	mu      sync.Mutex
+	teams   []string // 按首次提交的顺序排列，轮转从 next 开始
+	next    int
+	queued  map[string][]queuedRun
+	running map[string]int
+}
+
+func newJobQueue(cfg *config.JobsAPIConfig) *jobQueue {
+	return &jobQueue{cfg: cfg, wake: make(chan struct{}, 1), queued: make(map[string][]queuedRun), running: make(map[string]int)}
+}
+
+// notify 唤醒 processQueue，已有未处理的通知时不重复发送
+func (q *jobQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue 将任务加入团队的队列，团队排队的任务数已达到 daemon.api.queueSize 时返回 errQueueFull
+func (q *jobQueue) enqueue(run *controlRun, targets []Target) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// 排队期间已被取消的任务不再占用名额
+	pending := slices.DeleteFunc(q.queued[run.team], func(r queuedRun) bool { return r.run.ended() })
+	if len(pending) >= q.cfg.QueueSize {
+		q.queued[run.team] = pending
+		run.cancelQueued()
+		return errQueueFull
+	}
+	if !slices.Contains(q.teams, run.team) {
+		q.teams = append(q.teams, run.team)
+	}
+	q.queued[run.team] = append(pending, queuedRun{run: run, targets: targets})
+	q.notify()
+	return nil
+}
+
+// pick 从下一个有排队任务且未达到并发上限的团队取出最早提交的任务并开始执行，没有可执行的任务时返回 false
+func (q *jobQueue) pick() (queuedRun, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.teams {
+		team := q.teams[(q.next+i)%len(q.teams)]
+		if q.running[team] >= q.maxConcurrent(team) {
+			continue
+		}
+		for len(q.queued[team]) > 0 {
+			r := q.queued[team][0]
+			q.queued[team] = q.queued[team][1:]
+			if !r.run.begin() {
+				continue
+			}
+			q.running[team]++
+			q.next = (q.next + i + 1) % len(q.teams)
+			return r, true
+		}
+	}
+	return queuedRun{}, false
+}
+
+// done 在团队的任务结束后调用，释放团队的并发名额
+func (q *jobQueue) done(team string) {
+	q.mu.Lock()
+	q.running[team]--
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *jobQueue) maxConcurrent(team string) int {
+	for _, t := range q.cfg.Tenants {
+		if t.Team == team {
+			return t.MaxConcurrent
+		}
+	}
+	return 1
+}
+
+// cancelAll 取消所有仍在排队的任务
+func (q *jobQueue) cancelAll() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for team, runs := range q.queued {
+		for _, r := range runs {
+			r.run.cancelQueued()
+		}
+		delete(q.queued, team)
+	}
+}
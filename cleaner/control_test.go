@@ -0,0 +1,261 @@
+package cleaner
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// withTestStores 为 newTestRunManager 中的每个目标设置单独的存储，其中有一个过期和一个未过期的对象，
+// 返回按 <集群名称>/<存储桶> 索引的存储
+func withTestStores(m *runManager) map[string]*bucketStore {
+	now := time.Now()
+	m.health = &healthState{}
+	stores := make(map[string]*bucketStore)
+	for i, t := range m.targets {
+		store := &bucketStore{memStore: memStore{objects: []minio.ObjectInfo{
+			testObject("old", now.Add(-10*rules.Day), 1),
+			testObject("new", now, 1),
+		}}}
+		t.cfg.Cleanup.MaxAge = rules.Retention(rules.Day)
+		t.cfg.Cleanup.DryRun = m.cfg.Cleanup.DryRun
+		m.targets[i].store = store
+		stores[t.cfg.Minio.Name+"/"+t.cfg.Minio.Bucket] = store
+	}
+	return stores
+}
+
+func TestRunManagerStart(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []string
+		dryRun  bool
+		// cfgDryRun 为配置文件中的 cleanup.dryRun，为 true 时控制接口不能关闭预览
+		cfgDryRun bool
+		busy      bool
+		wantErr   error
+		// left 为运行结束后各存储桶剩余的对象数
+		left map[string]int
+	}{
+		{name: "all buckets", left: map[string]int{"c1/b": 1, "c2/b": 1, "c2/logs": 1}},
+		{name: "one bucket", buckets: []string{"c2/logs"}, left: map[string]int{"c1/b": 2, "c2/b": 2, "c2/logs": 1}},
+		{name: "dry run", buckets: []string{"c2/logs"}, dryRun: true, left: map[string]int{"c2/logs": 2}},
+		{name: "config dry run", buckets: []string{"c2/logs"}, cfgDryRun: true, left: map[string]int{"c2/logs": 2}},
+		// 不同集群上可能有同名的存储桶，需要写明集群
+		{name: "no cluster", buckets: []string{"b"}, wantErr: errors.New(tr(nil, msgControlNoCluster, "b"))},
+		{name: "unknown bucket", buckets: []string{"c1/logs"}, wantErr: errors.New(tr(nil, msgControlNoBucket, "c1/logs"))},
+		{name: "busy", busy: true, wantErr: errRunInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Cleanup.DryRun = tt.cfgDryRun
+			m := newTestRunManager(t, cfg)
+			stores := withTestStores(m)
+			if tt.busy {
+				m.slot <- struct{}{}
+			}
+			run, err := m.start(runTriggerGRPC, tt.dryRun, tt.buckets)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("start() = %v, want %v", err, tt.wantErr)
+				}
+				for name, store := range stores {
+					if len(store.objects) != 2 {
+						t.Errorf("%s: %d objects left after a rejected run, want 2", name, len(store.objects))
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			<-run.done
+			s := run.status()
+			if s.State != runStateSucceeded {
+				t.Fatalf("state = %s (%s), want %s", s.State, s.Error, runStateSucceeded)
+			}
+			if s.DryRun != (tt.dryRun || tt.cfgDryRun) {
+				t.Errorf("DryRun = %v, want %v", s.DryRun, tt.dryRun || tt.cfgDryRun)
+			}
+			for name, want := range tt.left {
+				if got := len(stores[name].objects); got != want {
+					t.Errorf("%s: %d objects left, want %d", name, got, want)
+				}
+			}
+			// 预览时不修改配置中的目标
+			for _, target := range m.currentTargets() {
+				if target.cfg.Cleanup.DryRun != tt.cfgDryRun {
+					t.Errorf("%s: cleanup.dryRun = %v after the run, want %v", target.cfg.Minio.Bucket, target.cfg.Cleanup.DryRun, tt.cfgDryRun)
+				}
+			}
+			select {
+			case m.slot <- struct{}{}:
+			default:
+				t.Error("run did not release its slot")
+			}
+		})
+	}
+}
+
+func TestRunManagerCancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(m *runManager) *controlRun
+		wantErr   error
+		wantState string
+	}{
+		// 排队的运行直接取消，之后不会开始
+		{
+			name:      "queued",
+			setup:     func(m *runManager) *controlRun { return m.newRun(runTriggerAPI, true) },
+			wantState: runStateCanceled,
+		},
+		{
+			name: "finished",
+			setup: func(m *runManager) *controlRun {
+				run, err := m.start(runTriggerGRPC, true, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				<-run.done
+				return run
+			},
+			wantErr:   errRunFinished,
+			wantState: runStateSucceeded,
+		},
+		{
+			name: "canceled",
+			setup: func(m *runManager) *controlRun {
+				run := m.newRun(runTriggerAPI, true)
+				run.cancelQueued()
+				return run
+			},
+			wantErr:   errRunFinished,
+			wantState: runStateCanceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestRunManager(t, testConfig())
+			withTestStores(m)
+			run := tt.setup(m)
+			if err := m.cancel(run); err != tt.wantErr {
+				t.Errorf("cancel() = %v, want %v", err, tt.wantErr)
+			}
+			if got := run.status().State; got != tt.wantState {
+				t.Errorf("state = %s, want %s", got, tt.wantState)
+			}
+			if run.begin() {
+				t.Error("begin() = true after cancel, want false")
+			}
+		})
+	}
+}
+
+func TestRunManagerTrack(t *testing.T) {
+	m := newTestRunManager(t, testConfig())
+	var queued []*controlRun
+	for i := range maxControlRuns + 5 {
+		run := m.newRun(runTriggerSchedule, true)
+		// 前 3 次运行仍在排队，淘汰时跳过
+		if i < 3 {
+			queued = append(queued, run)
+			continue
+		}
+		run.cancelQueued()
+	}
+	runs := m.list()
+	if len(runs) != maxControlRuns {
+		t.Fatalf("%d runs kept, want %d", len(runs), maxControlRuns)
+	}
+	for _, run := range queued {
+		if m.find(run.id) == nil {
+			t.Errorf("queued run %s was evicted", run.id)
+		}
+	}
+	if got := m.find(""); got != runs[len(runs)-1] {
+		t.Errorf("find(\"\") = %v, want the latest run", got.id)
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	type job struct {
+		team string
+		// canceled 为入队后在排队时取消
+		canceled bool
+		wantErr  error
+	}
+	tests := []struct {
+		name string
+		jobs []job
+		// want 为依次取出的任务所属的团队，直到没有可执行的任务
+		want []string
+		// next 为第一个取出的任务结束后再取出的任务所属的团队，为空时没有可执行的任务
+		next string
+	}{
+		// 各团队轮流取出任务，不超过团队的 maxConcurrent
+		{name: "round robin", jobs: []job{{team: "a"}, {team: "a"}, {team: "a"}, {team: "b"}, {team: "b"}}, want: []string{"a", "b", "a"}, next: "a"},
+		{name: "other team waits", jobs: []job{{team: "b"}, {team: "b"}, {team: "a"}}, want: []string{"b", "a"}, next: "b"},
+		// 未配置的团队同时只执行一个任务
+		{name: "unknown team", jobs: []job{{team: "c"}, {team: "c"}}, want: []string{"c"}, next: "c"},
+		{name: "queue full", jobs: []job{{team: "a"}, {team: "a"}, {team: "a"}, {team: "a", wantErr: errQueueFull}}, want: []string{"a", "a"}, next: "a"},
+		// 排队时取消的任务不执行，也不占用排队名额
+		{name: "canceled", jobs: []job{{team: "a", canceled: true}, {team: "a"}, {team: "a", canceled: true}, {team: "a"}, {team: "a"}}, want: []string{"a", "a"}, next: "a"},
+		{name: "all canceled", jobs: []job{{team: "b", canceled: true}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Daemon.API.QueueSize = 3
+			cfg.Daemon.API.Tenants = []config.JobsTenant{{Team: "a", MaxConcurrent: 2}, {Team: "b", MaxConcurrent: 1}}
+			m := newTestRunManager(t, cfg)
+			q := m.jobs
+			var runs []*controlRun
+			for _, j := range tt.jobs {
+				run := m.prepareRun(runTriggerAPI, true)
+				run.team = j.team
+				if err := q.enqueue(run, nil); err != j.wantErr {
+					t.Fatalf("enqueue(%s) = %v, want %v", j.team, err, j.wantErr)
+				}
+				if j.canceled {
+					run.cancelQueued()
+				}
+				runs = append(runs, run)
+			}
+			var got []string
+			for {
+				r, ok := q.pick()
+				if !ok {
+					break
+				}
+				if r.run.status().State != runStateRunning {
+					t.Errorf("picked a %s run", r.run.status().State)
+				}
+				got = append(got, r.run.team)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("picked %q, want %q", got, tt.want)
+			}
+			// 取消的任务和队列已满时拒绝的任务不会开始
+			for i, j := range tt.jobs {
+				if s := runs[i].status().State; (j.canceled || j.wantErr != nil) && s != runStateCanceled {
+					t.Errorf("job %d: state = %s, want %s", i, s, runStateCanceled)
+				}
+			}
+			if len(got) == 0 {
+				return
+			}
+			q.done(got[0])
+			r, ok := q.pick()
+			if ok != (tt.next != "") || ok && r.run.team != tt.next {
+				t.Errorf("pick() after done = %q %v, want %q", r.run.team, ok, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// estimateSavings 估算清理每月节省的存储费用，未配置存储单价时返回 nil。
+// 阶梯单价按本次扫描的字节数计算所在档位，删除的数据从最高的档位扣除
+func estimateSavings(cost *config.CostConfig, rep *report.RunReport) *report.Cost {
+	if !cost.Enabled() {
+		return nil
+	}
+	reclaimed := rep.DeletedBytes
+	if rep.DryRun {
+		reclaimed = 0
+		for _, r := range rep.Rules {
+			reclaimed += r.MatchedBytes
+		}
+	}
+	var scanned int64
+	for _, p := range rep.Prefixes {
+		scanned += p.ScannedBytes
+	}
+	scanned = max(scanned, reclaimed)
+	return &report.Cost{
+		Bytes:    reclaimed,
+		Monthly:  cost.Monthly(scanned) - cost.Monthly(scanned-reclaimed),
+		Currency: cmp.Or(cost.Currency, config.DefaultCurrency),
+	}
+}
+
+// validateCost 检查存储单价：单价不能为负，阶梯单价的上限依次递增，只有最后一档可以不设置上限。
+// 问题的描述使用语言 lang
+func validateCost(cost *config.CostConfig, lang string) []string {
+	var problems []string
+	if cost.PricePerGBMonth < 0 {
+		problems = append(problems, trLang(lang, msgValidateNegative, "cost.pricePerGBMonth"))
+	}
+	if cost.PricePerGBMonth > 0 && len(cost.Tiers) > 0 {
+		problems = append(problems, trLang(lang, msgCostBoth))
+	}
+	for name, price := range map[string]float64{"list": cost.Requests.List, "get": cost.Requests.Get, "delete": cost.Requests.Delete, "other": cost.Requests.Other} {
+		if price < 0 {
+			problems = append(problems, trLang(lang, msgValidateNegative, "cost.requests."+name))
+		}
+	}
+	var prev rules.ByteSize
+	for i, t := range cost.Tiers {
+		if t.PricePerGBMonth < 0 {
+			problems = append(problems, trLang(lang, msgValidateNegative, fmt.Sprintf("cost.tiers[%d].pricePerGBMonth", i)))
+		}
+		if t.UpTo <= prev && (t.UpTo != 0 || i < len(cost.Tiers)-1) {
+			problems = append(problems, trLang(lang, msgCostTierOrder, i))
+		}
+		prev = t.UpTo
+	}
+	return problems
+}
@@ -0,0 +1,215 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// healthState 记录守护进程的运行状态，供健康检查使用
+type healthState struct {
+	mu           sync.Mutex
+	running      int // 正在进行的运行数
+	lastProgress time.Time
+	targets      []Target
+}
+
+func (h *healthState) start() {
+	h.mu.Lock()
+	h.running++
+	h.lastProgress = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *healthState) progress() {
+	h.mu.Lock()
+	h.lastProgress = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *healthState) finish() {
+	h.mu.Lock()
+	h.running--
+	h.lastProgress = time.Now()
+	h.mu.Unlock()
+}
+
+// listProgressStore 在列举每返回一个结果时调用 onListed。删除前的统计、建立日历索引等只列举不处理对象的过程，
+// 以及大量对象被跳过的列举，也据此视为有进展，不会被存活检查判定为停滞
+type listProgressStore struct {
+	objectStore
+	onListed func()
+}
+
+func (s listProgressStore) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	in := s.objectStore.list(ctx, bucket, opts)
+	out := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(out)
+		for obj := range in {
+			s.onListed()
+			select {
+			case out <- obj:
+			case <-ctx.Done():
+				// 调用方已停止读取，列举随 ctx 取消结束
+				for range in {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// withListProgress 返回列举时调用 onListed 的清理目标
+func withListProgress(targets []Target, onListed func()) []Target {
+	wrapped := slices.Clone(targets)
+	for i := range wrapped {
+		wrapped[i].store = listProgressStore{objectStore: wrapped[i].store, onListed: onListed}
+	}
+	return wrapped
+}
+
+// setTargets 更新就绪检查使用的清理目标
+func (h *healthState) setTargets(targets []Target) {
+	h.mu.Lock()
+	h.targets = targets
+	h.mu.Unlock()
+}
+
+func (h *healthState) currentTargets() []Target {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.targets
+}
+
+// stalled 判断清理过程是否已超过 timeout 没有任何进展
+func (h *healthState) stalled(timeout time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.running > 0 && timeout > 0 && time.Since(h.lastProgress) > timeout
+}
+
+// newHealthServer 创建提供 /healthz 和 /readyz 的 HTTP 服务
+func newHealthServer(cfg *config.Config, h *healthState) *http.Server {
+	mux := http.NewServeMux()
+
+	// 存活检查：清理过程长时间无进展时返回失败，便于编排系统重启实例
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if h.stalled(cfg.Daemon.StallTimeout) {
+			http.Error(w, tr(cfg, msgHealthStalled), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	// 就绪检查：配置已加载且所有目标的 MinIO 可访问、存储桶存在
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		for _, t := range h.currentTargets() {
+			var exists bool
+			err := withTimeout(r.Context(), "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+				var err error
+				exists, err = t.store.bucketExists(ctx, t.cfg.Minio.Bucket)
+				return err
+			})
+			if err != nil {
+				http.Error(w, tr(cfg, msgHealthUnreach, err), http.StatusServiceUnavailable)
+				return
+			}
+			if !exists {
+				http.Error(w, tr(cfg, msgHealthNoBucket, t.cfg.Minio.Bucket), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	return &http.Server{
+		Addr:              cfg.Daemon.HealthAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// RunDaemon 按配置的间隔循环执行清理，直到 ctx 被取消。objects 不为 nil 时逐个输出待清理或已删除的对象
+func RunDaemon(ctx context.Context, cfg *config.Config, targets []Target, objects *ObjectOutput) {
+	h := &healthState{targets: targets}
+
+	if cfg.Daemon.HealthAddr != "" {
+		srv := newHealthServer(cfg, h)
+		go func() {
+			slog.Info(tr(cfg, msgHealthListen, cfg.Daemon.HealthAddr))
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error(tr(cfg, msgHealthExit, err), "error", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+	}
+
+	watching := newWatchers(ctx, objects)
+	defer watching.stop()
+
+	runs := newRunManager(ctx, cfg, h, objects)
+	if cfg.Daemon.GRPC.Addr != "" {
+		srv, err := newGRPCServer(cfg, runs)
+		if err != nil {
+			slog.Error(tr(cfg, msgGRPCExit, err), "action", "grpc", "error", err)
+		} else {
+			go func() {
+				slog.Info(tr(cfg, msgGRPCListen, cfg.Daemon.GRPC.Addr), "action", "grpc")
+				if err := srv.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error(tr(cfg, msgGRPCExit, err), "action", "grpc", "error", err)
+				}
+			}()
+			defer srv.shutdown()
+		}
+	}
+	if cfg.Daemon.API.Addr != "" {
+		srv := newJobsServer(cfg, runs)
+		go func() {
+			slog.Info(tr(cfg, msgJobsListen, cfg.Daemon.API.Addr), "action", "api")
+			if err := srv.serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error(tr(cfg, msgJobsExit, err), "action", "api", "error", err)
+			}
+		}()
+		defer srv.shutdown()
+	}
+
+	slog.Info(tr(cfg, msgDaemonStart, cfg.Daemon.Interval))
+	for first := true; ; first = false {
+		// 按 bucketPattern 发现存储桶时每个周期重新列举，新建的存储桶无需重启即可清理
+		if !first && cfg.DiscoversBuckets() {
+			if refreshed, err := Targets(ctx, cfg); err != nil {
+				slog.Error(tr(cfg, msgTargetRediscover, err), "action", "discover", "error", err)
+			} else {
+				targets = refreshed
+				runs.setTargets(targets)
+			}
+		}
+		if len(cfg.Watch.Rules) > 0 {
+			watching.update(targets)
+		}
+
+		runs.runScheduled()
+
+		select {
+		case <-ctx.Done():
+			runs.wait()
+			slog.Info(tr(cfg, msgDaemonStop))
+			return
+		case <-time.After(cfg.Daemon.Interval):
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestListProgress(t *testing.T) {
+	var objects []minio.ObjectInfo
+	for i := range 10 {
+		objects = append(objects, testObject(fmt.Sprintf("k%d", i), time.Now(), 1))
+	}
+	store := &memStore{objects: objects}
+	listed := 0
+	targets := withListProgress([]Target{{cfg: testConfig(), store: store}}, func() { listed++ })
+
+	n := 0
+	listObjects(context.Background(), targets[0].store, "b", minio.ListObjectsOptions{Recursive: true}, 0, func(minio.ObjectInfo) { n++ })
+	if n != 10 || listed != 10 {
+		t.Errorf("listed %d objects with %d progress calls, want 10 and 10", n, listed)
+	}
+
+	// 调用方取消后停止列举，返回的通道关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := targets[0].store.list(ctx, "b", minio.ListObjectsOptions{Recursive: true})
+	<-ch
+	cancel()
+	for range ch {
+	}
+}
+
+func TestHealthStalledWhileListing(t *testing.T) {
+	h := &healthState{}
+	h.start()
+	defer h.finish()
+	h.lastProgress = time.Now().Add(-time.Hour)
+	if !h.stalled(time.Minute) {
+		t.Fatal("stalled() = false for a run without progress for an hour")
+	}
+	// 只列举而没有处理对象（如删除前的统计）也视为有进展
+	targets := withListProgress([]Target{{cfg: testConfig(), store: &memStore{objects: []minio.ObjectInfo{testObject("a", time.Now(), 1)}}}}, h.progress)
+	listObjects(context.Background(), targets[0].store, "b", minio.ListObjectsOptions{Recursive: true}, 0, func(minio.ObjectInfo) {})
+	if h.stalled(time.Minute) {
+		t.Error("stalled() = true right after listing")
+	}
+}
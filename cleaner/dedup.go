@@ -0,0 +1,143 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+type dedupPreset struct {
+	cfg    *config.Config
+	preset *config.DedupPresetConfig
+
+	// primary 为主存储桶中对象的内容标识，开启 matchKey 时包含对象名。由 prepare 生成，之后只读；为 nil 时保留所有对象
+	primary *keySet
+}
+
+func newDedupPreset(cfg *config.Config) appPreset {
+	return &dedupPreset{cfg: cfg, preset: &cfg.Presets.Dedup}
+}
+
+func (d *dedupPreset) rules() []rules.Rule {
+	return []rules.Rule{{Name: "dedup", MaxAge: d.preset.MaxAge}}
+}
+
+// contentKey 返回对象的内容标识。ETag 相同但大小不同的对象不认为相同；
+// 分片上传的 ETag 与分片大小有关，内容相同但分片方式不同的对象不会被认出
+func (d *dedupPreset) contentKey(obj rules.ObjectInfo) (string, bool) {
+	etag := strings.Trim(obj.ETag, `"`)
+	if etag == "" {
+		return "", false
+	}
+	id := etag + "/" + strconv.FormatInt(obj.Size, 10)
+	if d.preset.MatchKey {
+		id += "/" + obj.Key
+	}
+	return id, true
+}
+
+// prepare 列举主存储桶，记录其中所有对象的内容标识
+func (d *dedupPreset) prepare(ctx context.Context, store objectStore, _ time.Time) error {
+	cfg, primary := d.cfg, d.preset.Primary
+	if primary == "" || primary == cfg.Minio.Bucket {
+		return errors.New(tr(cfg, msgDedupPrimary, cfg.Minio.Bucket))
+	}
+	// 主存储桶的对象数可能很多，内容标识超出内存上限后写入临时文件
+	index := newKeySet()
+	var mu sync.Mutex
+	var objects int64
+	var listErr error
+	listBucket(ctx, store, primary, cfg.Cleanup.Listers, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		id, ok := d.contentKey(obj)
+		if !ok {
+			return
+		}
+		index.add(id)
+		mu.Lock()
+		defer mu.Unlock()
+		objects++
+	}, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		listErr = err
+	})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if listErr != nil {
+		return errors.New(tr(cfg, msgDedupIndexFailed, primary, listErr))
+	}
+	if err := index.seal(); err != nil {
+		return errors.New(tr(cfg, msgDedupIndexFailed, primary, err))
+	}
+	slog.Info(tr(cfg, msgDedupIndexed, primary, objects, index.len()), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "preset")
+	d.primary = index
+	return nil
+}
+
+// validateDedup 检查选择了 dedup 预设的集群：需要配置主存储桶，主存储桶不能同时是该集群的清理目标，
+// 否则主存储桶中的对象可能在副本被删除之后也被清理，两份都不复存在
+func validateDedup(cfg *config.Config) []string {
+	var problems []string
+	primary := cfg.Presets.Dedup.Primary
+	for _, m := range cfg.AllClusters() {
+		buckets := m.Buckets
+		if m.Bucket != "" {
+			buckets = append([]config.BucketConfig{{Name: m.Bucket, Preset: m.Preset}}, buckets...)
+		}
+		dedup := m.Preset == "dedup"
+		for _, b := range buckets {
+			dedup = dedup || b.Preset == "dedup"
+		}
+		if !dedup {
+			continue
+		}
+		name := cmp.Or(m.Name, m.EndpointAlias, m.Endpoint)
+		if primary == "" {
+			problems = append(problems, tr(cfg, msgDedupNoPrimary, name))
+			continue
+		}
+		for _, b := range buckets {
+			if b.Name == primary {
+				problems = append(problems, tr(cfg, msgDedupPrimaryTarget, primary, name))
+			}
+		}
+	}
+	return problems
+}
+
+// checkDedupTargets 检查解析后的清理目标：集群中有使用 dedup 预设的目标时，主存储桶不能同时是该集群的清理目标。
+// validateDedup 只检查配置中列出的存储桶，bucketPattern 匹配到的存储桶在列举后才能检查
+func checkDedupTargets(targets []Target) error {
+	for _, t := range targets {
+		if t.cfg.Minio.Preset != "dedup" {
+			continue
+		}
+		primary := t.cfg.Presets.Dedup.Primary
+		for _, other := range targets {
+			if other.cfg.Minio.Name == t.cfg.Minio.Name && other.cfg.Minio.Bucket == primary {
+				return errors.New(tr(other.cfg, msgDedupPrimaryTarget, primary, t.cfg.Minio.Name))
+			}
+		}
+	}
+	return nil
+}
+
+// keep 只放行主存储桶中有相同内容的对象，读取索引出错时保留
+func (d *dedupPreset) keep(obj rules.ObjectInfo) bool {
+	id, ok := d.contentKey(obj)
+	if !ok || d.primary == nil {
+		return true
+	}
+	found, err := d.primary.has(id)
+	return err != nil || !found
+}
@@ -0,0 +1,140 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestDedupKeep(t *testing.T) {
+	now := time.Now()
+	primary := []minio.ObjectInfo{
+		{Key: "a.bin", ETag: `"e1"`, Size: 10, LastModified: now},
+		{Key: "dir/b.bin", ETag: "e2", Size: 20, LastModified: now},
+		// 没有 ETag 的对象不进入索引
+		{Key: "c.bin", Size: 30, LastModified: now},
+	}
+	tests := []struct {
+		name     string
+		matchKey bool
+		obj      minio.ObjectInfo
+		want     bool
+	}{
+		{name: "same content", obj: minio.ObjectInfo{Key: "copy.bin", ETag: "e1", Size: 10}, want: false},
+		// ETag 带不带引号都按同一内容比较
+		{name: "quoted etag", obj: minio.ObjectInfo{Key: "copy.bin", ETag: `"e2"`, Size: 20}, want: false},
+		{name: "different size", obj: minio.ObjectInfo{Key: "copy.bin", ETag: "e1", Size: 11}, want: true},
+		{name: "different etag", obj: minio.ObjectInfo{Key: "a.bin", ETag: "e3", Size: 10}, want: true},
+		{name: "no etag", obj: minio.ObjectInfo{Key: "c.bin", Size: 30}, want: true},
+		{name: "matchKey same key", matchKey: true, obj: minio.ObjectInfo{Key: "dir/b.bin", ETag: "e2", Size: 20}, want: false},
+		{name: "matchKey other key", matchKey: true, obj: minio.ObjectInfo{Key: "copy.bin", ETag: "e1", Size: 10}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Presets.Dedup = config.DedupPresetConfig{Primary: "primary", MatchKey: tt.matchKey}
+			d := newDedupPreset(cfg).(*dedupPreset)
+			if err := d.prepare(context.Background(), &memStore{objects: primary}, now); err != nil {
+				t.Fatal(err)
+			}
+			defer d.primary.close()
+			if got := d.keep(tt.obj); got != tt.want {
+				t.Errorf("keep(%s) = %v, want %v", tt.obj.Key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupPrepareErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+		listErr error
+		want    string
+	}{
+		{name: "no primary", want: tr(nil, msgDedupPrimary, "b")},
+		{name: "primary is target", primary: "b", want: tr(nil, msgDedupPrimary, "b")},
+		{name: "list failed", primary: "primary", listErr: errors.New("boom"), want: tr(nil, msgDedupIndexFailed, "primary", errors.New("boom"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Presets.Dedup.Primary = tt.primary
+			d := newDedupPreset(cfg).(*dedupPreset)
+			store := &memStore{objects: []minio.ObjectInfo{{Key: "a.bin", ETag: "e1", Size: 1}}, listErr: tt.listErr}
+			err := d.prepare(context.Background(), store, time.Now())
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("prepare() = %v, want %q", err, tt.want)
+			}
+			// 没有索引时保留所有对象
+			if !d.keep(minio.ObjectInfo{Key: "a.bin", ETag: "e1", Size: 1}) {
+				t.Error("keep() = false without an index, want true")
+			}
+		})
+	}
+}
+
+func TestValidateDedup(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+		preset  string
+		buckets []config.BucketConfig
+		want    []string
+	}{
+		{name: "not dedup", preset: "", want: nil},
+		{name: "ok", primary: "primary", preset: "dedup", want: nil},
+		{name: "no primary", preset: "dedup", want: []string{tr(nil, msgDedupNoPrimary, "test")}},
+		{name: "primary is target", primary: "b", preset: "dedup", want: []string{tr(nil, msgDedupPrimaryTarget, "b", "test")}},
+		// 只有列表中的一个存储桶选择 dedup 时，主存储桶同样不能是该集群的其他清理目标
+		{
+			name:    "primary in buckets",
+			primary: "primary",
+			buckets: []config.BucketConfig{{Name: "copy", Preset: "dedup"}, {Name: "primary"}},
+			want:    []string{tr(nil, msgDedupPrimaryTarget, "primary", "test")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Minio.Preset = tt.preset
+			cfg.Minio.Buckets = tt.buckets
+			cfg.Presets.Dedup.Primary = tt.primary
+			if got := validateDedup(cfg); !slices.Equal(got, tt.want) {
+				t.Errorf("validateDedup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDedupTargets(t *testing.T) {
+	target := func(cluster, bucket, preset string) Target {
+		cfg := testConfig()
+		cfg.Minio.Name, cfg.Minio.Bucket, cfg.Minio.Preset = cluster, bucket, preset
+		cfg.Presets.Dedup.Primary = "primary"
+		return Target{cfg: cfg}
+	}
+	tests := []struct {
+		name    string
+		targets []Target
+		wantErr bool
+	}{
+		{name: "copy only", targets: []Target{target("a", "copy", "dedup")}},
+		// bucketPattern 在另一个集群匹配到同名存储桶时不算冲突
+		{name: "primary on other cluster", targets: []Target{target("a", "copy", "dedup"), target("b", "primary", "")}},
+		{name: "primary discovered", targets: []Target{target("a", "copy", "dedup"), target("a", "primary", "")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDedupTargets(tt.targets)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkDedupTargets() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// removeEmptyBucket 在清理后检查存储桶是否还有对象，没有则删除存储桶本身，返回是否已删除。
+// 创建时间比 safety.minObjectAge 新或无法获得的存储桶不删除，与对象的最短保留时间一致。
+// 存储桶中的历史版本和删除标记同样使删除失败，此时只记录警告，存储桶保留到下次运行
+func (c *cleaner) removeEmptyBucket(ctx context.Context) bool {
+	cfg, store := c.cfg, c.store
+	bucket := cfg.Minio.Bucket
+	if c.created.IsZero() || c.created.After(cfg.Now().Add(-time.Duration(cfg.Safety.MinObjectAge))) {
+		slog.Debug(tr(cfg, msgBucketTooNew, bucket, c.created), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "removeBucket")
+		return false
+	}
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var remaining bool
+	var listErr error
+	listObjects(listCtx, store, bucket, minio.ListObjectsOptions{Recursive: true, MaxKeys: 1}, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		if obj.Err != nil {
+			if listCtx.Err() == nil {
+				listErr = obj.Err
+			}
+			return
+		}
+		remaining = true
+		cancel()
+	})
+	if listErr != nil {
+		slog.Warn(tr(cfg, msgBucketRemoveFailed, bucket, listErr), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "removeBucket", "error", listErr)
+		return false
+	}
+	if remaining || ctx.Err() != nil {
+		slog.Debug(tr(cfg, msgBucketNotEmpty, bucket), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "removeBucket")
+		return false
+	}
+	err := withTimeout(ctx, "removeBucket", cfg.Timeouts.Delete, func(ctx context.Context) error {
+		return store.removeBucket(ctx, bucket)
+	})
+	if err != nil {
+		slog.Warn(tr(cfg, msgBucketRemoveFailed, bucket, err), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "removeBucket", "error", err)
+		return false
+	}
+	slog.Info(tr(cfg, msgBucketRemoved, bucket), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "removeBucket")
+	return true
+}
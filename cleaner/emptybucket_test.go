@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// bucketStore 在 memStore 的基础上支持删除和上传对象以及删除存储桶，存储桶未开启版本控制
+type bucketStore struct {
+	memStore
+	mu      sync.Mutex
+	removed bool
+}
+
+func (s *bucketStore) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	s.mu.Lock()
+	snapshot := &memStore{objects: slices.Clone(s.objects)}
+	s.mu.Unlock()
+	return snapshot.list(ctx, bucket, opts)
+}
+
+func (s *bucketStore) versioning(ctx context.Context, bucket string) (bool, error) {
+	return false, nil
+}
+
+func (s *bucketStore) remove(ctx context.Context, bucket, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects = slices.DeleteFunc(s.objects, func(o minio.ObjectInfo) bool { return o.Key == key })
+	return "", nil
+}
+
+func (s *bucketStore) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.removed {
+		return minio.ErrorResponse{Code: "NoSuchBucket", BucketName: bucket}
+	}
+	s.objects = append(s.objects, testObject(key, time.Now(), size))
+	return nil
+}
+
+func (s *bucketStore) removeBucket(ctx context.Context, bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.objects) > 0 {
+		return minio.ErrorResponse{Code: "BucketNotEmpty", BucketName: bucket}
+	}
+	s.removed = true
+	return nil
+}
+
+func TestRemoveEmptyBucket(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour)
+	tests := []struct {
+		name          string
+		summaryObject string
+		created       time.Time
+		objects       []minio.ObjectInfo
+		wantRemoved   bool
+		wantRemaining []string
+	}{
+		{name: "emptied", created: old, objects: []minio.ObjectInfo{testObject("a", old, 1), testObject("b", old, 1)}, wantRemoved: true},
+		{name: "objects left", created: old, objects: []minio.ObjectInfo{testObject("a", old, 1), testObject("b", time.Now(), 1)}, wantRemaining: []string{"b"}},
+		// 汇总报告在删除存储桶之前上传，存储桶因此保留
+		{name: "summary object", created: old, summaryObject: "reports/summary.json", objects: []minio.ObjectInfo{testObject("a", old, 1)}, wantRemaining: []string{"reports/summary.json"}},
+		// 本来就为空、本次没有删除对象的存储桶（如刚创建、等待第一次上传）不删除
+		{name: "nothing deleted", created: old},
+		// 创建时间比 safety.minObjectAge 新或未知时不删除
+		{name: "newly created", created: time.Now().Add(-time.Minute), objects: []minio.ObjectInfo{testObject("a", old, 1)}},
+		{name: "creation unknown", objects: []minio.ObjectInfo{testObject("a", old, 1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+			cfg.Report.SummaryObject = tt.summaryObject
+			// 清空存储桶超过默认的删除比例限制，与使用 -force 运行相同
+			cfg.Safety.MaxDeletePercent = 100
+			store := &bucketStore{memStore: memStore{objects: tt.objects}}
+			c := &cleaner{cfg: cfg, store: store, removable: true, created: tt.created}
+			report, err := c.run(context.Background())
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if report.BucketRemoved != tt.wantRemoved || store.removed != tt.wantRemoved {
+				t.Errorf("run() BucketRemoved = %v, bucket removed = %v, want %v", report.BucketRemoved, store.removed, tt.wantRemoved)
+			}
+			var remaining []string
+			for _, o := range store.objects {
+				remaining = append(remaining, o.Key)
+			}
+			if !slices.Equal(remaining, tt.wantRemaining) {
+				t.Errorf("objects left = %v, want %v", remaining, tt.wantRemaining)
+			}
+		})
+	}
+}
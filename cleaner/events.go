@@ -0,0 +1,99 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// deletionEvent 为一个已删除对象的事件消息，字段与删除清单相同
+type deletionEvent struct {
+	Cluster string `json:"cluster,omitempty"`
+	manifestEntry
+}
+
+// eventMessage 为发布的一条消息，key 为 Kafka 消息键，NATS 不使用
+type eventMessage struct {
+	key   []byte
+	value []byte
+}
+
+// eventPublisher 将消息发布到消息系统，连接在第一次发布时建立。不可被并发调用
+type eventPublisher interface {
+	publish(ctx context.Context, msgs []eventMessage) error
+	close() error
+}
+
+// newEventPublisher 按配置创建发布者，未配置时返回 nil
+func newEventPublisher(cfg *config.EventsConfig) eventPublisher {
+	switch {
+	case cfg.Kafka.Enabled():
+		return newKafkaProducer(&cfg.Kafka, cfg.Timeout)
+	case cfg.NATS.Enabled():
+		return newNATSPublisher(&cfg.NATS, cfg.Timeout)
+	}
+	return nil
+}
+
+// newEventMessage 将已删除对象编码为消息，消息键为存储桶和对象名，同一对象的事件总是进入同一分区
+func newEventMessage(cluster string, e manifestEntry) eventMessage {
+	value, _ := json.Marshal(deletionEvent{Cluster: cluster, manifestEntry: e})
+	return eventMessage{key: []byte(e.Bucket + "/" + e.Key), value: value}
+}
+
+// eventBatch 收集删除成功的对象，攒满一批后发布，可被并发调用
+type eventBatch struct {
+	mu        sync.Mutex
+	publisher eventPublisher
+	msgs      []eventMessage
+}
+
+// newEventBatch 在配置了 events 时创建，预览模式下返回 nil
+func newEventBatch(cfg *config.Config) *eventBatch {
+	if !cfg.Events.Enabled() || cfg.Cleanup.DryRun {
+		return nil
+	}
+	return &eventBatch{publisher: newEventPublisher(&cfg.Events)}
+}
+
+// publishDeleted 记录一个删除成功的对象，攒满一批时在调用方协程中发布
+func (p *pipeline) publishDeleted(ctx context.Context, e manifestEntry) {
+	b := p.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.msgs = append(b.msgs, newEventMessage(p.cluster(), e))
+	if len(b.msgs) >= p.cfg.Events.BatchSize {
+		p.publishEvents(ctx)
+	}
+}
+
+// flushEvents 发布剩余未满一批的事件并关闭连接
+func (p *pipeline) flushEvents(ctx context.Context) {
+	b := p.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.msgs) > 0 {
+		p.publishEvents(ctx)
+	}
+	b.publisher.close()
+}
+
+// publishEvents 发布已收集的事件，调用方需持有 b.mu。对象已经删除，运行中止或超时后仍然发布，失败时记为错误
+func (p *pipeline) publishEvents(ctx context.Context) {
+	b := p.events
+	msgs := b.msgs
+	b.msgs = nil
+	if err := b.publisher.publish(context.WithoutCancel(ctx), msgs); err != nil {
+		p.fail(tr(p.cfg, msgEventsFailed, len(msgs), err), "bucket", p.bucket, "action", "events", "files", len(msgs), "error", err)
+	}
+}
+
+// cluster 返回多集群配置下的集群名称，单集群时为空
+func (p *pipeline) cluster() string {
+	if len(p.cfg.Clusters) > 0 {
+		return p.cfg.Minio.Name
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package cleaner
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// skipFilter 为对象符合规则，但 Config.Filters 中的过滤器决定保留
+const skipFilter = "filter"
+
+// filterStats 为一个过滤器在本次运行中的统计，可被并发更新
+type filterStats struct {
+	name      string
+	filter    rules.Filter
+	evaluated int64
+	kept      int64
+	keptBytes int64
+}
+
+// newFilterStats 为 Config.Filters 中的每个过滤器创建统计
+func newFilterStats(filters []rules.Filter) []*filterStats {
+	stats := make([]*filterStats, len(filters))
+	for i, f := range filters {
+		name := fmt.Sprintf("filter%d", i+1)
+		if n, ok := f.(rules.NamedFilter); ok {
+			name = n.Name
+		}
+		stats[i] = &filterStats{name: name, filter: f}
+	}
+	return stats
+}
+
+// filtersKeep 返回是否有过滤器决定保留对象，不统计
+func filtersKeep(filters []rules.Filter, obj rules.ObjectInfo) bool {
+	return slices.ContainsFunc(filters, func(f rules.Filter) bool { return f.Match(obj) == rules.Keep })
+}
+
+// keptByFilter 依次交给各过滤器判断，返回决定保留对象的过滤器，可以删除时返回 nil。
+// 第一个返回 Keep 的过滤器之后的过滤器不再调用
+func keptByFilter(filters []*filterStats, obj rules.ObjectInfo) *filterStats {
+	for _, f := range filters {
+		atomic.AddInt64(&f.evaluated, 1)
+		if f.filter.Match(obj) == rules.Keep {
+			atomic.AddInt64(&f.kept, 1)
+			atomic.AddInt64(&f.keptBytes, obj.Size)
+			return f
+		}
+	}
+	return nil
+}
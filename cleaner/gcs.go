@@ -0,0 +1,251 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/api/transport"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// gcsDefaultEndpoint 为 GCS JSON API 的默认地址
+const gcsDefaultEndpoint = "storage.googleapis.com"
+
+// gcsStore 通过官方客户端库访问 Google Cloud Storage，读写均使用 JSON API
+type gcsStore struct {
+	client  *storage.Client
+	project string
+}
+
+// newGCSStore 创建 GCS 客户端。依次使用 gcs.credentialsFile、GOOGLE_APPLICATION_CREDENTIALS、
+// gcloud 的应用默认凭证文件，都没有时从元数据服务获取运行环境服务账号的令牌
+func newGCSStore(cfg *config.Config) (*gcsStore, error) {
+	m := &cfg.Minio
+	tr, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	authOpts := []option.ClientOption{option.WithScopes(storage.ScopeReadWrite)}
+	if m.GCS.CredentialsFile != "" {
+		authOpts = append(authOpts, option.WithCredentialsFile(m.GCS.CredentialsFile))
+	}
+	creds, err := transport.Creds(ctx, authOpts...)
+	if err != nil {
+		return nil, err
+	}
+	// 访问令牌由客户端库获取和刷新，请求仍经过 transport、tls 和代理配置，并计入 API 请求数
+	authed, err := htransport.NewTransport(ctx, apiCallTransport{tr}, option.WithCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	endpoint := ""
+	if m.Endpoint != "" && m.Endpoint != gcsDefaultEndpoint {
+		scheme := "http://"
+		if m.UseSSL {
+			scheme = "https://"
+		}
+		endpoint = scheme + strings.TrimSuffix(m.Endpoint, "/") + "/storage/v1/"
+	}
+	project := cmp.Or(m.GCS.Project, creds.ProjectID, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	return newGCSClient(ctx, &http.Client{Transport: authed}, endpoint, project)
+}
+
+// newGCSClient 创建使用 httpClient 发送请求的客户端，endpoint 为空时使用默认地址
+func newGCSClient(ctx context.Context, httpClient *http.Client, endpoint, project string) (*gcsStore, error) {
+	opts := []option.ClientOption{option.WithHTTPClient(httpClient), storage.WithJSONReads()}
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// 重试由调用方按 cleanup.retry 统一处理
+	client.SetRetry(storage.WithPolicy(storage.RetryNever))
+	return &gcsStore{client: client, project: project}, nil
+}
+
+// gcsError 将客户端库返回的错误转换为 S3 错误，key 为空时请求的是存储桶本身
+func gcsError(err error, bucket, key string) error {
+	if err == nil {
+		return nil
+	}
+	status, message := 0, err.Error()
+	var ge *googleapi.Error
+	switch {
+	case errors.As(err, &ge):
+		status, message = ge.Code, ge.Message
+	case errors.Is(err, storage.ErrObjectNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, storage.ErrBucketNotExist):
+		status, key = http.StatusNotFound, ""
+	default:
+		return err
+	}
+	resp := &http.Response{StatusCode: status, Status: fmt.Sprintf("%d %s", status, http.StatusText(status))}
+	return httpError(resp, bucket, key, message)
+}
+
+// gcsObjectInfo 转换对象的属性，generation 作为版本 ID
+func gcsObjectInfo(attrs *storage.ObjectAttrs) minio.ObjectInfo {
+	return minio.ObjectInfo{
+		Key:          attrs.Name,
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+		ETag:         attrs.Etag,
+		ContentType:  attrs.ContentType,
+		VersionID:    strconv.FormatInt(attrs.Generation, 10),
+		UserMetadata: attrs.Metadata,
+	}
+}
+
+func (s *gcsStore) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo, 1)
+	go func() {
+		defer close(ch)
+		q := &storage.Query{Prefix: opts.Prefix}
+		if !opts.Recursive {
+			q.Delimiter = "/"
+		}
+		q.SetAttrSelection([]string{"Name", "Size", "Updated", "Etag", "ContentType", "Generation"})
+		pager := iterator.NewPager(s.client.Bucket(bucket).Objects(ctx, q), 1000, "")
+		for {
+			var page []*storage.ObjectAttrs
+			token, err := pager.NextPage(&page)
+			if err != nil {
+				sendObject(ctx, ch, minio.ObjectInfo{Err: gcsError(err, bucket, "")})
+				return
+			}
+			// 一页中的对象和公共前缀各自有序，公共前缀只有 Prefix
+			var objects []minio.ObjectInfo
+			var prefixes []string
+			for _, attrs := range page {
+				if attrs.Prefix != "" {
+					prefixes = append(prefixes, attrs.Prefix)
+				} else {
+					objects = append(objects, gcsObjectInfo(attrs))
+				}
+			}
+			if !sendListPage(ctx, ch, objects, prefixes) {
+				return
+			}
+			if token == "" {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *gcsStore) stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error) {
+	attrs, err := s.client.Bucket(bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return minio.ObjectInfo{}, gcsError(err, bucket, key)
+	}
+	return gcsObjectInfo(attrs), nil
+}
+
+func (s *gcsStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	r, err := s.client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, gcsError(err, bucket, key)
+	}
+	return r, nil
+}
+
+func (s *gcsStore) remove(ctx context.Context, bucket, key string) (string, error) {
+	// 开启对象版本控制时被删除的 generation 保留为非当前版本，删除不产生删除标记
+	return "", gcsError(s.client.Bucket(bucket).Object(key).Delete(ctx), bucket, key)
+}
+
+func (s *gcsStore) copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	// 大对象或跨区域复制需要多次调用 rewrite，由 Copier 完成
+	src := s.client.Bucket(srcBucket).Object(srcKey)
+	_, err := s.client.Bucket(dstBucket).Object(dstKey).CopierFrom(src).Run(ctx)
+	return gcsError(err, srcBucket, srcKey)
+}
+
+func (s *gcsStore) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	// 取消 ctx 使未完成的上传中止，不产生不完整的对象
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := s.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	// 大小已知，在一个请求中上传，不在内存中缓冲分块
+	w.ChunkSize = 0
+	if _, err := io.Copy(w, io.LimitReader(r, size)); err != nil {
+		cancel()
+		w.Close()
+		return gcsError(err, bucket, key)
+	}
+	return gcsError(w.Close(), bucket, key)
+}
+
+func (s *gcsStore) bucketExists(ctx context.Context, bucket string) (bool, error) {
+	_, err := s.client.Bucket(bucket).Attrs(ctx)
+	if errors.Is(err, storage.ErrBucketNotExist) {
+		return false, nil
+	}
+	return err == nil, gcsError(err, bucket, "")
+}
+
+// removeBucket 删除存储桶，GCS 拒绝删除还有对象的存储桶
+func (s *gcsStore) removeBucket(ctx context.Context, bucket string) error {
+	return gcsError(s.client.Bucket(bucket).Delete(ctx), bucket, "")
+}
+
+func (s *gcsStore) versioning(ctx context.Context, bucket string) (bool, error) {
+	attrs, err := s.client.Bucket(bucket).Attrs(ctx)
+	if err != nil {
+		return false, gcsError(err, bucket, "")
+	}
+	return attrs.VersioningEnabled, nil
+}
+
+func (s *gcsStore) objectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	attrs, err := s.client.Bucket(bucket).Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, gcsError(err, bucket, key)
+	}
+	return attrs.Metadata, nil
+}
+
+func (s *gcsStore) bucketTags(ctx context.Context, bucket string) (map[string]string, error) {
+	attrs, err := s.client.Bucket(bucket).Attrs(ctx)
+	if err != nil {
+		return nil, gcsError(err, bucket, "")
+	}
+	return attrs.Labels, nil
+}
+
+func (s *gcsStore) listBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	if s.project == "" {
+		return nil, errors.New(trLang(contextLanguage(ctx), msgGCSNoProject))
+	}
+	var buckets []minio.BucketInfo
+	it := s.client.Buckets(ctx, s.project)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return buckets, nil
+		}
+		if err != nil {
+			return nil, gcsError(err, "", "")
+		}
+		buckets = append(buckets, minio.BucketInfo{Name: attrs.Name, CreationDate: attrs.Created})
+	}
+}
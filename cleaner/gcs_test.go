@@ -0,0 +1,228 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// gcsTestServer 为模拟 JSON API 的假服务器，记录收到的请求和上传的内容
+type gcsTestServer struct {
+	mu       sync.Mutex
+	requests []string
+	uploaded string
+}
+
+func (s *gcsTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method + " " + r.URL.Path {
+	// 存储桶 logs 的对象分两页返回，非递归列举时 dir/ 为公共前缀
+	case "GET /storage/v1/b/logs/o":
+		if r.URL.Query().Get("pageToken") == "" {
+			body := `{"items": [{"name": "a.log", "size": "5", "updated": "2024-06-10T00:00:00Z", "etag": "e1", "generation": "3"}], "nextPageToken": "p2"`
+			if r.URL.Query().Get("delimiter") == "/" {
+				body += `, "prefixes": ["dir/"]`
+			}
+			w.Write([]byte(body + "}"))
+			return
+		}
+		w.Write([]byte(`{"items": [{"name": "z.log", "size": "7"}]}`))
+	case "GET /storage/v1/b/logs/o/a.log":
+		w.Write([]byte(`{"name": "a.log", "bucket": "logs", "size": "5", "generation": "3", "metadata": {"owner": "ops"}}`))
+	case "DELETE /storage/v1/b/logs/o/a.log":
+		w.WriteHeader(http.StatusNoContent)
+	case "GET /storage/v1/b/logs":
+		w.Write([]byte(`{"name": "logs", "versioning": {"enabled": true}, "labels": {"team": "ops"}}`))
+	case "GET /storage/v1/b":
+		w.Write([]byte(`{"items": [{"name": "logs", "timeCreated": "2024-01-01T00:00:00Z"}]}`))
+	case "POST /upload/storage/v1/b/logs/o":
+		// 请求为 multipart：第一部分为对象的元数据，第二部分为内容。客户端会校验返回的 CRC32C
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		var parts []string
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(p)
+			parts = append(parts, string(data))
+		}
+		s.uploaded = strings.Join(parts, "\n")
+		var crc [4]byte
+		if len(parts) == 2 {
+			binary.BigEndian.PutUint32(crc[:], crc32.Checksum([]byte(parts[1]), crc32.MakeTable(crc32.Castagnoli)))
+		}
+		w.Write([]byte(`{"name": "report.json", "bucket": "logs", "crc32c": "` + base64.StdEncoding.EncodeToString(crc[:]) + `"}`))
+	case "GET /storage/v1/b/logs/o/locked":
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"code": 429, "message": "rate limit exceeded"}}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"code": 404, "message": "Not Found"}}`))
+	}
+}
+
+func TestGCSStore(t *testing.T) {
+	tests := []struct {
+		name string
+		// project 为空时无法列举存储桶
+		project string
+		call    func(ctx context.Context, s *gcsStore) (any, error)
+		want    any
+		// code 为期望的 S3 错误码
+		code string
+	}{
+		{
+			name: "list recursive",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return listGCSKeys(ctx, s, true) },
+			want: []string{"a.log", "z.log"},
+		},
+		// 非递归列举时公共前缀与对象按名称顺序返回
+		{
+			name: "list with prefixes",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return listGCSKeys(ctx, s, false) },
+			want: []string{"a.log", "dir/", "z.log"},
+		},
+		// generation 作为版本 ID，自定义元数据作为用户元数据
+		{
+			name: "stat",
+			call: func(ctx context.Context, s *gcsStore) (any, error) {
+				obj, err := s.stat(ctx, "logs", "a.log")
+				return []string{obj.Key, obj.VersionID, obj.UserMetadata["owner"]}, err
+			},
+			want: []string{"a.log", "3", "ops"},
+		},
+		{
+			name: "stat missing object",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.stat(ctx, "logs", "missing") },
+			code: "NoSuchKey",
+		},
+		{
+			name: "rate limited",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.stat(ctx, "logs", "locked") },
+			code: "SlowDown",
+		},
+		{
+			name: "list missing bucket",
+			call: func(ctx context.Context, s *gcsStore) (any, error) {
+				for obj := range s.list(ctx, "other", minio.ListObjectsOptions{Recursive: true}) {
+					return nil, obj.Err
+				}
+				return nil, nil
+			},
+			code: "NoSuchBucket",
+		},
+		{
+			name: "bucket exists",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.bucketExists(ctx, "logs") },
+			want: true,
+		},
+		{
+			name: "bucket missing",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.bucketExists(ctx, "other") },
+			want: false,
+		},
+		{
+			name: "versioning",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.versioning(ctx, "logs") },
+			want: true,
+		},
+		{
+			name: "bucket labels",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.bucketTags(ctx, "logs") },
+			want: map[string]string{"team": "ops"},
+		},
+		{
+			name: "remove",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.remove(ctx, "logs", "a.log") },
+			want: "",
+		},
+		{
+			name: "remove missing object",
+			call: func(ctx context.Context, s *gcsStore) (any, error) { return s.remove(ctx, "logs", "missing") },
+			code: "NoSuchKey",
+		},
+		{
+			name:    "list buckets",
+			project: "proj",
+			call: func(ctx context.Context, s *gcsStore) (any, error) {
+				buckets, err := s.listBuckets(ctx)
+				var names []string
+				for _, b := range buckets {
+					names = append(names, b.Name)
+				}
+				return names, err
+			},
+			want: []string{"logs"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&gcsTestServer{})
+			defer srv.Close()
+			ctx := context.Background()
+			s, err := newGCSClient(ctx, srv.Client(), srv.URL+"/storage/v1/", tt.project)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.call(ctx, s)
+			if code := minio.ToErrorResponse(err).Code; code != tt.code || (err != nil) != (tt.code != "") {
+				t.Fatalf("error = %v (code %q), want code %q", err, code, tt.code)
+			}
+			if tt.code == "" && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// listGCSKeys 列举存储桶 logs，返回对象名和公共前缀
+func listGCSKeys(ctx context.Context, s *gcsStore, recursive bool) ([]string, error) {
+	var keys []string
+	for obj := range s.list(ctx, "logs", minio.ListObjectsOptions{Recursive: recursive}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+func TestGCSPut(t *testing.T) {
+	fake := &gcsTestServer{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	ctx := context.Background()
+	s, err := newGCSClient(ctx, srv.Client(), srv.URL+"/storage/v1/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.put(ctx, "logs", "report.json", strings.NewReader(`{"deleted": 3}`), 14, "application/json"); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+	// 在一个请求中上传元数据和内容
+	if len(fake.requests) != 1 || !strings.Contains(fake.uploaded, `{"deleted": 3}`) || !strings.Contains(fake.uploaded, `"contentType":"application/json"`) {
+		t.Errorf("requests = %q, uploaded %q", fake.requests, fake.uploaded)
+	}
+
+	// 未配置项目时无法列举存储桶
+	if _, err := s.listBuckets(ctx); err == nil || err.Error() != tr(nil, msgGCSNoProject) {
+		t.Errorf("listBuckets() without project error = %v, want %q", err, tr(nil, msgGCSNoProject))
+	}
+}
@@ -0,0 +1,76 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// groupIndex 记录对象组（如一个备份目录）是否整组符合清理规则。组是原子的清理单位：
+// 组中只要有一个对象不符合规则，整组保留，避免只删除一部分导致数据无法使用
+type groupIndex struct {
+	// groupOf 返回对象所属的组，不属于任何组时返回 false
+	groupOf func(key string) (string, bool)
+	// seen 为列举到的组，kept 为其中有对象不符合清理规则的组。组的数量可能与对象数相当（如 groupDepth 到最后一级目录），
+	// 超出内存上限后写入临时文件
+	seen, kept *keySet
+}
+
+// buildGroupIndex 列举存储桶，按 now 时的清理规则判断各组中的对象。now 需与本次运行判断规则的时间相同，
+// 组中的对象在两次判断中的结果才会一致。keep 不为 nil 时，符合规则但由它决定保留的对象同样使所在的组不完整，
+// 运行中排在组过滤器前后的保留判断（集成预设、交互式审查、Config.Filters）因此不会只保留组中的一部分。
+// 列举出错时返回错误，不清理任何组
+func buildGroupIndex(ctx context.Context, cfg *config.Config, store objectStore, now time.Time, groupOf func(key string) (string, bool), keep func(obj rules.ObjectInfo) bool) (*groupIndex, error) {
+	compiled := rules.Compile(effectiveRules(cfg), now, cfg.Safety.MinObjectAge)
+	g := &groupIndex{groupOf: groupOf, seen: newKeySet(), kept: newKeySet()}
+	var mu sync.Mutex
+	var listErr error
+	bucket := cfg.Minio.Bucket
+	listBucket(ctx, store, bucket, cfg.Cleanup.Listers, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		group, ok := groupOf(obj.Key)
+		if !ok {
+			return
+		}
+		_, _, reason := rules.Select(compiled, obj)
+		g.seen.add(group)
+		if reason != "" || keep != nil && keep(obj) {
+			g.kept.add(group)
+		}
+	}, func(err error) {
+		mu.Lock()
+		listErr = err
+		mu.Unlock()
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if listErr != nil {
+		return nil, errors.New(tr(cfg, msgGroupIndexFailed, bucket, listErr))
+	}
+	for _, set := range []*keySet{g.seen, g.kept} {
+		if err := set.seal(); err != nil {
+			return nil, errors.New(tr(cfg, msgGroupIndexFailed, bucket, err))
+		}
+	}
+	return g, nil
+}
+
+// complete 返回对象所属的组是否整组符合清理规则。不属于任何组的对象返回 true，
+// 列举之后才出现的组和读取索引出错时返回 false
+func (g *groupIndex) complete(key string) bool {
+	group, ok := g.groupOf(key)
+	if !ok {
+		return true
+	}
+	seen, err := g.seen.has(group)
+	if err != nil || !seen {
+		return false
+	}
+	kept, err := g.kept.has(group)
+	return err == nil && !kept
+}
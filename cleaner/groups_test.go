@@ -0,0 +1,106 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestRunFiltersGroups(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-10 * rules.Day)
+	objects := []minio.ObjectInfo{
+		testObject("backups/a/1", old, 1),
+		testObject("backups/a/2", old, 1),
+		testObject("backups/b/1", old, 1),
+		testObject("backups/b/keep", old, 1),
+		testObject("backups/c/1", old, 1),
+		testObject("backups/c/2", now, 1),
+	}
+	tests := []struct {
+		name     string
+		filters  []rules.Filter
+		approved map[string]bool
+		// want 为整组可以删除的对象组
+		want []string
+	}{
+		{name: "rules only", want: []string{"backups/a/", "backups/b/"}},
+		// 过滤器保留组中的一个对象时整组保留，而不是只删除组中的其他对象
+		{
+			name: "kept by filter",
+			filters: []rules.Filter{rules.FilterFunc(func(obj rules.ObjectInfo) rules.Decision {
+				if strings.HasSuffix(obj.Key, "/keep") {
+					return rules.Keep
+				}
+				return rules.Abstain
+			})},
+			want: []string{"backups/a/"},
+		},
+		{
+			name:     "partly approved",
+			approved: map[string]bool{"backups/a/1": true, "backups/b/1": true, "backups/b/keep": true},
+			want:     []string{"backups/b/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{Name: "backups", Prefix: "backups/", MaxAge: rules.Retention(rules.Day), GroupDepth: 1})
+			cfg.Filters = tt.filters
+			filters, err := runFilters(context.Background(), cfg, &memStore{objects: objects}, now, tt.approved)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, obj := range objects {
+				group := obj.Key[:strings.LastIndexByte(obj.Key, '/')+1]
+				if filters[0].Match(obj) != rules.Keep && !slices.Contains(got, group) {
+					got = append(got, group)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("complete groups = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGroupsPerObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   rules.Rule
+		preset string
+		setup  func(cfg *config.Config)
+		want   string
+	}{
+		{name: "groups only", rule: rules.Rule{GroupDepth: 1}, setup: func(*config.Config) {}},
+		{name: "plugin without groups", setup: func(cfg *config.Config) { cfg.FilterPlugin.Command = []string{"plugin"} }},
+		{name: "plugin", rule: rules.Rule{GroupDepth: 1}, setup: func(cfg *config.Config) { cfg.FilterPlugin.Command = []string{"plugin"} }, want: "filterPlugin"},
+		{name: "preDelete", rule: rules.Rule{Partition: "dt=2006-01-02"}, setup: func(cfg *config.Config) { cfg.Hooks.PreDelete.URL = "http://hook" }, want: "hooks.preDelete"},
+		{name: "verifyBeforeDelete", rule: rules.Rule{GroupDepth: 1}, setup: func(cfg *config.Config) { cfg.Cleanup.VerifyBeforeDelete = true }, want: "cleanup.verifyBeforeDelete"},
+		{name: "skipUnreplicated", rule: rules.Rule{GroupDepth: 1}, setup: func(cfg *config.Config) { cfg.Cleanup.SkipUnreplicated = true }, want: "cleanup.skipUnreplicated"},
+		{name: "velero preset", preset: "velero", setup: func(cfg *config.Config) { cfg.Hooks.PreDelete.URL = "http://hook" }, want: "hooks.preDelete"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Prefix, tt.rule.MaxAge = "backups/", rules.Retention(rules.Day)
+			cfg := testConfig(tt.rule)
+			cfg.Minio.Endpoint = "localhost:9000"
+			cfg.Minio.Preset = tt.preset
+			tt.setup(cfg)
+			problems := validateConfig(cfg)
+			found := slices.ContainsFunc(problems, func(p string) bool { return strings.Contains(p, "groupDepth") })
+			if found != (tt.want != "") {
+				t.Fatalf("validateConfig() = %q, want problem about %q", problems, tt.want)
+			}
+			if tt.want != "" && !slices.Contains(problems, tr(cfg, msgGroupsPerObject, tt.want)) {
+				t.Errorf("validateConfig() = %q, want %q", problems, tr(cfg, msgGroupsPerObject, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package cleaner
+
+//go:generate protoc -I ../proto --go_out=../proto --go_opt=paths=source_relative --go-grpc_out=../proto --go-grpc_opt=paths=source_relative minio_cleaner/v1/control.proto
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	cleanerv1 "github.com/fjcanyue/minio-cleaner/proto/minio_cleaner/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcProgressInterval 为 StreamEvents 发送进度的间隔
+const grpcProgressInterval = time.Second
+
+// grpcServer 为 gRPC 控制服务，消息由 proto/minio_cleaner/v1 中生成的代码编解码
+type grpcServer struct {
+	cleanerv1.UnimplementedControlServer
+	cfg  *config.GRPCConfig
+	runs *runManager
+	srv  *grpc.Server
+}
+
+func newGRPCServer(cfg *config.Config, runs *runManager) (*grpcServer, error) {
+	g := &grpcServer{cfg: &cfg.Daemon.GRPC, runs: runs}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(g.unaryInterceptor),
+		grpc.ChainStreamInterceptor(g.streamInterceptor),
+	}
+	if g.cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(g.cfg.CertFile, g.cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+		if g.cfg.ClientCAFile != "" {
+			pem, err := os.ReadFile(g.cfg.ClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.ClientCAs = x509.NewCertPool()
+			if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+				return nil, errors.New(tr(cfg, msgGRPCBadClientCA, g.cfg.ClientCAFile))
+			}
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	g.srv = grpc.NewServer(opts...)
+	cleanerv1.RegisterControlServer(g.srv, g)
+	return g, nil
+}
+
+func (g *grpcServer) serve() error {
+	lis, err := net.Listen("tcp", g.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return g.srv.Serve(lis)
+}
+
+// shutdown 等待进行中的调用结束，StreamEvents 等 5 秒内未结束的调用被中断
+func (g *grpcServer) shutdown() {
+	done := make(chan struct{})
+	go func() {
+		g.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		g.srv.Stop()
+	}
+}
+
+func (g *grpcServer) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := g.authorize(ctx); err != nil {
+		return nil, logGRPCError(info.FullMethod, err)
+	}
+	resp, err := handler(ctx, req)
+	return resp, logGRPCError(info.FullMethod, err)
+}
+
+func (g *grpcServer) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(ss.Context()); err != nil {
+		return logGRPCError(info.FullMethod, err)
+	}
+	return logGRPCError(info.FullMethod, handler(srv, ss))
+}
+
+// logGRPCError 记录调用失败的原因，调用方取消的调用不记录
+func logGRPCError(fullMethod string, err error) error {
+	if err == nil {
+		return nil
+	}
+	s := status.Convert(err)
+	if s.Code() != codes.Canceled {
+		method := fullMethod[strings.LastIndexByte(fullMethod, '/')+1:]
+		slog.Warn(tr(nil, msgGRPCFailed, method, s.Message()), "action", "grpc", "method", method, "code", s.Code().String())
+	}
+	return err
+}
+
+// authorize 检查 authorization 元数据中的 Bearer 令牌。未配置令牌时只接受已由 TLS 验证过客户端证书的调用，
+// 两者都未配置时拒绝所有调用（validate 会报告这种配置）
+func (g *grpcServer) authorize(ctx context.Context) error {
+	if g.cfg.Token == "" {
+		if g.cfg.ClientCAFile != "" && g.cfg.CertFile != "" {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "no token or client certificate authority configured")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var got string
+	if v := md.Get("authorization"); len(v) > 0 {
+		got = v[0]
+	}
+	if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+g.cfg.Token)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+	}
+	return nil
+}
+
+func (g *grpcServer) StartRun(ctx context.Context, req *cleanerv1.StartRunRequest) (*cleanerv1.StartRunResponse, error) {
+	// 未设置 dry_run 时预览，需要明确设置为 false 才删除对象
+	dryRun := req.DryRun == nil || *req.DryRun
+	run, err := g.runs.start(runTriggerGRPC, dryRun, req.GetBuckets())
+	if errors.Is(err, errRunInProgress) {
+		return nil, status.Error(codes.FailedPrecondition, tr(nil, msgControlBusy))
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	slog.Info(tr(nil, msgControlStarted, run.id, runTriggerGRPC), "action", "grpc", "run", run.id, "dryRun", run.dryRun)
+	return &cleanerv1.StartRunResponse{Run: runStatusProto(run.status())}, nil
+}
+
+// findRun 按 run_id 查找运行，为空时为最近一次运行
+func (g *grpcServer) findRun(id string) (*controlRun, error) {
+	run := g.runs.find(id)
+	if run == nil {
+		return nil, status.Error(codes.NotFound, tr(nil, msgControlNoRun, id))
+	}
+	return run, nil
+}
+
+func (g *grpcServer) GetStatus(ctx context.Context, req *cleanerv1.GetStatusRequest) (*cleanerv1.RunStatus, error) {
+	run, err := g.findRun(req.GetRunId())
+	if err != nil {
+		return nil, err
+	}
+	return runStatusProto(run.status()), nil
+}
+
+// CancelRun 取消排队或正在进行的运行，已删除的对象不会恢复，返回取消请求发出时的状态
+func (g *grpcServer) CancelRun(ctx context.Context, req *cleanerv1.CancelRunRequest) (*cleanerv1.RunStatus, error) {
+	run, err := g.findRun(req.GetRunId())
+	if err != nil {
+		return nil, err
+	}
+	if err := g.runs.cancel(run); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, tr(nil, msgControlNotRunning, run.id))
+	}
+	slog.Info(tr(nil, msgControlCanceled, run.id), "action", "grpc", "run", run.id)
+	return runStatusProto(run.status()), nil
+}
+
+// StreamEvents 先发送当前进度，之后发送运行处理的每个对象和定期的进度，运行结束时发送 finished 后结束。
+// 调用方接收过慢时丢弃部分对象事件，进度和结束事件总会发送
+func (g *grpcServer) StreamEvents(req *cleanerv1.StreamEventsRequest, stream grpc.ServerStreamingServer[cleanerv1.Event]) error {
+	run, err := g.findRun(req.GetRunId())
+	if err != nil {
+		return err
+	}
+	objects, unsubscribe := run.subscribe()
+	defer unsubscribe()
+
+	send := func(event string, object *objectEvent) error {
+		e := &cleanerv1.Event{Type: event, Time: time.Now().UnixMilli()}
+		if object != nil {
+			e.Object = objectEventProto(*object)
+		} else {
+			e.Status = runStatusProto(run.status())
+		}
+		return stream.Send(e)
+	}
+	if err := send("progress", nil); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(grpcProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case o := <-objects:
+			if err := send("object", &o); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := send("progress", nil); err != nil {
+				return err
+			}
+		case <-run.done:
+			// 运行结束后不再有新对象，先发送已缓冲的对象
+			for len(objects) > 0 {
+				o := <-objects
+				if err := send("object", &o); err != nil {
+					return err
+				}
+			}
+			return send("finished", nil)
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// runStatusProto 转换运行状态，时间为 Unix 毫秒，未开始或未结束时为 0；report_json 为运行结束后的结果文档
+func runStatusProto(s runStatus) *cleanerv1.RunStatus {
+	p := &cleanerv1.RunStatus{
+		RunId:          s.ID,
+		State:          s.State,
+		Trigger:        s.Trigger,
+		DryRun:         s.DryRun,
+		ProcessedFiles: s.Processed,
+		MatchedFiles:   s.Matched,
+		DeletedFiles:   s.Deleted,
+		DeletedBytes:   s.DeletedBytes,
+		FailedFiles:    s.Failed,
+		Error:          s.Error,
+	}
+	if !s.StartedAt.IsZero() {
+		p.StartedAt = s.StartedAt.UnixMilli()
+	}
+	if !s.FinishedAt.IsZero() {
+		p.FinishedAt = s.FinishedAt.UnixMilli()
+	}
+	if s.Report != nil {
+		data, _ := json.Marshal(s.Report)
+		p.ReportJson = string(data)
+	}
+	return p
+}
+
+// objectEventProto 转换处理的对象，字段与 -output jsonl 相同
+func objectEventProto(o objectEvent) *cleanerv1.ObjectEvent {
+	p := &cleanerv1.ObjectEvent{
+		Cluster:   o.Cluster,
+		Bucket:    o.Bucket,
+		Key:       o.Key,
+		Size:      o.Size,
+		VersionId: o.VersionID,
+		Rule:      o.Rule,
+		Action:    o.Action,
+		Error:     o.Error,
+	}
+	if !o.LastModified.IsZero() {
+		p.LastModified = o.LastModified.UnixMilli()
+	}
+	return p
+}
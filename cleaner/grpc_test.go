@@ -0,0 +1,241 @@
+package cleaner
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	cleanerv1 "github.com/fjcanyue/minio-cleaner/proto/minio_cleaner/v1"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestGRPCClient 启动令牌为 token 的控制服务，返回连接到它的客户端
+func newTestGRPCClient(t *testing.T, runs *runManager, token string) cleanerv1.ControlClient {
+	t.Helper()
+	cfg := testConfig()
+	cfg.Daemon.GRPC.Token = token
+	g, err := newGRPCServer(cfg, runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go g.srv.Serve(lis)
+	t.Cleanup(g.shutdown)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return cleanerv1.NewControlClient(conn)
+}
+
+func TestGRPCControl(t *testing.T) {
+	tests := []struct {
+		name string
+		// busy 为同时进行的运行已达到上限
+		busy bool
+		// noToken 为服务未配置令牌
+		noToken bool
+		token   string
+		call    func(ctx context.Context, c cleanerv1.ControlClient) error
+		want    codes.Code
+	}{
+		{
+			name: "missing token",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.GetStatus(ctx, &cleanerv1.GetStatusRequest{})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:  "wrong token",
+			token: "Bearer nope",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		// 未配置令牌和客户端证书时拒绝所有调用，而不是不认证
+		{
+			name:    "no authentication configured",
+			noToken: true,
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{})
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		// 流式调用同样需要认证
+		{
+			name: "stream without token",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				stream, err := c.StreamEvents(ctx, &cleanerv1.StreamEventsRequest{})
+				if err != nil {
+					return err
+				}
+				_, err = stream.Recv()
+				return err
+			},
+			want: codes.Unauthenticated,
+		},
+		{
+			name:  "no runs",
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.GetStatus(ctx, &cleanerv1.GetStatusRequest{})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name:  "cancel unknown run",
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.CancelRun(ctx, &cleanerv1.CancelRunRequest{RunId: "nope"})
+				return err
+			},
+			want: codes.NotFound,
+		},
+		{
+			name:  "unknown bucket",
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{Buckets: []string{"other"}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		// 不同集群上可能有同名的存储桶，需要写明集群
+		{
+			name:  "bucket without cluster",
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{Buckets: []string{"logs"}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name:  "bucket on another cluster",
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{Buckets: []string{"c1/logs"}})
+				return err
+			},
+			want: codes.InvalidArgument,
+		},
+		{
+			name:  "qualified bucket while busy",
+			busy:  true,
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{Buckets: []string{"c2/logs"}})
+				return err
+			},
+			want: codes.FailedPrecondition,
+		},
+		{
+			name:  "run in progress",
+			busy:  true,
+			token: "Bearer secret",
+			call: func(ctx context.Context, c cleanerv1.ControlClient) error {
+				_, err := c.StartRun(ctx, &cleanerv1.StartRunRequest{})
+				return err
+			},
+			want: codes.FailedPrecondition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := newTestRunManager(t, testConfig())
+			if tt.busy {
+				runs.slot <- struct{}{}
+			}
+			token := "secret"
+			if tt.noToken {
+				token = ""
+			}
+			c := newTestGRPCClient(t, runs, token)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.token)
+			}
+			if got := status.Code(tt.call(ctx, c)); got != tt.want {
+				t.Errorf("code = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStatusProto(t *testing.T) {
+	tests := []struct {
+		name   string
+		status runStatus
+		want   *cleanerv1.RunStatus
+	}{
+		{name: "finished", status: runStatus{
+			ID: "r1", State: "succeeded", Trigger: "grpc", DryRun: true,
+			StartedAt:  time.UnixMilli(1718452800000),
+			FinishedAt: time.UnixMilli(1718452861000),
+			Processed:  1000, Deleted: 300, DeletedBytes: 5 << 30, Failed: 2,
+			// 不在 RunStatus 中的字段不转换
+			Team: "ops", SubmittedAt: time.UnixMilli(1718452700000),
+		}, want: &cleanerv1.RunStatus{
+			RunId: "r1", State: "succeeded", Trigger: "grpc", DryRun: true,
+			StartedAt: 1718452800000, FinishedAt: 1718452861000,
+			ProcessedFiles: 1000, DeletedFiles: 300, DeletedBytes: 5 << 30, FailedFiles: 2,
+		}},
+		// 未开始的运行没有时间
+		{name: "queued", status: runStatus{ID: "r2", State: "queued", Trigger: "schedule"},
+			want: &cleanerv1.RunStatus{RunId: "r2", State: "queued", Trigger: "schedule"}},
+	}
+	for _, tt := range tests {
+		if got := runStatusProto(tt.status); !proto.Equal(got, tt.want) {
+			t.Errorf("%s: runStatusProto() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateGRPC(t *testing.T) {
+	tests := []struct {
+		name string
+		grpc config.GRPCConfig
+		want []msgID
+	}{
+		{name: "token", grpc: config.GRPCConfig{Addr: ":9090", Token: "secret"}},
+		{name: "mTLS", grpc: config.GRPCConfig{Addr: ":9090", CertFile: "/tls.crt", KeyFile: "/tls.key", ClientCAFile: "/ca.crt"}},
+		{name: "no authentication", grpc: config.GRPCConfig{Addr: ":9090"}, want: []msgID{msgGRPCNoAuth}},
+		{name: "client CA without TLS", grpc: config.GRPCConfig{Addr: ":9090", ClientCAFile: "/ca.crt"}, want: []msgID{msgGRPCClientCANoTLS}},
+		{name: "disabled", grpc: config.GRPCConfig{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+			cfg.Minio.Endpoint = "localhost:9000"
+			cfg.Daemon.GRPC = tt.grpc
+			problems := validateConfig(cfg)
+			var want []string
+			for _, id := range tt.want {
+				want = append(want, tr(cfg, id))
+			}
+			if !slices.Equal(problems, want) {
+				t.Errorf("validateConfig() = %q, want %q", problems, want)
+			}
+		})
+	}
+}
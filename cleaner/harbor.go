@@ -0,0 +1,98 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+type harborPreset struct {
+	cfg  *config.HarborPresetConfig
+	root string
+	// uploadsBefore 为本次运行中未完成的上传可以清理的时间，由 prepare 按运行的时间计算；为零值时保留所有上传
+	uploadsBefore time.Time
+}
+
+func newHarborPreset(cfg *config.Config) appPreset {
+	h := &cfg.Presets.Harbor
+	root := strings.Trim(h.RootDirectory, "/")
+	if root != "" {
+		root += "/"
+	}
+	return &harborPreset{cfg: h, root: root + registryReposRoot}
+}
+
+// keep 只放行 repositories/<仓库>/_uploads/ 下超过 uploadMaxAge 的对象。仓库名可以包含多级路径，
+// _layers、_manifests 中的链接和 blobs 中的镜像层即使符合清理规则也保留
+func (h *harborPreset) keep(obj rules.ObjectInfo) bool {
+	return keepUpload(obj, h.root, h.uploadsBefore)
+}
+
+// harborGCJob 为 Harbor 垃圾回收历史中的一次任务
+type harborGCJob struct {
+	ID     int64  `json:"id"`
+	Status string `json:"job_status"`
+}
+
+// rules 默认清理根目录下超过 uploadMaxAge 的对象，keep 只放行其中未完成的上传
+func (h *harborPreset) rules() []rules.Rule {
+	return []rules.Rule{{Name: "harbor-uploads", Prefix: h.root, MaxAge: h.cfg.UploadMaxAge}}
+}
+
+// prepare 按 now 计算未完成的上传可以清理的时间，并查询最近一次垃圾回收任务，正在等待或进行中时返回错误。
+// 无法查询时同样不清理，避免与回收同时删除
+func (h *harborPreset) prepare(ctx context.Context, _ objectStore, now time.Time) error {
+	h.uploadsBefore = h.cfg.UploadMaxAge.Before(now)
+	if h.cfg.URL == "" {
+		return nil
+	}
+	job, err := h.latestGC(ctx)
+	if err != nil {
+		return errors.New(trLang(contextLanguage(ctx), msgHarborGCFailed, err))
+	}
+	if job != nil && (job.Status == "Pending" || job.Status == "Running") {
+		return errors.New(trLang(contextLanguage(ctx), msgHarborGCRunning, job.ID, job.Status))
+	}
+	return nil
+}
+
+func (h *harborPreset) latestGC(ctx context.Context) (*harborGCJob, error) {
+	url := strings.TrimSuffix(h.cfg.URL, "/") + "/api/v2.0/system/gc?page=1&page_size=1&sort=-creation_time"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if h.cfg.Username != "" {
+		password := h.cfg.Password
+		if h.cfg.PasswordFile != "" {
+			if password, err = readSecretFile(h.cfg.PasswordFile); err != nil {
+				return nil, err
+			}
+		}
+		req.SetBasicAuth(h.cfg.Username, password)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := notifyHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var jobs []harborGCJob
+	if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
@@ -0,0 +1,94 @@
+package cleaner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+func TestHarborKeep(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		root string
+		key  string
+		age  time.Duration
+		want bool
+	}{
+		{name: "stale upload", key: registryReposRoot + "lib/app/_uploads/u1/data", age: 8 * rules.Day, want: false},
+		{name: "stale upload under root", root: "/harbor/", key: "harbor/" + registryReposRoot + "app/_uploads/u1/startedat", age: 8 * rules.Day, want: false},
+		{name: "recent upload", key: registryReposRoot + "app/_uploads/u1/data", age: 6 * rules.Day, want: true},
+		// 只有未完成的上传由预设清理，仓库中的链接和镜像层只能由 Harbor 的垃圾回收删除
+		{name: "layer link", key: registryReposRoot + "app/_layers/sha256/aa01/link", age: 30 * rules.Day, want: true},
+		{name: "manifest link", key: registryReposRoot + "app/_manifests/revisions/sha256/aa01/link", age: 30 * rules.Day, want: true},
+		{name: "blob", key: registryBlobsRoot + "sha256/aa/aa01/data", age: 30 * rules.Day, want: true},
+		{name: "outside root", root: "harbor", key: registryReposRoot + "app/_uploads/u1/data", age: 30 * rules.Day, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Presets.Harbor = config.HarborPresetConfig{RootDirectory: tt.root, UploadMaxAge: rules.Retention(7 * rules.Day)}
+			h := newHarborPreset(cfg)
+			if err := h.prepare(context.Background(), nil, now); err != nil {
+				t.Fatal(err)
+			}
+			if got := h.keep(testObject(tt.key, now.Add(-tt.age), 1)); got != tt.want {
+				t.Errorf("keep(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHarborPrepareGC(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		// wantErr 为错误中应包含的内容，为空时不应出错
+		wantErr string
+	}{
+		{name: "no gc", status: http.StatusOK, body: `[]`},
+		{name: "gc finished", status: http.StatusOK, body: `[{"id":3,"job_status":"Success"}]`},
+		{name: "gc pending", status: http.StatusOK, body: `[{"id":4,"job_status":"Pending"}]`, wantErr: tr(nil, msgHarborGCRunning, 4, "Pending")},
+		{name: "gc running", status: http.StatusOK, body: `[{"id":5,"job_status":"Running"}]`, wantErr: tr(nil, msgHarborGCRunning, 5, "Running")},
+		// 无法查询垃圾回收状态时同样不清理
+		{name: "http error", status: http.StatusForbidden, wantErr: "HTTP 403"},
+		{name: "invalid body", status: http.StatusOK, body: `{`, wantErr: "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+					t.Errorf("basic auth = %q %q %v, want admin secret", user, password, ok)
+				}
+				if r.URL.Path != "/api/v2.0/system/gc" || r.URL.Query().Get("sort") != "-creation_time" {
+					t.Errorf("request %s, want the latest gc job", r.URL)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			passwordFile := filepath.Join(t.TempDir(), "password")
+			writeTestFile(t, passwordFile, "secret\n")
+			cfg := testConfig()
+			cfg.Presets.Harbor = config.HarborPresetConfig{URL: srv.URL + "/", Username: "admin", PasswordFile: passwordFile}
+			err := newHarborPreset(cfg).prepare(context.Background(), nil, time.Now())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("prepare() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("prepare() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package cleaner
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// recordHistory 将一次运行的配置、统计和错误保存到历史数据库，失败只记录日志
+func recordHistory(cfg *config.Config, rep *report.RunReport, runErr error) {
+	if cfg.History.File == "" {
+		return
+	}
+
+	rec := report.HistoryRecord{
+		Bucket: cfg.Minio.Bucket,
+		Config: redactedConfig(cfg),
+		Report: rep,
+	}
+	if rep != nil {
+		rec.RunID = rep.RunID
+		rec.StartTime = rep.StartTime
+	} else {
+		rec.StartTime = time.Now()
+		rec.RunID = newRunID(rec.StartTime)
+	}
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+
+	if err := report.SaveHistory(cfg.History.File, rec); err != nil {
+		slog.Error(tr(cfg, msgHistorySaveFailed, err), "bucket", cfg.Minio.Bucket, "action", "history", "error", err)
+	}
+}
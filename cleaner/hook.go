@@ -0,0 +1,182 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// 删除钩子的事件类型
+const (
+	hookEventPreDelete  = "preDelete"  // 一批对象即将删除
+	hookEventPostDelete = "postDelete" // 一批对象删除完成
+)
+
+// hookRequest 为发送给钩子的 JSON 内容，objects 在 preDelete 中为 planEntry，在 postDelete 中为 manifestEntry
+type hookRequest struct {
+	Event     string    `json:"event"`
+	Bucket    string    `json:"bucket"`
+	Timestamp time.Time `json:"timestamp"`
+	Files     int       `json:"files"`
+	Bytes     int64     `json:"bytes"`
+	Objects   any       `json:"objects"`
+}
+
+// callHook 执行一次钩子，返回否决原因：命令以非零状态退出或 HTTP 服务返回非 2xx 状态码时为否决，
+// 原因为标准错误或响应内容。命令无法执行、超时或请求失败时返回错误
+func callHook(ctx context.Context, h *config.HookConfig, req hookRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	if len(h.Command) > 0 {
+		_, err = runPluginCommand(ctx, h.Command, body)
+		var exitErr *exec.ExitError
+		if err != nil && ctx.Err() == nil && errors.As(err, &exitErr) {
+			return err.Error(), nil
+		}
+		return "", err
+	}
+	_, err = requestJSON(ctx, http.MethodPost, h.URL, h.Headers, body)
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return err.Error(), nil
+	}
+	return "", err
+}
+
+// batch 在配置了 hooks.preDelete 时将待清理对象攒成批，每批删除前执行一次钩子。
+// 钩子否决或执行失败时整批保留，记为已处理，不再交给下一阶段
+func (p *pipeline) batch(ctx context.Context, in <-chan candidate, out chan<- candidate) {
+	size := p.cfg.Hooks.BatchSize
+	pending := make([]candidate, 0, size)
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if ctx.Err() == nil && p.preDelete(ctx, pending) {
+			for _, c := range pending {
+				out <- c
+			}
+		} else {
+			for _, c := range pending {
+				if p.samples != nil {
+					p.samples.kept.offer(c.obj)
+				}
+				p.processed(c.obj)
+			}
+		}
+		pending = pending[:0]
+	}
+	for c := range in {
+		pending = append(pending, c)
+		if len(pending) >= size {
+			flush()
+		}
+	}
+	flush()
+}
+
+// preDelete 执行 hooks.preDelete，返回是否允许删除这批对象
+func (p *pipeline) preDelete(ctx context.Context, batch []candidate) bool {
+	objects := make([]planEntry, len(batch))
+	var size int64
+	for i, c := range batch {
+		objects[i] = newPlanEntry(p.bucket, c.obj, c.rule.Name)
+		size += c.obj.Size
+	}
+	veto, err := callHook(ctx, &p.cfg.Hooks.PreDelete, hookRequest{
+		Event:     hookEventPreDelete,
+		Bucket:    p.bucket,
+		Timestamp: time.Now(),
+		Files:     len(batch),
+		Bytes:     size,
+		Objects:   objects,
+	})
+	switch {
+	case err != nil:
+		if ctx.Err() == nil {
+			p.fail(tr(p.cfg, msgHookPreFailed, len(batch), err), "bucket", p.bucket, "action", "hook", "files", len(batch), "error", err)
+		}
+		return false
+	case veto != "":
+		atomic.AddInt64(&p.stats.hookVetoed, int64(len(batch)))
+		slog.Warn(tr(p.cfg, msgHookVetoed, len(batch), veto), "bucket", p.bucket, "action", "hook", "files", len(batch), "reason", veto)
+		return false
+	}
+	return true
+}
+
+// deletedBatch 收集删除成功的对象，攒满一批后执行 hooks.postDelete，可被并发调用
+type deletedBatch struct {
+	mu      sync.Mutex
+	entries []manifestEntry
+}
+
+// newDeletedBatch 在配置了 hooks.postDelete 时创建，预览模式下返回 nil
+func newDeletedBatch(cfg *config.Config) *deletedBatch {
+	if !cfg.Hooks.PostDelete.Enabled() || cfg.Cleanup.DryRun {
+		return nil
+	}
+	return &deletedBatch{}
+}
+
+// deleted 记录一个删除成功的对象，攒满一批时在调用方协程中执行 hooks.postDelete
+func (p *pipeline) deleted(ctx context.Context, e manifestEntry) {
+	b := p.deletedBatch
+	b.mu.Lock()
+	b.entries = append(b.entries, e)
+	var full []manifestEntry
+	if len(b.entries) >= p.cfg.Hooks.BatchSize {
+		full, b.entries = b.entries, nil
+	}
+	b.mu.Unlock()
+	if full != nil {
+		p.postDelete(ctx, full)
+	}
+}
+
+// flushDeleted 对剩余未满一批的已删除对象执行 hooks.postDelete
+func (p *pipeline) flushDeleted(ctx context.Context) {
+	b := p.deletedBatch
+	b.mu.Lock()
+	rest := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+	if len(rest) > 0 {
+		p.postDelete(ctx, rest)
+	}
+}
+
+// postDelete 执行 hooks.postDelete。对象已经删除，运行中止或超时后仍然执行，失败时记为错误
+func (p *pipeline) postDelete(ctx context.Context, entries []manifestEntry) {
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	veto, err := callHook(context.WithoutCancel(ctx), &p.cfg.Hooks.PostDelete, hookRequest{
+		Event:     hookEventPostDelete,
+		Bucket:    p.bucket,
+		Timestamp: time.Now(),
+		Files:     len(entries),
+		Bytes:     size,
+		Objects:   entries,
+	})
+	if err == nil && veto != "" {
+		err = errors.New(veto)
+	}
+	if err != nil {
+		p.fail(tr(p.cfg, msgHookPostFailed, len(entries), err), "bucket", p.bucket, "action", "hook", "files", len(entries), "error", err)
+	}
+}
@@ -0,0 +1,236 @@
+package cleaner
+
+import (
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+const (
+	// inventoryManifestLimit 为读取 manifest.json 的最大字节数
+	inventoryManifestLimit = 64 << 20
+)
+
+// inventoryDir 匹配清单前缀下每次生成的清单目录，如 2024-01-05T01-00Z/
+var inventoryDir = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}Z/$`)
+
+// inventoryManifest 为清单目录中的 manifest.json
+type inventoryManifest struct {
+	SourceBucket      string `json:"sourceBucket"`
+	CreationTimestamp string `json:"creationTimestamp"` // 毫秒时间戳
+	FileFormat        string `json:"fileFormat"`
+	FileSchema        string `json:"fileSchema"`
+	Files             []struct {
+		Key string `json:"key"`
+	} `json:"files"`
+}
+
+// listSource 列举清理目标中的对象：配置了 inventory 时读取清单，否则实时列举存储桶。emit 和 onError 会被并发调用
+func listSource(ctx context.Context, cfg *config.Config, store objectStore, emit func(minio.ObjectInfo), onError func(error)) {
+	if !cfg.Inventory.Enabled() {
+		listBucket(ctx, store, cfg.Minio.Bucket, cfg.Cleanup.Listers, cfg.Timeouts.List, emit, onError)
+		return
+	}
+	if err := listInventory(ctx, cfg, store, emit); err != nil {
+		onError(err)
+	}
+}
+
+// listInventory 读取最近一次完整生成的清单，将其中的对象交给 emit。清单文件由 cleanup.listers 个协程并发读取
+func listInventory(ctx context.Context, cfg *config.Config, store objectStore, emit func(minio.ObjectInfo)) error {
+	inv := &cfg.Inventory
+	bucket := cmp.Or(inv.Bucket, cfg.Minio.Bucket)
+	m, dir, err := latestInventory(ctx, cfg, store, bucket)
+	if err != nil {
+		return err
+	}
+	if m.SourceBucket != "" && m.SourceBucket != cfg.Minio.Bucket {
+		return errors.New(tr(cfg, msgInventoryBucket, dir, m.SourceBucket, cfg.Minio.Bucket))
+	}
+	if !strings.EqualFold(m.FileFormat, "CSV") {
+		return errors.New(tr(cfg, msgInventoryFormat, dir, m.FileFormat))
+	}
+	ms, err := strconv.ParseInt(m.CreationTimestamp, 10, 64)
+	if err != nil {
+		return errors.New(tr(cfg, msgInventoryFailed, dir, err))
+	}
+	if created := time.UnixMilli(ms); created.Before(inv.MaxAge.Before(time.Now())) {
+		return errors.New(tr(cfg, msgInventoryStale, dir, created.UTC().Format(time.RFC3339), inv.MaxAge))
+	}
+	columns, err := parseInventorySchema(m.FileSchema, cfg.Cleanup.Language)
+	if err != nil {
+		return errors.New(tr(cfg, msgInventoryFailed, dir, err))
+	}
+
+	files := make(chan string)
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for range max(cfg.Cleanup.Listers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range files {
+				if err := readInventoryFile(ctx, store, bucket, key, columns, emit); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = errors.New(tr(cfg, msgInventoryFailed, key, err))
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, f := range m.Files {
+		select {
+		case files <- f.Key:
+		case <-ctx.Done():
+		}
+	}
+	close(files)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// latestInventory 返回前缀下最近一次完整生成的清单及其目录。清单目录按生成时间命名，
+// manifest.json 在清单文件全部写入后才生成，还没有 manifest.json 的目录正在生成，使用上一次的清单
+func latestInventory(ctx context.Context, cfg *config.Config, store objectStore, bucket string) (*inventoryManifest, string, error) {
+	prefix := cfg.Inventory.Prefix
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var dirs []string
+	var listErr error
+	listObjects(ctx, store, bucket, minio.ListObjectsOptions{Prefix: prefix}, cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		switch {
+		case obj.Err != nil:
+			listErr = obj.Err
+		case isCommonPrefix(obj) && inventoryDir.MatchString(strings.TrimPrefix(obj.Key, prefix)):
+			dirs = append(dirs, obj.Key)
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	if listErr != nil {
+		return nil, "", errors.New(tr(cfg, msgInventoryFailed, bucket+"/"+prefix, listErr))
+	}
+	// 目录名中的时间位数固定，按名称倒序即从新到旧
+	slices.Sort(dirs)
+	slices.Reverse(dirs)
+	for _, dir := range dirs {
+		data, err := readObject(ctx, store, bucket, dir+"manifest.json", inventoryManifestLimit)
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			continue
+		}
+		if err != nil {
+			return nil, "", errors.New(tr(cfg, msgInventoryFailed, bucket+"/"+dir, err))
+		}
+		var m inventoryManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, "", errors.New(tr(cfg, msgInventoryFailed, bucket+"/"+dir, err))
+		}
+		return &m, bucket + "/" + dir, nil
+	}
+	return nil, "", errors.New(tr(cfg, msgInventoryNone, bucket+"/"+prefix))
+}
+
+// inventoryColumns 为清单文件中各字段所在的列，不存在的字段为 -1
+type inventoryColumns struct {
+	key, size, lastModified, etag, isLatest, isDeleteMarker, versionID int
+}
+
+// parseInventorySchema 按 manifest.json 的 fileSchema 找出各字段所在的列，清单需要包含 Key 和 LastModifiedDate。
+// 错误信息使用语言 lang
+func parseInventorySchema(schema, lang string) (inventoryColumns, error) {
+	index := func(name string) int {
+		for i, f := range strings.Split(schema, ",") {
+			if strings.TrimSpace(f) == name {
+				return i
+			}
+		}
+		return -1
+	}
+	c := inventoryColumns{
+		key:            index("Key"),
+		size:           index("Size"),
+		lastModified:   index("LastModifiedDate"),
+		etag:           index("ETag"),
+		isLatest:       index("IsLatest"),
+		isDeleteMarker: index("IsDeleteMarker"),
+		versionID:      index("VersionId"),
+	}
+	if c.key < 0 || c.lastModified < 0 {
+		return c, errors.New(trLang(lang, msgInventorySchema, schema))
+	}
+	return c, nil
+}
+
+// readInventoryFile 读取一个 gzip 压缩的 CSV 清单文件。包含历史版本的清单只交出每个对象的当前版本，删除标记不交出
+func readInventoryFile(ctx context.Context, store objectStore, bucket, key string, columns inventoryColumns, emit func(minio.ObjectInfo)) error {
+	r, err := store.open(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	cr := csv.NewReader(gz)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	field := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+	for ctx.Err() == nil {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if field(record, columns.isLatest) == "false" || field(record, columns.isDeleteMarker) == "true" {
+			continue
+		}
+		// CSV 清单中的对象名经过 URL 编码
+		name, err := url.QueryUnescape(field(record, columns.key))
+		if err != nil {
+			return err
+		}
+		modified, err := time.Parse(time.RFC3339, field(record, columns.lastModified))
+		if err != nil {
+			return err
+		}
+		size, _ := strconv.ParseInt(field(record, columns.size), 10, 64)
+		emit(minio.ObjectInfo{
+			Key:          name,
+			Size:         size,
+			LastModified: modified,
+			ETag:         field(record, columns.etag),
+			VersionID:    field(record, columns.versionID),
+		})
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,291 @@
+package cleaner
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// jobsMaxBodySize 为任务描述的最大长度
+const jobsMaxBodySize = 1 << 20
+
+// jobSpec 为通过 REST 接口提交的清理任务，以 JSON 格式提交
+type jobSpec struct {
+	Cluster string       `yaml:"cluster"` // 集群名称，同名存储桶位于多个集群时必填
+	Bucket  string       `yaml:"bucket"`  // 要清理的存储桶，需为已配置的清理目标
+	DryRun  *bool        `yaml:"dryRun"`  // 是否以预览模式运行，默认 true
+	Rules   []rules.Rule `yaml:"rules"`   // 本次任务使用的清理规则，为空时使用该存储桶配置的规则
+}
+
+// jobStatus 为 REST 接口返回的任务状态
+type jobStatus struct {
+	ID             string     `json:"id"`
+	Team           string     `json:"team"`
+	State          string     `json:"state"`
+	Cluster        string     `json:"cluster"`
+	Bucket         string     `json:"bucket"`
+	DryRun         bool       `json:"dryRun"`
+	SubmittedAt    time.Time  `json:"submittedAt"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	FinishedAt     *time.Time `json:"finishedAt,omitempty"`
+	ProcessedFiles int64      `json:"processedFiles"`
+	MatchedFiles   int64      `json:"matchedFiles"`
+	DeletedFiles   int64      `json:"deletedFiles"`
+	DeletedBytes   int64      `json:"deletedBytes"`
+	FailedFiles    int64      `json:"failedFiles"`
+	Error          string     `json:"error,omitempty"`
+	// Report 为运行结束后的结果文档，与 -output json 相同
+	Report *Report `json:"report,omitempty"`
+}
+
+func newJobStatus(s runStatus) jobStatus {
+	j := jobStatus{
+		ID:             s.ID,
+		Team:           s.Team,
+		State:          s.State,
+		Cluster:        s.Cluster,
+		Bucket:         s.Bucket,
+		DryRun:         s.DryRun,
+		SubmittedAt:    s.SubmittedAt,
+		ProcessedFiles: s.Processed,
+		MatchedFiles:   s.Matched,
+		DeletedFiles:   s.Deleted,
+		DeletedBytes:   s.DeletedBytes,
+		FailedFiles:    s.Failed,
+		Error:          s.Error,
+		Report:         s.Report,
+	}
+	if !s.StartedAt.IsZero() {
+		j.StartedAt = &s.StartedAt
+	}
+	if !s.FinishedAt.IsZero() {
+		j.FinishedAt = &s.FinishedAt
+	}
+	return j
+}
+
+// jobsServer 提供提交、查询和取消清理任务的 REST 接口。任务按团队排队，与定时运行共用运行许可
+type jobsServer struct {
+	cfg  *config.JobsAPIConfig
+	runs *runManager
+	srv  *http.Server
+	// limiters 为各团队所有任务共用的删除限速器，未限制速率的团队没有
+	limiters map[string]*rateLimiter
+
+	// stores 按集群和凭证缓存使用团队凭证创建的客户端
+	mu     sync.Mutex
+	stores map[string]Target
+}
+
+func newJobsServer(cfg *config.Config, runs *runManager) *jobsServer {
+	j := &jobsServer{cfg: &cfg.Daemon.API, runs: runs, limiters: make(map[string]*rateLimiter), stores: make(map[string]Target)}
+	for _, t := range j.cfg.Tenants {
+		if t.MaxDeletesPerSecond > 0 {
+			j.limiters[t.Team] = newRateLimiter(t.MaxDeletesPerSecond)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/jobs", j.authorized(j.submit))
+	mux.HandleFunc("GET /v1/jobs", j.authorized(j.list))
+	mux.HandleFunc("GET /v1/jobs/{id}", j.authorized(j.get))
+	mux.HandleFunc("DELETE /v1/jobs/{id}", j.authorized(j.cancel))
+	j.srv = &http.Server{
+		Addr:              j.cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return j
+}
+
+func (j *jobsServer) serve() error {
+	if j.cfg.CertFile != "" {
+		return j.srv.ListenAndServeTLS(j.cfg.CertFile, j.cfg.KeyFile)
+	}
+	return j.srv.ListenAndServe()
+}
+
+func (j *jobsServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	j.srv.Shutdown(ctx)
+}
+
+// authorized 按 Authorization 头中的 Bearer 令牌确定请求的团队，令牌无效时返回 401
+func (j *jobsServer) authorized(handle func(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		var tenant *config.JobsTenant
+		for i := range j.cfg.Tenants {
+			if subtle.ConstantTimeCompare(got, []byte("Bearer "+j.cfg.Tenants[i].Token)) == 1 {
+				tenant = &j.cfg.Tenants[i]
+			}
+		}
+		if tenant == nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJobsError(w, http.StatusUnauthorized, tr(nil, msgJobsUnauthorized), nil)
+			return
+		}
+		handle(w, r, tenant)
+	}
+}
+
+// submit 校验任务描述并加入队列，返回 202 和排队状态的任务
+func (j *jobsServer) submit(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, jobsMaxBodySize))
+	if err != nil {
+		writeJobsError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	var spec jobSpec
+	doc, err := config.JSONNode(data)
+	if err == nil {
+		err = config.Decode(doc, &spec, true)
+	}
+	if err != nil {
+		writeJobsError(w, http.StatusBadRequest, tr(nil, msgJobsBadSpec), config.DecodeErrors(err))
+		return
+	}
+	if problems := validateRules(spec.Rules, ""); len(problems) > 0 {
+		writeJobsError(w, http.StatusBadRequest, tr(nil, msgJobsBadSpec), problems)
+		return
+	}
+	t, err := j.findTarget(spec.Cluster, spec.Bucket)
+	if err != nil {
+		writeJobsError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	// 团队只能清理允许的存储桶，并且总是使用团队自己的凭证，不使用存储桶配置的凭证
+	if !tenant.Allows(t.cfg.Minio.Name, t.cfg.Minio.Bucket) || !tenant.HasCreds() {
+		writeJobsError(w, http.StatusForbidden, tr(t.cfg, msgJobsForbidden, tenant.Team, t.cfg.Minio.Bucket), nil)
+		return
+	}
+
+	// 配置文件中 cleanup.dryRun 为 true 时任务总是以预览模式运行
+	dryRun := spec.DryRun == nil || *spec.DryRun || t.cfg.Cleanup.DryRun
+	cfg := *t.cfg
+	cfg.Cleanup.DryRun = dryRun
+	if len(spec.Rules) > 0 {
+		cfg.Rules = spec.Rules
+	}
+	t.cfg = &cfg
+	if err := j.connectAs(&t, tenant); err != nil {
+		writeJobsError(w, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	t.limiter = j.limiters[tenant.Team]
+
+	team := tenant.Team
+	run := j.runs.prepareRun(runTriggerAPI, dryRun)
+	run.team, run.cluster, run.bucket = team, cfg.Minio.Name, cfg.Minio.Bucket
+	if err := j.runs.jobs.enqueue(run, []Target{t}); err != nil {
+		writeJobsError(w, http.StatusServiceUnavailable, tr(t.cfg, msgJobsQueueFull, team, j.cfg.QueueSize), nil)
+		return
+	}
+	j.runs.track(run)
+	slog.Info(tr(t.cfg, msgJobsSubmitted, run.id, team, cfg.Minio.Bucket), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket,
+		"action", "api", "run", run.id, "team", team, "dryRun", dryRun)
+	writeJobsJSON(w, http.StatusAccepted, newJobStatus(run.status()))
+}
+
+// connectAs 将目标改为使用团队的凭证访问存储桶，存储服务据此限制团队能够删除的对象
+func (j *jobsServer) connectAs(t *Target, tenant *config.JobsTenant) error {
+	cfg := *t.cfg
+	cfg.Minio.AccessKeyID = tenant.AccessKeyID
+	cfg.Minio.SecretAccessKey = tenant.SecretAccessKey
+	cfg.Minio.AccessKeyIDFile = tenant.AccessKeyIDFile
+	cfg.Minio.SecretAccessKeyFile = tenant.SecretAccessKeyFile
+	cfg.Minio.STS = config.STSConfig{}
+	cfg.Minio.Vault = config.VaultConfig{}
+	t.cfg = &cfg
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return t.connect(j.stores)
+}
+
+// findTarget 在已配置的清理目标中查找任务的存储桶，团队不能清理未配置的存储桶
+func (j *jobsServer) findTarget(cluster, bucket string) (Target, error) {
+	if bucket == "" {
+		return Target{}, errors.New(tr(nil, msgJobsNoBucket))
+	}
+	var found []Target
+	for _, t := range j.runs.currentTargets() {
+		if t.cfg.Minio.Bucket == bucket && (cluster == "" || t.cfg.Minio.Name == cluster) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Target{}, errors.New(tr(nil, msgControlNoBucket, bucket))
+	case 1:
+		return found[0], nil
+	default:
+		return Target{}, errors.New(tr(nil, msgJobsAmbiguous, bucket))
+	}
+}
+
+// list 返回团队提交的、仍保留状态的任务，按提交顺序排列
+func (j *jobsServer) list(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant) {
+	jobs := []jobStatus{}
+	for _, run := range j.runs.list() {
+		if run.trigger == runTriggerAPI && run.team == tenant.Team {
+			jobs = append(jobs, newJobStatus(run.status()))
+		}
+	}
+	writeJobsJSON(w, http.StatusOK, map[string][]jobStatus{"jobs": jobs})
+}
+
+// findJob 查找团队提交的任务，其他团队的任务视为不存在
+func (j *jobsServer) findJob(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant) *controlRun {
+	id := r.PathValue("id")
+	run := j.runs.find(id)
+	if id == "" || run == nil || run.trigger != runTriggerAPI || run.team != tenant.Team {
+		writeJobsError(w, http.StatusNotFound, tr(nil, msgControlNoRun, id), nil)
+		return nil
+	}
+	return run
+}
+
+func (j *jobsServer) get(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant) {
+	if run := j.findJob(w, r, tenant); run != nil {
+		writeJobsJSON(w, http.StatusOK, newJobStatus(run.status()))
+	}
+}
+
+// cancel 取消排队或正在进行的任务，已删除的对象不会恢复；任务已结束时返回 409
+func (j *jobsServer) cancel(w http.ResponseWriter, r *http.Request, tenant *config.JobsTenant) {
+	run := j.findJob(w, r, tenant)
+	if run == nil {
+		return
+	}
+	if err := j.runs.cancel(run); err != nil {
+		writeJobsError(w, http.StatusConflict, tr(nil, msgControlNotRunning, run.id), nil)
+		return
+	}
+	slog.Info(tr(nil, msgControlCanceled, run.id), "action", "api", "run", run.id, "team", tenant.Team)
+	writeJobsJSON(w, http.StatusOK, newJobStatus(run.status()))
+}
+
+func writeJobsJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeJobsError 返回错误信息，problems 为任务描述中的各项问题
+func writeJobsError(w http.ResponseWriter, code int, msg string, problems []string) {
+	writeJobsJSON(w, code, struct {
+		Error    string   `json:"error"`
+		Problems []string `json:"problems,omitempty"`
+	}{msg, problems})
+}
@@ -0,0 +1,107 @@
+package cleaner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// newTestRunManager 返回清理集群 c1 上的存储桶 b 和 c2 上的存储桶 b、logs 的运行管理，任务只排队而不执行
+func newTestRunManager(t *testing.T, cfg *config.Config) *runManager {
+	t.Helper()
+	var targets []Target
+	for _, name := range []string{"c1/b", "c2/b", "c2/logs"} {
+		cluster, bucket, _ := strings.Cut(name, "/")
+		cfg := testConfig()
+		cfg.Minio.Name, cfg.Minio.Bucket, cfg.Minio.Endpoint = cluster, bucket, "localhost:9000"
+		targets = append(targets, Target{cfg: cfg, store: &memStore{}})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &runManager{ctx: ctx, cfg: cfg, slot: make(chan struct{}, 1), jobs: newJobQueue(&cfg.Daemon.API), targets: targets}
+}
+
+// newTestJobsServer 返回 newTestRunManager 中各目标的任务接口
+func newTestJobsServer(t *testing.T, tenants ...config.JobsTenant) *jobsServer {
+	t.Helper()
+	cfg := testConfig()
+	cfg.Daemon.API.Tenants = tenants
+	config.SetDefaults(cfg)
+	return newJobsServer(cfg, newTestRunManager(t, cfg))
+}
+
+func TestJobsSubmit(t *testing.T) {
+	tenants := []config.JobsTenant{
+		{Team: "a", Token: "token-a", Buckets: []string{"logs", "c1/b"}, AccessKeyID: "a", SecretAccessKey: "a-secret"},
+		{Team: "nocreds", Token: "token-n", Buckets: []string{"logs"}},
+	}
+	tests := []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{name: "allowed on any cluster", token: "token-a", body: `{"bucket": "logs"}`, want: http.StatusAccepted},
+		{name: "allowed on one cluster", token: "token-a", body: `{"bucket": "b", "cluster": "c1", "dryRun": false}`, want: http.StatusAccepted},
+		// 团队不能清理其他团队的存储桶，即使存储桶是已配置的清理目标
+		{name: "bucket not allowed", token: "token-a", body: `{"bucket": "b", "cluster": "c2", "dryRun": false}`, want: http.StatusForbidden},
+		// 没有自己凭证的团队不使用存储桶配置的凭证
+		{name: "tenant without credentials", token: "token-n", body: `{"bucket": "logs"}`, want: http.StatusForbidden},
+		{name: "ambiguous bucket", token: "token-a", body: `{"bucket": "b"}`, want: http.StatusBadRequest},
+		{name: "unknown bucket", token: "token-a", body: `{"bucket": "other"}`, want: http.StatusBadRequest},
+		{name: "unknown field", token: "token-a", body: `{"bucket": "logs", "force": true}`, want: http.StatusBadRequest},
+		{name: "invalid token", token: "wrong", body: `{"bucket": "logs"}`, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := newTestJobsServer(t, tenants...)
+			req := httptest.NewRequest(http.MethodPost, "/v1/jobs", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			j.srv.Handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("POST /v1/jobs = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateJobsTenants(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant config.JobsTenant
+		want   []msgID
+	}{
+		{name: "valid", tenant: config.JobsTenant{Team: "a", Token: "t", Buckets: []string{"b"}, AccessKeyIDFile: "/id", SecretAccessKeyFile: "/secret"}},
+		{name: "no buckets", tenant: config.JobsTenant{Team: "a", Token: "t", AccessKeyID: "id", SecretAccessKey: "secret"}, want: []msgID{msgJobsNoBuckets}},
+		{name: "no credentials", tenant: config.JobsTenant{Team: "a", Token: "t", Buckets: []string{"b"}}, want: []msgID{msgJobsTenantNoCreds}},
+		{name: "partial credentials", tenant: config.JobsTenant{Team: "a", Token: "t", Buckets: []string{"b"}, AccessKeyID: "id"}, want: []msgID{msgJobsTenantCreds}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+			cfg.Minio.Endpoint = "localhost:9000"
+			cfg.Daemon.API.Addr = ":8090"
+			cfg.Daemon.API.Tenants = []config.JobsTenant{tt.tenant}
+			problems := validateConfig(cfg)
+			var want []string
+			for _, id := range tt.want {
+				want = append(want, tr(cfg, id, 0))
+			}
+			for _, w := range want {
+				if !slices.Contains(problems, w) {
+					t.Errorf("validateConfig() = %q, want %q", problems, w)
+				}
+			}
+			if len(want) == 0 && len(problems) > 0 {
+				t.Errorf("validateConfig() = %q, want no problems", problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cleaner
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+)
+
+// kafkaProducer 通过 franz-go 客户端发布消息：按消息键的哈希选择分区（与 Java 客户端相同），
+// 以 acks=all 发送，所有同步副本写入后才算发布成功。leader 切换和重试由客户端处理
+type kafkaProducer struct {
+	cfg     *config.KafkaConfig
+	timeout time.Duration
+	client  *kgo.Client
+}
+
+func newKafkaProducer(cfg *config.KafkaConfig, timeout time.Duration) *kafkaProducer {
+	return &kafkaProducer{cfg: cfg, timeout: timeout}
+}
+
+// options 返回客户端的配置
+func (k *kafkaProducer) options() []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(k.cfg.Brokers...),
+		kgo.DefaultProduceTopic(k.cfg.Topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.DialTimeout(k.timeout),
+		kgo.ProduceRequestTimeout(k.timeout),
+		kgo.RecordDeliveryTimeout(k.timeout),
+		// 主题需预先创建，不存在时发布失败而不是由 broker 自动创建
+		kgo.UnknownTopicRetries(1),
+	}
+	if k.cfg.TLS {
+		// 客户端按 broker 的主机名设置 ServerName
+		opts = append(opts, kgo.DialTLSConfig(&tls.Config{}))
+	}
+	if k.cfg.Username != "" {
+		opts = append(opts, kgo.SASL(plain.Auth{User: k.cfg.Username, Pass: k.cfg.Password}.AsMechanism()))
+	}
+	return opts
+}
+
+func (k *kafkaProducer) publish(ctx context.Context, msgs []eventMessage) error {
+	if k.client == nil {
+		client, err := kgo.NewClient(k.options()...)
+		if err != nil {
+			return err
+		}
+		k.client = client
+	}
+	ctx, cancel := context.WithTimeout(ctx, k.timeout)
+	defer cancel()
+	records := make([]*kgo.Record, len(msgs))
+	for i, m := range msgs {
+		records[i] = &kgo.Record{Key: m.key, Value: m.value}
+	}
+	return k.client.ProduceSync(ctx, records...).FirstErr()
+}
+
+func (k *kafkaProducer) close() error {
+	if k.client != nil {
+		k.client.Close()
+		k.client = nil
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// consumeKafka 读取主题中的全部消息，返回 "键=值" 的列表
+func consumeKafka(t *testing.T, brokers []string, topic string, n int) []string {
+	t.Helper()
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics(topic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var got []string
+	for len(got) < n {
+		fetches := client.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("consume: got %q, want %d messages: %v", got, n, err)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			got = append(got, string(r.Key)+"="+string(r.Value))
+		})
+	}
+	slices.Sort(got)
+	return got
+}
+
+func TestKafkaPublish(t *testing.T) {
+	msgs := []eventMessage{
+		{key: []byte("b/logs/a.log"), value: []byte(`{"bucket":"b","key":"logs/a.log"}`)},
+		{key: []byte("b/x"), value: []byte("v")},
+	}
+	tests := []struct {
+		name string
+		// topic 为发布的主题，假集群只有主题 events；失败的发布在超时后返回
+		topic    string
+		username string
+		password string
+		wantErr  bool
+	}{
+		{name: "ok", topic: "events"},
+		{name: "sasl plain", topic: "events", username: "user", password: "pass"},
+		{name: "wrong password", topic: "events", username: "user", password: "wrong", wantErr: true},
+		// 主题需预先创建，不自动创建
+		{name: "unknown topic", topic: "other", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []kfake.Opt{kfake.NumBrokers(2), kfake.SeedTopics(3, "events")}
+			if tt.username != "" {
+				opts = append(opts, kfake.EnableSASL(), kfake.Superuser("PLAIN", "user", "pass"))
+			}
+			cluster, err := kfake.NewCluster(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cluster.Close()
+			cfg := &config.KafkaConfig{Brokers: cluster.ListenAddrs(), Topic: tt.topic, Username: tt.username, Password: tt.password}
+			k := newKafkaProducer(cfg, time.Second)
+			defer k.close()
+			err = k.publish(context.Background(), msgs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("publish() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr || tt.username != "" {
+				return
+			}
+			want := []string{`b/logs/a.log={"bucket":"b","key":"logs/a.log"}`, "b/x=v"}
+			if got := consumeKafka(t, cfg.Brokers, tt.topic, len(want)); !slices.Equal(got, want) {
+				t.Errorf("published = %q, want %q", got, want)
+			}
+		})
+	}
+}
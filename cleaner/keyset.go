@@ -0,0 +1,349 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+)
+
+const (
+	// keySetMemLimit 为集合在内存中缓存的键数，超出后排序写入临时文件，决定每个集合的内存占用（约 40MB）
+	keySetMemLimit = 1 << 20
+	// keySetHashSize 为集合保存的键摘要的字节数，上亿个键中出现摘要冲突的概率可以忽略
+	keySetHashSize = 16
+	// keySetIndexStride 为临时文件中每隔多少个摘要在内存中记录一个索引，查询时只读取两个索引之间的一段
+	keySetIndexStride = 256
+)
+
+// keyHash 为集合中键的摘要
+type keyHash [keySetHashSize]byte
+
+func hashKey(key string) keyHash {
+	sum := sha256.Sum256([]byte(key))
+	return keyHash(sum[:keySetHashSize])
+}
+
+func compareKeyHash(a, b keyHash) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// keySet 是内存占用有上限的字符串集合，用于记录数量随存储桶中对象数增长的键，如主存储桶中对象的内容标识。
+// 集合只保存键的摘要：内存中最多缓存 limit 个，超出后排序去重写入临时文件（位于 TMPDIR 下），
+// 大小相近的临时文件逐级归并，文件数保持在对数级别。临时文件在集合不再被引用后关闭并删除。可被并发调用
+type keySet struct {
+	mu sync.RWMutex
+	// limit 为内存中最多缓存的摘要数，默认为 keySetMemLimit
+	limit int
+	mem   map[keyHash]struct{}
+	runs  []*keySetRun
+	// err 为写入临时文件的错误，出错后不再加入新的键
+	err error
+}
+
+func newKeySet() *keySet {
+	return &keySet{limit: keySetMemLimit, mem: make(map[keyHash]struct{})}
+}
+
+// add 加入一个键，写入临时文件出错时由 seal 返回错误
+func (s *keySet) add(key string) {
+	h := hashKey(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.mem[h] = struct{}{}
+	if len(s.mem) >= s.limit {
+		s.err = s.flush()
+	}
+}
+
+// flush 将内存中的摘要写入新的临时文件，之后与前一个文件大小相近时逐级归并。调用方持有写锁
+func (s *keySet) flush() error {
+	w, err := newKeySetWriter()
+	if err != nil {
+		return err
+	}
+	for _, h := range slices.SortedFunc(maps.Keys(s.mem), compareKeyHash) {
+		if err := w.write(h); err != nil {
+			w.discard()
+			return err
+		}
+	}
+	run, err := w.finish()
+	if err != nil {
+		return err
+	}
+	clear(s.mem)
+	s.runs = append(s.runs, run)
+	for n := len(s.runs); n >= 2 && s.runs[n-2].n <= s.runs[n-1].n; n = len(s.runs) {
+		merged, err := mergeKeySetRuns(s.runs[n-2:])
+		if err != nil {
+			return err
+		}
+		s.runs = append(s.runs[:n-2], merged)
+	}
+	return nil
+}
+
+// seal 在加入全部键之后调用：已有临时文件时将内存中的摘要和全部临时文件归并为一个，之后的查询只需读取一个文件。
+// 返回加入键时写入临时文件的错误
+func (s *keySet) seal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil || len(s.runs) == 0 {
+		return s.err
+	}
+	if len(s.mem) > 0 {
+		if s.err = s.flush(); s.err != nil {
+			return s.err
+		}
+	}
+	if len(s.runs) > 1 {
+		merged, err := mergeKeySetRuns(s.runs)
+		if err != nil {
+			s.err = err
+			return err
+		}
+		s.runs = []*keySetRun{merged}
+	}
+	return nil
+}
+
+// len 返回集合中的键数，seal 之后为准确值，之前可能重复计入同一个键
+func (s *keySet) len() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := int64(len(s.mem))
+	for _, r := range s.runs {
+		n += r.n
+	}
+	return n
+}
+
+// has 返回集合中是否有 key，读取临时文件出错时返回错误，调用方应按无法判断处理
+func (s *keySet) has(key string) (bool, error) {
+	h := hashKey(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.err != nil {
+		return false, s.err
+	}
+	if _, ok := s.mem[h]; ok {
+		return true, nil
+	}
+	for _, r := range s.runs {
+		if found, err := r.has(h); found || err != nil {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// close 立即关闭并删除临时文件，之后不能再使用集合
+func (s *keySet) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.runs {
+		r.release()
+	}
+	s.runs = nil
+	clear(s.mem)
+}
+
+// keySetRun 为临时文件中一段已排序、去重的摘要
+type keySetRun struct {
+	f       *os.File
+	n       int64
+	index   []keyHash // 第 i 个为文件中第 i*keySetIndexStride 个摘要
+	cleanup runtime.Cleanup
+}
+
+// has 在索引中找到摘要所在的段，读取该段后二分查找
+func (r *keySetRun) has(h keyHash) (bool, error) {
+	i, found := slices.BinarySearchFunc(r.index, h, compareKeyHash)
+	if found {
+		return true, nil
+	}
+	if i == 0 {
+		return false, nil
+	}
+	start := int64(i-1) * keySetIndexStride
+	count := int(min(r.n-start, keySetIndexStride))
+	buf := make([]byte, count*keySetHashSize)
+	if _, err := r.f.ReadAt(buf, start*keySetHashSize); err != nil {
+		return false, err
+	}
+	record := func(j int) []byte { return buf[j*keySetHashSize : (j+1)*keySetHashSize] }
+	j := sort.Search(count, func(j int) bool { return bytes.Compare(record(j), h[:]) >= 0 })
+	return j < count && bytes.Equal(record(j), h[:]), nil
+}
+
+// release 关闭并删除临时文件
+func (r *keySetRun) release() {
+	r.cleanup.Stop()
+	removeTempFile(r.f)
+}
+
+func removeTempFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// keySetWriter 将按顺序排列的摘要写入新的临时文件，跳过与上一个相同的摘要
+type keySetWriter struct {
+	w    *bufio.Writer
+	run  *keySetRun
+	last keyHash
+}
+
+func newKeySetWriter() (*keySetWriter, error) {
+	f, err := os.CreateTemp("", "minio-cleaner-set-*.bin")
+	if err != nil {
+		return nil, err
+	}
+	return &keySetWriter{w: bufio.NewWriter(f), run: &keySetRun{f: f}}, nil
+}
+
+func (w *keySetWriter) write(h keyHash) error {
+	r := w.run
+	if r.n > 0 && h == w.last {
+		return nil
+	}
+	if r.n%keySetIndexStride == 0 {
+		r.index = append(r.index, h)
+	}
+	if _, err := w.w.Write(h[:]); err != nil {
+		return err
+	}
+	r.n++
+	w.last = h
+	return nil
+}
+
+// finish 写完临时文件并返回可查询的一段摘要。集合可能被其他对象长期引用，没有明确的关闭时机，
+// 临时文件在这段摘要不再被引用后由运行时关闭并删除
+func (w *keySetWriter) finish() (*keySetRun, error) {
+	if err := w.w.Flush(); err != nil {
+		w.discard()
+		return nil, err
+	}
+	r := w.run
+	r.cleanup = runtime.AddCleanup(r, removeTempFile, r.f)
+	return r, nil
+}
+
+func (w *keySetWriter) discard() {
+	removeTempFile(w.run.f)
+}
+
+// mergeKeySetRuns 将多段摘要归并为新的一段，成功后删除原来的临时文件
+func mergeKeySetRuns(runs []*keySetRun) (*keySetRun, error) {
+	w, err := newKeySetWriter()
+	if err != nil {
+		return nil, err
+	}
+	readers := make([]*bufio.Reader, len(runs))
+	heads := make([]keyHash, len(runs))
+	alive := make([]bool, len(runs))
+	next := func(i int) error {
+		_, err := io.ReadFull(readers[i], heads[i][:])
+		alive[i] = err == nil
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	for i, r := range runs {
+		readers[i] = bufio.NewReader(io.NewSectionReader(r.f, 0, r.n*keySetHashSize))
+		if err := next(i); err != nil {
+			w.discard()
+			return nil, err
+		}
+	}
+	// 段数很少，逐个比较各段当前的摘要即可
+	for {
+		m := -1
+		for i := range runs {
+			if alive[i] && (m < 0 || compareKeyHash(heads[i], heads[m]) < 0) {
+				m = i
+			}
+		}
+		if m < 0 {
+			break
+		}
+		if err := w.write(heads[m]); err != nil {
+			w.discard()
+			return nil, err
+		}
+		if err := next(m); err != nil {
+			w.discard()
+			return nil, err
+		}
+	}
+	merged, err := w.finish()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		r.release()
+	}
+	return merged, nil
+}
+
+// keyQueue 将字符串依次写入临时文件，之后再按写入顺序读出，用于数量随对象数增长的待处理列表。
+// 不能并发调用
+type keyQueue struct {
+	f   *os.File
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func newKeyQueue() (*keyQueue, error) {
+	f, err := os.CreateTemp("", "minio-cleaner-queue-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	return &keyQueue{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// push 加入一个不含换行符的字符串，写入出错时由 each 返回错误
+func (q *keyQueue) push(s string) {
+	if q.err != nil {
+		return
+	}
+	_, q.err = q.w.WriteString(s + "\n")
+	q.n++
+}
+
+// each 按写入顺序对每个字符串调用 fn，之后不能再加入
+func (q *keyQueue) each(fn func(s string)) error {
+	if q.err != nil {
+		return q.err
+	}
+	if err := q.w.Flush(); err != nil {
+		return err
+	}
+	if _, err := q.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(q.f)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// close 关闭并删除临时文件
+func (q *keyQueue) close() {
+	removeTempFile(q.f)
+}
@@ -0,0 +1,102 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		keys  int
+		// dup 为重复加入的键数，重复的键只计一次
+		dup int
+		// files 为 seal 之后应有的临时文件数
+		files int
+	}{
+		{name: "in memory", limit: 100, keys: 50, dup: 10, files: 0},
+		{name: "spilled", limit: 16, keys: 1000, dup: 300, files: 1},
+		// 一段摘要超过 keySetIndexStride 个时按索引找到所在的段
+		{name: "spilled with index", limit: 100, keys: 3*keySetIndexStride + 7, dup: 100, files: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			s := newKeySet()
+			s.limit = tt.limit
+			defer s.close()
+			var wg sync.WaitGroup
+			for w := range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := w; i < tt.keys; i += 4 {
+						s.add(fmt.Sprint("key-", i))
+					}
+				}()
+			}
+			wg.Wait()
+			for i := range tt.dup {
+				s.add(fmt.Sprint("key-", i))
+			}
+			if err := s.seal(); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.len(); got != int64(tt.keys) {
+				t.Errorf("len() = %d, want %d", got, tt.keys)
+			}
+			files, _ := filepath.Glob(filepath.Join(os.Getenv("TMPDIR"), "minio-cleaner-set-*"))
+			if len(files) != tt.files {
+				t.Errorf("%d temp files, want %d", len(files), tt.files)
+			}
+			for i := range tt.keys + 20 {
+				key := fmt.Sprint("key-", i)
+				found, err := s.has(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := i < tt.keys; found != want {
+					t.Errorf("has(%q) = %v, want %v", key, found, want)
+				}
+			}
+		})
+	}
+}
+
+func TestKeySetClose(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	s := newKeySet()
+	s.limit = 4
+	for i := range 20 {
+		s.add(fmt.Sprint(i))
+	}
+	s.close()
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("temp files left after close: %v", files)
+	}
+}
+
+func TestKeyQueue(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	q, err := newKeyQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.close()
+	want := []string{"sha256:a", "sha256:b", "sha256:a"}
+	for _, s := range want {
+		q.push(s)
+	}
+	var got []string
+	if err := q.each(func(s string) { got = append(got, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) || q.n != int64(len(want)) {
+		t.Errorf("each() = %q (n = %d), want %q", got, q.n, want)
+	}
+}
@@ -0,0 +1,88 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// killSwitchEngaged 检查紧急停止开关，开关打开时返回停止原因，否则返回空字符串
+func killSwitchEngaged(ctx context.Context, cfg *config.Config, store objectStore) (string, error) {
+	k, bucket := &cfg.KillSwitch, cfg.Minio.Bucket
+	if k.Object != "" {
+		err := withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+			_, err := store.stat(ctx, bucket, k.Object)
+			return err
+		})
+		if err == nil {
+			return tr(cfg, msgKillSwitchObject, bucket, k.Object), nil
+		}
+		if minio.ToErrorResponse(err).Code != "NoSuchKey" {
+			return "", err
+		}
+	}
+	if k.Tag != "" {
+		key, value, _ := strings.Cut(k.Tag, "=")
+		var tags map[string]string
+		err := withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			tags, err = store.bucketTags(ctx, bucket)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		if v, ok := tags[key]; ok && v == value {
+			return tr(cfg, msgKillSwitchTag, bucket, k.Tag), nil
+		}
+	}
+	return "", nil
+}
+
+// checkKillSwitch 在运行开始前检查紧急停止开关，开关打开或无法检查时返回错误，不清理该存储桶
+func checkKillSwitch(ctx context.Context, cfg *config.Config, store objectStore) error {
+	if !cfg.KillSwitch.Enabled() {
+		return nil
+	}
+	reason, err := killSwitchEngaged(ctx, cfg, store)
+	if err != nil {
+		return errors.New(tr(cfg, msgKillSwitchFailed, cfg.Minio.Bucket, err))
+	}
+	if reason != "" {
+		return errors.New(reason)
+	}
+	return nil
+}
+
+// watchKillSwitch 在运行中每隔 killSwitch.interval 检查一次紧急停止开关，开关打开时中止运行，直到 stop 被关闭。
+// 运行中检查失败只输出警告，不中止运行
+func (p *pipeline) watchKillSwitch(ctx context.Context, stop <-chan struct{}) {
+	k := &p.cfg.KillSwitch
+	if !k.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(k.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reason, err := killSwitchEngaged(ctx, p.cfg, p.store)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.Warn(tr(p.cfg, msgKillSwitchFailed, p.bucket, err), "bucket", p.bucket, "action", "killSwitch", "error", err)
+		case reason != "":
+			p.stop(reason, "bucket", p.bucket, "action", "killSwitch")
+			return
+		}
+	}
+}
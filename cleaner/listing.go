@@ -0,0 +1,103 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// listBucket 列举存储桶中的所有对象并逐个交给 emit，列举错误交给 onError。
+// listers 大于 1 时先按 / 分隔列出顶级前缀，再由 listers 个协程并发列举各前缀，
+// 此时 emit 和 onError 会被并发调用。timeout 为等待下一批列举结果的最长时间，0 表示不限制
+func listBucket(ctx context.Context, store objectStore, bucket string, listers int, timeout time.Duration,
+	emit func(minio.ObjectInfo), onError func(error)) {
+	if listers <= 1 {
+		listPrefix(ctx, store, bucket, "", timeout, emit, onError)
+		return
+	}
+
+	// 根目录下的对象直接处理，顶级前缀分发给并发的列举协程
+	prefixCh := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < listers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for prefix := range prefixCh {
+				listPrefix(ctx, store, bucket, prefix, timeout, emit, onError)
+			}
+		}()
+	}
+
+	listObjects(ctx, store, bucket, minio.ListObjectsOptions{}, timeout, func(obj minio.ObjectInfo) {
+		if obj.Err != nil {
+			onError(obj.Err)
+			return
+		}
+		if isCommonPrefix(obj) {
+			select {
+			case prefixCh <- obj.Key:
+			case <-ctx.Done():
+			}
+			return
+		}
+		emit(obj)
+	})
+	close(prefixCh)
+	wg.Wait()
+}
+
+// listPrefix 递归列举 prefix 下的所有对象
+func listPrefix(ctx context.Context, store objectStore, bucket, prefix string, timeout time.Duration,
+	emit func(minio.ObjectInfo), onError func(error)) {
+	opts := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}
+	listObjects(ctx, store, bucket, opts, timeout, func(obj minio.ObjectInfo) {
+		if obj.Err != nil {
+			onError(obj.Err)
+			return
+		}
+		emit(obj)
+	})
+}
+
+// listObjects 列举对象并逐个交给 fn。timeout 大于 0 时，超过 timeout 没有收到新的列举结果
+// 即取消列举，并以超时错误结束；fn 执行期间不计时，下游处理缓慢不会被误判为超时
+func listObjects(ctx context.Context, store objectStore, bucket string, opts minio.ListObjectsOptions,
+	timeout time.Duration, fn func(minio.ObjectInfo)) {
+	if timeout <= 0 {
+		for obj := range store.list(ctx, bucket, opts) {
+			fn(obj)
+		}
+		return
+	}
+
+	listCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timer := time.AfterFunc(timeout, func() {
+		cancel(&opTimeoutError{op: "list", timeout: timeout, lang: contextLanguage(ctx)})
+	})
+	defer timer.Stop()
+	for obj := range store.list(listCtx, bucket, opts) {
+		timer.Stop()
+		if obj.Err != nil && ctx.Err() == nil {
+			var terr *opTimeoutError
+			if errors.As(context.Cause(listCtx), &terr) {
+				obj.Err = terr
+			}
+		}
+		fn(obj)
+		timer.Reset(timeout)
+	}
+}
+
+// isCommonPrefix 判断非递归列举结果是否为公共前缀而非对象。
+// 公共前缀只有以 / 结尾的 Key，没有 ETag；以 / 结尾的目录标记对象带有 ETag
+func isCommonPrefix(obj minio.ObjectInfo) bool {
+	return obj.ETag == "" && len(obj.Key) > 0 && obj.Key[len(obj.Key)-1] == '/'
+}
@@ -0,0 +1,144 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// textHandler 保持与标准 log 包一致的纯文本输出格式，只输出时间和消息
+type textHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+}
+
+func newTextHandler(w io.Writer, level slog.Leveler) *textHandler {
+	return &textHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	line := r.Time.Format("2006/01/02 15:04:05 ") + r.Message + "\n"
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line)
+	return err
+}
+
+// 文本格式下结构化字段已包含在消息中，忽略附加属性
+func (h *textHandler) WithAttrs(_ []slog.Attr) slog.Handler { return h }
+
+func (h *textHandler) WithGroup(_ string) slog.Handler { return h }
+
+// newJSONHandler 创建结构化 JSON 日志处理器，字段名与日志平台约定一致
+func newJSONHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				a.Key = "timestamp"
+			case slog.MessageKey:
+				a.Key = "message"
+			}
+			return a
+		},
+	})
+}
+
+// parseLogLevel 解析日志级别配置，为空时默认为 info。错误信息使用语言 lang
+func parseLogLevel(s, lang string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, errors.New(trLang(lang, msgLogBadLevel, s))
+	}
+}
+
+// SetupLogging 设置日志输出，控制台日志和进度条输出到 out：通常为标准输出，
+// 以 -output json 或 jsonl 输出运行结果时为标准错误
+func SetupLogging(cfg *config.Config, out *os.File) (*os.File, error) {
+	var w io.Writer = out
+	var f *os.File
+
+	// 输出为终端时，日志与进度条共用输出以避免相互覆盖
+	if IsTerminal(out) {
+		console = &terminal{w: out}
+		w = console
+	}
+
+	if logFile := cfg.Cleanup.LogFile; logFile != "" {
+		// 确保日志目录存在
+		logDir := filepath.Dir(logFile)
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			return nil, errors.New(tr(cfg, msgLogDirFailed, err))
+		}
+
+		// 打开日志文件
+		var err error
+		f, err = os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, errors.New(tr(cfg, msgLogOpenFailed, err))
+		}
+
+		// 设置日志输出到文件和控制台
+		w = io.MultiWriter(w, f)
+	}
+
+	level, err := parseLogLevel(cfg.Cleanup.LogLevel, cfg.Cleanup.Language)
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, err
+	}
+
+	var handler slog.Handler
+	switch cfg.Cleanup.LogFormat {
+	case "", "text":
+		handler = newTextHandler(w, level)
+	case "json":
+		handler = newJSONHandler(w, level)
+	default:
+		if f != nil {
+			f.Close()
+		}
+		return nil, errors.New(tr(cfg, msgLogBadFormat, cfg.Cleanup.LogFormat))
+	}
+
+	if cfg.Syslog.Address != "" {
+		sh, err := newSyslogHandler(&cfg.Syslog, level)
+		if err != nil {
+			if f != nil {
+				f.Close()
+			}
+			return nil, errors.New(tr(cfg, msgSyslogFailed, err))
+		}
+		handler = multiHandler{handler, sh}
+	}
+
+	// 标准 log 包的输出也经由该处理器
+	slog.SetDefault(slog.New(handler))
+	return f, nil
+}
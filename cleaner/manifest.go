@@ -0,0 +1,106 @@
+package cleaner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestEntry 记录一个已删除的对象
+type manifestEntry struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	VersionID    string    `json:"versionId"`
+	// DeleteMarker 为开启版本控制的 S3 存储桶中删除产生的删除标记的版本 ID，删除该删除标记即可恢复对象
+	DeleteMarker string    `json:"deleteMarkerVersionId,omitempty"`
+	Rule         string    `json:"rule"`
+	DeletedAt    time.Time `json:"deletedAt"`
+}
+
+var manifestCSVHeader = []string{"bucket", "key", "size", "lastModified", "versionId", "rule", "deletedAt", "deleteMarkerVersionId"}
+
+// manifestWriter 将已删除对象清单以 CSV 或 JSONL 格式写入文件，可被多个工作协程并发调用
+type manifestWriter struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	csv  *csv.Writer
+	enc  *json.Encoder
+}
+
+// manifestFormat 返回清单格式，未配置时按文件扩展名判断，默认为 jsonl
+func manifestFormat(path, format string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return "csv"
+	}
+	return "jsonl"
+}
+
+func openManifest(path, format, lang string) (*manifestWriter, error) {
+	format = manifestFormat(path, format)
+	if format != "csv" && format != "jsonl" {
+		return nil, errors.New(trLang(lang, msgManifestBadFormat, format))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifestWriter{path: path, f: f}
+	if format == "csv" {
+		m.csv = csv.NewWriter(f)
+		// 仅在新文件中写入表头
+		if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
+			m.csv.Write(manifestCSVHeader)
+			m.csv.Flush()
+		}
+	} else {
+		m.enc = json.NewEncoder(f)
+	}
+	return m, nil
+}
+
+func (m *manifestWriter) write(e manifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.enc != nil {
+		return m.enc.Encode(e)
+	}
+	m.csv.Write([]string{
+		e.Bucket,
+		e.Key,
+		strconv.FormatInt(e.Size, 10),
+		e.LastModified.Format(time.RFC3339Nano),
+		e.VersionID,
+		e.Rule,
+		e.DeletedAt.Format(time.RFC3339Nano),
+		e.DeleteMarker,
+	})
+	// 每条记录立即落盘，程序中途退出时清单仍然完整
+	m.csv.Flush()
+	return m.csv.Error()
+}
+
+func (m *manifestWriter) close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.csv != nil {
+		m.csv.Flush()
+	}
+	return m.f.Close()
+}
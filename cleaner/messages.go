@@ -0,0 +1,994 @@
+package cleaner
+
+import (
+	"context"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgRunStart            msgID = "run.start"
+	msgRunDryRun           msgID = "run.dryRun"
+	msgRunProgress         msgID = "run.progress"
+	msgRunTotal            msgID = "run.total"
+	msgRunProgressListing  msgID = "run.progressListing"
+	msgRunFinish           msgID = "run.finish"
+	msgListError           msgID = "list.error"
+	msgSkipMinSize         msgID = "skip.minSize"
+	msgSkipMaxAge          msgID = "skip.maxAge"
+	msgSkipMinObjectAge    msgID = "skip.minObjectAge"
+	msgSkipPartitionAge    msgID = "skip.partitionAge"
+	msgSkipNoPartition     msgID = "skip.noPartition"
+	msgMatch               msgID = "object.match"
+	msgDeleteFailed        msgID = "delete.failed"
+	msgDeleteOK            msgID = "delete.ok"
+	msgDaemonStart         msgID = "daemon.start"
+	msgDaemonStop          msgID = "daemon.stop"
+	msgHealthListen        msgID = "health.listen"
+	msgHealthExit          msgID = "health.exit"
+	msgHealthStalled       msgID = "health.stalled"
+	msgHealthUnreach       msgID = "health.unreachable"
+	msgHealthNoBucket      msgID = "health.noBucket"
+	msgLogDirFailed        msgID = "log.dirFailed"
+	msgLogOpenFailed       msgID = "log.openFailed"
+	msgLogBadFormat        msgID = "log.badFormat"
+	msgLogBadLevel         msgID = "log.badLevel"
+	msgClientFailed        msgID = "client.failed"
+	msgProgressBar         msgID = "progress.bar"
+	msgProgressListing     msgID = "progress.listing"
+	msgRunStartRules       msgID = "run.startRules"
+	msgRuleInfo            msgID = "run.ruleInfo"
+	msgSkipNoRule          msgID = "skip.noRule"
+	msgReportFailed        msgID = "report.failed"
+	msgReportWritten       msgID = "report.written"
+	msgReportUploaded      msgID = "report.uploaded"
+	msgManifestBadFormat   msgID = "manifest.badFormat"
+	msgManifestOpenFailed  msgID = "manifest.openFailed"
+	msgManifestWriteFailed msgID = "manifest.writeFailed"
+	msgCandidatesFailed    msgID = "candidates.failed"
+	msgDiffReadFailed      msgID = "diff.readFailed"
+	msgDiffAdded           msgID = "diff.added"
+	msgDiffRemoved         msgID = "diff.removed"
+	msgDiffSummary         msgID = "diff.summary"
+	msgEmailFailed         msgID = "email.failed"
+	msgEmailSent           msgID = "email.sent"
+	msgEmailSubjectOK      msgID = "email.subjectOK"
+	msgEmailSubjectFail    msgID = "email.subjectFail"
+	msgNotifyBucket        msgID = "notify.bucket"
+	msgNotifyTime          msgID = "notify.time"
+	msgNotifyErrors        msgID = "notify.errors"
+	msgNotifyFreeSpace     msgID = "notify.freeSpace"
+	msgNotifyRule          msgID = "notify.rule"
+	msgNotifyManifest      msgID = "notify.manifest"
+	msgNotifyManifestURL   msgID = "notify.manifestURL"
+	msgNotifyRunError      msgID = "notify.runError"
+	msgChatFailed          msgID = "chat.failed"
+	msgChatSent            msgID = "chat.sent"
+	msgChatBadType         msgID = "chat.badType"
+	msgChatTitleThreshold  msgID = "chat.titleThreshold"
+	msgChatTitleErrorRate  msgID = "chat.titleErrorRate"
+	msgWebhookFailed       msgID = "webhook.failed"
+	msgAuditOpenFailed     msgID = "audit.openFailed"
+	msgAuditWriteFailed    msgID = "audit.writeFailed"
+	msgAuditUploadFailed   msgID = "audit.uploadFailed"
+	msgAuditUploaded       msgID = "audit.uploaded"
+	msgHistorySaveFailed   msgID = "history.saveFailed"
+	msgValidatePositive    msgID = "validate.positive"
+	msgValidateNegative    msgID = "validate.negative"
+	msgValidateRange       msgID = "validate.range"
+	msgValidateRuleDup     msgID = "validate.ruleDup"
+	msgValidateRuleShadow  msgID = "validate.ruleShadow"
+	msgValidateNoEndpoint  msgID = "validate.noEndpoint"
+	msgOutputFailed        msgID = "output.failed"
+	msgConfirmScanning     msgID = "confirm.scanning"
+	msgConfirmListFailed   msgID = "confirm.listFailed"
+	msgConfirmNothing      msgID = "confirm.nothing"
+	msgConfirmSummary      msgID = "confirm.summary"
+	msgConfirmPrompt       msgID = "confirm.prompt"
+	msgConfirmCancelled    msgID = "confirm.cancelled"
+	msgReviewGroup         msgID = "review.group"
+	msgReviewGroupPrompt   msgID = "review.groupPrompt"
+	msgReviewObjectPrompt  msgID = "review.objectPrompt"
+	msgReviewRule          msgID = "review.rule"
+	msgReviewNone          msgID = "review.none"
+	msgReviewConfirm       msgID = "review.confirm"
+	msgReviewCancelled     msgID = "review.cancelled"
+	msgTUITitle            msgID = "tui.title"
+	msgTUIColSize          msgID = "tui.colSize"
+	msgTUIColAge           msgID = "tui.colAge"
+	msgTUIColModified      msgID = "tui.colModified"
+	msgTUIColKey           msgID = "tui.colKey"
+	msgTUISortSize         msgID = "tui.sortSize"
+	msgTUISortAge          msgID = "tui.sortAge"
+	msgTUISortPrefix       msgID = "tui.sortPrefix"
+	msgTUIHelp             msgID = "tui.help"
+	msgTUIConfirm          msgID = "tui.confirm"
+	msgTUISelected         msgID = "tui.selected"
+	msgTUIFailed           msgID = "tui.failed"
+	msgSkipNotApproved     msgID = "skip.notApproved"
+	msgSkipFilter          msgID = "skip.filter"
+	msgValidateProfile     msgID = "validate.profile"
+	msgAnalyzeStart        msgID = "analyze.start"
+	msgAnalyzeProgress     msgID = "analyze.progress"
+	msgAnalyzeFinish       msgID = "analyze.finish"
+	msgAnalyzeTotal        msgID = "analyze.total"
+	msgAnalyzeByPrefix     msgID = "analyze.byPrefix"
+	msgAnalyzeByExt        msgID = "analyze.byExtension"
+	msgAnalyzeByAge        msgID = "analyze.byAge"
+	msgAnalyzeLargest      msgID = "analyze.largest"
+	msgAnalyzeOldest       msgID = "analyze.oldest"
+	msgAnalyzeGroupHeader  msgID = "analyze.groupHeader"
+	msgAnalyzeObjectHeader msgID = "analyze.objectHeader"
+	msgAnalyzeNoExt        msgID = "analyze.noExtension"
+	msgAnalyzeAgeHeader    msgID = "analyze.ageHeader"
+	msgRunAgeBucket        msgID = "run.ageBucket"
+	msgRunPrefix           msgID = "run.prefix"
+	msgSyslogFailed        msgID = "syslog.failed"
+	msgSyslogBadNetwork    msgID = "syslog.badNetwork"
+	msgSyslogBadFacility   msgID = "syslog.badFacility"
+	msgSentryFailed        msgID = "sentry.failed"
+	msgSentryRunErrors     msgID = "sentry.runErrors"
+	msgAutoTune            msgID = "autotune.adjust"
+	msgDeleteRetry         msgID = "delete.retry"
+	msgVerifyRetry         msgID = "verify.retry"
+	msgVerifyFailed        msgID = "verify.failed"
+	msgVerifyGone          msgID = "verify.gone"
+	msgVerifyChanged       msgID = "verify.changed"
+	msgVerifyUnreplicated  msgID = "verify.unreplicated"
+	msgRecheckStart        msgID = "verification.start"
+	msgRecheckFailed       msgID = "verification.failed"
+	msgRecheckExists       msgID = "verification.stillExists"
+	msgRecheckMissing      msgID = "verification.missing"
+	msgRecheckDone         msgID = "verification.done"
+	msgPlanUploaded        msgID = "plan.uploaded"
+	msgPlanFailed          msgID = "plan.failed"
+	msgKillSwitchObject    msgID = "killSwitch.object"
+	msgKillSwitchTag       msgID = "killSwitch.tag"
+	msgKillSwitchFailed    msgID = "killSwitch.failed"
+	msgKillSwitchBadTag    msgID = "killSwitch.badTag"
+	msgPluginFailed        msgID = "plugin.failed"
+	msgPluginTagsFailed    msgID = "plugin.tagsFailed"
+	msgPluginBoth          msgID = "plugin.both"
+	msgHookBoth            msgID = "hook.both"
+	msgHookPreFailed       msgID = "hook.preFailed"
+	msgHookVetoed          msgID = "hook.vetoed"
+	msgHookPostFailed      msgID = "hook.postFailed"
+	msgApprovalWaiting     msgID = "approval.waiting"
+	msgApprovalApproved    msgID = "approval.approved"
+	msgApprovalDenied      msgID = "approval.denied"
+	msgApprovalTimeout     msgID = "approval.timeout"
+	msgApprovalFailed      msgID = "approval.failed"
+	msgApprovalPollFailed  msgID = "approval.pollFailed"
+	msgWatchStart          msgID = "watch.start"
+	msgWatchFailed         msgID = "watch.failed"
+	msgWatchMatch          msgID = "watch.match"
+	msgWatchKillSwitch     msgID = "watch.killSwitch"
+	msgWatchRuleEmpty      msgID = "watch.ruleEmpty"
+	msgWatchNoSupport      msgID = "watch.noSupport"
+	msgEventsFailed        msgID = "events.failed"
+	msgEventsBoth          msgID = "events.both"
+	msgEventsNoTopic       msgID = "events.noTopic"
+	msgGRPCListen          msgID = "grpc.listen"
+	msgGRPCExit            msgID = "grpc.exit"
+	msgGRPCFailed          msgID = "grpc.failed"
+	msgGRPCCertPair        msgID = "grpc.certPair"
+	msgGRPCNoAuth          msgID = "grpc.noAuth"
+	msgGRPCClientCANoTLS   msgID = "grpc.clientCANoTLS"
+	msgGRPCBadClientCA     msgID = "grpc.badClientCA"
+	msgControlBusy         msgID = "control.busy"
+	msgControlStarted      msgID = "control.started"
+	msgControlNoRun        msgID = "control.noRun"
+	msgControlNotRunning   msgID = "control.notRunning"
+	msgControlCanceled     msgID = "control.canceled"
+	msgControlNoBucket     msgID = "control.noBucket"
+	msgControlNoCluster    msgID = "control.noCluster"
+	msgJobsListen          msgID = "jobs.listen"
+	msgJobsExit            msgID = "jobs.exit"
+	msgJobsUnauthorized    msgID = "jobs.unauthorized"
+	msgJobsBadSpec         msgID = "jobs.badSpec"
+	msgJobsNoBucket        msgID = "jobs.noBucket"
+	msgJobsAmbiguous       msgID = "jobs.ambiguous"
+	msgJobsQueueFull       msgID = "jobs.queueFull"
+	msgJobsSubmitted       msgID = "jobs.submitted"
+	msgJobsNoToken         msgID = "jobs.noToken"
+	msgJobsTokenEmpty      msgID = "jobs.tokenEmpty"
+	msgJobsTeamDuplicate   msgID = "jobs.teamDuplicate"
+	msgJobsCertPair        msgID = "jobs.certPair"
+	msgJobsTenantCreds     msgID = "jobs.tenantCreds"
+	msgJobsTenantNoCreds   msgID = "jobs.tenantNoCreds"
+	msgJobsNoBuckets       msgID = "jobs.noBuckets"
+	msgJobsForbidden       msgID = "jobs.forbidden"
+	msgPresetUnknown       msgID = "preset.unknown"
+	msgHarborGCRunning     msgID = "harbor.gcRunning"
+	msgHarborGCFailed      msgID = "harbor.gcFailed"
+	msgWatchPresetKept     msgID = "watch.presetKept"
+	msgWatchQueueFull      msgID = "watch.queueFull"
+	msgWatchApproval       msgID = "watch.approval"
+	msgGroupsPerObject     msgID = "groups.perObject"
+	msgPresetReady         msgID = "preset.ready"
+	msgCILayoutInvalid     msgID = "ci.layoutInvalid"
+	msgCIIndexFailed       msgID = "ci.indexFailed"
+	msgGroupIndexFailed    msgID = "group.indexFailed"
+	msgRegistryIndexFailed msgID = "registry.indexFailed"
+	msgRegistryReadFailed  msgID = "registry.manifestFailed"
+	msgRegistryNoManifest  msgID = "registry.manifestMissing"
+	msgRegistryReferences  msgID = "registry.references"
+	msgPartitionInvalid    msgID = "partition.invalid"
+	msgPartitionGroupDepth msgID = "partition.groupDepth"
+	msgAliasS3Only         msgID = "alias.s3Only"
+	msgInventoryFailed     msgID = "inventory.failed"
+	msgInventoryNone       msgID = "inventory.none"
+	msgInventoryStale      msgID = "inventory.stale"
+	msgInventoryBucket     msgID = "inventory.bucket"
+	msgInventoryFormat     msgID = "inventory.format"
+	msgInventorySchema     msgID = "inventory.schema"
+	msgAdminS3Only         msgID = "admin.s3Only"
+	msgAdminUsageFailed    msgID = "admin.usageFailed"
+	msgAdminUsage          msgID = "admin.usage"
+	msgAdminSpaceFailed    msgID = "admin.spaceFailed"
+	msgAdminSpace          msgID = "admin.space"
+	msgDedupPrimary        msgID = "dedup.primary"
+	msgDedupNoPrimary      msgID = "dedup.noPrimary"
+	msgDedupPrimaryTarget  msgID = "dedup.primaryTarget"
+	msgDedupIndexFailed    msgID = "dedup.indexFailed"
+	msgDedupIndexed        msgID = "dedup.indexed"
+	msgBucketRemoved       msgID = "bucket.removed"
+	msgBucketNotEmpty      msgID = "bucket.notEmpty"
+	msgBucketTooNew        msgID = "bucket.tooNew"
+	msgBucketRemoveFailed  msgID = "bucket.removeFailed"
+	msgBucketRemoveAzure   msgID = "bucket.removeAzure"
+	msgBucketRmNoPattern   msgID = "bucket.removeNoPattern"
+	msgBucketRmAzure       msgID = "bucket.removeAzureType"
+	msgBadPeriod           msgID = "config.badPeriod"
+	msgCalendarIndexFailed msgID = "calendar.indexFailed"
+	msgCalendarGroups      msgID = "calendar.groups"
+	msgTTLFailed           msgID = "ttl.failed"
+	msgTTLSkipInvalid      msgID = "ttl.skipInvalid"
+	msgSkipTTL             msgID = "skip.ttl"
+	msgTTLGroups           msgID = "ttl.groups"
+	msgBadUntil            msgID = "config.badUntil"
+	msgRuleSuspended       msgID = "rule.suspended"
+	msgSkipSuspended       msgID = "skip.suspended"
+	msgCostSavings         msgID = "cost.savings"
+	msgCostBoth            msgID = "cost.both"
+	msgCostTierOrder       msgID = "cost.tierOrder"
+	msgAPICalls            msgID = "api.calls"
+	msgAPICallsEstimate    msgID = "api.callsEstimate"
+	msgAPICallsCost        msgID = "api.callsCost"
+	msgAbortAPICalls       msgID = "api.abort"
+	msgAPIBudgetEstimate   msgID = "api.budgetEstimate"
+	msgConfigInvalid       msgID = "config.invalid"
+	msgWatchPresetFailed   msgID = "watch.presetFailed"
+	msgSkipPlugin          msgID = "skip.plugin"
+	msgAbortMaxErrors      msgID = "abort.maxErrors"
+	msgAbortErrorRate      msgID = "abort.errorRate"
+	msgCircuitOpen         msgID = "circuit.open"
+	msgCircuitProbeFailed  msgID = "circuit.probeFailed"
+	msgCircuitClosed       msgID = "circuit.closed"
+	msgOpTimeout           msgID = "timeout.op"
+	msgRunTimedOut         msgID = "run.timedOut"
+	msgDeleteStopped       msgID = "delete.stopped"
+	msgBenchStage          msgID = "bench.stage"
+	msgBenchTotal          msgID = "bench.total"
+	msgBenchBottleneck     msgID = "bench.bottleneck"
+	msgBenchStageList      msgID = "bench.stageList"
+	msgBenchStageFilter    msgID = "bench.stageFilter"
+	msgBenchStageDelete    msgID = "bench.stageDelete"
+	msgBenchStageDryRun    msgID = "bench.stageDryRun"
+	msgTargetNoBucket      msgID = "target.noBucket"
+	msgTargetDuplicate     msgID = "target.duplicate"
+	msgTargetNoBucketName  msgID = "target.noBucketName"
+	msgTargetPartialCreds  msgID = "target.partialCreds"
+	msgTargetClusterCreds  msgID = "target.clusterCreds"
+	msgTargetBadPattern    msgID = "target.badPattern"
+	msgTargetListFailed    msgID = "target.discoverFailed"
+	msgTargetDiscovered    msgID = "target.discovered"
+	msgTargetNoMatch       msgID = "target.noMatch"
+	msgTargetExcluded      msgID = "target.excluded"
+	msgBucketProtected     msgID = "bucket.protected"
+	msgTargetRediscover    msgID = "target.refreshFailed"
+	msgTargetPathConflict  msgID = "target.pathConflict"
+	msgTargetStart         msgID = "target.start"
+	msgSafetyScanning      msgID = "safety.scanning"
+	msgSafetyPassed        msgID = "safety.passed"
+	msgSafetyTooMany       msgID = "safety.tooMany"
+	msgSafetyListFailed    msgID = "safety.listFailed"
+	msgSafetyShortRule     msgID = "safety.shortRule"
+	msgVersioningDisabled  msgID = "versioning.disabled"
+	msgVersioningFailed    msgID = "versioning.failed"
+	msgCanaryStart         msgID = "canary.start"
+	msgCanaryDone          msgID = "canary.done"
+	msgCanaryDeleted       msgID = "canary.deleted"
+	msgCanaryWaiting       msgID = "canary.waiting"
+	msgCanaryPrompt        msgID = "canary.prompt"
+	msgCanaryFailed        msgID = "canary.failed"
+	msgCanaryErrors        msgID = "canary.errors"
+	msgCanaryCancelled     msgID = "canary.cancelled"
+	msgCanaryNoTerminal    msgID = "canary.noTerminal"
+	msgSTSUnknownType      msgID = "sts.unknownType"
+	msgSTSRenewed          msgID = "sts.renewed"
+	msgSTSFailed           msgID = "sts.failed"
+	msgVaultFailed         msgID = "vault.failed"
+	msgVaultNoField        msgID = "vault.noField"
+	msgVaultNoToken        msgID = "vault.noToken"
+	msgVaultLoaded         msgID = "vault.loaded"
+	msgTLSNoCert           msgID = "tls.noCert"
+	msgTLSPartialCert      msgID = "tls.partialCert"
+	msgTLSInsecure         msgID = "tls.insecure"
+	msgProxyInvalid        msgID = "proxy.invalid"
+	msgAddressingInvalid   msgID = "addressing.invalid"
+	msgStorageInvalid      msgID = "storage.invalid"
+	msgGCSNoProject        msgID = "gcs.noProject"
+	msgReviewTooMany       msgID = "review.tooMany"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = i18n.Catalogs[msgID]{
+	"zh": {
+		msgRunStart:            "开始清理过程，阈值时间: %v, 最小文件大小: %.2f MB",
+		msgRunDryRun:           "运行模式: 预览（不会实际删除文件）",
+		msgRunProgress:         "进度: %.2f%% (已处理: %d, 总数: %d, 已删除: %d, 已删除大小: %.2f MB)",
+		msgRunTotal:            "列举完成，总文件数: %d",
+		msgRunProgressListing:  "进度: 已处理: %d, 已发现: %d（列举中）, 已删除: %d, 已删除大小: %.2f MB",
+		msgRunFinish:           "清理过程完成。总文件数: %d, 已处理: %d, 已删除: %d, 已删除大小: %.2f MB",
+		msgListError:           "列举对象时发生错误: %v",
+		msgSkipMinSize:         "跳过文件: %s (大小 %d 字节小于最小文件大小 %d 字节)",
+		msgSkipMaxAge:          "跳过文件: %s (修改时间 %v 晚于阈值时间)",
+		msgSkipMinObjectAge:    "跳过文件: %s (修改时间 %v 晚于 safety.minObjectAge 的保护时间)",
+		msgSkipPartitionAge:    "跳过文件: %s (分区 %s 的日期 %v 晚于阈值时间)",
+		msgSkipNoPartition:     "跳过文件: %s (不在规则的日期分区 %s 中)",
+		msgMatch:               "发现需要清理的文件: %s (大小: %.2f MB, 修改时间: %v)",
+		msgDeleteFailed:        "删除文件失败 %s: %v",
+		msgDeleteOK:            "成功删除文件: %s",
+		msgDaemonStart:         "守护模式已启动，清理间隔: %v",
+		msgDaemonStop:          "守护模式已停止",
+		msgHealthListen:        "健康检查服务监听于: %s",
+		msgHealthExit:          "健康检查服务异常退出: %v",
+		msgHealthStalled:       "清理过程无进展",
+		msgHealthUnreach:       "无法访问MinIO: %v",
+		msgHealthNoBucket:      "存储桶不存在: %s",
+		msgLogDirFailed:        "创建日志目录失败: %v",
+		msgLogOpenFailed:       "打开日志文件失败: %v",
+		msgLogBadFormat:        "不支持的日志格式: %s",
+		msgLogBadLevel:         "不支持的日志级别: %s",
+		msgClientFailed:        "创建Minio客户端失败: %v",
+		msgProgressBar:         "%5.1f%% %d/%d | %.0f 个/秒 %.2f MB/秒 | 已删除: %d (%.2f MB) | 剩余: %s",
+		msgProgressListing:     "%d/%d+ | %.0f 个/秒 %.2f MB/秒 | 已删除: %d (%.2f MB) | 列举中...",
+		msgRunStartRules:       "开始清理过程，共 %d 条规则",
+		msgRuleInfo:            "规则 %s: 前缀 %q, 阈值时间: %v, 最小文件大小: %.2f MB",
+		msgSkipNoRule:          "跳过文件: %s (未匹配任何规则)",
+		msgReportFailed:        "写入汇总报告失败: %v",
+		msgReportWritten:       "汇总报告已写入: %s",
+		msgReportUploaded:      "汇总报告已上传: %s/%s",
+		msgManifestBadFormat:   "不支持的清单格式: %s",
+		msgManifestOpenFailed:  "打开已删除对象清单失败: %v",
+		msgManifestWriteFailed: "写入已删除对象清单失败 %s: %v",
+		msgCandidatesFailed:    "保存待清理对象列表失败: %v",
+		msgDiffReadFailed:      "读取待清理对象列表失败: %v",
+		msgDiffAdded:           "新增匹配: %s (大小: %.2f MB, 规则: %s)",
+		msgDiffRemoved:         "不再匹配: %s (大小: %.2f MB, 规则: %s)",
+		msgDiffSummary:         "对比完成。上次待清理: %d, 本次待清理: %d, 新增匹配: %d, 不再匹配: %d",
+		msgEmailFailed:         "发送通知邮件失败: %v",
+		msgEmailSent:           "通知邮件已发送: %s",
+		msgEmailSubjectOK:      "[minio-cleaner] 清理完成: %s",
+		msgEmailSubjectFail:    "[minio-cleaner] 清理失败: %s",
+		msgNotifyBucket:        "存储桶: %s",
+		msgNotifyTime:          "运行时间: %s ~ %s",
+		msgNotifyErrors:        "错误数: %d",
+		msgNotifyFreeSpace:     "集群剩余空间: 清理前 %.2f GB, 清理后 %.2f GB",
+		msgNotifyRule:          "规则 %s: 匹配 %d, 已删除 %d (%.2f MB)",
+		msgNotifyManifest:      "已删除对象清单: %s",
+		msgNotifyManifestURL:   "清单链接: %s",
+		msgNotifyRunError:      "运行失败: %v",
+		msgChatFailed:          "发送 %s 通知失败: %v",
+		msgChatSent:            "%s 通知已发送: %s",
+		msgChatBadType:         "不支持的聊天平台类型: %s",
+		msgChatTitleThreshold:  "[minio-cleaner] 删除量超过阈值: %s",
+		msgChatTitleErrorRate:  "[minio-cleaner] 错误率超过阈值: %s",
+		msgWebhookFailed:       "发送回调失败 %s (%s): %v",
+		msgAuditOpenFailed:     "打开审计日志失败: %v",
+		msgAuditWriteFailed:    "写入审计日志失败: %v",
+		msgAuditUploadFailed:   "上传审计日志失败: %v",
+		msgAuditUploaded:       "审计日志已上传: %s/%s",
+		msgHistorySaveFailed:   "保存运行历史失败: %v",
+		msgValidatePositive:    "%s 必须大于 0，当前为 %v",
+		msgValidateNegative:    "%s 不能为负数",
+		msgValidateRange:       "%s 必须在 %v 到 %v 之间，当前为 %v",
+		msgValidateRuleDup:     "规则名称重复: %s",
+		msgValidateRuleShadow:  "规则 %s 永远不会生效：前面的规则 %s 的前缀 %q 已包含其前缀 %q",
+		msgValidateNoEndpoint:  "集群 %s 未配置 endpoint",
+		msgOutputFailed:        "输出运行结果失败: %v",
+		msgConfirmScanning:     "正在统计集群 %s 上的存储桶 %s 中将被删除的对象...",
+		msgConfirmListFailed:   "统计集群 %s 上的存储桶 %s 时出现 %d 个列举错误，跳过该存储桶",
+		msgConfirmNothing:      "集群 %s 上的存储桶 %s 中没有需要删除的对象",
+		msgConfirmSummary:      "将从集群 %s 上的存储桶 %s 中删除 %d 个对象，共 %.2f GB，占用最多的前缀:",
+		msgConfirmPrompt:       "输入存储桶名称 %s 确认删除，输入其他内容取消: ",
+		msgConfirmCancelled:    "未确认删除，跳过集群 %s 上的存储桶 %s",
+		msgReviewGroup:         "[%d/%d] %s: %d 个对象，共 %.2f GB",
+		msgReviewGroupPrompt:   "(a) 全部清理  (s) 跳过  (r) 逐个审查  (q) 结束审查:",
+		msgReviewObjectPrompt:  "  (y) 清理  (n) 跳过  (a) 清理本组其余对象  (s) 跳过本组其余对象  (q) 结束审查:",
+		msgReviewRule:          "规则 %s",
+		msgReviewNone:          "没有批准清理的对象",
+		msgReviewConfirm:       "开始清理已批准的 %d 个对象（共 %.2f GB）？(y/n):",
+		msgReviewCancelled:     "已取消清理",
+		msgTUITitle:            "集群 %s 存储桶 %s: %d 个待清理对象，标记删除 %d 个（%.2f GB）  排序: %s",
+		msgTUIColSize:          "大小",
+		msgTUIColAge:           "年龄",
+		msgTUIColModified:      "最后修改时间",
+		msgTUIColKey:           "对象",
+		msgTUISortSize:         "大小",
+		msgTUISortAge:          "年龄",
+		msgTUISortPrefix:       "前缀",
+		msgTUIHelp:             "↑↓ 移动  空格 保留/删除  p 整个前缀  a 全删  n 全留  s 排序  r 反序  Enter 确认  q 结束",
+		msgTUIConfirm:          "清理标记删除的 %d 个对象（共 %.2f GB）？(y/n)",
+		msgTUISelected:         "已选择清理 %d 个对象（共 %.2f GB）",
+		msgTUIFailed:           "无法进入终端界面: %v",
+		msgSkipNotApproved:     "跳过文件: %s (交互式审查或 tui 中未选择)",
+		msgSkipFilter:          "跳过文件: %s (过滤器 %s 决定保留)",
+		msgValidateProfile:     "profile %s: %s",
+		msgAnalyzeStart:        "开始分析存储桶: %s（不会删除任何文件）",
+		msgAnalyzeProgress:     "已扫描: %d 个文件 (%.2f MB)",
+		msgAnalyzeFinish:       "分析完成。总文件数: %d, 总大小: %.2f MB",
+		msgAnalyzeTotal:        "存储桶: %s\t文件数: %d\t总大小: %.2f MB",
+		msgAnalyzeByPrefix:     "按顶级前缀",
+		msgAnalyzeByExt:        "按扩展名",
+		msgAnalyzeByAge:        "按文件年龄",
+		msgAnalyzeLargest:      "最大的文件",
+		msgAnalyzeOldest:       "最旧的文件",
+		msgAnalyzeGroupHeader:  "分组\t文件数\t大小\t占比",
+		msgAnalyzeObjectHeader: "对象\t大小\t修改时间",
+		msgAnalyzeNoExt:        "(无扩展名)",
+		msgAnalyzeAgeHeader:    "年龄\t文件数\t大小\t占比\t",
+		msgRunAgeBucket:        "文件年龄 %s: %d 个文件, %.2f MB (%.1f%%)",
+		msgRunPrefix:           "前缀 %s: 扫描 %d 个文件 (%.2f MB), 匹配 %d 个 (%.2f MB), 删除 %d 个 (%.2f MB)",
+		msgSyslogFailed:        "连接 syslog 服务器失败: %v",
+		msgSyslogBadNetwork:    "不支持的 syslog 传输协议: %s",
+		msgSyslogBadFacility:   "不支持的 syslog 设施: %s",
+		msgSentryFailed:        "上报 Sentry 超时",
+		msgSentryRunErrors:     "清理存储桶 %s 时发生 %d 个错误",
+		msgDeleteRetry:         "删除文件失败 %s（第 %d/%d 次尝试），%v 后重试: %v",
+		msgVerifyRetry:         "删除前查询文件失败 %s（第 %d/%d 次尝试），%v 后重试: %v",
+		msgVerifyFailed:        "删除前查询文件失败，跳过 %s: %v",
+		msgVerifyGone:          "文件 %s 在列举后已被删除，跳过",
+		msgVerifyChanged:       "文件 %s 在列举后已被覆盖写入，跳过（大小 %d -> %d，修改时间 %v -> %v）",
+		msgVerifyUnreplicated:  "文件 %s 尚未复制到目标站点（复制状态 %s），跳过",
+		msgRecheckStart:        "开始复查存储桶 %s：%d 个已删除对象，%d 个未清理对象",
+		msgRecheckFailed:       "复查时查询文件失败 %s: %v",
+		msgRecheckExists:       "复查发现已删除的文件仍然存在: %s",
+		msgRecheckMissing:      "复查发现未清理的文件已不存在: %s",
+		msgRecheckDone:         "存储桶 %s 复查完成：%d 个已删除对象仍然存在，%d 个未清理对象已不存在，%d 个对象查询失败",
+		msgPlanUploaded:        "已上传计划删除清单（%d 个对象）到存储桶 %s: %s",
+		msgPlanFailed:          "无法保存计划删除清单，不执行删除: %v",
+		msgKillSwitchObject:    "存储桶 %s 中存在紧急停止哨兵对象 %s，停止清理",
+		msgKillSwitchTag:       "存储桶 %s 带有紧急停止标签 %s，停止清理",
+		msgKillSwitchFailed:    "无法检查存储桶 %s 的紧急停止开关: %v",
+		msgKillSwitchBadTag:    "killSwitch.tag 格式应为 key=value: %s",
+		msgPluginFailed:        "过滤插件判断文件失败，保留 %s: %v",
+		msgPluginTagsFailed:    "查询文件标签失败，保留 %s: %v",
+		msgPluginBoth:          "filterPlugin.command 和 filterPlugin.url 只能配置一个",
+		msgHookBoth:            "%[1]s.command 和 %[1]s.url 只能配置一个",
+		msgHookPreFailed:       "删除前钩子执行失败，保留这批 %d 个文件: %v",
+		msgHookVetoed:          "删除前钩子否决删除，保留这批 %d 个文件: %s",
+		msgHookPostFailed:      "删除后钩子执行失败，这批 %d 个文件已删除: %v",
+		msgApprovalWaiting:     "存储桶 %s 将删除 %d 个文件（%.2f MB），已提交审批，最多等待 %v",
+		msgApprovalApproved:    "存储桶 %s 的删除已批准 %s",
+		msgApprovalDenied:      "存储桶 %s 的删除被拒绝，不删除任何对象: %s",
+		msgApprovalTimeout:     "存储桶 %s 的删除在 %v 内未获批准，不删除任何对象",
+		msgApprovalFailed:      "存储桶 %s 的删除审批失败，不删除任何对象: %v",
+		msgApprovalPollFailed:  "查询存储桶 %s 的审批状态失败，稍后重试: %v",
+		msgWatchStart:          "开始订阅存储桶 %s 的对象创建通知，实时清理规则 %d 条",
+		msgWatchFailed:         "存储桶 %s 的通知订阅中断，%v 后重新订阅: %v",
+		msgWatchMatch:          "新文件 %s 符合实时清理规则 %s，大小: %.2f MB，将在 %v 后删除",
+		msgWatchKillSwitch:     "%s，不删除新文件 %s",
+		msgWatchRuleEmpty:      "watch.rules[%d] 未设置 prefix、suffixes 或 minSize，会删除所有新写入的文件",
+		msgWatchNoSupport:      "集群 %s 的存储类型 %s 不支持 watch.rules，只有 MinIO 提供存储桶通知",
+		msgEventsFailed:        "发布 %d 个文件的删除事件失败，文件已删除: %v",
+		msgEventsBoth:          "events.kafka 和 events.nats 只能配置一个",
+		msgEventsNoTopic:       "未设置 %s，无法发布删除事件",
+		msgGRPCListen:          "gRPC 控制服务监听: %s",
+		msgGRPCExit:            "gRPC 控制服务异常退出: %v",
+		msgGRPCFailed:          "gRPC 调用 %s 失败: %s",
+		msgGRPCCertPair:        "daemon.grpc.certFile 和 daemon.grpc.keyFile 需要同时配置",
+		msgGRPCNoAuth:          "配置了 daemon.grpc.addr 时需要配置 daemon.grpc.token 或 daemon.grpc.clientCAFile，控制服务不能不认证",
+		msgGRPCClientCANoTLS:   "daemon.grpc.clientCAFile 需要同时配置 daemon.grpc.certFile 和 daemon.grpc.keyFile",
+		msgGRPCBadClientCA:     "daemon.grpc.clientCAFile %s 中没有有效的 PEM 证书",
+		msgControlBusy:         "同时进行的运行已达到上限，请等待其他运行结束后再启动",
+		msgControlStarted:      "已启动运行 %s（触发方式: %s）",
+		msgControlNoRun:        "运行 %q 不存在或已过期",
+		msgControlNotRunning:   "运行 %s 已结束",
+		msgControlCanceled:     "已请求取消运行 %s",
+		msgControlNoBucket:     "存储桶 %s 不是清理目标",
+		msgControlNoCluster:    "存储桶 %s 需要写成 <集群名称>/<存储桶>",
+		msgJobsListen:          "任务接口监听: %s",
+		msgJobsExit:            "任务接口退出: %v",
+		msgJobsUnauthorized:    "令牌无效或缺失",
+		msgJobsBadSpec:         "任务描述无效",
+		msgJobsNoBucket:        "任务需要指定 bucket",
+		msgJobsAmbiguous:       "存储桶 %s 位于多个集群中，请指定 cluster",
+		msgJobsQueueFull:       "团队 %s 排队的任务已达到上限 %d，请稍后再提交",
+		msgJobsSubmitted:       "团队 %[2]s 提交了任务 %[1]s，清理存储桶 %[3]s",
+		msgJobsNoToken:         "配置了 daemon.api.addr 时需要在 daemon.api.tenants 中配置至少一个团队",
+		msgJobsTokenEmpty:      "daemon.api.tenants[%d] 需要同时配置 team 和 token",
+		msgJobsTeamDuplicate:   "daemon.api.tenants 中的团队 %s 重复",
+		msgJobsCertPair:        "daemon.api.certFile 和 daemon.api.keyFile 需要同时配置",
+		msgJobsTenantCreds:     "daemon.api.tenants[%d] 需要同时配置访问密钥 ID 和访问密钥",
+		msgJobsTenantNoCreds:   "daemon.api.tenants[%d] 需要配置团队自己的访问密钥，团队的任务不使用存储桶配置的凭证",
+		msgJobsNoBuckets:       "daemon.api.tenants[%d] 需要在 buckets 中列出团队可以清理的存储桶",
+		msgJobsForbidden:       "团队 %s 无权清理存储桶 %s",
+		msgPresetUnknown:       "未知的集成预设 %s，可选值为: %s",
+		msgHarborGCRunning:     "Harbor 垃圾回收任务 %d 状态为 %s，等待回收结束后再清理",
+		msgHarborGCFailed:      "无法查询 Harbor 垃圾回收状态，本次不清理: %v",
+		msgWatchPresetKept:     "实时清理跳过 %s: 集成预设 %s 决定保留",
+		msgWatchQueueFull:      "实时清理跳过 %s: 等待删除的新文件已达上限 %d",
+		msgGroupsPerObject:     "规则设置了 groupDepth 或 partition 或选择了 velero 预设时不能配置 %s：它在删除时逐个决定保留对象，会使对象组只删除一部分",
+		msgWatchApproval:       "watch.rules 不能与 approval 或 canary 同时配置：实时清理逐个删除新写入的文件，无法先审批或先删除金丝雀对象",
+		msgPresetReady:         "集成预设 %s 已就绪，存储桶 %s",
+		msgCILayoutInvalid:     "presets.%s.layout 无效: %v",
+		msgCIIndexFailed:       "无法列举存储桶 %s 中的构建，本次不清理: %v",
+		msgGroupIndexFailed:    "无法列举存储桶 %s 中的对象组，本次不清理: %v",
+		msgRegistryIndexFailed: "无法列举存储桶 %s 中的镜像清单，本次不清理: %v",
+		msgRegistryReadFailed:  "无法读取镜像清单 %s，本次不清理: %v",
+		msgRegistryNoManifest:  "镜像清单 %s 的内容 %s 不存在，跳过该清单",
+		msgRegistryReferences:  "存储桶 %s 中的 %d 个镜像清单共引用 %d 个 blob，这些 blob 将保留",
+		msgPartitionInvalid:    "rules[%d].partition %q 无效: %v",
+		msgPartitionGroupDepth: "rules[%d] 不能同时配置 partition 和 groupDepth，分区本身即为整体清理的单位",
+		msgAliasS3Only:         "集群 %s 的 endpointAlias 只能用于 s3 类型的存储",
+		msgInventoryFailed:     "无法读取 inventory 清单 %s，本次不清理: %v",
+		msgInventoryNone:       "%s 下没有已生成完成的 inventory 清单，本次不清理",
+		msgInventoryStale:      "inventory 清单 %s 生成于 %s，早于 inventory.maxAge（%s），本次不清理",
+		msgInventoryBucket:     "inventory 清单 %s 的源存储桶为 %s，与清理的存储桶 %s 不同，本次不清理",
+		msgInventoryFormat:     "inventory 清单 %s 的格式为 %s，只支持 CSV，本次不清理",
+		msgInventorySchema:     "inventory 清单的字段 %q 中缺少 Key 或 LastModifiedDate",
+		msgAdminS3Only:         "集群 %s 的 adminAPI 只能用于 s3 类型的 MinIO",
+		msgAdminUsageFailed:    "无法通过管理接口查询集群 %s 的数据用量，按配置顺序清理: %v",
+		msgAdminUsage:          "集群 %s 的存储桶按数据用量（统计于 %s）从大到小清理: %s",
+		msgAdminSpaceFailed:    "无法通过管理接口查询集群 %s 的剩余空间: %v",
+		msgAdminSpace:          "集群 %s 剩余空间: 清理前 %.2f GB, 清理后 %.2f GB（变化 %+.2f GB）",
+		msgDedupPrimary:        "存储桶 %s 选择了 dedup 预设，presets.dedup.primary 需要配置为同一集群上的另一个存储桶",
+		msgDedupNoPrimary:      "集群 %s 的存储桶选择了 dedup 预设，需要配置 presets.dedup.primary",
+		msgDedupPrimaryTarget:  "主存储桶 %s 同时是集群 %s 的清理目标，副本删除后主存储桶中的对象也可能被清理",
+		msgDedupIndexFailed:    "无法列举主存储桶 %s，本次不清理: %v",
+		msgDedupIndexed:        "主存储桶 %s 中有 %d 个对象，共 %d 种内容",
+		msgBucketRemoved:       "存储桶 %s 清理后为空，已删除",
+		msgBucketNotEmpty:      "存储桶 %s 清理后仍有对象，不删除",
+		msgBucketTooNew:        "存储桶 %s 的创建时间 %v 比 safety.minObjectAge 新或无法获得，不删除",
+		msgBucketRemoveFailed:  "删除空存储桶 %s 失败：%v",
+		msgBucketRemoveAzure:   "Azure Blob 存储不支持删除容器",
+		msgBucketRmNoPattern:   "集群 %s 的 removeEmptyBuckets 只删除按 bucketPattern 发现的存储桶，但没有配置 bucketPattern",
+		msgBucketRmAzure:       "集群 %s 的 removeEmptyBuckets 不能用于 azure 类型的存储",
+		msgBadPeriod:           "无效的日历周期: %q，应为 day、week、month、quarter 或 year",
+		msgCalendarIndexFailed: "无法列举存储桶 %s 中各日历周期最新的对象，本次不清理: %v",
+		msgCalendarGroups:      "rules[%d] 的 keepLastOf 和 gfs 不能与 groupDepth 或 partition 同时配置，它们按单个对象保留",
+		msgTTLFailed:           "查询文件 %s 的过期时间失败: %v",
+		msgTTLSkipInvalid:      "保留文件 %s: %v",
+		msgSkipTTL:             "跳过文件: %s (对象的过期时间 %s 为 %v，尚未过期)",
+		msgTTLGroups:           "rules[%d] 的 ttl 不能与 groupDepth 或 partition 同时配置，ttl 按单个对象判断",
+		msgBadUntil:            "无效的截止时间: %q，应为日期（如 2025-03-31）或 RFC 3339 时间",
+		msgRuleSuspended:       "规则 %s（前缀 %q）暂停清理到 %s，期间匹配该规则的文件都不清理",
+		msgSkipSuspended:       "跳过文件: %s (规则 %s 暂停清理到 %s)",
+		msgCostSavings:         "预计每月节省存储费用 %.2f %s（%.2f GB）",
+		msgCostBoth:            "cost.pricePerGBMonth 和 cost.tiers 只能配置一个",
+		msgCostTierOrder:       "cost.tiers[%d].upTo 必须大于前一档，只有最后一档可以不设置",
+		msgAPICalls:            "API 请求: LIST %d 次, HEAD %d 次, GET %d 次, DELETE %d 次, 其他 %d 次, 共 %d 次",
+		msgAPICallsEstimate:    "实际删除时预计 API 请求: LIST %d 次, HEAD %d 次, GET %d 次, DELETE %d 次, 其他 %d 次, 共 %d 次",
+		msgAPICallsCost:        "请求费用约 %.4f %s",
+		msgAbortAPICalls:       "API 请求数超过 cleanup.maxAPICalls 限制的 %d 次，中止运行",
+		msgAPIBudgetEstimate:   "实际删除时预计发出 %d 次 API 请求，超过 cleanup.maxAPICalls 限制的 %d 次，运行将被中止",
+		msgConfigInvalid:       "配置有 %d 个问题:\n  - %s",
+		msgWatchPresetFailed:   "集成预设 %s 尚未就绪，存储桶 %s 的通知订阅在 %v 后重试: %v",
+		msgSkipPlugin:          "过滤插件决定保留文件: %s %s",
+		msgAbortMaxErrors:      "删除错误数 %d 超过上限 %d，中止清理",
+		msgAbortErrorRate:      "删除错误率 %.1f%% (%d/%d) 超过上限 %.1f%%，中止清理",
+		msgCircuitOpen:         "连续 %d 次连接或认证错误，暂停删除，每 %v 探测一次 endpoint: %v",
+		msgCircuitProbeFailed:  "探测 endpoint 失败，继续暂停删除: %v",
+		msgCircuitClosed:       "endpoint 已恢复，继续删除（暂停了 %v）",
+		msgOpTimeout:           "%s 请求超过 %v 未完成",
+		msgRunTimedOut:         "运行时间超过 maxRuntime (%v)，停止清理并汇总已完成的部分",
+		msgDeleteStopped:       "运行已停止，未删除文件: %s",
+		msgBenchStage:          "[基准] %s: %d 个对象，工作时间 %v，%d 个协程，吞吐量 %.0f 个/秒",
+		msgBenchTotal:          "[基准] 总计: %d 个对象，耗时 %v，整体吞吐量 %.0f 个/秒",
+		msgBenchBottleneck:     "[基准] 最慢的阶段为%s，吞吐量 %.0f 个/秒",
+		msgBenchStageList:      "列举",
+		msgBenchStageFilter:    "筛选",
+		msgBenchStageDelete:    "删除",
+		msgBenchStageDryRun:    "记录（预览模式）",
+		msgTargetNoBucket:      "集群 %s 未配置要清理的存储桶",
+		msgTargetDuplicate:     "集群名称重复: %s，请为每个集群配置不同的 name",
+		msgTargetNoBucketName:  "集群 %s 的 buckets 中有未配置 name 的存储桶",
+		msgTargetPartialCreds:  "集群 %s 的存储桶 %s 需要同时配置 accessKeyId 和 secretAccessKey",
+		msgTargetClusterCreds:  "集群 %s 需要同时配置 accessKeyId 和 secretAccessKey，或者都不配置以使用环境变量、凭证文件或 IAM 角色",
+		msgTargetBadPattern:    "集群 %s 的 bucketPattern 无效: %v",
+		msgTargetListFailed:    "列举集群 %s 上的存储桶失败: %v",
+		msgTargetDiscovered:    "集群 %s 上有 %d 个存储桶匹配 bucketPattern: %s",
+		msgTargetNoMatch:       "集群 %s 上没有匹配 bucketPattern 的存储桶",
+		msgTargetExcluded:      "集群 %s 上的存储桶 %s 在 excludeBuckets 或 protectedBuckets 中，不会被清理",
+		msgBucketProtected:     "存储桶 %s 在 excludeBuckets 或 protectedBuckets 中，拒绝清理",
+		msgTargetRediscover:    "重新发现存储桶失败，继续使用上次的清理目标: %v",
+		msgTargetPathConflict:  "报告路径 %s 被多个清理目标共用（%s 和 %s），请在路径中使用 {cluster} 和 {bucket} 占位符",
+		msgTargetStart:         "开始清理集群 %s 上的存储桶 %s",
+		msgSafetyScanning:      "删除前统计存储桶 %s 中将被删除的对象比例",
+		msgSafetyPassed:        "安全检查通过：存储桶 %s 中将删除 %d 个对象，共 %d 个对象（%.1f%%）",
+		msgSafetyTooMany:       "拒绝删除：存储桶 %s 中有 %d 个对象符合规则，共 %d 个对象（%.1f%%），超过 safety.maxDeletePercent 限制的 %g%%。请检查清理规则和系统时钟，确认无误后使用 -force 运行",
+		msgSafetyListFailed:    "删除前统计存储桶 %s 时出现 %d 个列举错误，无法确认删除比例，本次不删除",
+		msgSafetyShortRule:     "规则 %s 的保留时间 %v 短于 safety.minObjectAge（%v），比 safety.minObjectAge 新的对象不会被清理",
+		msgVersioningDisabled:  "存储桶 %s 未开启版本控制，删除的对象无法恢复",
+		msgVersioningFailed:    "无法查询存储桶 %s 的版本控制状态: %v",
+		msgCanaryStart:         "金丝雀删除：先删除存储桶 %s 中随机抽取的 %d 个对象",
+		msgCanaryDone:          "存储桶 %s 的金丝雀删除完成，已删除 %d 个对象，清单: %s",
+		msgCanaryDeleted:       "存储桶 %s 的金丝雀删除完成，已删除 %d 个对象",
+		msgCanaryWaiting:       "等待 %[2]s 后继续清理存储桶 %[1]s 中的其余对象，可在此期间中止运行",
+		msgCanaryPrompt:        "请检查金丝雀删除的结果，是否继续清理存储桶 %s 中的其余对象？[y/N]",
+		msgCanaryFailed:        "存储桶 %s 的金丝雀删除失败，不再删除其余对象: %v",
+		msgCanaryErrors:        "存储桶 %s 的金丝雀删除出现 %d 个错误，不再删除其余对象",
+		msgCanaryCancelled:     "未确认继续，不再删除存储桶 %s 中的其余对象",
+		msgCanaryNoTerminal:    "canary.confirm 需要在终端中运行，且不能与 -daemon 同时使用",
+		msgSTSUnknownType:      "不支持的 STS 凭证类型: %s，可选值为 assumeRole 或 webIdentity",
+		msgSTSRenewed:          "集群 %s 已通过 STS 获取临时凭证，有效期至 %s",
+		msgSTSFailed:           "集群 %s 通过 STS 获取临时凭证失败: %v",
+		msgVaultFailed:         "从 Vault 读取 %s 失败: %v",
+		msgVaultNoField:        "Vault 路径 %s 中没有字段 %s",
+		msgVaultNoToken:        "未配置 Vault 令牌，请配置 vault.token、vault.tokenFile、vault.kubernetesRole 或环境变量 VAULT_TOKEN",
+		msgVaultLoaded:         "已从 Vault 读取集群 %s 的凭证: %s",
+		msgTLSNoCert:           "CA 文件 %s 中没有有效的 PEM 证书",
+		msgTLSPartialCert:      "tls.certFile 和 tls.keyFile 需要同时配置",
+		msgTLSInsecure:         "集群 %s 已跳过 TLS 证书校验，连接可能被中间人攻击，请勿在生产环境使用",
+		msgProxyInvalid:        "无效的代理地址: %s，需要为 http://、https://、socks5:// 或 socks5h:// 开头的 URL，或 direct",
+		msgAddressingInvalid:   "无效的存储桶寻址方式: %s，可选值为 auto、path 或 virtualHost",
+		msgStorageInvalid:      "不支持的存储类型: %s，可选值为 s3、azure 或 gcs",
+		msgGCSNoProject:        "列举 GCS 存储桶需要项目 ID，请配置 gcs.project 或 GOOGLE_CLOUD_PROJECT",
+		msgAutoTune:            "并发数调整为 %d（平均删除延迟: %v, 错误率: %.1f%%, 限流: %d 次）",
+		msgReviewTooMany:       "集群 %s 上的存储桶 %s 中待清理的对象超过 %d 个，无法逐个审查，跳过该存储桶；可以缩小规则范围（如 prefix）后分批审查",
+	},
+	"en": {
+		msgRunStart:            "Starting cleanup, threshold time: %v, minimum file size: %.2f MB",
+		msgRunDryRun:           "Mode: dry run (no files will be deleted)",
+		msgRunProgress:         "Progress: %.2f%% (processed: %d, total: %d, deleted: %d, deleted size: %.2f MB)",
+		msgRunTotal:            "Listing complete, total files: %d",
+		msgRunProgressListing:  "Progress: processed: %d, discovered: %d (listing), deleted: %d, deleted size: %.2f MB",
+		msgRunFinish:           "Cleanup finished. Total files: %d, processed: %d, deleted: %d, deleted size: %.2f MB",
+		msgListError:           "Error listing objects: %v",
+		msgSkipMinSize:         "Skipping file: %s (size %d bytes is below minimum size %d bytes)",
+		msgSkipMaxAge:          "Skipping file: %s (last modified %v is after threshold time)",
+		msgSkipMinObjectAge:    "Skipping file: %s (last modified %v is within safety.minObjectAge)",
+		msgSkipPartitionAge:    "Skipping file: %s (date %[3]v of partition %[2]s is after threshold time)",
+		msgSkipNoPartition:     "Skipping file: %s (not in a date partition %s of the rule)",
+		msgMatch:               "Found file to clean up: %s (size: %.2f MB, last modified: %v)",
+		msgDeleteFailed:        "Failed to delete file %s: %v",
+		msgDeleteOK:            "Deleted file: %s",
+		msgDaemonStart:         "Daemon mode started, cleanup interval: %v",
+		msgDaemonStop:          "Daemon mode stopped",
+		msgHealthListen:        "Health check server listening on: %s",
+		msgHealthExit:          "Health check server exited unexpectedly: %v",
+		msgHealthStalled:       "cleanup is making no progress",
+		msgHealthUnreach:       "MinIO is unreachable: %v",
+		msgHealthNoBucket:      "bucket does not exist: %s",
+		msgLogDirFailed:        "failed to create log directory: %v",
+		msgLogOpenFailed:       "failed to open log file: %v",
+		msgLogBadFormat:        "unsupported log format: %s",
+		msgLogBadLevel:         "unsupported log level: %s",
+		msgClientFailed:        "Failed to create MinIO client: %v",
+		msgProgressBar:         "%5.1f%% %d/%d | %.0f obj/s %.2f MB/s | deleted: %d (%.2f MB) | ETA: %s",
+		msgProgressListing:     "%d/%d+ | %.0f obj/s %.2f MB/s | deleted: %d (%.2f MB) | listing...",
+		msgRunStartRules:       "Starting cleanup with %d rules",
+		msgRuleInfo:            "Rule %s: prefix %q, threshold time: %v, minimum file size: %.2f MB",
+		msgSkipNoRule:          "Skipping file: %s (no matching rule)",
+		msgReportFailed:        "Failed to write summary report: %v",
+		msgReportWritten:       "Summary report written to: %s",
+		msgReportUploaded:      "Summary report uploaded to: %s/%s",
+		msgManifestBadFormat:   "unsupported manifest format: %s",
+		msgManifestOpenFailed:  "Failed to open deleted-objects manifest: %v",
+		msgManifestWriteFailed: "Failed to write manifest entry for %s: %v",
+		msgCandidatesFailed:    "Failed to save candidate list: %v",
+		msgDiffReadFailed:      "Failed to read candidate list: %v",
+		msgDiffAdded:           "Newly matched: %s (size: %.2f MB, rule: %s)",
+		msgDiffRemoved:         "No longer matched: %s (size: %.2f MB, rule: %s)",
+		msgDiffSummary:         "Diff finished. Previous candidates: %d, current candidates: %d, newly matched: %d, no longer matched: %d",
+		msgEmailFailed:         "Failed to send notification email: %v",
+		msgEmailSent:           "Notification email sent to: %s",
+		msgEmailSubjectOK:      "[minio-cleaner] Cleanup finished: %s",
+		msgEmailSubjectFail:    "[minio-cleaner] Cleanup FAILED: %s",
+		msgNotifyBucket:        "Bucket: %s",
+		msgNotifyTime:          "Run time: %s ~ %s",
+		msgNotifyErrors:        "Errors: %d",
+		msgNotifyFreeSpace:     "Cluster free space: %.2f GB before, %.2f GB after",
+		msgNotifyRule:          "Rule %s: matched %d, deleted %d (%.2f MB)",
+		msgNotifyManifest:      "Deleted-objects manifest: %s",
+		msgNotifyManifestURL:   "Manifest link: %s",
+		msgNotifyRunError:      "Run failed: %v",
+		msgChatFailed:          "Failed to send %s notification: %v",
+		msgChatSent:            "%s notification sent: %s",
+		msgChatBadType:         "unsupported chat platform type: %s",
+		msgChatTitleThreshold:  "[minio-cleaner] Deletion threshold exceeded: %s",
+		msgChatTitleErrorRate:  "[minio-cleaner] Error rate threshold exceeded: %s",
+		msgWebhookFailed:       "Webhook %s (%s) failed: %v",
+		msgAuditOpenFailed:     "Failed to open audit log: %v",
+		msgAuditWriteFailed:    "Failed to write audit log: %v",
+		msgAuditUploadFailed:   "Failed to upload audit log: %v",
+		msgAuditUploaded:       "Audit log uploaded to: %s/%s",
+		msgHistorySaveFailed:   "Failed to save run history: %v",
+		msgValidatePositive:    "%s must be greater than 0, got %v",
+		msgValidateNegative:    "%s must not be negative",
+		msgValidateRange:       "%s must be between %v and %v, got %v",
+		msgValidateRuleDup:     "Duplicate rule name: %s",
+		msgValidateRuleShadow:  "Rule %s never matches: the earlier rule %s with prefix %q already covers its prefix %q",
+		msgValidateNoEndpoint:  "Cluster %s has no endpoint",
+		msgOutputFailed:        "Failed to write results: %v",
+		msgConfirmScanning:     "Counting objects to delete in bucket %[2]s on cluster %[1]s...",
+		msgConfirmListFailed:   "%[3]d listing error(s) while counting bucket %[2]s on cluster %[1]s, skipping the bucket",
+		msgConfirmNothing:      "Bucket %[2]s on cluster %[1]s has no objects to delete",
+		msgConfirmSummary:      "About to delete %[3]d objects (%.2[4]f GB) from bucket %[2]s on cluster %[1]s, top prefixes:",
+		msgConfirmPrompt:       "Type the bucket name %s to confirm the deletion, anything else cancels: ",
+		msgConfirmCancelled:    "Deletion not confirmed, skipping bucket %[2]s on cluster %[1]s",
+		msgReviewGroup:         "[%d/%d] %s: %d objects, %.2f GB",
+		msgReviewGroupPrompt:   "(a) approve all  (s) skip  (r) review each  (q) quit review:",
+		msgReviewObjectPrompt:  "  (y) approve  (n) skip  (a) approve the rest of the group  (s) skip the rest of the group  (q) quit review:",
+		msgReviewRule:          "rule %s",
+		msgReviewNone:          "No objects were approved for cleanup",
+		msgReviewConfirm:       "Clean up the %d approved objects (%.2f GB)? (y/n):",
+		msgReviewCancelled:     "Cleanup cancelled",
+		msgTUITitle:            "Bucket %[2]s on cluster %[1]s: %[3]d candidates, %[4]d marked for deletion (%[5].2f GB)  sort: %[6]s",
+		msgTUIColSize:          "size",
+		msgTUIColAge:           "age",
+		msgTUIColModified:      "last modified",
+		msgTUIColKey:           "object",
+		msgTUISortSize:         "size",
+		msgTUISortAge:          "age",
+		msgTUISortPrefix:       "prefix",
+		msgTUIHelp:             "↑↓ move  space keep/delete  p whole prefix  a delete all  n keep all  s sort  r reverse  Enter confirm  q quit",
+		msgTUIConfirm:          "Clean up the %d objects marked for deletion (%.2f GB)? (y/n)",
+		msgTUISelected:         "Selected %d objects (%.2f GB) for cleanup",
+		msgTUIFailed:           "cannot start the terminal UI: %v",
+		msgSkipNotApproved:     "Skipping file: %s (not selected in the interactive review or tui)",
+		msgSkipFilter:          "Skipping file: %s (kept by filter %s)",
+		msgValidateProfile:     "profile %s: %s",
+		msgAnalyzeStart:        "Analyzing bucket: %s (no files will be deleted)",
+		msgAnalyzeProgress:     "Scanned: %d files (%.2f MB)",
+		msgAnalyzeFinish:       "Analysis complete. Total files: %d, total size: %.2f MB",
+		msgAnalyzeTotal:        "Bucket: %s\tFiles: %d\tTotal size: %.2f MB",
+		msgAnalyzeByPrefix:     "By top-level prefix",
+		msgAnalyzeByExt:        "By extension",
+		msgAnalyzeByAge:        "By age",
+		msgAnalyzeLargest:      "Largest files",
+		msgAnalyzeOldest:       "Oldest files",
+		msgAnalyzeGroupHeader:  "GROUP\tFILES\tSIZE\tSHARE",
+		msgAnalyzeObjectHeader: "OBJECT\tSIZE\tLAST MODIFIED",
+		msgAnalyzeNoExt:        "(no extension)",
+		msgAnalyzeAgeHeader:    "AGE\tFILES\tSIZE\tSHARE\t",
+		msgRunAgeBucket:        "Age %s: %d files, %.2f MB (%.1f%%)",
+		msgRunPrefix:           "Prefix %s: scanned %d files (%.2f MB), matched %d (%.2f MB), deleted %d (%.2f MB)",
+		msgSyslogFailed:        "Failed to connect to syslog server: %v",
+		msgSyslogBadNetwork:    "Unsupported syslog network: %s",
+		msgSyslogBadFacility:   "Unsupported syslog facility: %s",
+		msgSentryFailed:        "Timed out reporting to Sentry",
+		msgSentryRunErrors:     "%[2]d errors while cleaning bucket %[1]s",
+		msgDeleteRetry:         "Failed to delete %s (attempt %d/%d), retrying in %v: %v",
+		msgVerifyRetry:         "Failed to stat %s before delete (attempt %d/%d), retrying in %v: %v",
+		msgVerifyFailed:        "Failed to stat %s before delete, skipping: %v",
+		msgVerifyGone:          "%s no longer exists, skipping",
+		msgVerifyChanged:       "%s was overwritten after listing, skipping (size %d -> %d, last modified %v -> %v)",
+		msgVerifyUnreplicated:  "%s has not been replicated yet (replication status %s), skipping",
+		msgRecheckStart:        "verifying bucket %s: %d deleted objects, %d kept objects",
+		msgRecheckFailed:       "Failed to stat %s during verification: %v",
+		msgRecheckExists:       "verification: deleted object still exists: %s",
+		msgRecheckMissing:      "verification: kept object is missing: %s",
+		msgRecheckDone:         "verification of bucket %s finished: %d deleted objects still exist, %d kept objects are missing, %d objects could not be checked",
+		msgPlanUploaded:        "uploaded the planned deletion list (%d objects) to bucket %s: %s",
+		msgPlanFailed:          "failed to save the planned deletion list, not deleting: %v",
+		msgKillSwitchObject:    "kill switch: sentinel object %[2]s exists in bucket %[1]s, stopping cleanup",
+		msgKillSwitchTag:       "kill switch: bucket %s is tagged %s, stopping cleanup",
+		msgKillSwitchFailed:    "failed to check the kill switch of bucket %s: %v",
+		msgKillSwitchBadTag:    "killSwitch.tag must be in key=value form: %s",
+		msgPluginFailed:        "filter plugin failed for %s, keeping it: %v",
+		msgPluginTagsFailed:    "failed to get tags of %s, keeping it: %v",
+		msgPluginBoth:          "only one of filterPlugin.command and filterPlugin.url can be set",
+		msgHookBoth:            "only one of %[1]s.command and %[1]s.url can be set",
+		msgHookPreFailed:       "pre-delete hook failed, keeping batch of %d files: %v",
+		msgHookVetoed:          "pre-delete hook vetoed batch of %d files: %s",
+		msgHookPostFailed:      "post-delete hook failed for batch of %d deleted files: %v",
+		msgApprovalWaiting:     "Deleting %[2]d files (%[3].2f MB) from bucket %[1]s requires approval, waiting up to %[4]v",
+		msgApprovalApproved:    "Deletion from bucket %s approved %s",
+		msgApprovalDenied:      "deletion from bucket %s denied, nothing deleted: %s",
+		msgApprovalTimeout:     "deletion from bucket %s not approved within %v, nothing deleted",
+		msgApprovalFailed:      "approval for bucket %s failed, nothing deleted: %v",
+		msgApprovalPollFailed:  "failed to poll approval status for bucket %s, retrying: %v",
+		msgWatchStart:          "subscribed to object-created notifications of bucket %s with %d watch rules",
+		msgWatchFailed:         "bucket notifications for %s interrupted, resubscribing in %v: %v",
+		msgWatchMatch:          "new file %s matches watch rule %s, size: %.2f MB, deleting after %v",
+		msgWatchKillSwitch:     "%s, not deleting new file %s",
+		msgWatchRuleEmpty:      "watch.rules[%d] sets none of prefix, suffixes or minSize and would delete every new file",
+		msgWatchNoSupport:      "cluster %s uses storage type %s, which does not support watch.rules; only MinIO provides bucket notifications",
+		msgEventsFailed:        "failed to publish deletion events for %d deleted files: %v",
+		msgEventsBoth:          "only one of events.kafka and events.nats may be set",
+		msgEventsNoTopic:       "%s is not set, deletion events cannot be published",
+		msgGRPCListen:          "gRPC control service listening on %s",
+		msgGRPCExit:            "gRPC control service exited: %v",
+		msgGRPCFailed:          "gRPC call %s failed: %s",
+		msgGRPCCertPair:        "daemon.grpc.certFile and daemon.grpc.keyFile must be set together",
+		msgGRPCNoAuth:          "daemon.grpc.addr requires daemon.grpc.token or daemon.grpc.clientCAFile, the control service cannot run unauthenticated",
+		msgGRPCClientCANoTLS:   "daemon.grpc.clientCAFile requires daemon.grpc.certFile and daemon.grpc.keyFile",
+		msgGRPCBadClientCA:     "daemon.grpc.clientCAFile %s contains no valid PEM certificate",
+		msgControlBusy:         "the maximum number of concurrent runs is in progress, start again after one finishes",
+		msgControlStarted:      "started run %s (trigger: %s)",
+		msgControlNoRun:        "run %q not found or expired",
+		msgControlNotRunning:   "run %s has already finished",
+		msgControlCanceled:     "cancellation requested for run %s",
+		msgControlNoBucket:     "bucket %s is not a cleanup target",
+		msgControlNoCluster:    "bucket %s must be written as <cluster name>/<bucket>",
+		msgJobsListen:          "Jobs API listening on %s",
+		msgJobsExit:            "Jobs API stopped: %v",
+		msgJobsUnauthorized:    "invalid or missing bearer token",
+		msgJobsBadSpec:         "invalid job spec",
+		msgJobsNoBucket:        "the job must specify a bucket",
+		msgJobsAmbiguous:       "bucket %s exists on several clusters, specify cluster",
+		msgJobsQueueFull:       "team %s already has %d queued jobs, try again later",
+		msgJobsSubmitted:       "Team %[2]s submitted job %[1]s for bucket %[3]s",
+		msgJobsNoToken:         "daemon.api.tenants needs at least one team when daemon.api.addr is set",
+		msgJobsTokenEmpty:      "daemon.api.tenants[%d] must set both team and token",
+		msgJobsTeamDuplicate:   "team %s appears more than once in daemon.api.tenants",
+		msgJobsCertPair:        "daemon.api.certFile and daemon.api.keyFile must be set together",
+		msgJobsTenantCreds:     "daemon.api.tenants[%d] must set both the access key ID and the secret access key",
+		msgJobsTenantNoCreds:   "daemon.api.tenants[%d] must set the team's own access keys, jobs do not use the credentials configured for the bucket",
+		msgJobsNoBuckets:       "daemon.api.tenants[%d] must list the buckets the team may clean in buckets",
+		msgJobsForbidden:       "team %s is not allowed to clean bucket %s",
+		msgPresetUnknown:       "unknown preset %s, valid values: %s",
+		msgHarborGCRunning:     "Harbor garbage collection job %d is %s, skipping cleanup until it finishes",
+		msgHarborGCFailed:      "failed to query the Harbor garbage collection status, skipping cleanup: %v",
+		msgWatchPresetKept:     "Watch skipped %s: kept by preset %s",
+		msgWatchQueueFull:      "Watch skipped %s: %d new files are already waiting to be deleted",
+		msgGroupsPerObject:     "%s cannot be used with rules that set groupDepth or partition or with the velero preset: it keeps objects one by one at delete time, which would delete only part of a group",
+		msgWatchApproval:       "watch.rules cannot be combined with approval or canary: watch deletes new files one by one and cannot wait for approval or delete canary objects first",
+		msgPresetReady:         "Preset %s is ready for bucket %s",
+		msgCILayoutInvalid:     "presets.%s.layout is invalid: %v",
+		msgCIIndexFailed:       "failed to list the builds in bucket %s, skipping cleanup: %v",
+		msgGroupIndexFailed:    "failed to list the object groups in bucket %s, skipping cleanup: %v",
+		msgRegistryIndexFailed: "failed to list image manifests in bucket %s, skipping cleanup: %v",
+		msgRegistryReadFailed:  "failed to read image manifest %s, skipping cleanup: %v",
+		msgRegistryNoManifest:  "content %[2]s of image manifest %[1]s does not exist, skipping the manifest",
+		msgRegistryReferences:  "%[2]d image manifests in bucket %[1]s reference %[3]d blobs, which will be kept",
+		msgPartitionInvalid:    "rules[%d].partition %q is invalid: %v",
+		msgPartitionGroupDepth: "rules[%d] cannot set both partition and groupDepth, each partition is already deleted as a whole",
+		msgAliasS3Only:         "endpointAlias of cluster %s can only be used with s3 storage",
+		msgInventoryFailed:     "failed to read inventory %s, skipping cleanup: %v",
+		msgInventoryNone:       "no completed inventory found under %s, skipping cleanup",
+		msgInventoryStale:      "inventory %s was generated at %s, older than inventory.maxAge (%s), skipping cleanup",
+		msgInventoryBucket:     "inventory %s lists source bucket %s, not the cleaned bucket %s, skipping cleanup",
+		msgInventoryFormat:     "inventory %s has format %s, only CSV is supported, skipping cleanup",
+		msgInventorySchema:     "inventory schema %q lacks Key or LastModifiedDate",
+		msgAdminS3Only:         "adminAPI of cluster %s can only be used with MinIO (s3 storage)",
+		msgAdminUsageFailed:    "failed to query data usage of cluster %s via the admin API, cleaning in configured order: %v",
+		msgAdminUsage:          "cleaning buckets of cluster %s by data usage (as of %s), largest first: %s",
+		msgAdminSpaceFailed:    "failed to query free space of cluster %s via the admin API: %v",
+		msgAdminSpace:          "cluster %s free space: %.2f GB before, %.2f GB after (%+.2f GB)",
+		msgDedupPrimary:        "bucket %s uses the dedup preset, presets.dedup.primary must name another bucket on the same cluster",
+		msgDedupNoPrimary:      "buckets of cluster %s use the dedup preset, presets.dedup.primary is required",
+		msgDedupPrimaryTarget:  "primary bucket %s is also a cleanup target of cluster %s, its objects could be removed after their copies",
+		msgDedupIndexFailed:    "failed to list primary bucket %s, skipping cleanup: %v",
+		msgDedupIndexed:        "primary bucket %s has %d objects with %d distinct contents",
+		msgBucketRemoved:       "bucket %s is empty after cleanup and has been removed",
+		msgBucketNotEmpty:      "bucket %s still has objects after cleanup, not removing it",
+		msgBucketTooNew:        "bucket %s was created at %v, which is newer than safety.minObjectAge or unknown, not removing it",
+		msgBucketRemoveFailed:  "failed to remove empty bucket %s: %v",
+		msgBucketRemoveAzure:   "removing containers is not supported for Azure Blob storage",
+		msgBucketRmNoPattern:   "removeEmptyBuckets of cluster %s only removes buckets discovered by bucketPattern, but bucketPattern is not set",
+		msgBucketRmAzure:       "removeEmptyBuckets of cluster %s cannot be used with azure storage",
+		msgBadPeriod:           "Invalid calendar period: %q, expected day, week, month, quarter or year",
+		msgCalendarIndexFailed: "failed to list the latest objects of each calendar period in bucket %s, skipping cleanup: %v",
+		msgCalendarGroups:      "rules[%d] cannot combine keepLastOf or gfs with groupDepth or partition, they keep individual objects",
+		msgTTLFailed:           "failed to read the expiry of %s: %v",
+		msgTTLSkipInvalid:      "keeping %s: %v",
+		msgSkipTTL:             "Skipping file: %s (its own expiry %s is %v, not expired yet)",
+		msgTTLGroups:           "rules[%d] cannot combine ttl with groupDepth or partition, ttl applies to individual objects",
+		msgBadUntil:            "Invalid date: %q, expected a date such as 2025-03-31 or an RFC 3339 time",
+		msgRuleSuspended:       "Rule %s (prefix %q) is suspended until %s, files matching it will not be cleaned up",
+		msgSkipSuspended:       "Skipping file: %s (rule %s is suspended until %s)",
+		msgCostSavings:         "Estimated monthly storage savings: %.2f %s (%.2f GB)",
+		msgCostBoth:            "only one of cost.pricePerGBMonth and cost.tiers can be set",
+		msgCostTierOrder:       "cost.tiers[%d].upTo must be greater than the previous tier; only the last tier may leave it unset",
+		msgAPICalls:            "API requests: %d LIST, %d HEAD, %d GET, %d DELETE, %d other, %d total",
+		msgAPICallsEstimate:    "Estimated API requests for a real run: %d LIST, %d HEAD, %d GET, %d DELETE, %d other, %d total",
+		msgAPICallsCost:        "Request cost: about %.4f %s",
+		msgAbortAPICalls:       "API requests exceeded the cleanup.maxAPICalls limit of %d, aborting the run",
+		msgAPIBudgetEstimate:   "A real run is expected to make %d API requests, exceeding the cleanup.maxAPICalls limit of %d; it would be aborted",
+		msgConfigInvalid:       "configuration has %d problem(s):\n  - %s",
+		msgWatchPresetFailed:   "preset %s is not ready, retrying to subscribe to notifications of bucket %s in %v: %v",
+		msgSkipPlugin:          "Filter plugin kept %s %s",
+		msgAbortMaxErrors:      "%d delete errors exceed the limit of %d, aborting cleanup",
+		msgAbortErrorRate:      "Delete error rate %.1f%% (%d/%d) exceeds the limit of %.1f%%, aborting cleanup",
+		msgCircuitOpen:         "%d consecutive connection or authentication errors, pausing deletes and probing the endpoint every %v: %v",
+		msgCircuitProbeFailed:  "Endpoint probe failed, deletes remain paused: %v",
+		msgCircuitClosed:       "Endpoint recovered, resuming deletes (paused for %v)",
+		msgOpTimeout:           "%s request did not complete within %v",
+		msgRunTimedOut:         "Run exceeded maxRuntime (%v), stopping cleanup and summarizing partial progress",
+		msgDeleteStopped:       "Run stopped, file not deleted: %s",
+		msgBenchStage:          "[bench] %s: %d objects, busy %v, %d goroutines, throughput %.0f objects/s",
+		msgBenchTotal:          "[bench] Total: %d objects in %v, overall throughput %.0f objects/s",
+		msgBenchBottleneck:     "[bench] Slowest stage is %s at %.0f objects/s",
+		msgBenchStageList:      "list",
+		msgBenchStageFilter:    "filter",
+		msgBenchStageDelete:    "delete",
+		msgBenchStageDryRun:    "record (dry-run)",
+		msgTargetNoBucket:      "No bucket configured for cluster %s",
+		msgTargetDuplicate:     "Duplicate cluster name: %s, give each cluster a distinct name",
+		msgTargetNoBucketName:  "A bucket in the buckets list of cluster %s has no name",
+		msgTargetPartialCreds:  "Bucket %[2]s on cluster %[1]s must set both accessKeyId and secretAccessKey",
+		msgTargetClusterCreds:  "Cluster %s must set both accessKeyId and secretAccessKey, or neither to use environment variables, credential files or an IAM role",
+		msgTargetBadPattern:    "Invalid bucketPattern for cluster %s: %v",
+		msgTargetListFailed:    "Failed to list buckets on cluster %s: %v",
+		msgTargetDiscovered:    "%[2]d buckets on cluster %[1]s match bucketPattern: %[3]s",
+		msgTargetNoMatch:       "No bucket on cluster %s matches bucketPattern",
+		msgTargetExcluded:      "Bucket %[2]s on cluster %[1]s is listed in excludeBuckets or protectedBuckets and will not be cleaned",
+		msgBucketProtected:     "Refusing to clean bucket %s: it is listed in excludeBuckets or protectedBuckets",
+		msgTargetRediscover:    "Failed to rediscover buckets, keeping the previous targets: %v",
+		msgTargetPathConflict:  "Report path %s is shared by several targets (%s and %s), use the {cluster} and {bucket} placeholders",
+		msgTargetStart:         "Starting cleanup of bucket %[2]s on cluster %[1]s",
+		msgSafetyScanning:      "Counting the share of objects to be deleted in bucket %s before deleting",
+		msgSafetyPassed:        "Safety check passed: %[2]d of %[3]d objects in bucket %[1]s will be deleted (%.1[4]f%%)",
+		msgSafetyTooMany:       "Refusing to delete: %[2]d of %[3]d objects in bucket %[1]s match the rules (%.1[4]f%%), above the safety.maxDeletePercent limit of %[5]g%%. Check the rules and the system clock, and rerun with -force if this is intended",
+		msgSafetyListFailed:    "Listing bucket %s before deleting failed %d times, the share of objects to delete is unknown so nothing is deleted",
+		msgSafetyShortRule:     "Rule %s keeps objects for %v, shorter than safety.minObjectAge (%v), objects newer than safety.minObjectAge will not be cleaned",
+		msgVersioningDisabled:  "versioning is not enabled on bucket %s, deleted objects cannot be recovered",
+		msgVersioningFailed:    "failed to get the versioning status of bucket %s: %v",
+		msgCanaryStart:         "canary: deleting %[2]d randomly sampled objects from bucket %[1]s first",
+		msgCanaryDone:          "canary deletion in bucket %s finished, deleted %d objects, manifest: %s",
+		msgCanaryDeleted:       "canary deletion in bucket %s finished, deleted %d objects",
+		msgCanaryWaiting:       "waiting %[2]s before deleting the remaining objects in bucket %[1]s, abort the run now to stop",
+		msgCanaryPrompt:        "check the canary deletion results; continue with the remaining objects in bucket %s? [y/N]",
+		msgCanaryFailed:        "canary deletion in bucket %s failed, not deleting the remaining objects: %v",
+		msgCanaryErrors:        "canary deletion in bucket %s had %d errors, not deleting the remaining objects",
+		msgCanaryCancelled:     "not confirmed, not deleting the remaining objects in bucket %s",
+		msgCanaryNoTerminal:    "canary.confirm requires a terminal and cannot be used together with -daemon",
+		msgSTSUnknownType:      "Unsupported STS credential type: %s, expected assumeRole or webIdentity",
+		msgSTSRenewed:          "Obtained temporary STS credentials for cluster %s, valid until %s",
+		msgSTSFailed:           "Failed to obtain temporary STS credentials for cluster %s: %v",
+		msgVaultFailed:         "Failed to read %s from Vault: %v",
+		msgVaultNoField:        "Vault path %s has no field %s",
+		msgVaultNoToken:        "No Vault token configured, set vault.token, vault.tokenFile, vault.kubernetesRole or the VAULT_TOKEN environment variable",
+		msgVaultLoaded:         "Loaded credentials for cluster %s from Vault: %s",
+		msgTLSNoCert:           "No valid PEM certificate found in CA file %s",
+		msgTLSPartialCert:      "tls.certFile and tls.keyFile must be set together",
+		msgTLSInsecure:         "TLS certificate verification is disabled for cluster %s, connections are open to man-in-the-middle attacks; do not use this in production",
+		msgProxyInvalid:        "Invalid proxy address: %s, expected an http://, https://, socks5:// or socks5h:// URL, or direct",
+		msgAddressingInvalid:   "Invalid bucket addressing: %s, expected auto, path or virtualHost",
+		msgStorageInvalid:      "Unsupported storage type: %s, expected s3, azure or gcs",
+		msgGCSNoProject:        "Listing GCS buckets requires a project ID, set gcs.project or GOOGLE_CLOUD_PROJECT",
+		msgAutoTune:            "Concurrency adjusted to %d (average delete latency: %v, error rate: %.1f%%, throttled: %d)",
+		msgReviewTooMany:       "Bucket %[2]s on cluster %[1]s has more than %[3]d objects to clean up, too many to review, skipping the bucket; narrow the rules (e.g. prefix) and review in batches",
+	},
+}
+
+// tr 按本次运行的配置 cfg 中 cleanup.language 设置的语言格式化消息，缺失的翻译回退到中文。
+// cfg 为 nil 或未设置语言时使用命令行程序设置的语言，守护模式、交互式审查等只在命令行程序中使用的功能传入 nil
+func tr(cfg *config.Config, id msgID, args ...any) string {
+	return trLang(language(cfg), id, args...)
+}
+
+// trLang 按语言 lang 格式化消息，供只记录了运行语言、不持有配置的对象使用
+func trLang(lang string, id msgID, args ...any) string {
+	return catalogs.Format(lang, id, args...)
+}
+
+// languageKey 为 context 中保存运行语言的键
+type languageKey struct{}
+
+// withLanguage 返回记录运行语言 lang 的 context，不持有配置的代码（如请求超时的错误）通过它取得语言
+func withLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// contextLanguage 返回 ctx 中记录的运行语言，没有时为空
+func contextLanguage(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
+
+// language 返回 cfg 中 cleanup.language 设置的语言，cfg 为 nil 时为空
+func language(cfg *config.Config) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Cleanup.Language
+}
@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	nats "github.com/nats-io/nats.go"
+)
+
+// natsPublisher 通过 nats.go 客户端发布消息。每批消息发送后等待服务器确认（PING/PONG），
+// 服务器拒绝发布（如没有主题的权限）时视为发布失败。连接断开后不自动重连，下次发布时重新连接
+type natsPublisher struct {
+	cfg     *config.NATSConfig
+	timeout time.Duration
+	conn    *nats.Conn
+}
+
+func newNATSPublisher(cfg *config.NATSConfig, timeout time.Duration) *natsPublisher {
+	return &natsPublisher{cfg: cfg, timeout: timeout}
+}
+
+// options 返回客户端的配置
+func (n *natsPublisher) options() []nats.Option {
+	opts := []nats.Option{
+		nats.Name("minio-cleaner"),
+		nats.Timeout(n.timeout),
+		// 断开期间发布的消息会缓存在客户端，无法确认是否送达，因此不自动重连
+		nats.NoReconnect(),
+		// 服务器的异步错误通过 LastError 检查，不再输出默认的日志
+		nats.ErrorHandler(func(*nats.Conn, *nats.Subscription, error) {}),
+	}
+	if n.cfg.TLS {
+		// 客户端按服务器的主机名设置 ServerName
+		opts = append(opts, nats.Secure(&tls.Config{}))
+	}
+	if n.cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(n.cfg.Username, n.cfg.Password))
+	}
+	if n.cfg.Token != "" {
+		opts = append(opts, nats.Token(n.cfg.Token))
+	}
+	return opts
+}
+
+func (n *natsPublisher) publish(ctx context.Context, msgs []eventMessage) error {
+	if n.conn == nil {
+		conn, err := nats.Connect(n.cfg.URL, n.options()...)
+		if err != nil {
+			return err
+		}
+		n.conn = conn
+	}
+	err := n.send(ctx, msgs)
+	if err != nil {
+		// 连接状态未知，下次发布时重新连接
+		n.close()
+	}
+	return err
+}
+
+func (n *natsPublisher) send(ctx context.Context, msgs []eventMessage) error {
+	for _, m := range msgs {
+		if err := n.conn.Publish(n.cfg.Subject, m.value); err != nil {
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	if err := n.conn.FlushWithContext(ctx); err != nil {
+		return err
+	}
+	// 服务器先处理这批消息再回复 PONG，拒绝发布的错误在确认前已经记录
+	return n.conn.LastError()
+}
+
+func (n *natsPublisher) close() error {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	return nil
+}
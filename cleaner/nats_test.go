@@ -0,0 +1,96 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/nats-io/nats-server/v2/server"
+	nats "github.com/nats-io/nats.go"
+)
+
+// natsTestServer 启动只允许用户 u（密码 p）向 minio.deletions 发布的 NATS 服务器，返回服务器地址
+func natsTestServer(t *testing.T) string {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   -1,
+		NoLog:  true,
+		NoSigs: true,
+		Users: []*server.User{{
+			Username: "u",
+			Password: "p",
+			Permissions: &server.Permissions{
+				Publish:   &server.SubjectPermission{Allow: []string{"minio.deletions"}},
+				Subscribe: &server.SubjectPermission{Allow: []string{">"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	t.Cleanup(s.Shutdown)
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	return s.ClientURL()
+}
+
+func TestNATSPublish(t *testing.T) {
+	msgs := []eventMessage{{key: []byte("b/a"), value: []byte(`{"key":"a"}`)}, {value: []byte{}}}
+	tests := []struct {
+		name     string
+		subject  string
+		password string
+		wantErr  bool
+	}{
+		{name: "ok", subject: "minio.deletions", password: "p"},
+		{name: "authorization violation", subject: "minio.deletions", password: "wrong", wantErr: true},
+		// 服务器拒绝发布时返回错误，而不是只在日志中记录
+		{name: "permissions violation", subject: "other", password: "p", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := natsTestServer(t)
+			sub, err := nats.Connect(url, nats.UserInfo("u", "p"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sub.Close()
+			received, err := sub.SubscribeSync(">")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub.Flush()
+
+			cfg := &config.NATSConfig{URL: url, Subject: tt.subject, Username: "u", Password: tt.password}
+			p := newNATSPublisher(cfg, 5*time.Second)
+			defer p.close()
+			err = p.publish(context.Background(), msgs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("publish() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// 失败后关闭连接，下次发布时重新连接
+			if (p.conn == nil) != tt.wantErr {
+				t.Errorf("publish() left conn = %v", p.conn)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for range msgs {
+				m, err := received.NextMsg(5 * time.Second)
+				if err != nil {
+					t.Fatalf("receive: %v", err)
+				}
+				got = append(got, m.Subject+" "+string(m.Data))
+			}
+			if want := []string{`minio.deletions {"key":"a"}`, "minio.deletions "}; !slices.Equal(got, want) {
+				t.Errorf("published = %q, want %q", got, want)
+			}
+		})
+	}
+}
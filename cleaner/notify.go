@@ -0,0 +1,233 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// notifyHTTPClient 为各类 HTTP 通知共用的客户端
+var notifyHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// maxAttachmentSize 为邮件附件大小上限，超过时只在正文中给出清单路径
+const maxAttachmentSize = 10 * 1024 * 1024
+
+// runOnce 执行一次清理，记录运行历史并发送运行结果通知
+func runOnce(ctx context.Context, t Target, onProgress func(), objects *ObjectOutput) (*report.RunReport, error) {
+	cfg := t.cfg
+	c := &cleaner{cfg: cfg, store: t.store, admin: t.admin, removable: t.removable, created: t.created, approved: t.approved, sharedLimiter: t.limiter, onProgress: onProgress, objects: objects}
+	report, err := c.run(ctx)
+	recordHistory(cfg, report, err)
+	notifyRun(cfg, report, err)
+	return report, err
+}
+
+// runFailed 判断一次运行是否视为失败：运行出错或过程中出现错误
+func runFailed(report *report.RunReport, err error) bool {
+	return err != nil || (report != nil && report.ErrorCount > 0)
+}
+
+// notifyRun 根据配置发送运行结果通知，发送失败只记录日志
+func notifyRun(cfg *config.Config, report *report.RunReport, runErr error) {
+	notifyEmail(cfg, report, runErr)
+	notifyChat(cfg, report, runErr)
+	notifySentry(cfg, report, runErr)
+}
+
+// notifyEmail 发送运行结果邮件
+func notifyEmail(cfg *config.Config, report *report.RunReport, runErr error) {
+	email := &cfg.Notify.Email
+	if email.Host == "" || len(email.To) == 0 {
+		return
+	}
+	failed := runFailed(report, runErr)
+	if !failed && email.OnSuccess != nil && !*email.OnSuccess {
+		return
+	}
+
+	msg, err := buildEmail(cfg, report, runErr)
+	if err == nil {
+		err = sendEmail(email, msg)
+	}
+	if err != nil {
+		slog.Error(tr(cfg, msgEmailFailed, err), "bucket", cfg.Minio.Bucket, "action", "notify", "error", err)
+		return
+	}
+	slog.Info(tr(cfg, msgEmailSent, strings.Join(email.To, ", ")), "bucket", cfg.Minio.Bucket, "action", "notify")
+}
+
+// summaryText 生成运行结果的纯文本摘要，供各类通知使用
+func summaryText(cfg *config.Config, report *report.RunReport, runErr error) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, tr(cfg, msgNotifyBucket, cfg.Minio.Bucket))
+	if report != nil {
+		fmt.Fprintln(&b, tr(cfg, msgNotifyTime, report.StartTime.Format("2006-01-02 15:04:05"), report.EndTime.Format("2006-01-02 15:04:05")))
+		if report.DryRun {
+			fmt.Fprintln(&b, tr(cfg, msgRunDryRun))
+		}
+		fmt.Fprintln(&b, tr(cfg, msgRunFinish, report.TotalFiles, report.ProcessedFiles, report.DeletedFiles, float64(report.DeletedBytes)/1024/1024))
+		fmt.Fprintln(&b, tr(cfg, msgNotifyErrors, report.ErrorCount))
+		if a := report.APICalls; a.Total > 0 {
+			fmt.Fprintln(&b, tr(cfg, msgAPICalls, a.List, a.Head, a.Get, a.Delete, a.Other, a.Total))
+		}
+		if a := report.EstimatedAPICalls; a != nil {
+			fmt.Fprintln(&b, tr(cfg, msgAPICallsEstimate, a.List, a.Head, a.Get, a.Delete, a.Other, a.Total))
+		}
+		if s := report.EstimatedSavings; s != nil {
+			fmt.Fprintln(&b, tr(cfg, msgCostSavings, s.Monthly, s.Currency, float64(s.Bytes)/1024/1024/1024))
+		}
+		if report.FreeSpaceAfter > 0 {
+			fmt.Fprintln(&b, tr(cfg, msgNotifyFreeSpace, float64(report.FreeSpaceBefore)/1024/1024/1024, float64(report.FreeSpaceAfter)/1024/1024/1024))
+		}
+		for _, r := range report.Rules {
+			fmt.Fprintln(&b, tr(cfg, msgNotifyRule, r.Name, r.MatchedFiles, r.DeletedFiles, float64(r.DeletedBytes)/1024/1024))
+		}
+		if report.ManifestFile != "" {
+			fmt.Fprintln(&b, tr(cfg, msgNotifyManifest, report.ManifestFile))
+		}
+	}
+	if runErr != nil {
+		fmt.Fprintln(&b, tr(cfg, msgNotifyRunError, runErr))
+	}
+	return b.String()
+}
+
+// buildEmail 生成 MIME 格式的邮件内容
+func buildEmail(cfg *config.Config, report *report.RunReport, runErr error) ([]byte, error) {
+	email := &cfg.Notify.Email
+
+	subject := tr(cfg, msgEmailSubjectOK, cfg.Minio.Bucket)
+	if runFailed(report, runErr) {
+		subject = tr(cfg, msgEmailSubjectFail, cfg.Minio.Bucket)
+	}
+
+	body := summaryText(cfg, report, runErr)
+	if email.ManifestURL != "" && report != nil && report.ManifestFile != "" {
+		body += tr(cfg, msgNotifyManifestURL, expandReportName(email.ManifestURL, report.StartTime)) + "\n"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", email.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, []byte(body))
+
+	// 附带已删除对象清单
+	if email.AttachManifest && report != nil && report.ManifestFile != "" {
+		if fi, err := os.Stat(report.ManifestFile); err == nil && fi.Size() <= maxAttachmentSize {
+			data, err := os.ReadFile(report.ManifestFile)
+			if err != nil {
+				return nil, err
+			}
+			name := filepath.Base(report.ManifestFile)
+			part, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {"application/octet-stream"},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+			})
+			if err != nil {
+				return nil, err
+			}
+			writeBase64(part, data)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 以每行 76 个字符的格式写入 base64 编码内容
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// sendEmail 通过 SMTP 发送邮件
+func sendEmail(email *config.EmailConfig, msg []byte) error {
+	port := email.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(email.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if email.Username != "" {
+		auth = smtp.PlainAuth("", email.Username, email.Password, email.Host)
+	}
+
+	if !email.UseTLS {
+		return smtp.SendMail(addr, auth, email.From, email.To, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: email.Host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, email.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(email.From); err != nil {
+		return err
+	}
+	for _, to := range email.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,77 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// objectEvent 为 -output jsonl 输出的一行：预览模式下 action 为 match，否则为 delete，删除失败时 error 为失败原因
+type objectEvent struct {
+	Cluster      string    `json:"cluster,omitempty"` // 配置了 clusters 时为集群名称
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	VersionID    string    `json:"versionId,omitempty"`
+	Rule         string    `json:"rule"`
+	Action       string    `json:"action"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// ObjectOutput 将 objectEvent 逐行写入标准输出，或交给回调函数，可被多个目标的工作协程并发调用
+type ObjectOutput struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	fn  func(objectEvent) error
+}
+
+func NewObjectOutput(w io.Writer) *ObjectOutput {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &ObjectOutput{enc: enc}
+}
+
+// newObjectFunc 创建将每个对象交给 fn 的输出，fn 会被并发调用
+func newObjectFunc(fn func(objectEvent) error) *ObjectOutput {
+	return &ObjectOutput{fn: fn}
+}
+
+func (o *ObjectOutput) write(e objectEvent) error {
+	if o.fn != nil {
+		return o.fn(e)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.enc.Encode(e)
+}
+
+// Report 为一次运行全部清理目标的结果，Run 返回该结果，-output json 输出同样的文档
+type Report struct {
+	Runs []TargetReport `json:"runs"` // 每个目标一项，按目标顺序排列
+}
+
+// TargetReport 为一个目标的运行结果：运行报告的全部字段，运行出错时另有 error。
+// 运行在生成报告之前出错（如删除比例超过安全限制）时 RunReport 为 nil
+type TargetReport struct {
+	Cluster string `json:"cluster"`
+	Bucket  string `json:"bucket"`
+	Error   string `json:"error,omitempty"`
+	*report.RunReport
+}
+
+// newReport 按目标顺序汇总各目标的运行结果
+func newReport(results []targetResult) Report {
+	report := Report{Runs: []TargetReport{}}
+	for _, r := range results {
+		out := TargetReport{Cluster: r.cluster, Bucket: r.bucket, RunReport: r.report}
+		if r.err != nil {
+			out.Error = r.err.Error()
+		}
+		report.Runs = append(report.Runs, out)
+	}
+	return report
+}
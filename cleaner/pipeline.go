@@ -0,0 +1,673 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// candidate 是通过规则筛选、等待执行清理的对象
+type candidate struct {
+	obj     minio.ObjectInfo
+	ruleIdx int
+	rule    *rules.Compiled
+	// ttl 为规则配置了 ttl，需要在 enrich 阶段查询对象自带的过期时间后再决定是否清理
+	ttl bool
+	// expired 为对象按规则的 maxAge 已过期，对象没有自带的过期时间时按它判断
+	expired bool
+}
+
+// stageError 是流水线各阶段上报的错误，由错误收集协程统一计数和记录日志
+type stageError struct {
+	msg   string
+	attrs []any
+}
+
+// pipeline 将一次清理拆分为相互独立的阶段，阶段之间通过有界通道连接：
+//
+//	list -> filter -> enrich -> [batch ->] execute
+//
+// 配置了 hooks.preDelete 时在删除前增加 batch 阶段，每批对象执行一次钩子。
+// 每个阶段在输入通道关闭且自身协程全部退出后关闭输出通道，
+// 错误统一发送到 errCh，由单独的协程计数并记录日志
+type pipeline struct {
+	cfg        *config.Config
+	store      objectStore
+	bucket     string
+	startTime  time.Time
+	rules      []*rules.Compiled
+	stats      *runStats
+	manifest   *manifestWriter
+	audit      *auditLog
+	candidates *candidateWriter
+	hooks      *webhookDispatcher
+	approved   map[string]bool
+	onProgress func()
+	objects    *ObjectOutput
+
+	// preset 不为 nil 时代替列举存储桶，只处理这些对象
+	preset []minio.ObjectInfo
+	// samples 收集运行结束后复查的对象，为 nil 时不复查
+	samples *verificationSamples
+	// deletedBatch 收集交给 hooks.postDelete 的已删除对象，为 nil 时不执行
+	deletedBatch *deletedBatch
+	// events 收集发布到 Kafka 或 NATS 的删除事件，为 nil 时不发布
+	events *eventBatch
+
+	// limiter 限制删除请求速率，为 nil 时不限制
+	limiter *rateLimiter
+	// shared 为与其他清理过程共用的删除限速器，预览模式下不使用
+	shared *rateLimiter
+	// tuner 根据删除延迟和错误率调整并发数，为 nil 时使用固定的 workers 个工作协程
+	tuner *autoTuner
+	// bench 在基准模式下统计各阶段的吞吐量，为 nil 时不统计
+	bench *benchStats
+	// breaker 在 endpoint 连续出现连接或认证错误时暂停删除，为 nil 时不启用
+	breaker *circuitBreaker
+
+	errCh chan stageError
+
+	// deleteAttempts 和 deleteErrors 用于判断删除错误是否超过 maxErrors 或 maxErrorRate
+	deleteAttempts int64
+	deleteErrors   int64
+	abortOnce      sync.Once
+	abort          context.CancelCauseFunc
+	// aborted 为错误超过阈值或紧急停止开关打开时中止运行的原因，在 run 返回前设置
+	aborted error
+}
+
+// runStage 启动 n 个协程执行 fn，全部退出后调用 done
+func runStage(n int, fn func(), done func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer ReportPanic()
+			fn()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		done()
+	}()
+}
+
+// fail 上报一个错误
+func (p *pipeline) fail(msg string, attrs ...any) {
+	p.errCh <- stageError{msg: msg, attrs: attrs}
+}
+
+// abortError 表示删除错误超过阈值或紧急停止开关打开导致运行中止
+type abortError struct{ reason string }
+
+func (e *abortError) Error() string { return e.reason }
+
+// abortCause 返回运行中止的原因，未中止时返回 nil
+func abortCause(ctx context.Context) error {
+	var aerr *abortError
+	if errors.As(context.Cause(ctx), &aerr) {
+		return aerr
+	}
+	return nil
+}
+
+// errorRateMinSamples 为按错误率中止运行前至少需要的删除次数，避免少量样本下误判
+const errorRateMinSamples = 100
+
+// recordDeleteResult 统计删除结果，删除错误超过配置的阈值时中止运行
+func (p *pipeline) recordDeleteResult(err error) {
+	attempts := atomic.AddInt64(&p.deleteAttempts, 1)
+	if err == nil {
+		return
+	}
+	failures := atomic.AddInt64(&p.deleteErrors, 1)
+
+	cleanup := &p.cfg.Cleanup
+	var reason string
+	if cleanup.MaxErrors > 0 && failures > int64(cleanup.MaxErrors) {
+		reason = tr(p.cfg, msgAbortMaxErrors, failures, cleanup.MaxErrors)
+	} else if rate := float64(failures) / float64(attempts); cleanup.MaxErrorRate > 0 &&
+		attempts >= errorRateMinSamples && rate > cleanup.MaxErrorRate {
+		reason = tr(p.cfg, msgAbortErrorRate, rate*100, failures, attempts, cleanup.MaxErrorRate*100)
+	}
+	if reason == "" {
+		return
+	}
+	p.stop(reason, "bucket", p.bucket, "action", "abort", "errors", failures, "attempts", attempts)
+}
+
+// stop 以 reason 为原因中止运行，只有第一次调用生效。reason 同时作为错误上报
+func (p *pipeline) stop(reason string, attrs ...any) {
+	p.abortOnce.Do(func() {
+		p.fail(reason, attrs...)
+		p.abort(&abortError{reason})
+	})
+}
+
+// run 执行流水线，直到所有对象处理完成，或删除错误超过阈值而中止
+func (p *pipeline) run(parent context.Context) {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+	p.abort = cancel
+	calls := apiCallsFrom(parent)
+	if calls != nil {
+		calls.setStop(func(reason string) {
+			p.stop(reason, "bucket", p.bucket, "action", "abort", "apiCalls", p.cfg.Cleanup.MaxAPICalls)
+		})
+	}
+
+	workers := p.cfg.Cleanup.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	if qps := p.cfg.Cleanup.MaxDeletesPerSecond; qps > 0 && !p.cfg.Cleanup.DryRun {
+		p.limiter = newRateLimiter(qps)
+	}
+	if p.cfg.Cleanup.AutoTune && !p.cfg.Cleanup.DryRun {
+		p.tuner = newAutoTuner(workers, p.bucket, p.cfg.Cleanup.Language)
+	}
+	if p.cfg.CircuitBreaker.FailureThreshold > 0 && !p.cfg.Cleanup.DryRun {
+		p.breaker = newCircuitBreaker(&p.cfg.CircuitBreaker, p.bucket, p.cfg.Cleanup.Language, func(ctx context.Context) error {
+			return withTimeout(ctx, "stat", p.cfg.Timeouts.Stat, func(ctx context.Context) error {
+				_, err := p.store.bucketExists(ctx, p.bucket)
+				return err
+			})
+		})
+	}
+
+	if p.cfg.Debug.Bench {
+		p.bench = newBenchStats(p.cfg.Cleanup.Listers, workers)
+	}
+
+	p.errCh = make(chan stageError, workers)
+	errDone := make(chan struct{})
+	go func() {
+		defer close(errDone)
+		for e := range p.errCh {
+			p.stats.recordError(e.msg)
+			slog.Error(e.msg, e.attrs...)
+		}
+	}()
+
+	progressStop := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		p.reportProgress(progressStop)
+	}()
+	if p.tuner != nil {
+		go p.tuner.run(progressStop)
+	}
+
+	listed := make(chan minio.ObjectInfo, workers*2)
+	matched := make(chan candidate, workers*2)
+	enriched := make(chan candidate, workers*2)
+	executed := make(chan struct{})
+
+	runStage(1, func() { p.list(ctx, listed) }, func() { close(listed) })
+	runStage(1, func() { p.filter(listed, matched) }, func() { close(matched) })
+	// 过滤插件和对象自带的过期时间逐个查询对象，与删除使用相同的并发数
+	enrichers := 1
+	if p.cfg.FilterPlugin.Enabled() || rules.HasTTL(p.rules) {
+		enrichers = workers
+	}
+	runStage(enrichers, func() { p.enrich(ctx, matched, enriched) }, func() { close(enriched) })
+	batched := enriched
+	if p.cfg.Hooks.PreDelete.Enabled() && !p.cfg.Cleanup.DryRun {
+		batched = make(chan candidate, workers*2)
+		runStage(1, func() { p.batch(ctx, enriched, batched) }, func() { close(batched) })
+	}
+	runStage(workers, func() { p.execute(ctx, batched) }, func() { close(executed) })
+
+	// 紧急停止开关的检查会上报错误，需要在关闭 errCh 之前退出
+	killStop := make(chan struct{})
+	killDone := make(chan struct{})
+	go func() {
+		defer close(killDone)
+		p.watchKillSwitch(ctx, killStop)
+	}()
+
+	<-executed
+	close(killStop)
+	<-killDone
+	if p.deletedBatch != nil {
+		p.flushDeleted(ctx)
+	}
+	if p.events != nil {
+		p.flushEvents(ctx)
+	}
+	// 流水线结束后不能再上报错误，之后超过请求数限制时直接取消运行
+	if calls != nil {
+		calls.setStop(nil)
+	}
+	close(p.errCh)
+	<-errDone
+	close(progressStop)
+	<-progressDone
+	p.aborted = abortCause(ctx)
+	if p.bench != nil {
+		p.bench.log(p.bucket, p.cfg.Cleanup.Language, time.Since(p.startTime), p.cfg.Cleanup.DryRun)
+	}
+}
+
+// reportProgress 在终端上绘制进度条，否则定期输出进度日志，直到 stop 被关闭
+func (p *pipeline) reportProgress(stop <-chan struct{}) {
+	rateStart := time.Now()
+	interval := 10 * time.Second
+	if console != nil {
+		interval = 500 * time.Millisecond
+		defer console.clearStatus()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		snap := p.stats.snapshot()
+
+		if console != nil {
+			console.setStatus(renderProgressBar(snap, time.Since(rateStart), p.cfg.Cleanup.Language))
+			continue
+		}
+
+		if !snap.listed {
+			slog.Info(tr(p.cfg, msgRunProgressListing,
+				snap.processed, snap.total, snap.deleted, float64(snap.deletedSize)/1024/1024),
+				"bucket", p.bucket, "action", "progress", "processed", snap.processed, "discovered", snap.total, "deleted", snap.deleted, "size", snap.deletedSize)
+		} else if snap.total > 0 {
+			progress := float64(snap.processed) / float64(snap.total) * 100
+			slog.Info(tr(p.cfg, msgRunProgress,
+				progress, snap.processed, snap.total, snap.deleted, float64(snap.deletedSize)/1024/1024),
+				"bucket", p.bucket, "action", "progress", "processed", snap.processed, "total", snap.total, "deleted", snap.deleted, "size", snap.deletedSize)
+		}
+	}
+}
+
+// processed 记录一个对象已处理完成（跳过、预览或删除）
+func (p *pipeline) processed(obj minio.ObjectInfo) {
+	stats := p.stats
+	atomic.AddInt64(&stats.processedFiles, 1)
+	atomic.AddInt64(&stats.processedSize, obj.Size)
+	if stats.ages != nil {
+		stats.recordAge(obj, p.startTime)
+	}
+	stats.countPrefix(obj.Key, func(b *report.PrefixBreakdown) {
+		b.ScannedFiles++
+		b.ScannedBytes += obj.Size
+	})
+	if p.onProgress != nil {
+		p.onProgress()
+	}
+}
+
+// list 列举存储桶中的所有对象，边列举边交给下一阶段，总数随列举进度累计。
+// 设置了 preset 时不列举存储桶，只交出 preset 中的对象
+func (p *pipeline) list(ctx context.Context, out chan<- minio.ObjectInfo) {
+	start := time.Now()
+	if p.preset != nil {
+		for _, obj := range p.preset {
+			if ctx.Err() != nil {
+				break
+			}
+			atomic.AddInt64(&p.stats.totalFiles, 1)
+			out <- obj
+		}
+	} else {
+		p.listAll(ctx, out)
+	}
+	atomic.StoreInt32(&p.stats.listed, 1)
+	p.bench.listDone(time.Since(start))
+	count := atomic.LoadInt64(&p.stats.totalFiles)
+	slog.Info(tr(p.cfg, msgRunTotal, count), "bucket", p.bucket, "action", "count", "total", count)
+}
+
+// listAll 分页列举存储桶中的所有对象，配置了 inventory 时读取清单
+func (p *pipeline) listAll(ctx context.Context, out chan<- minio.ObjectInfo) {
+	listSource(ctx, p.cfg, p.store, func(obj minio.ObjectInfo) {
+		atomic.AddInt64(&p.stats.totalFiles, 1)
+		sent := time.Now()
+		out <- obj
+		p.bench.listed(time.Since(sent))
+	}, func(err error) {
+		// 运行中止或超过 maxRuntime 导致的列举取消不再重复记为错误
+		if abortCause(ctx) != nil || runtimeExceeded(ctx) {
+			return
+		}
+		p.fail(tr(p.cfg, msgListError, err), "bucket", p.bucket, "action", "list", "error", err)
+	})
+}
+
+// filter 按规则筛选对象，不满足条件的对象直接记为已处理
+func (p *pipeline) filter(in <-chan minio.ObjectInfo, out chan<- candidate) {
+	for obj := range in {
+		start := time.Now()
+		c, ok := p.match(obj)
+		p.bench.filtered(time.Since(start))
+		if ok {
+			out <- c
+		} else if p.samples != nil {
+			p.samples.kept.offer(obj)
+		}
+	}
+}
+
+// match 查找对象适用的规则并检查大小和时间，不满足条件时记为已处理并返回 false
+func (p *pipeline) match(obj minio.ObjectInfo) (candidate, bool) {
+	bucket := p.bucket
+	c, reason := selectCandidate(p.rules, obj)
+	rule := c.rule
+	if reason == "" && p.approved != nil && !p.approved[obj.Key] {
+		reason = skipNotApproved
+	}
+	var keptBy *filterStats
+	if reason == "" {
+		if keptBy = keptByFilter(p.stats.filters, obj); keptBy != nil {
+			reason = skipFilter
+		}
+	}
+	switch reason {
+	case rules.SkipNoRule:
+		slog.Debug(tr(p.cfg, msgSkipNoRule, obj.Key),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "action", "skip", "reason", reason)
+	case rules.SkipMinSize:
+		slog.Debug(tr(p.cfg, msgSkipMinSize, obj.Key, obj.Size, rule.MinSize),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", reason)
+	case rules.SkipMaxAge:
+		if rule.Layout != nil {
+			dir, t, _ := rule.PartitionOf(obj.Key)
+			slog.Debug(tr(p.cfg, msgSkipPartitionAge, obj.Key, dir, t),
+				"bucket", bucket, "key", obj.Key, "size", obj.Size, "partition", dir, "rule", rule.Name, "action", "skip", "reason", reason)
+			break
+		}
+		slog.Debug(tr(p.cfg, msgSkipMaxAge, obj.Key, obj.LastModified),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "lastModified", obj.LastModified, "rule", rule.Name, "action", "skip", "reason", reason)
+	case rules.SkipMinObjectAge:
+		slog.Debug(tr(p.cfg, msgSkipMinObjectAge, obj.Key, obj.LastModified),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "lastModified", obj.LastModified, "rule", rule.Name, "action", "skip", "reason", reason)
+	case rules.SkipNoPartition:
+		slog.Debug(tr(p.cfg, msgSkipNoPartition, obj.Key, rule.Partition),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", reason)
+	case rules.SkipSuspended:
+		slog.Debug(tr(p.cfg, msgSkipSuspended, obj.Key, rule.Name, rule.SuspendUntil),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", reason)
+	case skipNotApproved:
+		slog.Debug(tr(p.cfg, msgSkipNotApproved, obj.Key),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", reason)
+	case skipFilter:
+		slog.Debug(tr(p.cfg, msgSkipFilter, obj.Key, keptBy.name),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", reason, "filter", keptBy.name)
+	default:
+		return c, true
+	}
+	p.processed(obj)
+	return candidate{}, false
+}
+
+// selectCandidate 查找对象适用的规则并检查大小和时间，返回待清理的对象，不清理时同时返回原因。
+// 对象自带的过期时间可能早于或晚于 maxAge，规则配置了 ttl 时未到 maxAge 的对象也作为待清理对象，
+// 由 enricher 查询过期时间后决定，但仍不清理比 safety.minObjectAge 新的对象
+func selectCandidate(compiled []*rules.Compiled, obj minio.ObjectInfo) (candidate, string) {
+	idx, rule, reason := rules.Select(compiled, obj)
+	expired := reason == ""
+	ttl := rule != nil && rule.TTL.Enabled() && (expired || reason == rules.SkipMaxAge && !obj.LastModified.After(rule.Floor))
+	if ttl {
+		reason = ""
+	}
+	return candidate{obj: obj, ruleIdx: idx, rule: rule, ttl: ttl, expired: expired}, reason
+}
+
+// enrich 为待清理对象补充列举结果之外的信息（如标签、元数据）。规则配置了 ttl 时按对象自带的过期时间判断，
+// 配置了过滤插件时由插件决定是否删除，决定保留或判断失败的对象记为已处理，不再交给下一阶段
+func (p *pipeline) enrich(ctx context.Context, in <-chan candidate, out chan<- candidate) {
+	e := &enricher{
+		cfg:        p.cfg,
+		store:      p.store,
+		bucket:     p.bucket,
+		now:        p.startTime,
+		fail:       p.fail,
+		ttlKept:    &p.stats.ttlKept,
+		pluginKept: &p.stats.pluginKept,
+	}
+	for c := range in {
+		if ctx.Err() != nil || e.remove(ctx, c) {
+			out <- c
+			continue
+		}
+		if p.samples != nil {
+			p.samples.kept.offer(c.obj)
+		}
+		p.processed(c.obj)
+	}
+}
+
+// enricher 逐个查询对象自带的过期时间或交给过滤插件，决定符合规则的对象是否清理。
+// 流水线和删除前的统计（scanCandidates）使用相同的判断，计划删除清单和删除比例与实际删除的对象一致
+type enricher struct {
+	cfg    *config.Config
+	store  objectStore
+	bucket string
+	now    time.Time
+	// fail 上报查询失败等错误，出错的对象保留
+	fail func(msg string, attrs ...any)
+	// ttlKept 和 pluginKept 为对象自带的过期时间未到、过滤插件决定保留的对象数，为 nil 时不统计
+	ttlKept, pluginKept *int64
+}
+
+// needed 返回是否需要逐个查询对象
+func (e *enricher) needed(compiled []*rules.Compiled) bool {
+	return e.cfg.FilterPlugin.Enabled() || rules.HasTTL(compiled)
+}
+
+// remove 返回对象是否清理：规则配置了 ttl 时按对象自带的过期时间判断，配置了过滤插件时再由插件决定
+func (e *enricher) remove(ctx context.Context, c candidate) bool {
+	if c.ttl && !e.checkTTL(ctx, c) {
+		return false
+	}
+	return !e.cfg.FilterPlugin.Enabled() || e.askPlugin(ctx, c)
+}
+
+// count 增加保留的对象数
+func (e *enricher) count(n *int64) {
+	if n != nil {
+		atomic.AddInt64(n, 1)
+	}
+}
+
+// execute 记录待清理对象，非预览模式下执行删除
+func (p *pipeline) execute(ctx context.Context, in <-chan candidate) {
+	for c := range in {
+		// 运行已中止时丢弃剩余对象
+		if ctx.Err() != nil {
+			continue
+		}
+		if p.tuner != nil {
+			p.tuner.gate.acquire()
+		}
+		start := time.Now()
+		p.executeOne(ctx, c)
+		p.bench.executed(time.Since(start))
+		if p.tuner != nil {
+			p.tuner.gate.release()
+		}
+		p.processed(c.obj)
+	}
+}
+
+func (p *pipeline) executeOne(ctx context.Context, c candidate) {
+	cfg, stats, bucket := p.cfg, p.stats, p.bucket
+	obj, rule := c.obj, c.rule
+
+	// 记录要删除的文件
+	rs := stats.rules[c.ruleIdx]
+	atomic.AddInt64(&rs.matchedFiles, 1)
+	atomic.AddInt64(&rs.matchedSize, obj.Size)
+	stats.countPrefix(obj.Key, func(b *report.PrefixBreakdown) {
+		b.MatchedFiles++
+		b.MatchedBytes += obj.Size
+	})
+	if cfg.Cleanup.DryRun {
+		stats.recordReclaimed(obj, rule.Name)
+		if p.candidates != nil {
+			err := p.candidates.write(candidateEntry{
+				Key:          obj.Key,
+				Size:         obj.Size,
+				LastModified: obj.LastModified,
+				Rule:         rule.Name,
+			})
+			if err != nil {
+				p.fail(tr(cfg, msgCandidatesFailed, err), "bucket", bucket, "key", obj.Key, "action", "candidates", "error", err)
+			}
+		}
+		p.output(obj, rule.Name, "match", nil)
+	}
+	slog.Info(tr(cfg, msgMatch,
+		obj.Key, float64(obj.Size)/1024/1024, obj.LastModified),
+		"bucket", bucket, "key", obj.Key, "size", obj.Size, "lastModified", obj.LastModified, "rule", rule.Name, "action", "match")
+
+	// 如果是预览模式，不执行删除
+	if cfg.Cleanup.DryRun {
+		return
+	}
+	if cfg.VerifiesBeforeDelete() || cfg.Cleanup.SkipUnreplicated {
+		current, ok := p.verify(ctx, obj, rule.Name)
+		if !ok {
+			return
+		}
+		// S3 列举结果不含版本 ID，使用删除前查询到的当前版本
+		if obj.VersionID == "" {
+			obj.VersionID = current.VersionID
+		}
+	}
+
+	var deleteMarker string
+	err := withRetry(ctx, &cfg.Retry, func() error {
+		if p.breaker != nil {
+			if err := p.breaker.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if p.limiter != nil {
+			if err := p.limiter.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if p.shared != nil {
+			if err := p.shared.wait(ctx); err != nil {
+				return err
+			}
+		}
+		start := time.Now()
+		err := withTimeout(ctx, "delete", cfg.Timeouts.Delete, func(ctx context.Context) error {
+			var err error
+			deleteMarker, err = p.store.remove(ctx, bucket, obj.Key)
+			return err
+		})
+		if p.tuner != nil {
+			p.tuner.observe(time.Since(start), err)
+		}
+		if p.breaker != nil {
+			p.breaker.record(ctx, err)
+		}
+		return err
+	}, func(attempt int, delay time.Duration, err error) {
+		atomic.AddInt64(&stats.retries, 1)
+		slog.Warn(tr(cfg, msgDeleteRetry, obj.Key, attempt, cfg.Retry.MaxAttempts, delay.Round(time.Millisecond), err),
+			"bucket", bucket, "key", obj.Key, "rule", rule.Name, "action", "retry", "attempt", attempt, "delay", delay, "error", err)
+	})
+	// 运行超过 maxRuntime 时未完成的删除不计为错误，对象留待下次运行处理
+	if err != nil && runtimeExceeded(ctx) {
+		slog.Debug(tr(cfg, msgDeleteStopped, obj.Key), "bucket", bucket, "key", obj.Key, "rule", rule.Name, "action", "timeout")
+		return
+	}
+	p.recordDeleteResult(err)
+	if p.audit != nil {
+		if aerr := p.audit.deleted(obj, rule.Name, err); aerr != nil {
+			p.fail(tr(cfg, msgAuditWriteFailed, aerr), "bucket", bucket, "key", obj.Key, "action", "audit", "error", aerr)
+		}
+	}
+	if err != nil {
+		atomic.AddInt64(&stats.failedFiles, 1)
+		atomic.AddInt64(&stats.failedSize, obj.Size)
+		p.fail(tr(cfg, msgDeleteFailed, obj.Key, err),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "delete", "error", err)
+		p.output(obj, rule.Name, "delete", err)
+		return
+	}
+
+	slog.Info(tr(cfg, msgDeleteOK, obj.Key),
+		"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "delete")
+	atomic.AddInt64(&stats.deletedFiles, 1)
+	atomic.AddInt64(&stats.deletedSize, obj.Size)
+	atomic.AddInt64(&rs.deletedFiles, 1)
+	atomic.AddInt64(&rs.deletedSize, obj.Size)
+	stats.countPrefix(obj.Key, func(b *report.PrefixBreakdown) {
+		b.DeletedFiles++
+		b.DeletedBytes += obj.Size
+	})
+	stats.recordReclaimed(obj, rule.Name)
+	if p.samples != nil {
+		p.samples.deleted.offer(obj)
+	}
+
+	entry := manifestEntry{
+		Bucket:       bucket,
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		VersionID:    obj.VersionID,
+		DeleteMarker: deleteMarker,
+		Rule:         rule.Name,
+		DeletedAt:    time.Now(),
+	}
+	if p.hooks != nil {
+		p.hooks.deleted(entry)
+	}
+	if p.deletedBatch != nil {
+		p.deleted(ctx, entry)
+	}
+	if p.events != nil {
+		p.publishDeleted(ctx, entry)
+	}
+	if p.manifest != nil {
+		if err := p.manifest.write(entry); err != nil {
+			p.fail(tr(cfg, msgManifestWriteFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "action", "manifest", "error", err)
+		}
+	}
+	p.output(obj, rule.Name, "delete", nil)
+}
+
+// output 在 -output jsonl 时输出一个待清理或已删除的对象
+func (p *pipeline) output(obj minio.ObjectInfo, rule, action string, err error) {
+	if p.objects == nil {
+		return
+	}
+	e := objectEvent{
+		Bucket:       p.bucket,
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		VersionID:    obj.VersionID,
+		Rule:         rule,
+		Action:       action,
+		Cluster:      p.cluster(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if werr := p.objects.write(e); werr != nil {
+		p.fail(tr(p.cfg, msgOutputFailed, werr), "bucket", p.bucket, "key", obj.Key, "action", "output", "error", werr)
+	}
+}
@@ -0,0 +1,102 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// planEntry 为计划删除清单中的一个对象
+type planEntry struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag,omitempty"`
+	Rule         string    `json:"rule"`
+}
+
+// planWriter 在删除前将计划删除的对象以 JSONL 格式写入临时文件，全部写完后上传到 report.planObject，可被并发调用
+type planWriter struct {
+	mu    sync.Mutex
+	f     *os.File
+	w     *bufio.Writer
+	enc   *json.Encoder
+	count int64
+	err   error
+}
+
+// openPlan 创建计划删除清单的临时文件，未配置 report.planObject 或预览模式下返回 nil
+func openPlan(cfg *config.Config) (*planWriter, error) {
+	if cfg.Report.PlanObject == "" || cfg.Cleanup.DryRun {
+		return nil, nil
+	}
+	f, err := os.CreateTemp("", "minio-cleaner-plan-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &planWriter{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func newPlanEntry(bucket string, obj minio.ObjectInfo, rule string) planEntry {
+	return planEntry{
+		Bucket:       bucket,
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		ETag:         obj.ETag,
+		Rule:         rule,
+	}
+}
+
+func (p *planWriter) add(bucket string, obj minio.ObjectInfo, rule string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return
+	}
+	p.err = p.enc.Encode(newPlanEntry(bucket, obj, rule))
+	p.count++
+}
+
+// upload 将计划删除清单上传到 report.planBucket（为空时为清理的存储桶）中的 report.planObject，返回上传的存储桶和对象名
+func (p *planWriter) upload(ctx context.Context, cfg *config.Config, store objectStore, start time.Time) (string, string, error) {
+	if p.err != nil {
+		return "", "", p.err
+	}
+	if err := p.w.Flush(); err != nil {
+		return "", "", err
+	}
+	size, err := p.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := p.f.Seek(0, io.SeekStart); err != nil {
+		return "", "", err
+	}
+	bucket := cfg.Report.PlanBucket
+	if bucket == "" {
+		bucket = cfg.Minio.Bucket
+	}
+	key := expandReportName(cfg.Report.PlanObject, start)
+	if err := store.put(ctx, bucket, key, p.f, size, "application/x-ndjson"); err != nil {
+		return "", "", err
+	}
+	slog.Info(tr(cfg, msgPlanUploaded, p.count, bucket, key), "bucket", cfg.Minio.Bucket, "key", key, "action", "plan", "files", p.count)
+	return bucket, key, nil
+}
+
+// close 关闭并删除临时文件
+func (p *planWriter) close() {
+	p.f.Close()
+	os.Remove(p.f.Name())
+}
@@ -0,0 +1,171 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// skipPlugin 为对象符合规则，但过滤插件决定保留
+const skipPlugin = "plugin"
+
+// pluginRequest 为发送给过滤插件的对象信息
+type pluginRequest struct {
+	Bucket       string            `json:"bucket"`
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"lastModified"`
+	ETag         string            `json:"etag,omitempty"`
+	Rule         string            `json:"rule"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// pluginResponse 为过滤插件的判断结果：delete 为 false 时保留对象，reason 记录在日志中
+type pluginResponse struct {
+	Delete *bool  `json:"delete"`
+	Reason string `json:"reason"`
+}
+
+// askPlugin 将对象交给过滤插件判断，返回是否删除及插件给出的原因。
+// 插件执行失败、超时或返回的结果无法解析时返回错误，调用方应保留对象
+func askPlugin(ctx context.Context, f *config.FilterPluginConfig, req pluginRequest) (bool, string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.Timeout)
+	defer cancel()
+
+	var out []byte
+	if len(f.Command) > 0 {
+		out, err = runPluginCommand(ctx, f.Command, body)
+	} else {
+		out, err = requestJSON(ctx, http.MethodPost, f.URL, f.Headers, body)
+	}
+	if err != nil {
+		return false, "", err
+	}
+	var resp pluginResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return false, "", fmt.Errorf("%w: %q", err, truncate(string(out), 200))
+	}
+	if resp.Delete == nil {
+		return false, "", fmt.Errorf("missing \"delete\" in %q", truncate(string(out), 200))
+	}
+	return *resp.Delete, resp.Reason, nil
+}
+
+// truncate 截断过长的插件输出，用于错误信息
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// runPluginCommand 执行插件命令，对象信息写入标准输入，返回标准输出。命令以非零状态退出时返回错误，包含标准错误的内容
+func runPluginCommand(ctx context.Context, command []string, body []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, truncate(msg, 200))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// httpStatusError 表示插件或钩子服务返回了非 2xx 状态码，body 为截断后的响应内容
+type httpStatusError struct {
+	code int
+	body string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("HTTP %d", e.code)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.body)
+}
+
+// requestJSON 向插件、钩子或审批服务发送请求，body 不为 nil 时作为 JSON 请求体，返回响应内容。
+// 状态码不是 2xx 时返回 *httpStatusError
+func requestJSON(ctx context.Context, method, url string, headers map[string]string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &httpStatusError{code: resp.StatusCode, body: truncate(strings.TrimSpace(string(out)), 200)}
+	}
+	return out, nil
+}
+
+// askPlugin 将待清理对象交给过滤插件判断，返回是否删除。插件出错时记为错误并保留对象
+func (e *enricher) askPlugin(ctx context.Context, c candidate) bool {
+	plugin, bucket, obj := &e.cfg.FilterPlugin, e.bucket, c.obj
+	req := pluginRequest{
+		Bucket:       bucket,
+		Key:          obj.Key,
+		Size:         obj.Size,
+		LastModified: obj.LastModified,
+		ETag:         obj.ETag,
+		Rule:         c.rule.Name,
+	}
+	if plugin.Tags {
+		err := withTimeout(ctx, "stat", e.cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			req.Tags, err = e.store.objectTags(ctx, bucket, obj.Key)
+			return err
+		})
+		if err != nil {
+			e.fail(tr(e.cfg, msgPluginTagsFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "rule", c.rule.Name, "action", "plugin", "error", err)
+			return false
+		}
+	}
+	remove, reason, err := askPlugin(ctx, plugin, req)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.fail(tr(e.cfg, msgPluginFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "rule", c.rule.Name, "action", "plugin", "error", err)
+		}
+		return false
+	}
+	if !remove {
+		e.count(e.pluginKept)
+		slog.Debug(tr(e.cfg, msgSkipPlugin, obj.Key, reason),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", c.rule.Name, "action", "skip", "reason", skipPlugin, "detail", reason)
+	}
+	return remove
+}
@@ -0,0 +1,119 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// appPreset 为针对某个应用存储布局的集成预设
+type appPreset interface {
+	// rules 返回未配置 rules 时使用的默认清理规则，代替 cleanup.maxAge 和 cleanup.minSize
+	rules() []rules.Rule
+	// prepare 在清理前检查应用的状态并读取判断所需的信息，不适合清理时返回错误。
+	// now 为本次运行判断清理规则的时间
+	prepare(ctx context.Context, store objectStore, now time.Time) error
+	// keep 返回对象是否必须保留：不在应用的可清理路径中，或应用仍可能使用该对象。
+	// 未调用 prepare 时同样可以调用，无法判断的对象应保留。会被多个协程并发调用
+	keep(obj rules.ObjectInfo) bool
+}
+
+// appPresets 为可以通过 preset 选择的集成预设
+var appPresets = map[string]func(cfg *config.Config) appPreset{
+	"harbor":   newHarborPreset,
+	"gitlab":   newGitLabPreset,
+	"jenkins":  newJenkinsPreset,
+	"velero":   newVeleroPreset,
+	"registry": newRegistryPreset,
+	"dedup":    newDedupPreset,
+}
+
+// presetNames 返回可选的预设名称，用于提示
+func presetNames() string {
+	return strings.Join(slices.Sorted(maps.Keys(appPresets)), ", ")
+}
+
+// newAppPreset 返回目标选择的集成预设，未选择时返回 nil
+func newAppPreset(cfg *config.Config) (appPreset, error) {
+	name := cfg.Minio.Preset
+	if name == "" {
+		return nil, nil
+	}
+	newPreset, ok := appPresets[name]
+	if !ok {
+		return nil, errors.New(tr(cfg, msgPresetUnknown, name, presetNames()))
+	}
+	return newPreset(cfg), nil
+}
+
+// presetRules 返回目标选择的集成预设提供的默认规则，未选择预设时返回 nil
+func presetRules(cfg *config.Config) []rules.Rule {
+	p, _ := newAppPreset(cfg)
+	if p == nil {
+		return nil
+	}
+	return p.rules()
+}
+
+// runFilters 返回一次运行使用的过滤器，now 为本次运行判断清理规则的时间，approved 不为 nil 时为交互式审查或 tui 中批准清理的对象：
+//   - 选择了集成预设时先由预设检查应用状态并读取判断所需的信息，预设决定保留的对象记在名为 preset:<名称> 的过滤器统计中
+//   - 有规则设置了 groupDepth 或 partition 时先列举存储桶判断各组是否整组符合规则，不完整的组中的对象记在名为 group 的过滤器统计中。
+//     预设、未批准和 Config.Filters 保留的对象同样使所在的组不完整
+//   - 有规则设置了 keepLastOf 或 gfs 时先列举存储桶找出各日历周期中最新的对象，这些对象记在名为 calendar 的过滤器统计中
+//
+// 以上过滤器排在 Config.Filters 之前
+func runFilters(ctx context.Context, cfg *config.Config, store objectStore, now time.Time, approved map[string]bool) ([]rules.Filter, error) {
+	var filters []rules.Filter
+	p, err := newAppPreset(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		if err := p.prepare(ctx, store, now); err != nil {
+			return nil, err
+		}
+		slog.Debug(tr(cfg, msgPresetReady, cfg.Minio.Preset, cfg.Minio.Bucket), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "preset")
+		filters = append(filters, rules.Named("preset:"+cfg.Minio.Preset, rules.FilterFunc(func(obj rules.ObjectInfo) rules.Decision {
+			if p.keep(obj) {
+				return rules.Keep
+			}
+			return rules.Abstain
+		})))
+	}
+	if rs := effectiveRules(cfg); rules.HasGroups(rs) {
+		keepers := append(slices.Clone(filters), cfg.Filters...)
+		keep := func(obj rules.ObjectInfo) bool {
+			return approved != nil && !approved[obj.Key] || filtersKeep(keepers, obj)
+		}
+		groups, err := buildGroupIndex(ctx, cfg, store, now, rules.Groups(rules.Compile(rs, now, cfg.Safety.MinObjectAge)), keep)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, rules.Named("group", rules.FilterFunc(func(obj rules.ObjectInfo) rules.Decision {
+			if !groups.complete(obj.Key) {
+				return rules.Keep
+			}
+			return rules.Abstain
+		})))
+	}
+	if rs := effectiveRules(cfg); rules.HasCalendar(rs) {
+		calendar, err := buildCalendarIndex(ctx, cfg, store, now)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, rules.Named("calendar", rules.FilterFunc(func(obj rules.ObjectInfo) rules.Decision {
+			if calendar.keep[obj.Key] {
+				return rules.Keep
+			}
+			return rules.Abstain
+		})))
+	}
+	return append(filters, cfg.Filters...), nil
+}
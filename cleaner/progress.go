@@ -0,0 +1,109 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// terminal 协调日志输出与进度条在同一终端上的显示，
+// 写入日志前先清除进度条所在行，写入后再重新绘制进度条
+type terminal struct {
+	mu     sync.Mutex
+	w      io.Writer
+	status string
+}
+
+// console 在标准输出为终端时非空，用于绘制进度条
+var console *terminal
+
+// IsTerminal 判断 f 是否为终端。/dev/null 等字符设备不是终端，cron、systemd 和容器中的标准输入通常是 /dev/null
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status != "" {
+		io.WriteString(t.w, "\r\033[K")
+	}
+	n, err := t.w.Write(p)
+	if t.status != "" {
+		io.WriteString(t.w, t.status)
+	}
+	return n, err
+}
+
+// setStatus 在当前行绘制状态信息，覆盖之前的内容
+func (t *terminal) setStatus(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.w, "\r\033[K"+s)
+	t.status = s
+}
+
+// clearStatus 清除状态行
+func (t *terminal) clearStatus() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status != "" {
+		io.WriteString(t.w, "\r\033[K")
+		t.status = ""
+	}
+}
+
+const progressBarWidth = 30
+
+// renderProgressBar 根据当前计数生成进度条文本，包括百分比、吞吐量和预计剩余时间。
+// 列举尚未完成时总数未知，只显示已处理数、已发现数和吞吐量。lang 为文本使用的语言
+func renderProgressBar(stats runStatsSnapshot, elapsed time.Duration, lang string) string {
+	seconds := elapsed.Seconds()
+	var objRate, mbRate float64
+	if seconds > 0 {
+		objRate = float64(stats.processed) / seconds
+		mbRate = float64(stats.processedSize) / 1024 / 1024 / seconds
+	}
+
+	if !stats.listed {
+		return trLang(lang, msgProgressListing, stats.processed, stats.total, objRate, mbRate,
+			stats.deleted, float64(stats.deletedSize)/1024/1024)
+	}
+
+	var ratio float64 = 1
+	if stats.total > 0 {
+		ratio = float64(stats.processed) / float64(stats.total)
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+
+	eta := "--:--:--"
+	if objRate > 0 {
+		remaining := time.Duration(float64(stats.total-stats.processed) / objRate * float64(time.Second))
+		eta = formatClock(remaining)
+	}
+
+	return fmt.Sprintf("[%s] %s", bar, trLang(lang, msgProgressBar,
+		ratio*100, stats.processed, stats.total, objRate, mbRate,
+		stats.deleted, float64(stats.deletedSize)/1024/1024, eta))
+}
+
+// formatClock 将时长格式化为 时:分:秒
+func formatClock(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	s := int64(d.Round(time.Second) / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,61 @@
+package cleaner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter 是令牌桶限速器，令牌以 rate 个/秒的速度补充，桶容量为 burst
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter 创建每秒最多放行 qps 次的限速器，桶容量为一秒的配额（至少为 1），
+// 允许短时突发但平均速率不超过限制
+func newRateLimiter(qps float64) *rateLimiter {
+	burst := float64(int(qps))
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: qps, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait 取得一个令牌，令牌不足时等待，ctx 被取消时返回其错误
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return l.waitN(ctx, 1)
+}
+
+// waitN 取得 n 个令牌，n 可以超过桶容量，此时按欠缺的数量等待
+func (l *rateLimiter) waitN(ctx context.Context, n float64) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// 先预占令牌，令牌数为负时按欠缺的数量计算等待时间
+	l.tokens -= n
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,65 @@
+package cleaner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		qps   float64
+		calls int
+		// n 为每次取得的令牌数，为 0 时按 1 个
+		n float64
+		// 桶容量为一秒的配额，超出的令牌按 qps 补充
+		minElapsed, maxElapsed time.Duration
+	}{
+		{name: "within burst", qps: 100, calls: 100, maxElapsed: 50 * time.Millisecond},
+		{name: "over burst", qps: 100, calls: 120, minElapsed: 150 * time.Millisecond, maxElapsed: 400 * time.Millisecond},
+		// qps 小于 1 时桶容量为 1，按 qps 补充
+		{name: "fractional qps", qps: 2.5, calls: 3, minElapsed: 300 * time.Millisecond, maxElapsed: 700 * time.Millisecond},
+		// 一次取得的令牌数可以超过桶容量，按欠缺的数量等待
+		{name: "more than burst", qps: 10, calls: 1, n: 13, minElapsed: 250 * time.Millisecond, maxElapsed: 600 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.qps)
+			n := max(tt.n, 1)
+			start := time.Now()
+			var wg sync.WaitGroup
+			for range tt.calls {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := l.waitN(context.Background(), n); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			elapsed := time.Since(start)
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("elapsed = %v, want between %v and %v", elapsed, tt.minElapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCanceled(t *testing.T) {
+	l := newRateLimiter(1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("wait() returned after %v, want it to return when ctx ends", elapsed)
+	}
+}
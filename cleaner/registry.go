@@ -0,0 +1,298 @@
+package cleaner
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+const (
+	// registryReposRoot 为 registry 在根目录下存放仓库元数据（清单和镜像层的链接、未完成的上传）的路径
+	registryReposRoot = "docker/registry/v2/repositories/"
+	// registryManifestLimit 为读取镜像清单的最大字节数，与 registry 接受的清单大小上限相同
+	registryManifestLimit = 4 << 20
+	// registryBlobsRoot 为 registry 在根目录下存放镜像层和清单内容的路径
+	registryBlobsRoot = "docker/registry/v2/blobs/"
+)
+
+var (
+	// registryBlob 匹配 blob 的内容 <算法>/<摘要前两位>/<摘要>/data
+	registryBlob = regexp.MustCompile(`^([a-z0-9]+)/[0-9a-f]{2}/([0-9a-f]+)/data$`)
+	// registryManifestLink 匹配仓库中镜像清单的链接：
+	// <仓库>/_manifests/revisions/<算法>/<摘要>/link 和 <仓库>/_manifests/tags/<标签>/index/<算法>/<摘要>/link
+	registryManifestLink = regexp.MustCompile(`/_manifests/(?:revisions|tags/[^/]+/index)/([a-z0-9]+)/([0-9a-f]+)/link$`)
+	// registryLayerLink 匹配仓库中镜像层的链接 <仓库>/_layers/<算法>/<摘要>/link。推送镜像时先上传镜像层并创建链接，
+	// 最后才上传清单，只有链接的镜像层可能属于正在推送的镜像
+	registryLayerLink = regexp.MustCompile(`/_layers/([a-z0-9]+)/([0-9a-f]+)/link$`)
+)
+
+type registryPreset struct {
+	cfg    *config.Config
+	preset *config.RegistryPresetConfig
+	// repos 和 blobs 为仓库元数据和 blob 所在的前缀
+	repos, blobs string
+
+	// referenced 为被镜像清单或仓库的镜像层链接引用的 blob 摘要，由 prepare 生成，之后只读；为 nil 时保留所有 blob
+	referenced *keySet
+	// uploadsBefore 为本次运行中未完成的上传可以清理的时间，由 prepare 按运行的时间计算；为零值时保留所有上传
+	uploadsBefore time.Time
+}
+
+func newRegistryPreset(cfg *config.Config) appPreset {
+	r := &cfg.Presets.Registry
+	root := strings.Trim(r.RootDirectory, "/")
+	if root != "" {
+		root += "/"
+	}
+	return &registryPreset{cfg: cfg, preset: r, repos: root + registryReposRoot, blobs: root + registryBlobsRoot}
+}
+
+func (r *registryPreset) rules() []rules.Rule {
+	rs := []rules.Rule{{Name: "registry-uploads", Prefix: r.repos, MaxAge: r.preset.UploadMaxAge}}
+	if r.preset.CheckReferences {
+		rs = append(rs, rules.Rule{Name: "registry-blobs", Prefix: r.blobs, MaxAge: r.preset.BlobMaxAge})
+	}
+	return rs
+}
+
+// keep 放行超过 uploadMaxAge 的未完成上传；开启 checkReferences 时还放行未被引用的 blob
+func (r *registryPreset) keep(obj rules.ObjectInfo) bool {
+	if strings.HasPrefix(obj.Key, r.repos) {
+		return keepUpload(obj, r.repos, r.uploadsBefore)
+	}
+	digest, ok := r.blobDigest(obj.Key)
+	if !ok || r.referenced == nil {
+		return true
+	}
+	found, err := r.referenced.has(digest)
+	return err != nil || found
+}
+
+// keepUpload 返回 repos 下的对象是否必须保留：只有 <仓库>/_uploads/ 下修改时间不晚于 before 的对象可以清理，
+// before 为零值时一律保留
+func keepUpload(obj rules.ObjectInfo, repos string, before time.Time) bool {
+	repo, ok := strings.CutPrefix(obj.Key, repos)
+	if !ok || !strings.Contains(repo, "/_uploads/") || before.IsZero() {
+		return true
+	}
+	return obj.LastModified.After(before)
+}
+
+// blobDigest 返回 blob 内容对象的摘要，如 sha256:<摘要>
+func (r *registryPreset) blobDigest(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, r.blobs)
+	if !ok {
+		return "", false
+	}
+	m := registryBlob.FindStringSubmatch(rest)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + ":" + m[2], true
+}
+
+// blobKey 返回摘要对应的 blob 内容对象名
+func (r *registryPreset) blobKey(digest string) (string, bool) {
+	alg, hex, ok := strings.Cut(digest, ":")
+	if !ok || len(hex) < 2 {
+		return "", false
+	}
+	return r.blobs + alg + "/" + hex[:2] + "/" + hex + "/data", true
+}
+
+// registryManifest 为镜像清单中引用其他 blob 的字段，兼容 Docker schema1、schema2 和 OCI 的清单及清单列表
+type registryManifest struct {
+	Config    *registryDescriptor  `json:"config"`
+	Layers    []registryDescriptor `json:"layers"`
+	Blobs     []registryDescriptor `json:"blobs"`
+	Manifests []registryDescriptor `json:"manifests"`
+	Subject   *registryDescriptor  `json:"subject"`
+	FSLayers  []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers"`
+}
+
+type registryDescriptor struct {
+	Digest string `json:"digest"`
+}
+
+// prepare 按 now 计算未完成的上传可以清理的时间。开启 checkReferences 时按 registry 垃圾回收的标记方式找出被引用的 blob：
+// 列举各仓库的清单链接，读取清单并记录其引用的镜像层和配置，清单列表中的子清单同样读取；
+// 与垃圾回收不同，仓库 _layers 中有链接的镜像层同样视为被引用，避免删除正在推送、清单尚未上传的镜像的镜像层。
+// 列举或读取出错时不清理，避免删除仍被引用的镜像层。
+// 被引用的 blob 和待读取的清单数量随镜像数增长，超出内存上限后写入临时文件
+func (r *registryPreset) prepare(ctx context.Context, store objectStore, now time.Time) error {
+	r.uploadsBefore = r.preset.UploadMaxAge.Before(now)
+	if !r.preset.CheckReferences {
+		return nil
+	}
+	bucket := r.cfg.Minio.Bucket
+	indexFailed := func(err error) error {
+		return errors.New(tr(r.cfg, msgRegistryIndexFailed, bucket, err))
+	}
+	referenced := newKeySet()
+	pending, err := newKeyQueue()
+	if err != nil {
+		return indexFailed(err)
+	}
+	var listErr error
+	listPrefix(ctx, store, bucket, r.repos, r.cfg.Timeouts.List, func(obj minio.ObjectInfo) {
+		if m := registryLayerLink.FindStringSubmatch(obj.Key); m != nil {
+			referenced.add(m[1] + ":" + m[2])
+			return
+		}
+		m := registryManifestLink.FindStringSubmatch(obj.Key)
+		if m == nil {
+			return
+		}
+		digest := m[1] + ":" + m[2]
+		found, err := referenced.has(digest)
+		if err != nil {
+			listErr = err
+			return
+		}
+		if !found {
+			referenced.add(digest)
+			pending.push(digest)
+		}
+	}, func(err error) {
+		listErr = err
+	})
+	if err := ctx.Err(); err != nil {
+		pending.close()
+		return err
+	}
+	if listErr != nil {
+		pending.close()
+		return indexFailed(listErr)
+	}
+	var manifests int64
+	for pending.n > 0 {
+		children, err := r.readManifests(ctx, store, pending, referenced)
+		manifests += pending.n
+		pending.close()
+		if err != nil {
+			return err
+		}
+		pending = children
+	}
+	pending.close()
+	if err := referenced.seal(); err != nil {
+		return indexFailed(err)
+	}
+	slog.Info(tr(r.cfg, msgRegistryReferences, bucket, manifests, referenced.len()), "cluster", r.cfg.Minio.Name, "bucket", bucket, "action", "preset")
+	r.referenced = referenced
+	return nil
+}
+
+// readManifests 由 cleanup.workers 个协程并发读取清单，将引用的 blob 记入 referenced，
+// 返回清单列表中尚未读取的子清单
+func (r *registryPreset) readManifests(ctx context.Context, store objectStore, digests *keyQueue, referenced *keySet) (*keyQueue, error) {
+	children, err := newKeyQueue()
+	if err != nil {
+		return nil, errors.New(tr(r.cfg, msgRegistryIndexFailed, r.cfg.Minio.Bucket, err))
+	}
+	var mu sync.Mutex
+	var firstErr error
+	mark := func(digest string, manifest bool) {
+		if digest == "" {
+			return
+		}
+		found, err := referenced.has(digest)
+		if err != nil {
+			firstErr = cmp.Or(firstErr, err)
+			return
+		}
+		if found {
+			return
+		}
+		referenced.add(digest)
+		if manifest {
+			children.push(digest)
+		}
+	}
+	ch := make(chan string)
+	var wg sync.WaitGroup
+	for range max(r.cfg.Cleanup.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for digest := range ch {
+				m, err := r.readManifest(ctx, store, digest)
+				mu.Lock()
+				switch {
+				case err != nil && firstErr == nil:
+					firstErr = err
+				case m != nil:
+					if m.Config != nil {
+						mark(m.Config.Digest, false)
+					}
+					if m.Subject != nil {
+						mark(m.Subject.Digest, true)
+					}
+					for _, d := range m.Layers {
+						mark(d.Digest, false)
+					}
+					for _, d := range m.Blobs {
+						mark(d.Digest, false)
+					}
+					for _, l := range m.FSLayers {
+						mark(l.BlobSum, false)
+					}
+					for _, d := range m.Manifests {
+						mark(d.Digest, true)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	readErr := digests.each(func(digest string) {
+		select {
+		case ch <- digest:
+		case <-ctx.Done():
+		}
+	})
+	close(ch)
+	wg.Wait()
+	err = cmp.Or(ctx.Err(), firstErr)
+	if readErr != nil && err == nil {
+		err = errors.New(tr(r.cfg, msgRegistryIndexFailed, r.cfg.Minio.Bucket, readErr))
+	}
+	if err != nil {
+		children.close()
+		return nil, err
+	}
+	return children, nil
+}
+
+// readManifest 读取并解析一个清单。清单的 blob 已不存在时镜像本身已无法拉取，记录警告后跳过
+func (r *registryPreset) readManifest(ctx context.Context, store objectStore, digest string) (*registryManifest, error) {
+	key, ok := r.blobKey(digest)
+	if !ok {
+		return nil, errors.New(tr(r.cfg, msgRegistryReadFailed, digest, errors.New("invalid digest")))
+	}
+	data, err := readObject(ctx, store, r.cfg.Minio.Bucket, key, registryManifestLimit)
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		slog.Warn(tr(r.cfg, msgRegistryNoManifest, digest, key), "cluster", r.cfg.Minio.Name, "bucket", r.cfg.Minio.Bucket, "key", key, "action", "preset")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New(tr(r.cfg, msgRegistryReadFailed, digest, err))
+	}
+	var m registryManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.New(tr(r.cfg, msgRegistryReadFailed, digest, err))
+	}
+	return &m, nil
+}
@@ -0,0 +1,162 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// contentStore 在 memStore 的基础上支持读取对象内容，contents 中没有的对象返回 NoSuchKey
+type contentStore struct {
+	memStore
+	contents map[string]string
+	// openErr 不为 nil 时读取任何对象都返回该错误
+	openErr error
+}
+
+func (s *contentStore) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	if s.openErr != nil {
+		return nil, s.openErr
+	}
+	data, ok := s.contents[key]
+	if !ok {
+		return nil, minio.ErrorResponse{Code: "NoSuchKey", BucketName: bucket, Key: key}
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+// registryStore 返回根目录为 root 的 registry 存储：
+// 标签 latest 指向清单列表 index，index 包含清单 image，image 引用配置 config 和镜像层 layer；
+// 仓库 pushing 只有镜像层链接 pushed；orphan 未被引用；清单链接 gone 的 blob 已不存在
+func registryStore(root string, now time.Time) *contentStore {
+	repos, blobs := root+registryReposRoot, root+registryBlobsRoot
+	blob := func(hex string) string { return blobs + "sha256/" + hex[:2] + "/" + hex + "/data" }
+	contents := map[string]string{
+		blob("aa01"): `{"manifests":[{"digest":"sha256:bb02"}]}`,
+		blob("bb02"): `{"config":{"digest":"sha256:cc03"},"layers":[{"digest":"sha256:dd04"}]}`,
+		blob("cc03"): "config",
+		blob("dd04"): "layer",
+		blob("ee05"): "pushed",
+		blob("ff06"): "orphan",
+	}
+	var objects []minio.ObjectInfo
+	for _, key := range []string{
+		repos + "app/_manifests/tags/latest/index/sha256/aa01/link",
+		repos + "app/_manifests/revisions/sha256/aa01/link",
+		repos + "app/_manifests/revisions/sha256/0907/link",
+		repos + "pushing/_layers/sha256/ee05/link",
+	} {
+		objects = append(objects, testObject(key, now, 71))
+	}
+	for key, data := range contents {
+		objects = append(objects, testObject(key, now, int64(len(data))))
+	}
+	return &contentStore{memStore: memStore{objects: objects}, contents: contents}
+}
+
+func TestRegistryKeep(t *testing.T) {
+	now := time.Now()
+	for _, root := range []string{"", "/registry/"} {
+		prefix := strings.Trim(root, "/")
+		if prefix != "" {
+			prefix += "/"
+		}
+		repos, blobs := prefix+registryReposRoot, prefix+registryBlobsRoot
+		tests := []struct {
+			name  string
+			key   string
+			age   time.Duration
+			check bool
+			want  bool
+		}{
+			{name: "stale upload", key: repos + "app/_uploads/u1/data", age: 8 * rules.Day, want: false},
+			{name: "recent upload", key: repos + "app/_uploads/u2/data", age: 6 * rules.Day, want: true},
+			{name: "manifest link", key: repos + "app/_manifests/revisions/sha256/aa01/link", age: 30 * rules.Day, want: true},
+			// 未开启 checkReferences 时 blobs 目录一律保留
+			{name: "orphan unchecked", key: blobs + "sha256/ff/ff06/data", age: 30 * rules.Day, want: true},
+			{name: "orphan", key: blobs + "sha256/ff/ff06/data", age: 30 * rules.Day, check: true, want: false},
+			{name: "manifest list", key: blobs + "sha256/aa/aa01/data", check: true, want: true},
+			{name: "child manifest", key: blobs + "sha256/bb/bb02/data", check: true, want: true},
+			{name: "image config", key: blobs + "sha256/cc/cc03/data", check: true, want: true},
+			{name: "image layer", key: blobs + "sha256/dd/dd04/data", check: true, want: true},
+			// 正在推送的镜像的清单尚未上传，只有镜像层链接
+			{name: "linked layer", key: blobs + "sha256/ee/ee05/data", check: true, want: true},
+			{name: "not a blob", key: blobs + "sha256/ff/ff06/other", check: true, want: true},
+			{name: "outside registry", key: "other/file", check: true, want: true},
+		}
+		for _, tt := range tests {
+			t.Run(prefix+tt.name, func(t *testing.T) {
+				t.Setenv("TMPDIR", t.TempDir())
+				cfg := testConfig()
+				cfg.Presets.Registry = config.RegistryPresetConfig{
+					RootDirectory:   root,
+					UploadMaxAge:    rules.Retention(7 * rules.Day),
+					CheckReferences: tt.check,
+				}
+				r := newRegistryPreset(cfg).(*registryPreset)
+				if err := r.prepare(context.Background(), registryStore(prefix, now), now); err != nil {
+					t.Fatal(err)
+				}
+				if r.referenced != nil {
+					defer r.referenced.close()
+				}
+				obj := testObject(tt.key, now.Add(-tt.age), 1)
+				if got := r.keep(obj); got != tt.want {
+					t.Errorf("keep(%s) = %v, want %v", tt.key, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestRegistryPrepareErrors(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		setup func(s *contentStore)
+		want  string
+	}{
+		{
+			name:  "list failed",
+			setup: func(s *contentStore) { s.listErr = errors.New("boom") },
+			want:  tr(nil, msgRegistryIndexFailed, "b", errors.New("boom")),
+		},
+		{
+			name:  "read failed",
+			setup: func(s *contentStore) { s.openErr = errors.New("boom") },
+			want:  tr(nil, msgRegistryReadFailed, "sha256:aa01", errors.New("boom")),
+		},
+		{
+			name: "invalid manifest",
+			setup: func(s *contentStore) {
+				s.contents[registryBlobsRoot+"sha256/aa/aa01/data"] = "{"
+			},
+			want: "sha256:aa01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			cfg := testConfig()
+			cfg.Presets.Registry = config.RegistryPresetConfig{UploadMaxAge: rules.Retention(7 * rules.Day), CheckReferences: true}
+			r := newRegistryPreset(cfg).(*registryPreset)
+			store := registryStore("", now)
+			tt.setup(store)
+			err := r.prepare(context.Background(), store, now)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("prepare() = %v, want %q", err, tt.want)
+			}
+			// 没有引用索引时保留所有 blob
+			if !r.keep(testObject(registryBlobsRoot+"sha256/ff/ff06/data", now.Add(-30*rules.Day), 1)) {
+				t.Error("keep() = false without an index, want true")
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package cleaner
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// objectHeap 是按对象大小排序的小顶堆，用于保留最大的若干个对象
+type objectHeap []report.Object
+
+func (h objectHeap) Len() int { return len(h) }
+
+func (h objectHeap) Less(i, j int) bool { return h[i].Size < h[j].Size }
+
+func (h objectHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *objectHeap) Push(x any) { *h = append(*h, x.(report.Object)) }
+
+func (h *objectHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// offer 加入一个对象，堆中超过 limit 个对象时淘汰最小的一个
+func (h *objectHeap) offer(o report.Object, limit int) {
+	if h.Len() < limit {
+		heap.Push(h, o)
+		return
+	}
+	if o.Size > (*h)[0].Size {
+		(*h)[0] = o
+		heap.Fix(h, 0)
+	}
+}
+
+// sorted 返回按大小从大到小排列的对象列表
+func (h objectHeap) sorted() []report.Object {
+	out := make([]report.Object, len(h))
+	copy(out, h)
+	sort.Slice(out, func(i, j int) bool { return out[i].Size > out[j].Size })
+	return out
+}
+
+// maxTopPrefixes 为报告中列出的前缀条数上限
+const maxTopPrefixes = 20
+
+// topPrefix 返回对象所在的顶级前缀（含末尾的 /），位于根目录的对象返回空字符串
+func topPrefix(key string) string {
+	return prefixAt(key, 1)
+}
+
+// prefixAt 返回对象所在目录的前 depth 层前缀（含末尾的 /），目录层级不足时返回对象所在的目录
+func prefixAt(key string, depth int) string {
+	end := 0
+	for i := 0; i < depth; i++ {
+		j := strings.Index(key[end:], "/")
+		if j < 0 {
+			break
+		}
+		end += j + 1
+	}
+	return key[:end]
+}
+
+// maxTrackedPrefixes 为按前缀或扩展名分组统计时单独记录的分组数上限，
+// 超出后新出现的分组合并计入 otherGroup，保证内存占用与存储桶中的对象数无关
+const maxTrackedPrefixes = 10000
+
+// otherGroup 为超出 maxTrackedPrefixes 后合并计入的分组名。真实前缀为空或以 / 结尾，不会与之冲突
+const otherGroup = "(other)"
+
+// groupEntry 返回 m 中 name 对应的分组，不存在时创建；分组数达到上限时返回 otherGroup
+func groupEntry[T any](m map[string]*T, name string) *T {
+	if e := m[name]; e != nil {
+		return e
+	}
+	if len(m) >= maxTrackedPrefixes {
+		name = otherGroup
+		if e := m[name]; e != nil {
+			return e
+		}
+	}
+	e := new(T)
+	m[name] = e
+	return e
+}
+
+func newRunReport(cfg *config.Config, rules []*rules.Compiled, stats *runStats, start, end time.Time) *report.RunReport {
+	rep := &report.RunReport{
+		StartTime:      start,
+		EndTime:        end,
+		ConfigHash:     config.Hash(cfg),
+		Bucket:         cfg.Minio.Bucket,
+		DryRun:         cfg.Cleanup.DryRun,
+		TotalFiles:     atomic.LoadInt64(&stats.totalFiles),
+		ProcessedFiles: atomic.LoadInt64(&stats.processedFiles),
+		DeletedFiles:   atomic.LoadInt64(&stats.deletedFiles),
+		DeletedBytes:   atomic.LoadInt64(&stats.deletedSize),
+		FailedFiles:    atomic.LoadInt64(&stats.failedFiles),
+		FailedBytes:    atomic.LoadInt64(&stats.failedSize),
+		ChangedFiles:   atomic.LoadInt64(&stats.changedFiles),
+		Unreplicated:   atomic.LoadInt64(&stats.unreplicated),
+		PluginKept:     atomic.LoadInt64(&stats.pluginKept),
+		TTLKept:        atomic.LoadInt64(&stats.ttlKept),
+		HookVetoed:     atomic.LoadInt64(&stats.hookVetoed),
+		ErrorCount:     atomic.LoadInt64(&stats.errorCount),
+		Retries:        atomic.LoadInt64(&stats.retries),
+		Errors:         []string{},
+	}
+	if len(cfg.Clusters) > 0 {
+		rep.Cluster = cfg.Minio.Name
+	}
+
+	stats.mu.Lock()
+	rep.Errors = append(rep.Errors, stats.errors...)
+	rep.LargestObjects = stats.largest.sorted()
+	rep.TopPrefixes = []report.Prefix{}
+	for prefix, ps := range stats.prefixes {
+		rep.TopPrefixes = append(rep.TopPrefixes, report.Prefix{Prefix: prefix, Files: ps.files, Bytes: ps.bytes})
+	}
+	rep.Prefixes = []report.PrefixBreakdown{}
+	for prefix, pc := range stats.breakdown {
+		b := *pc
+		b.Prefix = prefix
+		rep.Prefixes = append(rep.Prefixes, b)
+	}
+	stats.mu.Unlock()
+
+	sort.Slice(rep.Prefixes, func(i, j int) bool {
+		if rep.Prefixes[i].ScannedBytes != rep.Prefixes[j].ScannedBytes {
+			return rep.Prefixes[i].ScannedBytes > rep.Prefixes[j].ScannedBytes
+		}
+		return rep.Prefixes[i].Prefix < rep.Prefixes[j].Prefix
+	})
+	sort.Slice(rep.TopPrefixes, func(i, j int) bool {
+		return rep.TopPrefixes[i].Bytes > rep.TopPrefixes[j].Bytes
+	})
+	if len(rep.TopPrefixes) > maxTopPrefixes {
+		rep.TopPrefixes = rep.TopPrefixes[:maxTopPrefixes]
+	}
+
+	for i, a := range stats.ages {
+		rep.AgeHistogram = append(rep.AgeHistogram, report.Age{
+			Age:   ageBuckets[i].label,
+			Files: atomic.LoadInt64(&a.files),
+			Bytes: atomic.LoadInt64(&a.bytes),
+		})
+	}
+
+	for i, r := range rules {
+		rs := stats.rules[i]
+		rep.Rules = append(rep.Rules, report.Rule{
+			Name:         r.Name,
+			Prefix:       r.Prefix,
+			MatchedFiles: atomic.LoadInt64(&rs.matchedFiles),
+			MatchedBytes: atomic.LoadInt64(&rs.matchedSize),
+			DeletedFiles: atomic.LoadInt64(&rs.deletedFiles),
+			DeletedBytes: atomic.LoadInt64(&rs.deletedSize),
+		})
+	}
+	for _, f := range stats.filters {
+		rep.Filters = append(rep.Filters, report.Filter{
+			Name:      f.name,
+			Evaluated: atomic.LoadInt64(&f.evaluated),
+			KeptFiles: atomic.LoadInt64(&f.kept),
+			KeptBytes: atomic.LoadInt64(&f.keptBytes),
+		})
+	}
+	return rep
+}
+
+// logPrefixBreakdown 逐个前缀输出扫描、匹配和删除统计
+func logPrefixBreakdown(cfg *config.Config, prefixes []report.PrefixBreakdown) {
+	bucket := cfg.Minio.Bucket
+	for _, p := range prefixes {
+		name := p.Prefix
+		if name == "" {
+			name = "/"
+		}
+		slog.Info(tr(cfg, msgRunPrefix, name, p.ScannedFiles, float64(p.ScannedBytes)/1024/1024,
+			p.MatchedFiles, float64(p.MatchedBytes)/1024/1024, p.DeletedFiles, float64(p.DeletedBytes)/1024/1024),
+			"bucket", bucket, "action", "prefixSummary", "prefix", p.Prefix,
+			"scanned", p.ScannedFiles, "scannedSize", p.ScannedBytes,
+			"matched", p.MatchedFiles, "matchedSize", p.MatchedBytes,
+			"deleted", p.DeletedFiles, "size", p.DeletedBytes)
+	}
+}
+
+// logAgeHistogram 逐个年龄区间输出文件数、字节数及其占比
+func logAgeHistogram(cfg *config.Config, ages []report.Age) {
+	bucket := cfg.Minio.Bucket
+	var total int64
+	for _, a := range ages {
+		total += a.Bytes
+	}
+	for _, a := range ages {
+		var share float64
+		if total > 0 {
+			share = float64(a.Bytes) / float64(total) * 100
+		}
+		slog.Info(tr(cfg, msgRunAgeBucket, a.Age, a.Files, float64(a.Bytes)/1024/1024, share),
+			"bucket", bucket, "action", "ageHistogram", "age", a.Age, "files", a.Files, "size", a.Bytes)
+	}
+}
+
+// expandReportName 将路径中的 {time} 替换为运行开始时间
+func expandReportName(name string, start time.Time) string {
+	return strings.ReplaceAll(name, "{time}", start.Format("20060102-150405"))
+}
+
+// writeReports 将汇总报告写入本地文件和/或上传到存储桶
+func (c *cleaner) writeReports(ctx context.Context, rep *report.RunReport) {
+	cfg := c.cfg
+	if cfg.Report.HTMLFile != "" {
+		path := expandReportName(cfg.Report.HTMLFile, rep.StartTime)
+		if err := report.WriteHTML(path, rep, cfg.Cleanup.Language); err != nil {
+			slog.Error(tr(cfg, msgReportFailed, err), "action", "report", "error", err)
+		} else {
+			slog.Info(tr(cfg, msgReportWritten, path), "action", "report")
+		}
+	}
+
+	if cfg.Report.SummaryFile == "" && cfg.Report.SummaryObject == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		slog.Error(tr(cfg, msgReportFailed, err), "action", "report", "error", err)
+		return
+	}
+
+	if cfg.Report.SummaryFile != "" {
+		path := expandReportName(cfg.Report.SummaryFile, rep.StartTime)
+		if err := report.WriteFile(path, data); err != nil {
+			slog.Error(tr(cfg, msgReportFailed, err), "action", "report", "error", err)
+		} else {
+			slog.Info(tr(cfg, msgReportWritten, path), "action", "report")
+		}
+	}
+
+	if cfg.Report.SummaryObject != "" {
+		key := expandReportName(cfg.Report.SummaryObject, rep.StartTime)
+		err := c.store.put(ctx, cfg.Minio.Bucket, key, bytes.NewReader(data), int64(len(data)), "application/json")
+		if err != nil {
+			slog.Error(tr(cfg, msgReportFailed, err), "bucket", cfg.Minio.Bucket, "key", key, "action", "report", "error", err)
+		} else {
+			slog.Info(tr(cfg, msgReportUploaded, cfg.Minio.Bucket, key), "bucket", cfg.Minio.Bucket, "key", key, "action", "report")
+		}
+	}
+}
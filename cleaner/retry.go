@@ -0,0 +1,62 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// transientCodes 为可重试的 S3 错误码
+var transientCodes = map[string]bool{
+	"SlowDown":                   true,
+	"RequestTimeout":             true,
+	"InternalError":              true,
+	"ServiceUnavailable":         true,
+	"XMinioServerNotInitialized": true,
+}
+
+// isTransient 判断错误是否为可通过重试恢复的临时错误：超时、连接中断、5xx 和限流
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	resp := minio.ToErrorResponse(err)
+	if transientCodes[resp.Code] || resp.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetry 执行 op，遇到临时错误时按指数退避重试，每次重试前调用 onRetry。
+// 返回最后一次尝试的错误
+func withRetry(ctx context.Context, r *config.RetryConfig, op func() error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= r.MaxAttempts || !isTransient(err) {
+			return err
+		}
+		delay := r.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, delay, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
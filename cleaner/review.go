@@ -0,0 +1,200 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// skipNotApproved 为交互式审查中未被批准的对象在日志中的跳过原因
+const skipNotApproved = "notApproved"
+
+// maxReviewObjects 为交互式审查和 tui 中一个目标最多列出的待清理对象数。待清理对象需要全部读入内存排序后逐个显示，
+// 批准的对象也保存在内存中；超出时该目标不审查也不清理，内存占用因此不随存储桶中的对象数增长
+const maxReviewObjects = 100000
+
+// reviewGroup 为交互式审查中同一前缀下的待清理对象
+type reviewGroup struct {
+	prefix  string
+	bytes   int64
+	objects []candidateEntry
+}
+
+// reviewSession 记录交互式审查的输入输出和审查结果
+type reviewSession struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// quit 为 true 时操作者已结束审查，其余对象全部跳过
+	quit bool
+	// files 和 bytes 为所有目标中已批准的对象数和字节数
+	files int64
+	bytes int64
+}
+
+// choose 显示提示并读取一个选项，直到输入 options 中的字母为止。输入结束时视为结束审查
+func (r *reviewSession) choose(prompt, options string) byte {
+	for {
+		fmt.Fprint(r.out, prompt+" ")
+		line, err := r.in.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if len(answer) == 1 && strings.Contains(options, answer) {
+			return answer[0]
+		}
+		if err != nil {
+			fmt.Fprintln(r.out)
+			return 'q'
+		}
+	}
+}
+
+// scanReviewCandidates 与 scanCandidates 相同，但待清理对象超过 maxReviewObjects 个时停止列举，返回的 tooMany 为 true
+func scanReviewCandidates(ctx context.Context, cfg *config.Config, store objectStore, emit func(obj minio.ObjectInfo, rule *rules.Compiled)) (failures int64, tooMany bool) {
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var n atomic.Int64
+	_, failures = scanCandidates(scanCtx, cfg, store, func(obj minio.ObjectInfo, rule *rules.Compiled) {
+		if n.Add(1) > maxReviewObjects {
+			cancel()
+			return
+		}
+		emit(obj, rule)
+	})
+	return failures, n.Load() > maxReviewObjects
+}
+
+// collectReviewGroups 列举目标存储桶中的待清理对象，按 report.prefixDepth 层前缀分组并按名称排序，
+// 同时返回列举错误数和待清理对象是否超过 maxReviewObjects 个
+func collectReviewGroups(ctx context.Context, cfg *config.Config, store objectStore) ([]*reviewGroup, int64, bool) {
+	groups := make(map[string]*reviewGroup)
+	var mu sync.Mutex
+	failures, tooMany := scanReviewCandidates(ctx, cfg, store, func(obj minio.ObjectInfo, rule *rules.Compiled) {
+		prefix := prefixAt(obj.Key, cfg.Report.PrefixDepth)
+		mu.Lock()
+		defer mu.Unlock()
+		g := groups[prefix]
+		if g == nil {
+			g = &reviewGroup{prefix: prefix}
+			groups[prefix] = g
+		}
+		g.bytes += obj.Size
+		g.objects = append(g.objects, candidateEntry{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, Rule: rule.Name})
+	})
+	sorted := make([]*reviewGroup, 0, len(groups))
+	for _, g := range groups {
+		slices.SortFunc(g.objects, func(a, b candidateEntry) int { return strings.Compare(a.Key, b.Key) })
+		sorted = append(sorted, g)
+	}
+	slices.SortFunc(sorted, func(a, b *reviewGroup) int { return strings.Compare(a.prefix, b.prefix) })
+	return sorted, failures, tooMany
+}
+
+// ReviewTargets 逐个目标列出待清理对象，按前缀分组由操作者决定整组清理、跳过或逐个审查，
+// 返回带有批准对象集合的目标。审查结束后需再次确认才开始清理，未确认或没有批准任何对象时返回 nil
+func ReviewTargets(ctx context.Context, targets []Target, in io.Reader, out io.Writer) []Target {
+	r := &reviewSession{in: bufio.NewReader(in), out: out}
+	var reviewed []Target
+	for _, t := range targets {
+		if ctx.Err() != nil || r.quit {
+			break
+		}
+		cluster, bucket := t.cfg.Minio.Name, t.cfg.Minio.Bucket
+		fmt.Fprintln(out, tr(t.cfg, msgConfirmScanning, cluster, bucket))
+		groups, failures, tooMany := collectReviewGroups(ctx, t.cfg, t.store)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if tooMany {
+			slog.Warn(tr(t.cfg, msgReviewTooMany, cluster, bucket, maxReviewObjects), "cluster", cluster, "bucket", bucket, "action", "review")
+			continue
+		}
+		if failures > 0 {
+			slog.Warn(tr(t.cfg, msgConfirmListFailed, cluster, bucket, failures), "cluster", cluster, "bucket", bucket, "action", "review")
+			continue
+		}
+		if len(groups) == 0 {
+			fmt.Fprintln(out, tr(t.cfg, msgConfirmNothing, cluster, bucket))
+			continue
+		}
+
+		approved := r.reviewGroups(groups)
+		if len(approved) > 0 {
+			t.approved = approved
+			reviewed = append(reviewed, t)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if r.files == 0 {
+		fmt.Fprintln(out, tr(nil, msgReviewNone))
+		return nil
+	}
+	if r.choose(tr(nil, msgReviewConfirm, r.files, float64(r.bytes)/1024/1024/1024), "yn") != 'y' {
+		fmt.Fprintln(out, tr(nil, msgReviewCancelled))
+		return nil
+	}
+	return reviewed
+}
+
+// reviewGroups 审查一个目标的各组待清理对象，返回批准清理的对象
+func (r *reviewSession) reviewGroups(groups []*reviewGroup) map[string]bool {
+	approved := make(map[string]bool)
+	approve := func(e candidateEntry) {
+		approved[e.Key] = true
+		r.files++
+		r.bytes += e.Size
+	}
+	for i, g := range groups {
+		fmt.Fprintln(r.out, tr(nil, msgReviewGroup, i+1, len(groups), g.prefix, len(g.objects), float64(g.bytes)/1024/1024/1024))
+		switch r.choose(tr(nil, msgReviewGroupPrompt), "asrq") {
+		case 'a':
+			for _, e := range g.objects {
+				approve(e)
+			}
+		case 's':
+		case 'r':
+			r.reviewObjects(g, approve)
+		case 'q':
+			r.quit = true
+		}
+		if r.quit {
+			break
+		}
+	}
+	return approved
+}
+
+// reviewObjects 逐个审查一组中的对象，可以批准或跳过本组其余的全部对象
+func (r *reviewSession) reviewObjects(g *reviewGroup, approve func(candidateEntry)) {
+	for i, e := range g.objects {
+		fmt.Fprintf(r.out, "  %s  %.2f MB  %s  %s\n", e.Key, float64(e.Size)/1024/1024,
+			e.LastModified.Local().Format("2006-01-02 15:04:05"), tr(nil, msgReviewRule, e.Rule))
+		switch r.choose(tr(nil, msgReviewObjectPrompt), "ynasq") {
+		case 'y':
+			approve(e)
+		case 'n':
+		case 'a':
+			for _, rest := range g.objects[i:] {
+				approve(rest)
+			}
+			return
+		case 's':
+			return
+		case 'q':
+			r.quit = true
+			return
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cleaner
+
+import (
+	"fmt"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// effectiveRules 返回实际生效的规则列表。
+// 未配置 rules 时使用 cleanup.maxAge 和 cleanup.minSize 作为默认规则
+func effectiveRules(cfg *config.Config) []rules.Rule {
+	if len(cfg.Rules) > 0 {
+		rules := make([]rules.Rule, len(cfg.Rules))
+		copy(rules, cfg.Rules)
+		for i := range rules {
+			if rules[i].Name == "" {
+				rules[i].Name = fmt.Sprintf("rule-%d", i+1)
+			}
+		}
+		return rules
+	}
+	if rules := presetRules(cfg); len(rules) > 0 {
+		return rules
+	}
+	return []rules.Rule{{
+		Name:    rules.DefaultName,
+		MaxAge:  cfg.Cleanup.MaxAge,
+		MinSize: cfg.Cleanup.MinSize,
+	}}
+}
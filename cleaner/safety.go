@@ -0,0 +1,111 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// preflightResult 为删除前检查的结果
+type preflightResult struct {
+	// sample 为随机抽取的金丝雀删除对象
+	sample []minio.ObjectInfo
+	// files 和 bytes 为将被删除的对象数和总大小，bytes 只在配置了 approval 时统计
+	files int64
+	bytes int64
+	// objects 为审批请求中列出的对象，最多 approval.maxObjects 个
+	objects []planEntry
+}
+
+// preflight 在实际删除前列举一次存储桶：统计将被删除的对象占全部对象的比例，超过 safety.maxDeletePercent 时返回错误；
+// 配置了 canary.size 时同时随机抽取金丝雀删除的对象，plan 不为 nil 时将全部待删除对象写入计划删除清单。
+// 删除比例检查用于在系统时钟偏差、时区错误或规则写错导致几乎所有对象都符合规则时，删除开始之前就停止运行。
+// approved 不为 nil 时只统计交互式审查中批准的对象。filters 为本次运行已准备好的过滤器，now 为准备过滤器时判断规则的时间，
+// 与流水线共用，集成预设、分组和日历周期的索引不再重新生成
+func preflight(ctx context.Context, cfg *config.Config, store objectStore, now time.Time, filters []rules.Filter, approved map[string]bool, plan *planWriter) (*preflightResult, error) {
+	limit := cfg.Safety.MaxDeletePercent
+	if cfg.Cleanup.DryRun || !cfg.ScansBeforeDelete() {
+		return &preflightResult{}, nil
+	}
+	bucket := cfg.Minio.Bucket
+	slog.Info(tr(cfg, msgSafetyScanning, bucket), "bucket", bucket, "action", "safety")
+	sample := newReservoir(cfg.Canary.Size)
+	result := &preflightResult{}
+	var mu sync.Mutex
+	var matched int64
+	scanned, failures := scanFiltered(ctx, cfg, store, now, filters, func(obj minio.ObjectInfo, rule *rules.Compiled) {
+		if approved == nil || approved[obj.Key] {
+			atomic.AddInt64(&matched, 1)
+			sample.offer(obj)
+			if plan != nil {
+				plan.add(bucket, obj, rule.Name)
+			}
+			if cfg.Approval.Enabled() {
+				mu.Lock()
+				result.bytes += obj.Size
+				if len(result.objects) < cfg.Approval.MaxObjects {
+					result.objects = append(result.objects, newPlanEntry(bucket, obj, rule.Name))
+				}
+				mu.Unlock()
+			}
+		}
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// 列举不完整时无法判断删除比例，按超过限制处理
+	if failures > 0 {
+		return nil, errors.New(tr(cfg, msgSafetyListFailed, bucket, failures))
+	}
+	result.files = matched
+	if scanned == 0 {
+		return result, nil
+	}
+	percent := float64(matched) / float64(scanned) * 100
+	if percent > limit {
+		return nil, errors.New(tr(cfg, msgSafetyTooMany, bucket, matched, scanned, percent, limit))
+	}
+	slog.Info(tr(cfg, msgSafetyPassed, bucket, matched, scanned, percent),
+		"bucket", bucket, "action", "safety", "matched", matched, "scanned", scanned)
+	result.sample = sample.items
+	return result, nil
+}
+
+// warnUnversioned 在实际删除前检查存储桶的版本控制状态，未开启时警告删除的对象无法恢复
+func warnUnversioned(ctx context.Context, cfg *config.Config, store objectStore) {
+	if cfg.Cleanup.DryRun {
+		return
+	}
+	bucket := cfg.Minio.Bucket
+	var enabled bool
+	err := withTimeout(ctx, "versioning", cfg.Timeouts.Stat, func(ctx context.Context) error {
+		var err error
+		enabled, err = store.versioning(ctx, bucket)
+		return err
+	})
+	switch {
+	case errors.Is(err, errVersioningUnknown):
+	case err != nil:
+		slog.Warn(tr(cfg, msgVersioningFailed, bucket, err), "bucket", bucket, "action", "versioning", "error", err)
+	case !enabled:
+		slog.Warn(tr(cfg, msgVersioningDisabled, bucket), "bucket", bucket, "action", "versioning")
+	}
+}
+
+// shortRules 返回保留时间短于 safety.minObjectAge 的规则，这些规则只能清理比 minObjectAge 更旧的对象
+func shortRules(rs []rules.Rule, minObjectAge rules.Retention) []rules.Rule {
+	var short []rules.Rule
+	for _, r := range rs {
+		if r.MaxAge < minObjectAge {
+			short = append(short, r)
+		}
+	}
+	return short
+}
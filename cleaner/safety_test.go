@@ -0,0 +1,127 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestPreflight(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	// objects 返回 total 个对象，其中 expired 个超过保留时间
+	objects := func(total, expired int) []minio.ObjectInfo {
+		var objs []minio.ObjectInfo
+		for i := range total {
+			modified := now.Add(-2 * time.Hour)
+			if i < expired {
+				modified = now.Add(-48 * time.Hour)
+			}
+			objs = append(objs, testObject(fmt.Sprintf("k%02d", i), modified, 10))
+		}
+		return objs
+	}
+	tests := []struct {
+		name      string
+		percent   float64
+		dryRun    bool
+		canary    int
+		objects   []minio.ObjectInfo
+		listErr   error
+		approved  map[string]bool
+		wantErr   bool
+		wantFiles int64
+	}{
+		{name: "below the limit", percent: 50, objects: objects(10, 5), wantFiles: 5},
+		{name: "above the limit", percent: 50, objects: objects(10, 6), wantErr: true},
+		{name: "everything", percent: 99, objects: objects(10, 10), wantErr: true},
+		{name: "empty bucket", percent: 50, wantFiles: 0},
+		{name: "listing failed", percent: 50, objects: objects(10, 1), listErr: errors.New("boom"), wantErr: true},
+		{name: "only approved objects count", percent: 50, objects: objects(10, 8), approved: map[string]bool{"k00": true, "k01": true}, wantFiles: 2},
+		// 未配置时默认限制为 50%
+		{name: "default limit", objects: objects(10, 6), wantErr: true},
+		// 预览模式和设置为 100% 时不检查，也不列举
+		{name: "dry run", percent: 50, dryRun: true, objects: objects(10, 10), wantFiles: 0},
+		{name: "check disabled", percent: 100, objects: objects(10, 10), wantFiles: 0},
+		// 金丝雀删除需要列举，此时 100% 的限制不会拒绝
+		{name: "canary with no limit", percent: 100, canary: 2, objects: objects(10, 10), wantFiles: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+			if tt.percent != 0 {
+				cfg.Safety.MaxDeletePercent = tt.percent
+			}
+			cfg.Cleanup.DryRun = tt.dryRun
+			cfg.Canary.Size = tt.canary
+			store := &memStore{objects: tt.objects, listErr: tt.listErr}
+			result, err := preflight(context.Background(), cfg, store, now, nil, tt.approved, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("preflight() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.files != tt.wantFiles {
+				t.Errorf("preflight() files = %d, want %d", result.files, tt.wantFiles)
+			}
+			if want := min(tt.canary, int(tt.wantFiles)); len(result.sample) != want {
+				t.Errorf("preflight() sampled %d objects, want %d", len(result.sample), want)
+			}
+		})
+	}
+}
+
+func TestPreflightFilters(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+	cfg.Safety.MaxDeletePercent = 50
+	store := &memStore{objects: []minio.ObjectInfo{
+		testObject("keep/a", now.Add(-48*time.Hour), 1),
+		testObject("keep/b", now.Add(-48*time.Hour), 1),
+		testObject("del/c", now.Add(-48*time.Hour), 1),
+		testObject("new/d", now, 1),
+	}}
+	// 过滤器保留的对象不计入删除比例，3/4 降为 1/4
+	keep := rules.FilterFunc(func(obj rules.ObjectInfo) rules.Decision {
+		if strings.HasPrefix(obj.Key, "keep/") {
+			return rules.Keep
+		}
+		return rules.Abstain
+	})
+	result, err := preflight(context.Background(), cfg, store, now, []rules.Filter{keep}, nil, nil)
+	if err != nil {
+		t.Fatalf("preflight() error = %v", err)
+	}
+	if result.files != 1 {
+		t.Errorf("preflight() files = %d, want 1", result.files)
+	}
+}
+
+func TestScansBeforeDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(cfg *config.Config)
+		want bool
+	}{
+		// 默认检查删除比例
+		{name: "defaults", set: func(cfg *config.Config) {}, want: true},
+		{name: "maxDeletePercent", set: func(cfg *config.Config) { cfg.Safety.MaxDeletePercent = 90 }, want: true},
+		{name: "check disabled", set: func(cfg *config.Config) { cfg.Safety.MaxDeletePercent = 100 }},
+		{name: "canary", set: func(cfg *config.Config) { cfg.Safety.MaxDeletePercent, cfg.Canary.Size = 100, 1 }, want: true},
+		{name: "plan", set: func(cfg *config.Config) { cfg.Safety.MaxDeletePercent, cfg.Report.PlanObject = 100, "plan.jsonl" }, want: true},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+		tt.set(cfg)
+		if got := cfg.ScansBeforeDelete(); got != tt.want {
+			t.Errorf("%s: scansBeforeDelete() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cleaner
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	sentry "github.com/getsentry/sentry-go"
+)
+
+// sentryFlushTimeout 为等待事件发送完成的最长时间
+const sentryFlushTimeout = 5 * time.Second
+
+// ReportPanic 将 panic 上报到 Sentry 后继续向上抛出，需在 defer 中直接调用
+func ReportPanic() {
+	if r := recover(); r != nil {
+		if sentry.CurrentHub().Client() != nil {
+			sentry.CurrentHub().Recover(r)
+			sentry.Flush(sentryFlushTimeout)
+		}
+		panic(r)
+	}
+}
+
+// notifySentry 在运行失败时上报一个汇总事件，包含运行信息和前若干条错误
+func notifySentry(cfg *config.Config, report *report.RunReport, runErr error) {
+	if cfg.Notify.Sentry.DSN == "" || !runFailed(report, runErr) {
+		return
+	}
+
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelError)
+		scope.SetTag("bucket", cfg.Minio.Bucket)
+		scope.SetTag("endpoint", cfg.Minio.Endpoint)
+		if report != nil {
+			scope.SetTag("runId", report.RunID)
+			scope.SetContext("run", sentry.Context{
+				"startTime":      report.StartTime,
+				"endTime":        report.EndTime,
+				"configHash":     report.ConfigHash,
+				"totalFiles":     report.TotalFiles,
+				"processedFiles": report.ProcessedFiles,
+				"deletedFiles":   report.DeletedFiles,
+				"deletedBytes":   report.DeletedBytes,
+				"errorCount":     report.ErrorCount,
+			})
+			scope.SetExtra("errors", report.Errors)
+		}
+		// 同一存储桶的删除错误归为同一问题，避免每次运行产生新问题
+		if runErr != nil {
+			scope.SetFingerprint([]string{"run-error", cfg.Minio.Bucket})
+			sentry.CaptureException(runErr)
+		} else {
+			scope.SetFingerprint([]string{"run-errors", cfg.Minio.Bucket})
+			sentry.CaptureMessage(tr(cfg, msgSentryRunErrors, cfg.Minio.Bucket, report.ErrorCount))
+		}
+	})
+	if !sentry.Flush(sentryFlushTimeout) {
+		slog.Error(tr(cfg, msgSentryFailed), "bucket", cfg.Minio.Bucket, "action", "notify")
+	}
+}
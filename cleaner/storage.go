@@ -0,0 +1,218 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// 存储类型
+const (
+	storageS3    = "s3"
+	storageAzure = "azure"
+	storageGCS   = "gcs"
+)
+
+// objectStore 为清理流程使用的对象存储操作，S3 兼容服务、Azure Blob 和 GCS 分别实现。
+// 对象信息统一使用 minio.ObjectInfo 表示，错误统一转换为 minio.ErrorResponse，
+// 重试、熔断和自动调整并发都按 S3 错误码和 HTTP 状态码判断，与存储类型无关
+type objectStore interface {
+	// list 列举对象，结果通过通道返回，出错时返回 Err 不为空的条目后关闭通道。
+	// 非递归列举时子目录以公共前缀返回：Key 以 / 结尾且 ETag 为空
+	list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	// stat 查询单个对象的信息，包括用户元数据 UserMetadata（元数据名不含 x-amz-meta- 等前缀）
+	stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error)
+	// open 读取对象的内容，由调用方关闭。对象不存在等错误可能在第一次读取时才返回
+	open(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	// remove 删除对象，存储桶开启版本控制时返回删除产生的删除标记的版本 ID，否则返回空字符串
+	remove(ctx context.Context, bucket, key string) (string, error)
+	// copy 在服务端复制对象
+	copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error
+	// put 上传对象
+	put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error
+	// bucketExists 检查存储桶（Azure 为容器）是否存在
+	bucketExists(ctx context.Context, bucket string) (bool, error)
+	// removeBucket 删除空的存储桶，存储桶中还有对象（包括历史版本）时服务端拒绝删除并返回错误
+	removeBucket(ctx context.Context, bucket string) error
+	// listBuckets 列举服务上的全部存储桶（Azure 为容器），返回名称和创建时间，无法获得创建时间时为零值
+	listBuckets(ctx context.Context) ([]minio.BucketInfo, error)
+	// versioning 返回存储桶是否开启了版本控制，无法查询时返回 errVersioningUnknown
+	versioning(ctx context.Context, bucket string) (bool, error)
+	// objectTags 返回对象的标签（Azure 为 Blob 索引标签，GCS 为自定义元数据），没有标签时返回空
+	objectTags(ctx context.Context, bucket, key string) (map[string]string, error)
+	// bucketTags 返回存储桶的标签（Azure 为容器元数据，GCS 为存储桶标签），没有标签时返回空
+	bucketTags(ctx context.Context, bucket string) (map[string]string, error)
+}
+
+// readObject 读取对象的全部内容，最多读取 limit 字节，用于镜像清单等小对象
+func readObject(ctx context.Context, store objectStore, bucket, key string, limit int64) ([]byte, error) {
+	r, err := store.open(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, limit))
+}
+
+// errVersioningUnknown 表示存储类型不支持查询存储桶的版本控制状态（如 Azure 的版本控制在存储账户上配置）
+var errVersioningUnknown = errors.New("versioning status unknown")
+
+// newObjectStore 按 type 创建对象存储客户端，同时返回访问密钥，供 reloadSecrets 重新读取
+func newObjectStore(cfg *config.Config) (objectStore, *credentials.Credentials, error) {
+	switch cfg.Minio.Type {
+	case "", storageS3:
+		client, creds, err := newMinioClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &s3Store{client: client}, creds, nil
+	case storageAzure:
+		return newAzureStore(cfg)
+	case storageGCS:
+		store, err := newGCSStore(cfg)
+		return store, nil, err
+	}
+	return nil, nil, errors.New(tr(cfg, msgStorageInvalid, cfg.Minio.Type))
+}
+
+// s3Store 通过 MinIO 客户端访问 MinIO、AWS S3 等 S3 兼容服务
+type s3Store struct {
+	client *minio.Client
+}
+
+func (s *s3Store) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	return s.client.ListObjects(ctx, bucket, opts)
+}
+
+func (s *s3Store) stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error) {
+	return s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+}
+
+func (s *s3Store) open(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	return s.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *s3Store) remove(ctx context.Context, bucket, key string) (string, error) {
+	// RemoveObject 不返回删除标记的版本 ID，由 deleteMarkerTransport 从响应头中取出
+	var versionID string
+	err := s.client.RemoveObject(context.WithValue(ctx, deleteMarkerKey{}, &versionID), bucket, key, minio.RemoveObjectOptions{})
+	return versionID, err
+}
+
+func (s *s3Store) copy(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: dstBucket, Object: dstKey},
+		minio.CopySrcOptions{Bucket: srcBucket, Object: srcKey})
+	return err
+}
+
+func (s *s3Store) put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *s3Store) bucketExists(ctx context.Context, bucket string) (bool, error) {
+	return s.client.BucketExists(ctx, bucket)
+}
+
+func (s *s3Store) removeBucket(ctx context.Context, bucket string) error {
+	return s.client.RemoveBucket(ctx, bucket)
+}
+
+func (s *s3Store) versioning(ctx context.Context, bucket string) (bool, error) {
+	cfg, err := s.client.GetBucketVersioning(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+	return cfg.Enabled(), nil
+}
+
+func (s *s3Store) objectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	t, err := s.client.GetObjectTagging(ctx, bucket, key, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return t.ToMap(), nil
+}
+
+func (s *s3Store) bucketTags(ctx context.Context, bucket string) (map[string]string, error) {
+	t, err := s.client.GetBucketTagging(ctx, bucket)
+	if minio.ToErrorResponse(err).Code == "NoSuchTagSet" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.ToMap(), nil
+}
+
+func (s *s3Store) listBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	return s.client.ListBuckets(ctx)
+}
+
+// sendObject 将列举结果发送到通道，ctx 取消时返回 false
+func sendObject(ctx context.Context, ch chan<- minio.ObjectInfo, obj minio.ObjectInfo) bool {
+	select {
+	case ch <- obj:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendListPage 将一页列举结果按名称顺序发送到通道。objects 和 prefixes 各自有序，
+// 公共前缀以只有 Key 的条目返回。ctx 取消时返回 false
+func sendListPage(ctx context.Context, ch chan<- minio.ObjectInfo, objects []minio.ObjectInfo, prefixes []string) bool {
+	for len(objects) > 0 || len(prefixes) > 0 {
+		var obj minio.ObjectInfo
+		if len(prefixes) == 0 || (len(objects) > 0 && objects[0].Key < prefixes[0]) {
+			obj, objects = objects[0], objects[1:]
+		} else {
+			obj, prefixes = minio.ObjectInfo{Key: prefixes[0]}, prefixes[1:]
+		}
+		if !sendObject(ctx, ch, obj) {
+			return false
+		}
+	}
+	return true
+}
+
+// httpStatusCodes 为 Azure Blob 和 GCS 的 HTTP 状态码对应的 S3 错误码，
+// 使重试、熔断等按 S3 错误码判断的逻辑对所有存储类型生效
+var httpStatusCodes = map[int]string{
+	http.StatusUnauthorized:        "AccessDenied",
+	http.StatusForbidden:           "AccessDenied",
+	http.StatusTooManyRequests:     "SlowDown",
+	http.StatusInternalServerError: "InternalError",
+	http.StatusServiceUnavailable:  "ServiceUnavailable",
+	http.StatusRequestTimeout:      "RequestTimeout",
+}
+
+// httpError 将 Azure Blob 或 GCS 返回的错误转换为 S3 错误。
+// 404 按请求的是对象还是存储桶分别转换为 NoSuchKey 和 NoSuchBucket
+func httpError(resp *http.Response, bucket, key, message string) error {
+	code := httpStatusCodes[resp.StatusCode]
+	switch {
+	case resp.StatusCode == http.StatusNotFound && key != "":
+		code = "NoSuchKey"
+	case resp.StatusCode == http.StatusNotFound:
+		code = "NoSuchBucket"
+	case code == "":
+		code = http.StatusText(resp.StatusCode)
+	}
+	if message == "" {
+		message = resp.Status
+	}
+	return minio.ErrorResponse{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		Message:    message,
+		BucketName: bucket,
+		Key:        key,
+	}
+}
@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestMain(m *testing.M) {
+	// 测试中的运行日志没有意义，只输出测试结果
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// memStore 是内存中的对象存储，只实现列举，用于测试按列举结果判断的逻辑。调用其他方法时 panic
+type memStore struct {
+	objectStore
+	objects []minio.ObjectInfo
+	// listErr 不为 nil 时，列举完对象后以该错误结束
+	listErr error
+}
+
+func (s *memStore) list(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(ch)
+		send := func(obj minio.ObjectInfo) bool {
+			select {
+			case ch <- obj:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		prefixes := make(map[string]bool)
+		for _, obj := range s.objects {
+			if !strings.HasPrefix(obj.Key, opts.Prefix) {
+				continue
+			}
+			// 非递归列举时子目录以公共前缀返回
+			if i := strings.Index(obj.Key[len(opts.Prefix):], "/"); !opts.Recursive && i >= 0 {
+				p := obj.Key[:len(opts.Prefix)+i+1]
+				if !prefixes[p] {
+					prefixes[p] = true
+					if !send(minio.ObjectInfo{Key: p}) {
+						return
+					}
+				}
+				continue
+			}
+			if !send(obj) {
+				return
+			}
+		}
+		if s.listErr != nil {
+			send(minio.ObjectInfo{Err: s.listErr})
+		}
+	}()
+	return ch
+}
+
+// testObject 返回修改时间为 modified 的对象
+func testObject(key string, modified time.Time, size int64) minio.ObjectInfo {
+	return minio.ObjectInfo{Key: key, LastModified: modified, Size: size, ETag: "etag-" + key}
+}
+
+// testConfig 返回清理存储桶 b 的配置，已设置默认值
+func testConfig(rules ...rules.Rule) *config.Config {
+	cfg := &config.Config{Rules: rules}
+	cfg.Minio.Name = "test"
+	cfg.Minio.Bucket = "b"
+	cfg.Cleanup.Workers = 2
+	cfg.Cleanup.Listers = 1
+	config.SetDefaults(cfg)
+	return cfg
+}
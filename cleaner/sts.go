@@ -0,0 +1,132 @@
+package cleaner
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// STS 凭证类型
+const (
+	stsAssumeRole  = "assumeRole"
+	stsWebIdentity = "webIdentity"
+)
+
+// kubernetesTokenFile 为 Kubernetes 挂载的服务账号令牌路径
+const kubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// stsProvider 通过 STS 获取临时凭证，过期前由 credentials.Credentials 调用 Retrieve 续期。
+// assumeRole 每次续期都重新读取源凭证，webIdentity 每次续期都重新读取令牌文件，
+// 源凭证或令牌本身轮换时也能继续工作
+type stsProvider struct {
+	credentials.Expiry
+
+	cluster  string
+	cfg      config.STSConfig
+	endpoint string
+	source   *credentials.Credentials
+	client   *http.Client
+	lang     string
+}
+
+// newSTSCredentials 返回通过 STS 获取的临时凭证，source 为 assumeRole 调用 STS 时使用的凭证
+func newSTSCredentials(m *config.MinioConfig, source *credentials.Credentials, client *http.Client, lang string) (*credentials.Credentials, error) {
+	cfg := m.STS
+	if cfg.Type != stsAssumeRole && cfg.Type != stsWebIdentity {
+		return nil, errors.New(trLang(lang, msgSTSUnknownType, cfg.Type))
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "http://" + m.Endpoint
+		if m.UseSSL {
+			endpoint = "https://" + m.Endpoint
+		}
+	}
+	if cfg.Region == "" {
+		cfg.Region = m.Region
+	}
+	if cfg.Type == stsWebIdentity {
+		if cfg.RoleARN == "" {
+			cfg.RoleARN = os.Getenv("AWS_ROLE_ARN")
+		}
+		if cfg.TokenFile == "" {
+			cfg.TokenFile = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+		}
+		if cfg.TokenFile == "" {
+			cfg.TokenFile = kubernetesTokenFile
+		}
+	}
+	return credentials.New(&stsProvider{
+		cluster:  m.Name,
+		cfg:      cfg,
+		endpoint: endpoint,
+		source:   source,
+		client:   client,
+		lang:     lang,
+	}), nil
+}
+
+// readToken 读取 webIdentity 令牌文件
+func (p *stsProvider) readToken() (*credentials.WebIdentityToken, error) {
+	token, err := os.ReadFile(p.cfg.TokenFile)
+	if err != nil {
+		return nil, err
+	}
+	return &credentials.WebIdentityToken{Token: string(token), Expiry: int(p.cfg.Duration.Seconds())}, nil
+}
+
+func (p *stsProvider) Retrieve() (credentials.Value, error) {
+	return p.RetrieveWithCredContext(nil)
+}
+
+func (p *stsProvider) RetrieveWithCredContext(cc *credentials.CredContext) (credentials.Value, error) {
+	v, err := p.retrieve(cc)
+	if err != nil {
+		slog.Error(trLang(p.lang, msgSTSFailed, p.cluster, err), "cluster", p.cluster, "action", "sts", "error", err)
+		return v, err
+	}
+	p.SetExpiration(v.Expiration, credentials.DefaultExpiryWindow)
+	slog.Info(trLang(p.lang, msgSTSRenewed, p.cluster, v.Expiration.Format(time.RFC3339)),
+		"cluster", p.cluster, "action", "sts", "expiration", v.Expiration)
+	return v, nil
+}
+
+func (p *stsProvider) retrieve(cc *credentials.CredContext) (credentials.Value, error) {
+	var provider credentials.Provider
+	if p.cfg.Type == stsAssumeRole {
+		p.source.Expire()
+		src, err := p.source.GetWithContext(cc)
+		if err != nil {
+			return credentials.Value{}, err
+		}
+		provider = &credentials.STSAssumeRole{
+			Client:      p.client,
+			STSEndpoint: p.endpoint,
+			Options: credentials.STSAssumeRoleOptions{
+				AccessKey:       src.AccessKeyID,
+				SecretKey:       src.SecretAccessKey,
+				SessionToken:    src.SessionToken,
+				Policy:          p.cfg.Policy,
+				Location:        p.cfg.Region,
+				DurationSeconds: int(p.cfg.Duration.Seconds()),
+				RoleARN:         p.cfg.RoleARN,
+				RoleSessionName: p.cfg.RoleSessionName,
+				ExternalID:      p.cfg.ExternalID,
+			},
+		}
+	} else {
+		provider = &credentials.STSWebIdentity{
+			Client:              p.client,
+			STSEndpoint:         p.endpoint,
+			GetWebIDTokenExpiry: p.readToken,
+			RoleARN:             p.cfg.RoleARN,
+			Policy:              p.cfg.Policy,
+		}
+	}
+	return provider.RetrieveWithCredContext(cc)
+}
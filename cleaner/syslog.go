@@ -0,0 +1,204 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// syslogFacilities 为支持的设施名称及其编号
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSDID 为结构化数据元素的 SD-ID，32473 为 RFC 5612 保留的示例企业编号
+const syslogSDID = "cleaner@32473"
+
+// syslogHandler 将日志以 RFC 5424 格式发送到 syslog 服务器，
+// 日志的结构化字段写入 STRUCTURED-DATA
+type syslogHandler struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	conn     net.Conn
+	level    slog.Leveler
+	facility int
+	hostname string
+	appName  string
+	procID   string
+}
+
+func newSyslogHandler(cfg *config.SyslogConfig, level slog.Leveler) (*syslogHandler, error) {
+	network := strings.ToLower(cfg.Network)
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, errors.New(tr(nil, msgSyslogBadNetwork, cfg.Network))
+	}
+	facilityName := strings.ToLower(cfg.Facility)
+	if facilityName == "" {
+		facilityName = "user"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, errors.New(tr(nil, msgSyslogBadFacility, cfg.Facility))
+	}
+	appName := cfg.AppName
+	if appName == "" {
+		appName = "minio-cleaner"
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	h := &syslogHandler{
+		network:  network,
+		address:  cfg.Address,
+		level:    level,
+		facility: facility,
+		hostname: hostname,
+		appName:  appName,
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *syslogHandler) connect() error {
+	conn, err := net.DialTimeout(h.network, h.address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	h.conn = conn
+	return nil
+}
+
+func (h *syslogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// syslogSeverity 将日志级别映射为 syslog 严重性
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// sdEscaper 转义结构化数据参数值中的特殊字符
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func (h *syslogHandler) Handle(_ context.Context, r slog.Record) error {
+	var sd strings.Builder
+	r.Attrs(func(a slog.Attr) bool {
+		if sd.Len() == 0 {
+			sd.WriteString("[" + syslogSDID)
+		}
+		fmt.Fprintf(&sd, ` %s="%s"`, a.Key, sdEscaper.Replace(a.Value.Resolve().String()))
+		return true
+	})
+	if sd.Len() == 0 {
+		sd.WriteString("-")
+	} else {
+		sd.WriteString("]")
+	}
+
+	action := "-"
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "action" {
+			action = a.Value.String()
+			return false
+		}
+		return true
+	})
+
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		h.facility*8+syslogSeverity(r.Level), r.Time.Format(time.RFC3339Nano),
+		h.hostname, h.appName, h.procID, action, sd.String(), r.Message)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.write(msg)
+	if err != nil && h.network == "tcp" {
+		// TCP 连接断开后重连一次
+		h.conn.Close()
+		if err = h.connect(); err == nil {
+			err = h.write(msg)
+		}
+	}
+	return err
+}
+
+// write 发送一条消息，TCP 使用 RFC 6587 的八位组计数分帧
+func (h *syslogHandler) write(msg string) error {
+	if h.network == "tcp" {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	_, err := h.conn.Write([]byte(msg))
+	return err
+}
+
+func (h *syslogHandler) WithAttrs(_ []slog.Attr) slog.Handler { return h }
+
+func (h *syslogHandler) WithGroup(_ string) slog.Handler { return h }
+
+// multiHandler 将日志同时交给多个处理器
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
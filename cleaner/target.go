@@ -0,0 +1,292 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Target 为一个清理目标：某个集群上的一个存储桶。
+// cfg 为该目标专用的配置副本，其中 Minio.Bucket 为目标存储桶，报告路径中的占位符已展开
+type Target struct {
+	cfg   *config.Config
+	store objectStore
+	creds *credentials.Credentials
+	// admin 为开启 adminAPI 的集群的管理接口客户端，未开启时为 nil
+	admin *adminClient
+	// removable 为开启 removeEmptyBuckets 的集群上按 bucketPattern 发现的存储桶，清理后为空时删除；
+	// created 为存储桶的创建时间，未知时为零值
+	removable bool
+	created   time.Time
+	// approved 为交互式审查或 tui 中批准清理的对象，为 nil 时清理所有符合规则的对象
+	approved map[string]bool
+	// limiter 为多个目标共用的删除限速器，如 REST 接口提交任务的团队的限速，为 nil 时不限制
+	limiter *rateLimiter
+}
+
+// hasPartialCreds 返回是否只配置了访问密钥 ID 和访问密钥中的一项
+func hasPartialCreds(accessKeyID, accessKeyIDFile, secretAccessKey, secretAccessKeyFile string) bool {
+	return (accessKeyID == "" && accessKeyIDFile == "") != (secretAccessKey == "" && secretAccessKeyFile == "")
+}
+
+// reloadSecrets 使从文件或 Vault 读取的访问密钥在下次请求时重新读取，密钥轮换后无需重启
+func (t Target) reloadSecrets() {
+	if t.creds != nil && (t.cfg.Minio.SecretsFromFile() || t.cfg.Minio.Vault.Path != "") {
+		t.creds.Expire()
+	}
+}
+
+// Targets 为每个集群创建客户端，并为集群上的每个存储桶生成独立的配置副本。
+// 配置了 bucketPattern 的集群先列举服务上的存储桶，匹配的存储桶也作为清理目标
+func Targets(ctx context.Context, cfg *config.Config) ([]Target, error) {
+	var targets []Target
+	paths := make(map[string]string)
+	names := make(map[string]bool)
+	stores := make(map[string]Target)
+	for _, cluster := range cfg.AllClusters() {
+		if err := cluster.ResolveAlias(); err != nil {
+			return nil, err
+		}
+		if cluster.Name == "" {
+			cluster.Name = cluster.Endpoint
+		}
+		if names[cluster.Name] {
+			return nil, errors.New(tr(cfg, msgTargetDuplicate, cluster.Name))
+		}
+		names[cluster.Name] = true
+		if hasPartialCreds(cluster.AccessKeyID, cluster.AccessKeyIDFile, cluster.SecretAccessKey, cluster.SecretAccessKeyFile) {
+			return nil, errors.New(tr(cfg, msgTargetClusterCreds, cluster.Name))
+		}
+		var buckets []clusterBucket
+		if cluster.Bucket != "" {
+			buckets = append(buckets, clusterBucket{BucketConfig: config.BucketConfig{Name: cluster.Bucket}})
+		}
+		for _, b := range cluster.Buckets {
+			buckets = append(buckets, clusterBucket{BucketConfig: b})
+		}
+		if cluster.BucketPattern != "" {
+			c := *cfg
+			c.Minio = cluster
+			discovered, err := discoverBuckets(ctx, &c, stores)
+			if err != nil {
+				return nil, err
+			}
+			buckets = append(buckets, discovered...)
+		} else if len(buckets) == 0 {
+			return nil, errors.New(tr(cfg, msgTargetNoBucket, cluster.Name))
+		}
+
+		seen := make(map[string]bool)
+		for _, b := range buckets {
+			bucket := b.Name
+			if bucket == "" {
+				return nil, errors.New(tr(cfg, msgTargetNoBucketName, cluster.Name))
+			}
+			// 明确配置的存储桶同时匹配 bucketPattern 时只清理一次
+			if seen[bucket] {
+				continue
+			}
+			seen[bucket] = true
+			if cfg.Excluded(&cluster, bucket) {
+				slog.Warn(tr(cfg, msgTargetExcluded, cluster.Name, bucket), "cluster", cluster.Name, "bucket", bucket, "action", "exclude")
+				continue
+			}
+			if hasPartialCreds(b.AccessKeyID, b.AccessKeyIDFile, b.SecretAccessKey, b.SecretAccessKeyFile) {
+				return nil, errors.New(tr(cfg, msgTargetPartialCreds, cluster.Name, bucket))
+			}
+			c := *cfg
+			c.Minio = cluster
+			c.Minio.Bucket = bucket
+			c.Minio.Buckets = nil
+			c.Minio.BucketPattern = ""
+			if b.AccessKeyID != "" || b.AccessKeyIDFile != "" {
+				c.Minio.AccessKeyID = b.AccessKeyID
+				c.Minio.SecretAccessKey = b.SecretAccessKey
+				c.Minio.AccessKeyIDFile = b.AccessKeyIDFile
+				c.Minio.SecretAccessKeyFile = b.SecretAccessKeyFile
+			}
+			if b.Preset != "" {
+				c.Minio.Preset = b.Preset
+			}
+			expandTargetPaths(&c)
+
+			// 不同目标的报告写入同一路径会相互覆盖
+			for _, p := range []string{c.Report.SummaryFile, c.Report.SummaryObject, c.Report.PlanObject, c.Report.ManifestFile,
+				c.Report.HTMLFile, c.Report.CandidatesFile, c.Report.AnalyzeFile} {
+				if p == "" {
+					continue
+				}
+				if other, ok := paths[p]; ok {
+					return nil, errors.New(tr(cfg, msgTargetPathConflict, p, other, cluster.Name+"/"+bucket))
+				}
+				paths[p] = cluster.Name + "/" + bucket
+			}
+			targets = append(targets, Target{cfg: &c, removable: b.discovered && cluster.RemoveEmptyBuckets, created: b.created})
+		}
+	}
+
+	if err := checkDedupTargets(targets); err != nil {
+		return nil, err
+	}
+	// 全局带宽限制由全部目标共用
+	var bandwidth *rateLimiter
+	if bw := cfg.Cleanup.MaxBandwidth; bw > 0 {
+		bandwidth = newRateLimiter(float64(bw))
+	}
+	for i := range targets {
+		t := &targets[i]
+		if err := t.connect(stores); err != nil {
+			return nil, err
+		}
+		t.store = withBandwidthLimit(t.store, bandwidth, float64(t.cfg.Cleanup.MaxWorkerBandwidth))
+	}
+	return targets, nil
+}
+
+// connect 为目标创建客户端。同一集群上使用相同凭证的存储桶共用一个客户端，stores 按集群和凭证缓存已创建的客户端
+func (t *Target) connect(stores map[string]Target) error {
+	name := strings.Join([]string{t.cfg.Minio.Name, t.cfg.Minio.AccessKeyID, t.cfg.Minio.AccessKeyIDFile}, "\x00")
+	if shared, ok := stores[name]; ok {
+		t.store, t.creds, t.admin = shared.store, shared.creds, shared.admin
+		return nil
+	}
+	store, creds, err := newObjectStore(t.cfg)
+	if err != nil {
+		return errors.New(tr(t.cfg, msgClientFailed, err))
+	}
+	t.store, t.creds = store, creds
+	if t.cfg.Minio.AdminAPI && (t.cfg.Minio.Type == "" || t.cfg.Minio.Type == storageS3) {
+		if t.admin, err = newAdminClient(t.cfg, creds); err != nil {
+			return errors.New(tr(t.cfg, msgClientFailed, err))
+		}
+	}
+	stores[name] = *t
+	return nil
+}
+
+// clusterBucket 为集群上要清理的一个存储桶。discovered 为按 bucketPattern 发现的存储桶，
+// created 为发现时列举到的创建时间，未知时为零值
+type clusterBucket struct {
+	config.BucketConfig
+	discovered bool
+	created    time.Time
+}
+
+// discoverBuckets 使用集群的凭证列举服务上的存储桶，返回名称完整匹配 bucketPattern 的存储桶
+func discoverBuckets(ctx context.Context, cfg *config.Config, stores map[string]Target) ([]clusterBucket, error) {
+	cluster := cfg.Minio.Name
+	if _, err := regexp.Compile(cfg.Minio.BucketPattern); err != nil {
+		return nil, errors.New(tr(cfg, msgTargetBadPattern, cluster, err))
+	}
+	pattern := regexp.MustCompile("^(?:" + cfg.Minio.BucketPattern + ")$")
+	t := Target{cfg: cfg}
+	if err := t.connect(stores); err != nil {
+		return nil, err
+	}
+	var all []minio.BucketInfo
+	err := withTimeout(ctx, "listBuckets", cfg.Timeouts.Stat, func(ctx context.Context) error {
+		var err error
+		all, err = t.store.listBuckets(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New(tr(cfg, msgTargetListFailed, cluster, err))
+	}
+
+	var buckets []clusterBucket
+	var matched []string
+	for _, b := range all {
+		if pattern.MatchString(b.Name) && !cfg.Excluded(&cfg.Minio, b.Name) {
+			buckets = append(buckets, clusterBucket{BucketConfig: config.BucketConfig{Name: b.Name}, discovered: true, created: b.CreationDate})
+			matched = append(matched, b.Name)
+		}
+	}
+	if len(buckets) == 0 {
+		slog.Warn(tr(cfg, msgTargetNoMatch, cluster), "cluster", cluster, "action", "discover", "pattern", cfg.Minio.BucketPattern)
+	} else {
+		slog.Info(tr(cfg, msgTargetDiscovered, cluster, len(matched), strings.Join(matched, ", ")),
+			"cluster", cluster, "action", "discover", "pattern", cfg.Minio.BucketPattern, "buckets", len(matched))
+	}
+	return buckets, nil
+}
+
+// expandTargetPaths 将报告路径和清单位置中的 {cluster} 和 {bucket} 替换为目标的集群名称和存储桶
+func expandTargetPaths(cfg *config.Config) {
+	r := strings.NewReplacer("{cluster}", cfg.Minio.Name, "{bucket}", cfg.Minio.Bucket)
+	for _, p := range []*string{&cfg.Report.SummaryFile, &cfg.Report.SummaryObject, &cfg.Report.PlanObject, &cfg.Report.ManifestFile,
+		&cfg.Report.HTMLFile, &cfg.Report.CandidatesFile, &cfg.Report.AnalyzeFile, &cfg.Inventory.Bucket, &cfg.Inventory.Prefix} {
+		*p = r.Replace(*p)
+	}
+}
+
+// runTargets 对每个目标执行 fn，最多同时执行 parallel 个目标。
+// 单个目标失败不影响其他目标，返回所有失败目标的错误
+func runTargets(ctx context.Context, targets []Target, parallel int, fn func(t Target) error) error {
+	sem := make(chan struct{}, max(1, parallel))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer ReportPanic()
+			defer wg.Done()
+			defer func() { <-sem }()
+			if len(targets) > 1 {
+				slog.Info(tr(t.cfg, msgTargetStart, t.cfg.Minio.Name, t.cfg.Minio.Bucket),
+					"cluster", t.cfg.Minio.Name, "bucket", t.cfg.Minio.Bucket, "action", "target")
+			}
+			if err := fn(t); err != nil {
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// targetResult 为一个目标的运行结果，运行在生成报告之前出错时 report 为 nil
+type targetResult struct {
+	cluster string
+	bucket  string
+	report  *report.RunReport
+	err     error
+}
+
+// runAllTargets 清理所有目标，最多同时清理 cleanup.parallelTargets 个，返回与 targets 一一对应的运行结果。
+// 集群开启 adminAPI 时按存储桶大小从大到小清理。
+// objects 不为 nil 时逐个输出待清理或已删除的对象
+func runAllTargets(ctx context.Context, cfg *config.Config, targets []Target, onProgress func(), objects *ObjectOutput) ([]targetResult, error) {
+	for _, t := range targets {
+		t.reloadSecrets()
+	}
+	// 每个目标使用各自的配置，按配置找到目标的位置
+	results := make([]targetResult, len(targets))
+	index := make(map[*config.Config]int, len(targets))
+	for i, t := range targets {
+		results[i] = targetResult{cluster: t.cfg.Minio.Name, bucket: t.cfg.Minio.Bucket}
+		index[t.cfg] = i
+	}
+	err := runTargets(ctx, prioritizeTargets(ctx, targets), cfg.Cleanup.ParallelTargets, func(t Target) error {
+		report, err := runOnce(ctx, t, onProgress, objects)
+		if err != nil && len(targets) > 1 {
+			slog.Error(err.Error(), "cluster", t.cfg.Minio.Name, "bucket", t.cfg.Minio.Bucket, "error", err)
+		}
+		r := &results[index[t.cfg]]
+		r.report, r.err = report, err
+		return err
+	})
+	return results, err
+}
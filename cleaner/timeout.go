@@ -0,0 +1,49 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// opTimeoutError 表示单个请求超时。实现 net.Error，可被当作临时错误重试
+type opTimeoutError struct {
+	op      string
+	timeout time.Duration
+	lang    string
+}
+
+func (e *opTimeoutError) Error() string { return trLang(e.lang, msgOpTimeout, e.op, e.timeout) }
+
+func (e *opTimeoutError) Timeout() bool { return true }
+
+func (e *opTimeoutError) Temporary() bool { return true }
+
+// withTimeout 在最长 timeout 内执行 fn，timeout 为 0 时不限制。
+// 因超时而失败时返回 opTimeoutError，ctx 本身结束时原样返回 fn 的错误
+func withTimeout(ctx context.Context, op string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	opCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(opCtx)
+	if err != nil && opCtx.Err() != nil && ctx.Err() == nil {
+		return &opTimeoutError{op: op, timeout: timeout, lang: contextLanguage(ctx)}
+	}
+	return err
+}
+
+// runtimeExceededError 为运行超过 cleanup.maxRuntime 时取消 context 的原因
+type runtimeExceededError struct {
+	maxRuntime time.Duration
+	lang       string
+}
+
+func (e *runtimeExceededError) Error() string { return trLang(e.lang, msgRunTimedOut, e.maxRuntime) }
+
+// runtimeExceeded 判断 ctx 是否因运行超过 cleanup.maxRuntime 而结束
+func runtimeExceeded(ctx context.Context) bool {
+	var rerr *runtimeExceededError
+	return errors.As(context.Cause(ctx), &rerr)
+}
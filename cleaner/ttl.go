@@ -0,0 +1,82 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// objectExpiry 查询对象的标签和用户元数据中的过期时间，两者都配置时优先使用标签。对象没有过期时间时返回 false
+func (e *enricher) objectExpiry(ctx context.Context, obj minio.ObjectInfo, ttl *rules.TTLConfig) (string, bool, error) {
+	bucket := e.bucket
+	if ttl.Tag != "" {
+		var tags map[string]string
+		err := withTimeout(ctx, "stat", e.cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			tags, err = e.store.objectTags(ctx, bucket, obj.Key)
+			return err
+		})
+		if err != nil {
+			return "", false, err
+		}
+		if v, ok := tags[ttl.Tag]; ok {
+			return v, true, nil
+		}
+	}
+	if ttl.Metadata != "" {
+		var info minio.ObjectInfo
+		err := withTimeout(ctx, "stat", e.cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			info, err = e.store.stat(ctx, bucket, obj.Key)
+			return err
+		})
+		if err != nil {
+			return "", false, err
+		}
+		// 元数据名不区分大小写，S3 返回的元数据名首字母大写
+		for k, v := range info.UserMetadata {
+			if strings.EqualFold(k, ttl.Metadata) {
+				return v, true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// checkTTL 按对象自带的过期时间判断是否清理，没有过期时间的对象按规则的 maxAge 判断。
+// 查询失败或过期时间无法解析时保留对象，查询失败记为错误
+func (e *enricher) checkTTL(ctx context.Context, c candidate) bool {
+	bucket, obj, rule := e.bucket, c.obj, c.rule
+	value, ok, err := e.objectExpiry(ctx, obj, &rule.TTL)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.fail(tr(e.cfg, msgTTLFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "rule", rule.Name, "action", "ttl", "error", err)
+		}
+		return false
+	}
+	if !ok {
+		if !c.expired {
+			slog.Debug(tr(e.cfg, msgSkipMaxAge, obj.Key, obj.LastModified),
+				"bucket", bucket, "key", obj.Key, "size", obj.Size, "lastModified", obj.LastModified, "rule", rule.Name, "action", "skip", "reason", rules.SkipMaxAge)
+		}
+		return c.expired
+	}
+	expiry, err := rules.ParseExpiry(value, obj.LastModified, e.now.Location(), e.cfg.Cleanup.Language)
+	if err != nil {
+		slog.Warn(tr(e.cfg, msgTTLSkipInvalid, obj.Key, err),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", rules.SkipTTL, "ttl", value)
+		return false
+	}
+	if expiry.After(e.now) {
+		if c.expired {
+			e.count(e.ttlKept)
+		}
+		slog.Debug(tr(e.cfg, msgSkipTTL, obj.Key, value, expiry),
+			"bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", rules.SkipTTL, "ttl", value, "expiry", expiry)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+func TestSelectCandidateTTL(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	compiled := rules.Compile([]rules.Rule{
+		{Name: "ttl", Prefix: "ttl/", MaxAge: rules.Retention(30 * rules.Day), TTL: rules.TTLConfig{Tag: "ttl"}},
+		{Name: "plain", Prefix: "plain/", MaxAge: rules.Retention(30 * rules.Day)},
+	}, now, rules.Retention(time.Hour))
+	tests := []struct {
+		name        string
+		key         string
+		age         time.Duration
+		wantReason  string
+		wantTTL     bool
+		wantExpired bool
+	}{
+		// 对象自带的过期时间可能早于 maxAge，未到 maxAge 的对象也交给 enricher 判断
+		{name: "ttl before maxAge", key: "ttl/a", age: 2 * rules.Day, wantTTL: true},
+		{name: "ttl after maxAge", key: "ttl/a", age: 31 * rules.Day, wantTTL: true, wantExpired: true},
+		{name: "ttl newer than minObjectAge", key: "ttl/a", age: time.Minute, wantReason: rules.SkipMaxAge},
+		{name: "without ttl", key: "plain/a", age: 2 * rules.Day, wantReason: rules.SkipMaxAge},
+		{name: "without ttl expired", key: "plain/a", age: 31 * rules.Day, wantExpired: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, reason := selectCandidate(compiled, testObject(tt.key, now.Add(-tt.age), 1))
+			if reason != tt.wantReason || c.ttl != tt.wantTTL || c.expired != tt.wantExpired {
+				t.Errorf("selectCandidate(%s) = reason %q, ttl %v, expired %v, want %q, %v, %v",
+					tt.key, reason, c.ttl, c.expired, tt.wantReason, tt.wantTTL, tt.wantExpired)
+			}
+		})
+	}
+}
+
+// tagStore 返回固定的对象标签
+type tagStore struct {
+	memStore
+	tags map[string]string
+}
+
+func (s *tagStore) objectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	return s.tags, nil
+}
+
+func TestCheckTTL(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	rule := rules.Compile([]rules.Rule{{Name: "ttl", MaxAge: rules.Retention(30 * rules.Day), TTL: rules.TTLConfig{Tag: "ttl"}}}, now, 0)[0]
+	tests := []struct {
+		name string
+		tags map[string]string
+		age  time.Duration
+		want bool
+	}{
+		{name: "expired", tags: map[string]string{"ttl": "7d"}, age: 10 * rules.Day, want: true},
+		{name: "not expired", tags: map[string]string{"ttl": "90d"}, age: 40 * rules.Day},
+		{name: "without tag", age: 40 * rules.Day, want: true},
+		{name: "without tag before maxAge", age: 10 * rules.Day},
+		{name: "invalid", tags: map[string]string{"ttl": "soon"}, age: 40 * rules.Day},
+		// 无效的保留时间保留对象，即使已超过 maxAge
+		{name: "zero", tags: map[string]string{"ttl": "0"}, age: 40 * rules.Day},
+		{name: "negative", tags: map[string]string{"ttl": "-1"}, age: 40 * rules.Day},
+		{name: "overflow days", tags: map[string]string{"ttl": "110000d"}, age: 40 * rules.Day},
+		{name: "overflow weeks", tags: map[string]string{"ttl": "300000w"}, age: 40 * rules.Day},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &enricher{cfg: testConfig(), store: &tagStore{tags: tt.tags}, bucket: "b", now: now,
+				fail: func(msg string, attrs ...any) { t.Errorf("unexpected failure: %s", msg) }}
+			obj := testObject("a", now.Add(-tt.age), 1)
+			c := candidate{obj: obj, rule: rule, ttl: true, expired: tt.age > 30*rules.Day}
+			if got := e.checkTTL(context.Background(), c); got != tt.want {
+				t.Errorf("checkTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package cleaner
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// tuiSort 为 tui 中候选对象的排序方式
+type tuiSort int
+
+const (
+	tuiSortSize   tuiSort = iota // 按大小从大到小
+	tuiSortAge                   // 按年龄从老到新
+	tuiSortPrefix                // 按对象名排序，同一前缀的对象相邻
+)
+
+// 方向键等转义序列转换为负数的按键值，普通字符为其编码
+const (
+	keyUp = -(iota + 1)
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+)
+
+// errTUIInterrupted 表示操作者在 tui 中按下了 Ctrl+C
+var errTUIInterrupted = errors.New("interrupted")
+
+// tuiEntry 为 tui 中的一个待清理对象，keep 为 true 时标记为保留
+type tuiEntry struct {
+	candidateEntry
+	keep bool
+}
+
+// tuiBrowser 以终端表格显示一个目标的待清理对象，由操作者标记保留或删除
+type tuiBrowser struct {
+	out  io.Writer
+	in   *os.File
+	keys *bufio.Reader
+
+	cluster, bucket string
+	prefixDepth     int
+	now             time.Time
+	entries         []*tuiEntry
+
+	sort    tuiSort
+	reverse bool
+	cursor  int
+	top     int
+	// prompt 不为空时显示在底部，等待操作者按 y 确认
+	prompt string
+}
+
+// BrowseTargets 逐个目标列出待清理对象并进入 tui，返回带有选中对象集合的目标。
+// 每个目标在 tui 中按 Enter 确认后才会清理，按 q 结束浏览时其余目标全部跳过
+func BrowseTargets(ctx context.Context, targets []Target, in *os.File, out io.Writer) []Target {
+	keys := bufio.NewReader(in)
+	var browsed []Target
+	var files, bytes int64
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			return nil
+		}
+		cluster, bucket := t.cfg.Minio.Name, t.cfg.Minio.Bucket
+		fmt.Fprintln(out, tr(t.cfg, msgConfirmScanning, cluster, bucket))
+		entries, failures, tooMany := collectTUIEntries(ctx, t.cfg, t.store)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if tooMany {
+			slog.Warn(tr(t.cfg, msgReviewTooMany, cluster, bucket, maxReviewObjects), "cluster", cluster, "bucket", bucket, "action", "tui")
+			continue
+		}
+		if failures > 0 {
+			slog.Warn(tr(t.cfg, msgConfirmListFailed, cluster, bucket, failures), "cluster", cluster, "bucket", bucket, "action", "tui")
+			continue
+		}
+		if len(entries) == 0 {
+			fmt.Fprintln(out, tr(t.cfg, msgConfirmNothing, cluster, bucket))
+			continue
+		}
+
+		b := &tuiBrowser{out: out, in: in, keys: keys, cluster: cluster, bucket: bucket,
+			prefixDepth: t.cfg.Report.PrefixDepth, now: time.Now(), entries: entries}
+		selected, quit, err := b.run()
+		if errors.Is(err, errTUIInterrupted) {
+			fmt.Fprintln(out, tr(t.cfg, msgReviewCancelled))
+			return nil
+		}
+		if err != nil {
+			slog.Error(tr(t.cfg, msgTUIFailed, err), "action", "tui", "error", err)
+			return nil
+		}
+		if len(selected) > 0 {
+			t.approved = selected
+			browsed = append(browsed, t)
+			f, n := b.totals()
+			files += f
+			bytes += n
+		}
+		if quit {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if files == 0 {
+		fmt.Fprintln(out, tr(nil, msgReviewNone))
+		return nil
+	}
+	fmt.Fprintln(out, tr(nil, msgTUISelected, files, float64(bytes)/1024/1024/1024))
+	return browsed
+}
+
+// collectTUIEntries 列举目标存储桶中的待清理对象，默认全部标记为删除，
+// 同时返回列举错误数和待清理对象是否超过 maxReviewObjects 个
+func collectTUIEntries(ctx context.Context, cfg *config.Config, store objectStore) ([]*tuiEntry, int64, bool) {
+	var mu sync.Mutex
+	var entries []*tuiEntry
+	failures, tooMany := scanReviewCandidates(ctx, cfg, store, func(obj minio.ObjectInfo, rule *rules.Compiled) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, &tuiEntry{candidateEntry: candidateEntry{Key: obj.Key, Size: obj.Size, LastModified: obj.LastModified, Rule: rule.Name}})
+	})
+	return entries, failures, tooMany
+}
+
+// run 显示表格并处理按键，直到操作者确认（返回选中删除的对象）、跳过该目标（返回 nil）或结束浏览（quit 为 true）。
+// 按 Ctrl+C 时返回 errTUIInterrupted，所有目标都不清理
+func (b *tuiBrowser) run() (selected map[string]bool, quit bool, err error) {
+	restore, err := rawTerminal(b.in)
+	if err != nil {
+		return nil, false, err
+	}
+	// 使用备用屏幕并隐藏光标，退出后恢复原有的终端内容
+	fmt.Fprint(b.out, "\033[?1049h\033[?25l")
+	defer func() {
+		fmt.Fprint(b.out, "\033[?25h\033[?1049l")
+		restore()
+	}()
+
+	b.sortEntries()
+	b.cursor = 0
+	for {
+		b.render()
+		key, err := readKey(b.keys)
+		if err != nil {
+			return nil, true, nil
+		}
+		if key == 0x03 {
+			return nil, true, errTUIInterrupted
+		}
+
+		if b.prompt != "" {
+			b.prompt = ""
+			if key == 'y' || key == 'Y' {
+				return b.selected(), false, nil
+			}
+			continue
+		}
+		page := b.pageSize()
+		switch key {
+		case keyUp, 'k':
+			b.move(-1)
+		case keyDown, 'j':
+			b.move(1)
+		case keyPageUp, 'b':
+			b.move(-page)
+		case keyPageDown, 'f':
+			b.move(page)
+		case keyHome, 'g':
+			b.move(-len(b.entries))
+		case keyEnd, 'G':
+			b.move(len(b.entries))
+		case ' ':
+			e := b.entries[b.cursor]
+			e.keep = !e.keep
+			b.move(1)
+		case 'p':
+			// 同一前缀的对象统一标记为与当前对象相反的状态
+			cur := b.entries[b.cursor]
+			prefix, keep := prefixAt(cur.Key, b.prefixDepth), !cur.keep
+			for _, e := range b.entries {
+				if prefixAt(e.Key, b.prefixDepth) == prefix {
+					e.keep = keep
+				}
+			}
+		case 'a', 'n':
+			for _, e := range b.entries {
+				e.keep = key == 'n'
+			}
+		case 's':
+			b.sort = (b.sort + 1) % 3
+			b.sortEntries()
+		case 'r':
+			b.reverse = !b.reverse
+			b.sortEntries()
+		case '\r', '\n':
+			files, bytes := b.totals()
+			if files == 0 {
+				return nil, false, nil
+			}
+			b.prompt = tr(nil, msgTUIConfirm, files, float64(bytes)/1024/1024/1024)
+		case 'q', 0x1b:
+			return nil, true, nil
+		}
+	}
+}
+
+// sortEntries 按当前排序方式排序，光标保持在原来的对象上
+func (b *tuiBrowser) sortEntries() {
+	var cur *tuiEntry
+	if b.cursor < len(b.entries) {
+		cur = b.entries[b.cursor]
+	}
+	slices.SortStableFunc(b.entries, func(x, y *tuiEntry) int {
+		var c int
+		switch b.sort {
+		case tuiSortSize:
+			c = cmp.Compare(y.Size, x.Size)
+		case tuiSortAge:
+			c = x.LastModified.Compare(y.LastModified)
+		}
+		c = cmp.Or(c, strings.Compare(x.Key, y.Key))
+		if b.reverse {
+			c = -c
+		}
+		return c
+	})
+	b.cursor = max(slices.Index(b.entries, cur), 0)
+}
+
+func (b *tuiBrowser) move(delta int) {
+	b.cursor = min(max(b.cursor+delta, 0), len(b.entries)-1)
+}
+
+// pageSize 为表格可显示的行数，除去标题、表头和底部的两行
+func (b *tuiBrowser) pageSize() int {
+	rows, _ := terminalSize(b.in)
+	return max(rows-4, 1)
+}
+
+func (b *tuiBrowser) totals() (files, bytes int64) {
+	for _, e := range b.entries {
+		if !e.keep {
+			files++
+			bytes += e.Size
+		}
+	}
+	return files, bytes
+}
+
+func (b *tuiBrowser) selected() map[string]bool {
+	m := make(map[string]bool)
+	for _, e := range b.entries {
+		if !e.keep {
+			m[e.Key] = true
+		}
+	}
+	return m
+}
+
+// render 重新绘制整个屏幕：标题、表头、当前页的对象和按键说明
+func (b *tuiBrowser) render() {
+	rows, cols := terminalSize(b.in)
+	page := max(rows-4, 1)
+	if b.cursor < b.top {
+		b.top = b.cursor
+	} else if b.cursor >= b.top+page {
+		b.top = b.cursor - page + 1
+	}
+
+	w := bufio.NewWriter(b.out)
+	defer w.Flush()
+	line := func(style, s string) {
+		w.WriteString(style + truncateWidth(s, cols) + "\033[K\033[0m\r\n")
+	}
+	w.WriteString("\033[H")
+
+	files, bytes := b.totals()
+	sortNames := []msgID{msgTUISortSize, msgTUISortAge, msgTUISortPrefix}
+	order := "↓"
+	if b.reverse {
+		order = "↑"
+	}
+	line("\033[1m", tr(nil, msgTUITitle, b.cluster, b.bucket, len(b.entries), files, float64(bytes)/1024/1024/1024, tr(nil, sortNames[b.sort])+order))
+	line("", "    "+padWidth(tr(nil, msgTUIColSize), 12, true)+" "+padWidth(tr(nil, msgTUIColAge), 6, true)+"  "+
+		padWidth(tr(nil, msgTUIColModified), 16, false)+"  "+tr(nil, msgTUIColKey))
+
+	for i := b.top; i < b.top+page; i++ {
+		if i >= len(b.entries) {
+			line("", "")
+			continue
+		}
+		e := b.entries[i]
+		mark, style := "[x]", ""
+		if e.keep {
+			mark, style = "[ ]", "\033[2m"
+		}
+		if i == b.cursor {
+			style += "\033[7m"
+		}
+		days := int(b.now.Sub(e.LastModified).Hours() / 24)
+		row := fmt.Sprintf("%s %9.2f MB %5dd  %s  %s", mark, float64(e.Size)/1024/1024, days,
+			e.LastModified.Local().Format("2006-01-02 15:04"), e.Key)
+		line(style, row)
+	}
+
+	if b.prompt != "" {
+		line("\033[1m", b.prompt)
+	} else {
+		line("", tr(nil, msgTUIHelp))
+	}
+	w.WriteString("\033[J")
+}
+
+// runeWidth 为字符在终端中占的列数，中日韩等全角字符按两列计算
+func runeWidth(r rune) int {
+	if r >= 0x2e80 {
+		return 2
+	}
+	return 1
+}
+
+// truncateWidth 将 s 截断为在终端中最多占 n 列
+func truncateWidth(s string, n int) string {
+	width := 0
+	for i, r := range s {
+		if width+runeWidth(r) > n {
+			return s[:i]
+		}
+		width += runeWidth(r)
+	}
+	return s
+}
+
+// padWidth 用空格将 s 补齐到在终端中占 n 列，right 为 true 时右对齐
+func padWidth(s string, n int, right bool) string {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	pad := strings.Repeat(" ", max(n-width, 0))
+	if right {
+		return pad + s
+	}
+	return s + pad
+}
+
+// readKey 读取一个按键，方向键等转义序列转换为 keyUp 等常量
+func readKey(r *bufio.Reader) (int, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	// 单独按下 Esc 时之后没有其他输入，方向键的转义序列则一次到达
+	if c == 0x1b && r.Buffered() > 0 {
+		return readEscape(r), nil
+	}
+	return int(c), nil
+}
+
+// readEscape 解析 ESC 之后的 CSI 序列，无法识别时返回 0
+func readEscape(r *bufio.Reader) int {
+	c, err := r.ReadByte()
+	if err != nil || (c != '[' && c != 'O') {
+		return 0
+	}
+	var seq []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0
+		}
+		seq = append(seq, c)
+		if c >= 0x40 && c <= 0x7e {
+			break
+		}
+	}
+	switch string(seq) {
+	case "A":
+		return keyUp
+	case "B":
+		return keyDown
+	case "5~":
+		return keyPageUp
+	case "6~":
+		return keyPageDown
+	case "H", "1~", "7~":
+		return keyHome
+	case "F", "4~", "8~":
+		return keyEnd
+	}
+	return 0
+}
+
+// rawTerminal 通过 stty 关闭终端的行缓冲、回显和信号，按键无需回车即可读取，Ctrl+C 作为普通按键读取。
+// 返回恢复终端设置的函数
+func rawTerminal(f *os.File) (func(), error) {
+	saved, err := stty(f, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(f, "-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() { stty(f, strings.TrimSpace(saved)) }, nil
+}
+
+// terminalSize 返回终端的行数和列数，无法获取时为 24 行 80 列
+func terminalSize(f *os.File) (rows, cols int) {
+	out, err := stty(f, "size")
+	if err == nil {
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return rows, cols
+		}
+	}
+	return 24, 80
+}
+
+func stty(f *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,409 @@
+package cleaner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// validateConfig 检查配置项的取值和相互之间的冲突，不连接 MinIO，返回发现的全部问题
+func validateConfig(cfg *config.Config) []string {
+	var problems []string
+	add := func(id msgID, args ...any) {
+		problems = append(problems, tr(cfg, id, args...))
+	}
+
+	rules := cfg.Rules
+	if cfg.RulesDir != "" && !cfg.RulesDirLoaded() {
+		dirRules, dirProblems := config.LoadRulesDir(cfg.RulesDir)
+		problems = append(problems, dirProblems...)
+		rules = append(slices.Clip(rules), dirRules...)
+	}
+
+	c := &cfg.Cleanup
+	if c.Workers <= 0 {
+		add(msgValidatePositive, "cleanup.workers", c.Workers)
+	}
+	if len(rules) == 0 && c.MaxAge <= 0 && !cfg.PresetsCoverTargets() {
+		add(msgValidatePositive, "cleanup.maxAge", c.MaxAge)
+	}
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"cleanup.minSize", float64(c.MinSize)},
+		{"cleanup.listers", float64(c.Listers)},
+		{"cleanup.parallelTargets", float64(c.ParallelTargets)},
+		{"cleanup.maxDeletesPerSecond", c.MaxDeletesPerSecond},
+		{"cleanup.maxBandwidth", float64(c.MaxBandwidth)},
+		{"cleanup.maxWorkerBandwidth", float64(c.MaxWorkerBandwidth)},
+		{"cleanup.maxErrors", float64(c.MaxErrors)},
+		{"cleanup.maxRuntime", float64(c.MaxRuntime)},
+		{"cleanup.maxAPICalls", float64(c.MaxAPICalls)},
+		{"canary.size", float64(cfg.Canary.Size)},
+		{"canary.delay", float64(cfg.Canary.Delay)},
+		{"verification.sample", float64(cfg.Verification.Sample)},
+	} {
+		if f.value < 0 {
+			add(msgValidateNegative, f.name)
+		}
+	}
+	if c.MaxErrorRate < 0 || c.MaxErrorRate > 1 {
+		add(msgValidateRange, "cleanup.maxErrorRate", 0, 1, c.MaxErrorRate)
+	}
+	if len(cfg.FilterPlugin.Command) > 0 && cfg.FilterPlugin.URL != "" {
+		add(msgPluginBoth)
+	}
+	if h := &cfg.Hooks.PreDelete; len(h.Command) > 0 && h.URL != "" {
+		add(msgHookBoth, "hooks.preDelete")
+	}
+	if h := &cfg.Hooks.PostDelete; len(h.Command) > 0 && h.URL != "" {
+		add(msgHookBoth, "hooks.postDelete")
+	}
+	if e := &cfg.Events; e.Kafka.Enabled() && e.NATS.Enabled() {
+		add(msgEventsBoth)
+	}
+	if e := &cfg.Events.Kafka; e.Enabled() && e.Topic == "" {
+		add(msgEventsNoTopic, "events.kafka.topic")
+	}
+	if e := &cfg.Events.NATS; e.Enabled() && e.Subject == "" {
+		add(msgEventsNoTopic, "events.nats.subject")
+	}
+	if g := &cfg.Daemon.GRPC; (g.CertFile == "") != (g.KeyFile == "") {
+		add(msgGRPCCertPair)
+	}
+	// 控制服务可以启动删除对象的运行，不允许不认证
+	if g := &cfg.Daemon.GRPC; g.Addr != "" && g.Token == "" && g.ClientCAFile == "" {
+		add(msgGRPCNoAuth)
+	}
+	if g := &cfg.Daemon.GRPC; g.ClientCAFile != "" && g.CertFile == "" {
+		add(msgGRPCClientCANoTLS)
+	}
+	if err := cfg.Presets.GitLab.ValidateLayout(); err != nil {
+		add(msgCILayoutInvalid, "gitlab", err)
+	}
+	if err := cfg.Presets.Jenkins.ValidateLayout(); err != nil {
+		add(msgCILayoutInvalid, "jenkins", err)
+	}
+	problems = append(problems, validateDedup(cfg)...)
+	problems = append(problems, validateCost(&cfg.Cost, cfg.Cleanup.Language)...)
+	if a := &cfg.Daemon.API; a.Addr != "" {
+		if len(a.Tenants) == 0 {
+			add(msgJobsNoToken)
+		}
+		teams := make(map[string]bool)
+		for i, t := range a.Tenants {
+			if t.Team == "" || t.Token == "" {
+				add(msgJobsTokenEmpty, i)
+			} else if teams[t.Team] {
+				add(msgJobsTeamDuplicate, t.Team)
+			}
+			teams[t.Team] = true
+			if t.MaxDeletesPerSecond < 0 {
+				add(msgValidateNegative, fmt.Sprintf("daemon.api.tenants[%d].maxDeletesPerSecond", i))
+			}
+			if hasPartialCreds(t.AccessKeyID, t.AccessKeyIDFile, t.SecretAccessKey, t.SecretAccessKeyFile) {
+				add(msgJobsTenantCreds, i)
+			} else if !t.HasCreds() {
+				add(msgJobsTenantNoCreds, i)
+			}
+			if len(t.Buckets) == 0 {
+				add(msgJobsNoBuckets, i)
+			}
+		}
+		if (a.CertFile == "") != (a.KeyFile == "") {
+			add(msgJobsCertPair)
+		}
+	}
+	for i, r := range cfg.Watch.Rules {
+		if r.Prefix == "" && len(r.Suffixes) == 0 && r.MinSize <= 0 {
+			add(msgWatchRuleEmpty, i)
+		}
+	}
+	if len(cfg.Watch.Rules) > 0 && (cfg.Approval.Enabled() || cfg.Canary.Size > 0) {
+		add(msgWatchApproval)
+	}
+	if tag := cfg.KillSwitch.Tag; tag != "" && !strings.Contains(tag, "=") {
+		add(msgKillSwitchBadTag, tag)
+	}
+	if p := cfg.Safety.MaxDeletePercent; p < 0 || p > 100 {
+		add(msgValidateRange, "safety.maxDeletePercent", 0, 100, p)
+	}
+	if _, err := parseLogLevel(c.LogLevel, cfg.Cleanup.Language); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := i18n.ParseLanguage(c.Language); c.Language != "" && err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.LogFormat != "" && c.LogFormat != "text" && c.LogFormat != "json" {
+		add(msgLogBadFormat, c.LogFormat)
+	}
+	if f := manifestFormat(cfg.Report.ManifestFile, cfg.Report.ManifestFormat); f != "csv" && f != "jsonl" {
+		add(msgManifestBadFormat, f)
+	}
+	if network := strings.ToLower(cfg.Syslog.Network); network != "" && network != "udp" && network != "tcp" {
+		add(msgSyslogBadNetwork, cfg.Syslog.Network)
+	}
+	if _, ok := syslogFacilities[strings.ToLower(cfg.Syslog.Facility)]; !ok && cfg.Syslog.Facility != "" {
+		add(msgSyslogBadFacility, cfg.Syslog.Facility)
+	}
+	for _, chat := range cfg.Notify.Chat {
+		if !slices.Contains(config.ChatTypes, chat.Type) {
+			add(msgChatBadType, chat.Type)
+		}
+	}
+
+	problems = append(problems, validateRules(rules, cfg.Cleanup.Language)...)
+	// 保留时间为 0 或负数的规则已由 validateRules 报告
+	withDir := *cfg
+	withDir.Rules = rules
+	for _, r := range shortRules(effectiveRules(&withDir), cfg.Safety.MinObjectAge) {
+		if r.MaxAge > 0 {
+			add(msgSafetyShortRule, r.Name, r.MaxAge, cfg.Safety.MinObjectAge)
+		}
+	}
+
+	// 组的完整性在运行开始时判断，删除时才逐个决定保留对象的功能会使组只删除一部分
+	if cfg.GroupsObjects(effectiveRules(&withDir)) {
+		for _, o := range []struct {
+			name string
+			on   bool
+		}{
+			{"filterPlugin", cfg.FilterPlugin.Enabled()},
+			{"hooks.preDelete", cfg.Hooks.PreDelete.Enabled()},
+			{"cleanup.verifyBeforeDelete", cfg.Cleanup.VerifyBeforeDelete},
+			{"cleanup.skipUnreplicated", cfg.Cleanup.SkipUnreplicated},
+			{"inventory", cfg.Inventory.Enabled()},
+		} {
+			if o.on {
+				add(msgGroupsPerObject, o.name)
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, cluster := range cfg.AllClusters() {
+		problems = append(problems, validateCluster(&cluster, names, cfg.Cleanup.Language)...)
+		if len(cfg.Watch.Rules) > 0 && (cluster.Type == storageAzure || cluster.Type == storageGCS) {
+			add(msgWatchNoSupport, cmp.Or(cluster.Name, cluster.Endpoint), cluster.Type)
+		}
+	}
+	return problems
+}
+
+// validateRules 检查规则的保留天数、重名，以及因前面的规则前缀更短而永远不会生效的规则，问题的描述使用语言 lang
+func validateRules(rs []rules.Rule, lang string) []string {
+	if len(rs) == 0 {
+		return nil
+	}
+	var problems []string
+	effective := effectiveRules(&config.Config{Rules: rs})
+	names := make(map[string]bool)
+	for i, r := range effective {
+		if r.MaxAge <= 0 {
+			problems = append(problems, trLang(lang, msgValidatePositive, fmt.Sprintf("rules[%d].maxAge", i), r.MaxAge))
+		}
+		if r.MinSize < 0 {
+			problems = append(problems, trLang(lang, msgValidateNegative, fmt.Sprintf("rules[%d].minSize", i)))
+		}
+		if r.GroupDepth < 0 {
+			problems = append(problems, trLang(lang, msgValidateNegative, fmt.Sprintf("rules[%d].groupDepth", i)))
+		}
+		if r.Partition != "" {
+			if _, err := rules.ParsePartition(r.Partition, lang); err != nil {
+				problems = append(problems, trLang(lang, msgPartitionInvalid, i, r.Partition, err))
+			}
+			if r.GroupDepth > 0 {
+				problems = append(problems, trLang(lang, msgPartitionGroupDepth, i))
+			}
+		}
+		for name, n := range map[string]int{"daily": r.GFS.Daily, "weekly": r.GFS.Weekly, "monthly": r.GFS.Monthly} {
+			if n < 0 {
+				problems = append(problems, trLang(lang, msgValidateNegative, fmt.Sprintf("rules[%d].gfs.%s", i, name)))
+			}
+		}
+		// 从配置文件解析时已经检查过取值，在代码中构造的规则在这里检查
+		if r.KeepLastOf != "" && !slices.Contains(rules.Periods, r.KeepLastOf) {
+			problems = append(problems, trLang(lang, msgBadPeriod, r.KeepLastOf))
+		}
+		if !r.SuspendUntil.Valid() {
+			problems = append(problems, trLang(lang, msgBadUntil, r.SuspendUntil))
+		}
+		if (r.KeepLastOf != "" || r.GFS.Enabled()) && (r.GroupDepth > 0 || r.Partition != "") {
+			problems = append(problems, trLang(lang, msgCalendarGroups, i))
+		}
+		if r.TTL.Enabled() && (r.GroupDepth > 0 || r.Partition != "") {
+			problems = append(problems, trLang(lang, msgTTLGroups, i))
+		}
+		if names[r.Name] {
+			problems = append(problems, trLang(lang, msgValidateRuleDup, r.Name))
+		}
+		names[r.Name] = true
+
+		// 规则按顺序匹配，前面规则的前缀是该规则前缀的前缀时，该规则永远不会被匹配
+		for _, prev := range effective[:i] {
+			if strings.HasPrefix(r.Prefix, prev.Prefix) {
+				problems = append(problems, trLang(lang, msgValidateRuleShadow, r.Name, prev.Name, prev.Prefix, r.Prefix))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// validateCluster 检查一个集群的连接配置，names 用于检查集群名称是否重复，问题的描述使用语言 lang
+func validateCluster(m *config.MinioConfig, names map[string]bool, lang string) []string {
+	var problems []string
+	add := func(id msgID, args ...any) {
+		problems = append(problems, trLang(lang, id, args...))
+	}
+
+	name := m.Name
+	if name == "" {
+		name = cmp.Or(m.EndpointAlias, m.Endpoint)
+	}
+	if names[name] {
+		add(msgTargetDuplicate, name)
+	}
+	names[name] = true
+
+	switch m.Type {
+	case "", storageS3:
+		if m.Endpoint == "" && m.EndpointAlias == "" {
+			add(msgValidateNoEndpoint, name)
+		}
+	case storageAzure:
+		if m.Endpoint == "" {
+			add(msgValidateNoEndpoint, name)
+		}
+	case storageGCS:
+	default:
+		add(msgStorageInvalid, m.Type)
+	}
+	if m.EndpointAlias != "" && m.Type != "" && m.Type != storageS3 {
+		add(msgAliasS3Only, name)
+	}
+	if m.AdminAPI && m.Type != "" && m.Type != storageS3 {
+		add(msgAdminS3Only, name)
+	}
+	if m.RemoveEmptyBuckets && m.BucketPattern == "" {
+		add(msgBucketRmNoPattern, name)
+	}
+	if m.RemoveEmptyBuckets && m.Type == storageAzure {
+		add(msgBucketRmAzure, name)
+	}
+	if hasPartialCreds(m.AccessKeyID, m.AccessKeyIDFile, m.SecretAccessKey, m.SecretAccessKeyFile) {
+		add(msgTargetClusterCreds, name)
+	}
+	if m.Bucket == "" && len(m.Buckets) == 0 && m.BucketPattern == "" {
+		add(msgTargetNoBucket, name)
+	}
+	for _, b := range m.Buckets {
+		if b.Name == "" {
+			add(msgTargetNoBucketName, name)
+		} else if hasPartialCreds(b.AccessKeyID, b.AccessKeyIDFile, b.SecretAccessKey, b.SecretAccessKeyFile) {
+			add(msgTargetPartialCreds, name, b.Name)
+		}
+		if _, ok := appPresets[b.Preset]; b.Preset != "" && !ok {
+			add(msgPresetUnknown, b.Preset, presetNames())
+		}
+	}
+	if _, ok := appPresets[m.Preset]; m.Preset != "" && !ok {
+		add(msgPresetUnknown, m.Preset, presetNames())
+	}
+	if m.BucketPattern != "" {
+		if _, err := regexp.Compile(m.BucketPattern); err != nil {
+			add(msgTargetBadPattern, name, err)
+		}
+	}
+	if _, ok := bucketLookups[m.Addressing]; !ok {
+		add(msgAddressingInvalid, m.Addressing)
+	}
+	if m.STS.Type != "" && m.STS.Type != stsAssumeRole && m.STS.Type != stsWebIdentity {
+		add(msgSTSUnknownType, m.STS.Type)
+	}
+	if (m.TLS.CertFile == "") != (m.TLS.KeyFile == "") {
+		add(msgTLSPartialCert)
+	}
+	if m.Transport.Proxy != "" {
+		if _, err := proxyFunc(m.Transport.Proxy, lang); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// validateProfile 检查合并了 profile 后的配置，问题前加上 profile 名称。
+// profile 中的配置项按原文件的行号报告，合并后的配置只检查取值
+func validateProfile(path string, data []byte, name string) []string {
+	doc, err := config.ParseFile(path, data)
+	if err != nil {
+		return nil
+	}
+	var problems []string
+	if node, ok := config.ProfileNodes(doc)[name]; ok && node.Kind == yaml.MappingNode {
+		profileDoc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}
+		if err := config.Decode(profileDoc, &config.Config{}, true); err != nil {
+			problems = append(problems, config.DecodeErrors(err)...)
+		}
+	}
+	if err := config.ApplyProfile(doc, name); err != nil {
+		return append(problems, err.Error())
+	}
+	cfg := &config.Config{}
+	// 顶层配置和 profile 中的解析错误已在前面报告
+	config.Decode(doc, cfg, false)
+	config.SetDefaults(cfg)
+	problems = append(problems, validateConfig(cfg)...)
+	for i, p := range problems {
+		problems[i] = tr(cfg, msgValidateProfile, name, p)
+	}
+	return problems
+}
+
+// ValidateFile 严格解析配置文件并检查取值，不连接存储服务，与 validate 命令相同。
+// 配置了 profiles 时检查合并了各个 profile 后的配置，profile 不为空时只检查该 profile。
+// 返回发现的全部问题，读取文件失败时返回错误
+func ValidateFile(path, profile string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config.Config{}
+	doc, decodeErr := config.ParseFile(path, data)
+	if decodeErr == nil {
+		decodeErr = config.Decode(doc, cfg, true)
+	}
+	config.SetDefaults(cfg)
+
+	var problems []string
+	if decodeErr != nil {
+		problems = append(problems, config.DecodeErrors(decodeErr)...)
+	}
+	// 无法解析时其余配置不可信，不再继续检查
+	var terr *yaml.TypeError
+	if decodeErr == nil || errors.As(decodeErr, &terr) {
+		// 配置了 profiles 时顶层配置可能只包含各 profile 共用的部分，只检查合并后的配置
+		profiles := slices.Sorted(maps.Keys(cfg.Profiles))
+		if name := config.ProfileName(profile); name != "" {
+			profiles = []string{name}
+		}
+		if len(profiles) == 0 {
+			problems = append(problems, validateConfig(cfg)...)
+		}
+		for _, name := range profiles {
+			problems = append(problems, validateProfile(path, data, name)...)
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,179 @@
+package cleaner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// defaultVaultRefresh 为密钥没有租期时重新读取凭证的间隔
+const defaultVaultRefresh = time.Hour
+
+// vaultProvider 从 Vault KV 读取访问密钥。启动时读取一次，之后每次运行开始时和到达刷新间隔时重新读取
+type vaultProvider struct {
+	credentials.Expiry
+
+	cluster string
+	cfg     config.VaultConfig
+	client  *http.Client
+	lang    string
+}
+
+// newVaultCredentials 返回从 Vault 读取的凭证，并立即读取一次，配置错误时启动即失败
+func newVaultCredentials(m *config.MinioConfig, lang string) (*credentials.Credentials, error) {
+	cfg := m.Vault
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if cfg.AccessKeyField == "" {
+		cfg.AccessKeyField = "accessKeyId"
+	}
+	if cfg.SecretKeyField == "" {
+		cfg.SecretKeyField = "secretAccessKey"
+	}
+	if cfg.KubernetesMount == "" {
+		cfg.KubernetesMount = "kubernetes"
+	}
+	if cfg.JWTFile == "" {
+		cfg.JWTFile = kubernetesTokenFile
+	}
+	creds := credentials.New(&vaultProvider{
+		cluster: m.Name,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		lang:    lang,
+	})
+	if _, err := creds.Get(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+func (p *vaultProvider) Retrieve() (credentials.Value, error) {
+	return p.RetrieveWithCredContext(nil)
+}
+
+func (p *vaultProvider) RetrieveWithCredContext(*credentials.CredContext) (credentials.Value, error) {
+	secret, err := p.read()
+	if err != nil {
+		err = errors.New(trLang(p.lang, msgVaultFailed, p.cfg.Path, err))
+		slog.Error(err.Error(), "cluster", p.cluster, "action", "vault", "path", p.cfg.Path, "error", err)
+		return credentials.Value{}, err
+	}
+	v := credentials.Value{SignerType: credentials.SignatureV4}
+	var ok bool
+	if v.AccessKeyID, ok = secret.Data[p.cfg.AccessKeyField].(string); !ok || v.AccessKeyID == "" {
+		return credentials.Value{}, errors.New(trLang(p.lang, msgVaultNoField, p.cfg.Path, p.cfg.AccessKeyField))
+	}
+	if v.SecretAccessKey, ok = secret.Data[p.cfg.SecretKeyField].(string); !ok || v.SecretAccessKey == "" {
+		return credentials.Value{}, errors.New(trLang(p.lang, msgVaultNoField, p.cfg.Path, p.cfg.SecretKeyField))
+	}
+
+	refresh := p.cfg.Refresh
+	if refresh <= 0 {
+		refresh = time.Duration(secret.LeaseDuration) * time.Second
+	}
+	if refresh <= 0 {
+		refresh = defaultVaultRefresh
+	}
+	p.SetExpiration(time.Now().Add(refresh), 0)
+	slog.Info(trLang(p.lang, msgVaultLoaded, p.cluster, p.cfg.Path), "cluster", p.cluster, "action", "vault", "path", p.cfg.Path)
+	return v, nil
+}
+
+// vaultSecret 为 Vault 读取接口返回的密钥
+type vaultSecret struct {
+	LeaseDuration int            `json:"lease_duration"`
+	Data          map[string]any `json:"data"`
+	Auth          struct {
+		ClientToken string `json:"client_token"`
+	} `json:"auth"`
+}
+
+// read 读取 KV 路径。KV v2 的字段位于 data.data 中
+func (p *vaultProvider) read() (*vaultSecret, error) {
+	token, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := p.do(http.MethodGet, strings.TrimPrefix(p.cfg.Path, "/"), token, nil)
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := secret.Data["data"].(map[string]any); ok {
+		if _, v2 := secret.Data["metadata"]; v2 {
+			secret.Data = data
+		}
+	}
+	return secret, nil
+}
+
+// token 返回访问 Vault 使用的令牌。配置了 kubernetesRole 时每次都使用服务账号令牌重新登录
+func (p *vaultProvider) token() (string, error) {
+	if p.cfg.KubernetesRole != "" {
+		jwt, err := readSecretFile(p.cfg.JWTFile)
+		if err != nil {
+			return "", err
+		}
+		body, _ := json.Marshal(map[string]string{"role": p.cfg.KubernetesRole, "jwt": jwt})
+		secret, err := p.do(http.MethodPost, "auth/"+strings.Trim(p.cfg.KubernetesMount, "/")+"/login", "", body)
+		if err != nil {
+			return "", err
+		}
+		return secret.Auth.ClientToken, nil
+	}
+	if p.cfg.Token != "" {
+		return p.cfg.Token, nil
+	}
+	if p.cfg.TokenFile != "" {
+		return readSecretFile(p.cfg.TokenFile)
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	return "", errors.New(trLang(p.lang, msgVaultNoToken))
+}
+
+// do 调用 Vault HTTP API
+func (p *vaultProvider) do(method, path, token string, body []byte) (*vaultSecret, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(p.cfg.Address, "/")+"/v1/"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", method, path, strings.TrimSpace(string(data)))
+	}
+	var secret vaultSecret
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
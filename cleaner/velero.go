@@ -0,0 +1,96 @@
+package cleaner
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// veleroScheduled 匹配定时备份的名称 <定时任务名>-<YYYYMMDDHHMMSS>
+var veleroScheduled = regexp.MustCompile(`^(.+)-(\d{14})$`)
+
+type veleroPreset struct {
+	cfg    *config.Config
+	preset *config.VeleroPresetConfig
+	// backups 为备份目录的前缀 <prefix>/backups/
+	backups string
+
+	// groups 和 retained 由 prepare 生成，之后只读；groups 为 nil 时保留所有备份
+	groups   *groupIndex
+	retained map[string]bool
+}
+
+func newVeleroPreset(cfg *config.Config) appPreset {
+	v := &cfg.Presets.Velero
+	prefix := strings.Trim(v.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &veleroPreset{cfg: cfg, preset: v, backups: prefix + "backups/"}
+}
+
+func (v *veleroPreset) rules() []rules.Rule {
+	return []rules.Rule{{Name: "velero-backups", Prefix: v.backups, MaxAge: v.preset.MaxAge}}
+}
+
+// backupOf 返回对象所属的备份名称，对象不在备份目录中时返回 false
+func (v *veleroPreset) backupOf(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, v.backups)
+	if !ok {
+		return "", false
+	}
+	name, _, ok := strings.Cut(rest, "/")
+	return name, ok && name != ""
+}
+
+// prepare 列举备份目录，找出整组符合规则的备份和各定时任务最近的备份
+func (v *veleroPreset) prepare(ctx context.Context, store objectStore, now time.Time) error {
+	// 组索引只保存组名的摘要，列举时另外记下定时备份的名称。每个备份只有一个目录，名称的数量不随对象数增长
+	var mu sync.Mutex
+	names := make(map[string]bool)
+	backupOf := func(key string) (string, bool) {
+		name, ok := v.backupOf(key)
+		if ok && veleroScheduled.MatchString(name) {
+			mu.Lock()
+			names[name] = true
+			mu.Unlock()
+		}
+		return name, ok
+	}
+	groups, err := buildGroupIndex(ctx, v.cfg, store, now, backupOf, func(obj rules.ObjectInfo) bool { return filtersKeep(v.cfg.Filters, obj) })
+	if err != nil {
+		return err
+	}
+	// 之后判断对象所属的组时不需要再记录名称
+	groups.groupOf = v.backupOf
+	schedules := make(map[string][]string)
+	for name := range names {
+		m := veleroScheduled.FindStringSubmatch(name)
+		schedules[m[1]] = append(schedules[m[1]], name)
+	}
+	v.retained = make(map[string]bool)
+	for _, names := range schedules {
+		// 名称中的时间戳位数固定，按名称排序即按备份时间排序
+		slices.Sort(names)
+		for _, name := range names[max(len(names)-v.preset.KeepLatest, 0):] {
+			v.retained[name] = true
+		}
+	}
+	v.groups = groups
+	return nil
+}
+
+// keep 只放行定时备份中不在最近 keepLatest 个之内、且整个目录都符合规则的备份
+func (v *veleroPreset) keep(obj rules.ObjectInfo) bool {
+	name, ok := v.backupOf(obj.Key)
+	if !ok || v.groups == nil || !veleroScheduled.MatchString(name) {
+		return true
+	}
+	return v.retained[name] || !v.groups.complete(obj.Key)
+}
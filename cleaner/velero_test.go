@@ -0,0 +1,78 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+func TestVeleroKeep(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-60 * rules.Day)
+	objects := []minio.ObjectInfo{
+		testObject("velero/backups/daily-20240101000000/velero-backup.json", old, 1),
+		testObject("velero/backups/daily-20240101000000/daily-20240101000000.tar.gz", old, 1),
+		testObject("velero/backups/daily-20240102000000/velero-backup.json", old, 1),
+		// 备份目录中有未超过 maxAge 的对象时整个备份保留
+		testObject("velero/backups/daily-20240102000000/daily-20240102000000-logs.gz", now, 1),
+		testObject("velero/backups/daily-20240103000000/velero-backup.json", old, 1),
+		testObject("velero/backups/daily-20240104000000/velero-backup.json", old, 1),
+		testObject("velero/backups/weekly-20240101000000/velero-backup.json", old, 1),
+		testObject("velero/backups/manual/velero-backup.json", old, 1),
+		testObject("velero/restores/restore-1/restore-1-logs.gz", old, 1),
+	}
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "velero/backups/daily-20240101000000/velero-backup.json", want: false},
+		{key: "velero/backups/daily-20240101000000/daily-20240101000000.tar.gz", want: false},
+		{key: "velero/backups/daily-20240102000000/velero-backup.json", want: true},
+		// 每个定时任务最近的 keepLatest 个备份保留
+		{key: "velero/backups/daily-20240103000000/velero-backup.json", want: true},
+		{key: "velero/backups/daily-20240104000000/velero-backup.json", want: true},
+		{key: "velero/backups/weekly-20240101000000/velero-backup.json", want: true},
+		// 手动创建的备份和备份目录之外的对象不由预设清理
+		{key: "velero/backups/manual/velero-backup.json", want: true},
+		{key: "velero/restores/restore-1/restore-1-logs.gz", want: true},
+		// 列举之后才出现的备份保留
+		{key: "velero/backups/daily-20231231000000/velero-backup.json", want: true},
+	}
+	cfg := testConfig()
+	cfg.Minio.Preset = "velero"
+	cfg.Presets.Velero = config.VeleroPresetConfig{Prefix: "/velero/", KeepLatest: 2, MaxAge: rules.Retention(30 * rules.Day)}
+	v := newVeleroPreset(cfg).(*veleroPreset)
+	if err := v.prepare(context.Background(), &memStore{objects: objects}, now); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := v.keep(testObject(tt.key, old, 1)); got != tt.want {
+				t.Errorf("keep(%s) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVeleroPrepareFailed(t *testing.T) {
+	now := time.Now()
+	cfg := testConfig()
+	cfg.Minio.Preset = "velero"
+	cfg.Presets.Velero = config.VeleroPresetConfig{KeepLatest: 1, MaxAge: rules.Retention(rules.Day)}
+	v := newVeleroPreset(cfg).(*veleroPreset)
+	obj := testObject("backups/daily-20240101000000/velero-backup.json", now.Add(-10*rules.Day), 1)
+	store := &memStore{objects: []minio.ObjectInfo{obj}, listErr: errors.New("boom")}
+	if err := v.prepare(context.Background(), store, now); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("prepare() = %v, want list error", err)
+	}
+	// 列举出错时保留所有备份
+	if !v.keep(obj) {
+		t.Error("keep() = false after a failed prepare, want true")
+	}
+}
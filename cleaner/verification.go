@@ -0,0 +1,95 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// verificationSamples 在清理过程中收集复查的对象：deleted 为删除成功的对象，kept 为不符合清理条件的对象
+type verificationSamples struct {
+	deleted *reservoir
+	kept    *reservoir
+}
+
+// newVerificationSamples 按配置创建复查对象的抽样，未启用复查或预览模式下返回 nil
+func newVerificationSamples(cfg *config.Config) *verificationSamples {
+	v := &cfg.Verification
+	if !v.Enabled || cfg.Cleanup.DryRun {
+		return nil
+	}
+	deleted := v.Sample
+	if v.AllDeleted {
+		deleted = -1
+	}
+	return &verificationSamples{deleted: newReservoir(deleted), kept: newReservoir(v.Sample)}
+}
+
+// verifyRun 逐个查询抽取的对象，已删除的对象仍然存在或未清理的对象已不存在时输出警告并记录在复查结果中
+func verifyRun(ctx context.Context, cfg *config.Config, store objectStore, samples *verificationSamples) *report.Verification {
+	bucket := cfg.Minio.Bucket
+	deleted, kept := samples.deleted.items, samples.kept.items
+	slog.Info(tr(cfg, msgRecheckStart, bucket, len(deleted), len(kept)), "bucket", bucket, "action", "verification")
+
+	type check struct {
+		key     string
+		deleted bool
+	}
+	checks := make(chan check)
+	go func() {
+		defer close(checks)
+		for _, obj := range deleted {
+			checks <- check{obj.Key, true}
+		}
+		for _, obj := range kept {
+			checks <- check{obj.Key, false}
+		}
+	}()
+
+	report := &report.Verification{DeletedChecked: len(deleted), KeptChecked: len(kept), StillExists: []string{}, Missing: []string{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range max(cfg.Cleanup.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range checks {
+				if ctx.Err() != nil {
+					continue
+				}
+				err := withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+					_, err := store.stat(ctx, bucket, c.key)
+					return err
+				})
+				exists := err == nil
+				if err != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+					slog.Warn(tr(cfg, msgRecheckFailed, c.key, err), "bucket", bucket, "key", c.key, "action", "verification", "error", err)
+					mu.Lock()
+					report.Failed++
+					mu.Unlock()
+					continue
+				}
+				mu.Lock()
+				switch {
+				case c.deleted && exists:
+					report.StillExists = append(report.StillExists, c.key)
+					slog.Warn(tr(cfg, msgRecheckExists, c.key), "bucket", bucket, "key", c.key, "action", "verification")
+				case !c.deleted && !exists:
+					report.Missing = append(report.Missing, c.key)
+					slog.Warn(tr(cfg, msgRecheckMissing, c.key), "bucket", bucket, "key", c.key, "action", "verification")
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	slog.Info(tr(cfg, msgRecheckDone, bucket, len(report.StillExists), len(report.Missing), report.Failed),
+		"bucket", bucket, "action", "verification",
+		"stillExists", len(report.StillExists), "missing", len(report.Missing), "failed", report.Failed)
+	return report
+}
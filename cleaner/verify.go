@@ -0,0 +1,69 @@
+package cleaner
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// objectChanged 判断删除前查询到的对象与列举时是否不同：大小、修改时间或 ETag（两者都有时）任一不同即认为已被覆盖写入
+func objectChanged(listed, current minio.ObjectInfo) bool {
+	if listed.Size != current.Size || !listed.LastModified.Equal(current.LastModified) {
+		return true
+	}
+	listedTag, currentTag := strings.Trim(listed.ETag, `"`), strings.Trim(current.ETag, `"`)
+	return listedTag != "" && currentTag != "" && listedTag != currentTag
+}
+
+// unreplicated 判断对象是否尚未复制到目标站点：复制状态为 PENDING（等待复制）或 FAILED（复制失败）
+func unreplicated(obj minio.ObjectInfo) bool {
+	switch obj.ReplicationStatus {
+	case "PENDING", "FAILED":
+		return true
+	}
+	return false
+}
+
+// verify 在开启 cleanup.verifyBeforeDelete、cleanup.skipUnreplicated 或使用 inventory 清单时，删除前重新查询对象，返回查询到的对象信息和是否仍可删除。
+// 对象在列举后被覆盖写入、尚未完成复制或已不存在时跳过；查询失败时记为错误并跳过，不在无法确认的情况下删除
+func (p *pipeline) verify(ctx context.Context, obj minio.ObjectInfo, rule string) (minio.ObjectInfo, bool) {
+	cfg, bucket := p.cfg, p.bucket
+	var current minio.ObjectInfo
+	err := withRetry(ctx, &cfg.Retry, func() error {
+		return withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			current, err = p.store.stat(ctx, bucket, obj.Key)
+			return err
+		})
+	}, func(attempt int, delay time.Duration, err error) {
+		atomic.AddInt64(&p.stats.retries, 1)
+		slog.Warn(tr(cfg, msgVerifyRetry, obj.Key, attempt, cfg.Retry.MaxAttempts, delay.Round(time.Millisecond), err),
+			"bucket", bucket, "key", obj.Key, "rule", rule, "action", "retry", "attempt", attempt, "delay", delay, "error", err)
+	})
+	switch {
+	case err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey":
+		slog.Info(tr(cfg, msgVerifyGone, obj.Key), "bucket", bucket, "key", obj.Key, "rule", rule, "action", "verify")
+		return current, false
+	case err != nil:
+		if runtimeExceeded(ctx) {
+			return current, false
+		}
+		p.fail(tr(cfg, msgVerifyFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "rule", rule, "action", "verify", "error", err)
+		return current, false
+	case cfg.VerifiesBeforeDelete() && objectChanged(obj, current):
+		atomic.AddInt64(&p.stats.changedFiles, 1)
+		slog.Warn(tr(cfg, msgVerifyChanged, obj.Key, obj.Size, current.Size, obj.LastModified, current.LastModified),
+			"bucket", bucket, "key", obj.Key, "size", current.Size, "lastModified", current.LastModified, "rule", rule, "action", "verify")
+		return current, false
+	case cfg.Cleanup.SkipUnreplicated && unreplicated(current):
+		atomic.AddInt64(&p.stats.unreplicated, 1)
+		slog.Warn(tr(cfg, msgVerifyUnreplicated, obj.Key, current.ReplicationStatus),
+			"bucket", bucket, "key", obj.Key, "replicationStatus", current.ReplicationStatus, "rule", rule, "action", "verify")
+		return current, false
+	}
+	return current, true
+}
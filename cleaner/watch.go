@@ -0,0 +1,503 @@
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// watchResubscribeDelay 为通知连接中断后重新订阅前的等待时间
+const watchResubscribeDelay = 5 * time.Second
+
+// watchMaxPending 为每个存储桶等待达到 safety.minObjectAge 的新对象数上限，超过时新对象不实时删除
+const watchMaxPending = 100000
+
+// bucketNotifier 为支持订阅存储桶通知的对象存储，目前只有 S3 兼容服务（MinIO）实现
+type bucketNotifier interface {
+	// listenCreated 订阅存储桶的对象创建通知，连接中断时返回 Err 不为空的条目后关闭通道
+	listenCreated(ctx context.Context, bucket string) <-chan notification.Info
+}
+
+func (s *s3Store) listenCreated(ctx context.Context, bucket string) <-chan notification.Info {
+	return s.client.ListenBucketNotification(ctx, bucket, "", "", []string{string(notification.ObjectCreatedAll)})
+}
+
+// watchRuleName 返回规则名称，未命名的规则按顺序称为 watch-1、watch-2……
+func watchRuleName(rules []config.WatchRule, i int) string {
+	if rules[i].Name != "" {
+		return rules[i].Name
+	}
+	return fmt.Sprintf("watch-%d", i+1)
+}
+
+// watcher 订阅一个目标的对象创建通知，按实时清理规则删除新写入的对象
+type watcher struct {
+	t Target
+	// p 为删除对象使用的流水线，与周期清理相同，删除同样写入审计日志和已删除对象清单，并发送回调、钩子和删除事件
+	p *pipeline
+	// errDone 在 p.errCh 中的错误全部记录后关闭
+	errDone chan struct{}
+	// preset 为目标选择的集成预设，每次订阅前调用 prepare，为 nil 时未选择预设
+	preset appPreset
+	// killChecked 和 killReason 为最近一次检查紧急停止开关的时间和结果，最多每隔 killSwitch.interval 检查一次
+	killChecked time.Time
+	killReason  string
+	// enricher 在配置了过滤插件时由插件决定到期的对象是否删除
+	enricher *enricher
+	// skipUnreplicated 为配置的 cleanup.skipUnreplicated，到期的对象重新查询时检查复制状态
+	skipUnreplicated bool
+	// pending 为符合规则、等待达到 safety.minObjectAge 的新对象，按到期时间排序，只在订阅协程中访问
+	pending []pendingDelete
+}
+
+// pendingDelete 为一个等待删除的新对象
+type pendingDelete struct {
+	c   candidate
+	due time.Time
+}
+
+// watchConfig 返回实时清理使用的配置。实时清理逐个删除对象，hooks.preDelete、回调、postDelete 钩子和删除事件不攒批，
+// 每个对象发送一次；对象到期后由 watcher 重新查询是否被覆盖写入或完成复制，executeOne 不再查询
+func watchConfig(cfg *config.Config) *config.Config {
+	c := *cfg
+	c.Hooks.BatchSize = 1
+	c.Events.BatchSize = 1
+	c.Notify.Webhooks = slices.Clone(cfg.Notify.Webhooks)
+	for i := range c.Notify.Webhooks {
+		c.Notify.Webhooks[i].BatchSize = 1
+	}
+	c.Cleanup.VerifyBeforeDelete = false
+	c.Cleanup.SkipUnreplicated = false
+	c.Inventory = config.InventoryConfig{}
+	return &c
+}
+
+// newWatcher 为目标创建实时清理的流水线，打开已删除对象清单和审计日志，每条实时清理规则对应一条规则统计。
+// 删除错误超过 cleanup.maxErrors 或 cleanup.maxErrorRate 时调用 abort 停止订阅
+func newWatcher(t Target, objects *ObjectOutput, abort context.CancelCauseFunc) (*watcher, error) {
+	configured := t.cfg
+	cfg, bucket := watchConfig(configured), configured.Minio.Bucket
+	preset, err := newAppPreset(cfg)
+	if err != nil {
+		return nil, err
+	}
+	start := cfg.Now()
+	compiled := make([]*rules.Compiled, len(cfg.Watch.Rules))
+	stats := &runStats{rules: make([]*ruleStats, len(compiled)), filters: newFilterStats(cfg.Filters), prefixDepth: cfg.Report.PrefixDepth}
+	for i, r := range cfg.Watch.Rules {
+		compiled[i] = &rules.Compiled{Rule: rules.Rule{Name: watchRuleName(cfg.Watch.Rules, i), Prefix: r.Prefix, MinSize: r.MinSize}}
+		stats.rules[i] = &ruleStats{}
+	}
+	p := &pipeline{
+		cfg:       cfg,
+		store:     t.store,
+		bucket:    bucket,
+		startTime: start,
+		rules:     compiled,
+		stats:     stats,
+		objects:   objects,
+		abort:     abort,
+		errCh:     make(chan stageError),
+
+		deletedBatch: newDeletedBatch(cfg),
+		events:       newEventBatch(cfg),
+	}
+	// 与周期清理相同地限制删除速率，endpoint 连续出错时暂停删除
+	if qps := cfg.Cleanup.MaxDeletesPerSecond; qps > 0 && !cfg.Cleanup.DryRun {
+		p.limiter = newRateLimiter(qps)
+	}
+	if cfg.CircuitBreaker.FailureThreshold > 0 && !cfg.Cleanup.DryRun {
+		p.breaker = newCircuitBreaker(&cfg.CircuitBreaker, bucket, cfg.Cleanup.Language, func(ctx context.Context) error {
+			return withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+				_, err := t.store.bucketExists(ctx, bucket)
+				return err
+			})
+		})
+	}
+	t.cfg = cfg
+	w := &watcher{
+		t:       t,
+		p:       p,
+		errDone: make(chan struct{}),
+		preset:  preset,
+		enricher: &enricher{
+			cfg:        cfg,
+			store:      t.store,
+			bucket:     bucket,
+			now:        start,
+			fail:       p.fail,
+			pluginKept: &stats.pluginKept,
+		},
+		skipUnreplicated: configured.Cleanup.SkipUnreplicated,
+	}
+	if cfg.Report.ManifestFile != "" && !cfg.Cleanup.DryRun {
+		if p.manifest, err = openManifest(expandReportName(cfg.Report.ManifestFile, start), cfg.Report.ManifestFormat, cfg.Cleanup.Language); err != nil {
+			return nil, errors.New(tr(cfg, msgManifestOpenFailed, err))
+		}
+	}
+	if cfg.Audit.File != "" {
+		if p.audit, err = openAuditLog(cfg.Audit.File, newRunID(start), bucket); err == nil {
+			// 审计日志记录配置文件中的配置，不含实时清理对批量大小等的调整
+			err = p.audit.runStart(configured)
+		}
+		if err != nil {
+			w.closeFiles()
+			return nil, errors.New(tr(cfg, msgAuditOpenFailed, err))
+		}
+	}
+	go func() {
+		defer close(w.errDone)
+		for e := range p.errCh {
+			stats.recordError(e.msg)
+			slog.Error(e.msg, e.attrs...)
+		}
+	}()
+	if p.hooks = newWebhookDispatcher(cfg); p.hooks != nil {
+		p.hooks.start()
+	}
+	return w, nil
+}
+
+// close 在停止订阅时发送剩余的钩子和事件，在审计日志中写入结束记录，并发送运行结束回调
+func (w *watcher) close(ctx context.Context) {
+	p := w.p
+	if p.deletedBatch != nil {
+		p.flushDeleted(ctx)
+	}
+	if p.events != nil {
+		p.flushEvents(ctx)
+	}
+	close(p.errCh)
+	<-w.errDone
+	report := newRunReport(p.cfg, p.rules, p.stats, p.startTime, p.cfg.Now())
+	if p.audit != nil {
+		if err := p.audit.runEnd(report); err != nil {
+			slog.Error(tr(p.cfg, msgAuditWriteFailed, err), "bucket", p.bucket, "action", "audit", "error", err)
+		}
+	}
+	w.closeFiles()
+	if p.hooks != nil {
+		p.hooks.finish(report, abortCause(ctx))
+	}
+}
+
+// closeFiles 关闭已删除对象清单和审计日志
+func (w *watcher) closeFiles() {
+	if w.p.manifest != nil {
+		w.p.manifest.close()
+	}
+	if w.p.audit != nil {
+		w.p.audit.close()
+	}
+}
+
+// watchTarget 订阅目标存储桶的对象创建通知直到 ctx 被取消，连接中断时等待后重新订阅。
+// 选择了集成预设时每次订阅前先准备预设，应用不适合清理时等待后重试，不订阅
+func watchTarget(ctx context.Context, t Target, objects *ObjectOutput) {
+	cfg, bucket := t.cfg, t.cfg.Minio.Bucket
+	n, ok := t.store.(bucketNotifier)
+	if !ok {
+		slog.Error(tr(cfg, msgWatchNoSupport, cfg.Minio.Name, cfg.Minio.Type), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "watch")
+		return
+	}
+	ctx, abort := context.WithCancelCause(withLanguage(ctx, cfg.Cleanup.Language))
+	defer abort(nil)
+	w, err := newWatcher(t, objects, abort)
+	if err != nil {
+		slog.Error(err.Error(), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "watch", "error", err)
+		return
+	}
+	defer w.close(ctx)
+	slog.Info(tr(cfg, msgWatchStart, bucket, len(cfg.Watch.Rules)), "cluster", cfg.Minio.Name, "bucket", bucket, "action", "watch")
+	for {
+		if err := w.prepare(ctx); err != nil {
+			if ctx.Err() == nil {
+				slog.Warn(tr(cfg, msgWatchPresetFailed, cfg.Minio.Preset, bucket, watchResubscribeDelay, err),
+					"cluster", cfg.Minio.Name, "bucket", bucket, "action", "watch", "error", err)
+			}
+		} else {
+			w.listen(ctx, n)
+		}
+		w.wait(ctx, watchResubscribeDelay)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// listen 订阅存储桶的对象创建通知直到连接中断或 ctx 被取消，期间删除到期的对象
+func (w *watcher) listen(ctx context.Context, n bucketNotifier) {
+	cfg, bucket := w.t.cfg, w.t.cfg.Minio.Bucket
+	events := n.listenCreated(ctx, bucket)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case info, ok := <-events:
+			if !ok {
+				return
+			}
+			if info.Err != nil {
+				if ctx.Err() == nil {
+					slog.Warn(tr(cfg, msgWatchFailed, bucket, watchResubscribeDelay, info.Err),
+						"cluster", cfg.Minio.Name, "bucket", bucket, "action", "watch", "error", info.Err)
+				}
+				return
+			}
+			for _, e := range info.Records {
+				w.handle(e)
+			}
+		case <-w.next():
+			w.flush(ctx, cfg.Now())
+		}
+	}
+}
+
+// wait 等待 d 或直到 ctx 被取消，期间删除到期的对象
+func (w *watcher) wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			return
+		case <-w.next():
+			w.flush(ctx, w.t.cfg.Now())
+		}
+	}
+}
+
+// prepare 按当前时间准备集成预设，未选择预设时直接返回
+func (w *watcher) prepare(ctx context.Context) error {
+	if w.preset == nil {
+		return nil
+	}
+	return w.preset.prepare(ctx, w.t.store, w.t.cfg.Now())
+}
+
+// handle 按实时清理规则处理一个对象创建事件，符合规则的对象在修改时间达到 safety.minObjectAge 后删除
+func (w *watcher) handle(e notification.Event) {
+	cfg, bucket := w.t.cfg, w.t.cfg.Minio.Bucket
+	// 通知中的对象名经过 URL 编码
+	key, err := url.QueryUnescape(e.S3.Object.Key)
+	if err != nil {
+		key = e.S3.Object.Key
+	}
+	obj := minio.ObjectInfo{
+		Key:       key,
+		Size:      e.S3.Object.Size,
+		ETag:      e.S3.Object.ETag,
+		VersionID: e.S3.Object.VersionID,
+	}
+	obj.LastModified, err = time.Parse(time.RFC3339Nano, e.EventTime)
+	if err != nil {
+		obj.LastModified = cfg.Now()
+	}
+
+	rules := cfg.Watch.Rules
+	idx := -1
+	for i := range rules {
+		if rules[i].Match(obj) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	rule := w.p.rules[idx]
+	if len(w.pending) >= watchMaxPending {
+		slog.Warn(tr(cfg, msgWatchQueueFull, obj.Key, watchMaxPending),
+			"cluster", cfg.Minio.Name, "bucket", bucket, "key", obj.Key, "rule", rule.Name, "action", "skip")
+		return
+	}
+	due := obj.LastModified.Add(time.Duration(cfg.Safety.MinObjectAge))
+	slog.Info(tr(cfg, msgWatchMatch, obj.Key, rule.Name, float64(obj.Size)/1024/1024, due),
+		"cluster", cfg.Minio.Name, "bucket", bucket, "key", obj.Key, "size", obj.Size, "rule", rule.Name, "action", "watch", "due", due)
+	w.schedule(candidate{obj: obj, ruleIdx: idx, rule: rule}, due)
+}
+
+// schedule 将对象按到期时间插入等待队列
+func (w *watcher) schedule(c candidate, due time.Time) {
+	i := sort.Search(len(w.pending), func(i int) bool { return w.pending[i].due.After(due) })
+	w.pending = slices.Insert(w.pending, i, pendingDelete{c: c, due: due})
+}
+
+// next 返回最早到期的对象到期时触发的通道，没有等待的对象时返回 nil
+func (w *watcher) next() <-chan time.Time {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	return time.After(w.pending[0].due.Sub(w.t.cfg.Now()))
+}
+
+// flush 依次处理在 now 之前到期的对象
+func (w *watcher) flush(ctx context.Context, now time.Time) {
+	for len(w.pending) > 0 && !w.pending[0].due.After(now) && ctx.Err() == nil {
+		c := w.pending[0].c
+		w.pending[0] = pendingDelete{}
+		w.pending = w.pending[1:]
+		w.remove(ctx, c, now)
+	}
+}
+
+// remove 处理一个到期的对象：重新查询确认对象未被覆盖写入，再依次经过集成预设、Config.Filters、过滤插件和
+// hooks.preDelete 的判断，最后与周期清理相同地限速删除
+func (w *watcher) remove(ctx context.Context, c candidate, now time.Time) {
+	cfg, bucket := w.t.cfg, w.t.cfg.Minio.Bucket
+	key, rule := c.obj.Key, c.rule
+	if !cfg.Cleanup.DryRun {
+		if reason := w.killSwitch(ctx); reason != "" {
+			slog.Warn(tr(cfg, msgWatchKillSwitch, reason, key), "cluster", cfg.Minio.Name, "bucket", bucket, "key", key, "action", "killSwitch")
+			return
+		}
+	}
+	obj, ok := w.current(ctx, c, now)
+	if !ok {
+		return
+	}
+	c.obj = obj
+	// 选择了集成预设时，预设认定应用仍可能使用的对象不实时删除
+	if w.preset != nil && w.preset.keep(obj) {
+		slog.Info(tr(cfg, msgWatchPresetKept, key, cfg.Minio.Preset),
+			"cluster", cfg.Minio.Name, "bucket", bucket, "key", key, "rule", rule.Name, "action", "skip")
+		return
+	}
+	if keptBy := keptByFilter(w.p.stats.filters, obj); keptBy != nil {
+		slog.Debug(tr(cfg, msgSkipFilter, key, keptBy.name),
+			"cluster", cfg.Minio.Name, "bucket", bucket, "key", key, "size", obj.Size, "rule", rule.Name, "action", "skip", "reason", skipFilter, "filter", keptBy.name)
+		return
+	}
+	if !w.enricher.remove(ctx, c) {
+		return
+	}
+	if cfg.Hooks.PreDelete.Enabled() && !cfg.Cleanup.DryRun && !w.p.preDelete(ctx, []candidate{c}) {
+		return
+	}
+	w.p.executeOne(ctx, c)
+}
+
+// current 重新查询到期的对象。对象已不存在、被覆盖写入或尚未完成复制（cleanup.skipUnreplicated）时返回 false；
+// 修改时间仍比 safety.minObjectAge 新时重新排队，也返回 false。查询失败时记为错误，对象保留
+func (w *watcher) current(ctx context.Context, c candidate, now time.Time) (minio.ObjectInfo, bool) {
+	p := w.p
+	cfg, bucket, obj, rule := p.cfg, p.bucket, c.obj, c.rule.Name
+	var current minio.ObjectInfo
+	err := withRetry(ctx, &cfg.Retry, func() error {
+		return withTimeout(ctx, "stat", cfg.Timeouts.Stat, func(ctx context.Context) error {
+			var err error
+			current, err = p.store.stat(ctx, bucket, obj.Key)
+			return err
+		})
+	}, func(attempt int, delay time.Duration, err error) {
+		atomic.AddInt64(&p.stats.retries, 1)
+		slog.Warn(tr(cfg, msgVerifyRetry, obj.Key, attempt, cfg.Retry.MaxAttempts, delay.Round(time.Millisecond), err),
+			"bucket", bucket, "key", obj.Key, "rule", rule, "action", "retry", "attempt", attempt, "delay", delay, "error", err)
+	})
+	minAge := time.Duration(cfg.Safety.MinObjectAge)
+	// 通知中没有对象的修改时间，只比较大小和 ETag
+	changed := current.Size != obj.Size ||
+		obj.ETag != "" && current.ETag != "" && strings.Trim(obj.ETag, `"`) != strings.Trim(current.ETag, `"`)
+	switch {
+	case err != nil && minio.ToErrorResponse(err).Code == "NoSuchKey":
+		slog.Info(tr(cfg, msgVerifyGone, obj.Key), "bucket", bucket, "key", obj.Key, "rule", rule, "action", "verify")
+		return current, false
+	case err != nil:
+		if ctx.Err() == nil {
+			p.fail(tr(cfg, msgVerifyFailed, obj.Key, err), "bucket", bucket, "key", obj.Key, "rule", rule, "action", "verify", "error", err)
+		}
+		return current, false
+	case changed:
+		atomic.AddInt64(&p.stats.changedFiles, 1)
+		slog.Warn(tr(cfg, msgVerifyChanged, obj.Key, obj.Size, current.Size, obj.LastModified, current.LastModified),
+			"bucket", bucket, "key", obj.Key, "size", current.Size, "lastModified", current.LastModified, "rule", rule, "action", "verify")
+		return current, false
+	case current.LastModified.After(now.Add(-minAge)):
+		slog.Debug(tr(cfg, msgSkipMinObjectAge, obj.Key, current.LastModified),
+			"bucket", bucket, "key", obj.Key, "size", current.Size, "lastModified", current.LastModified, "rule", rule, "action", "skip", "reason", rules.SkipMinObjectAge)
+		w.schedule(c, current.LastModified.Add(minAge))
+		return current, false
+	case w.skipUnreplicated && unreplicated(current):
+		atomic.AddInt64(&p.stats.unreplicated, 1)
+		slog.Warn(tr(cfg, msgVerifyUnreplicated, obj.Key, current.ReplicationStatus),
+			"bucket", bucket, "key", obj.Key, "replicationStatus", current.ReplicationStatus, "rule", rule, "action", "verify")
+		return current, false
+	}
+	return current, true
+}
+
+// killSwitch 返回紧急停止开关打开的原因，未打开时返回空字符串。检查失败时沿用上一次的结果
+func (w *watcher) killSwitch(ctx context.Context) string {
+	cfg := w.t.cfg
+	if !cfg.KillSwitch.Enabled() || time.Since(w.killChecked) < cfg.KillSwitch.Interval {
+		return w.killReason
+	}
+	reason, err := killSwitchEngaged(ctx, cfg, w.t.store)
+	if err != nil {
+		slog.Warn(tr(cfg, msgKillSwitchFailed, cfg.Minio.Bucket, err), "cluster", cfg.Minio.Name, "bucket", cfg.Minio.Bucket, "action", "killSwitch", "error", err)
+		return w.killReason
+	}
+	w.killChecked, w.killReason = time.Now(), reason
+	return reason
+}
+
+// watchers 管理守护模式下各目标的通知订阅，目标随 bucketPattern 重新发现而增减时同步启动或停止订阅
+type watchers struct {
+	ctx     context.Context
+	objects *ObjectOutput
+	cancels map[string]context.CancelFunc
+	// wg 等待各订阅写完审计日志的结束记录并发送剩余的回调和事件
+	wg sync.WaitGroup
+}
+
+func newWatchers(ctx context.Context, objects *ObjectOutput) *watchers {
+	return &watchers{ctx: ctx, objects: objects, cancels: make(map[string]context.CancelFunc)}
+}
+
+// update 为新出现的目标订阅通知，停止已不再清理的目标的订阅
+func (w *watchers) update(targets []Target) {
+	current := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		key := t.cfg.Minio.Name + "/" + t.cfg.Minio.Bucket
+		current[key] = true
+		if w.cancels[key] != nil {
+			continue
+		}
+		ctx, cancel := context.WithCancel(w.ctx)
+		w.cancels[key] = cancel
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			watchTarget(ctx, t, w.objects)
+		}()
+	}
+	for key, cancel := range w.cancels {
+		if !current[key] {
+			cancel()
+			delete(w.cancels, key)
+		}
+	}
+}
+
+// stop 停止全部订阅，等待各订阅退出
+func (w *watchers) stop() {
+	for _, cancel := range w.cancels {
+		cancel()
+	}
+	w.wg.Wait()
+}
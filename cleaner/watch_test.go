@@ -0,0 +1,187 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+// watchStore 为实时清理测试使用的存储，stat 返回 current 中的对象，remove 记录删除的对象名
+type watchStore struct {
+	memStore
+	current map[string]minio.ObjectInfo
+	removed []string
+}
+
+func (s *watchStore) stat(ctx context.Context, bucket, key string) (minio.ObjectInfo, error) {
+	obj, ok := s.current[key]
+	if !ok {
+		return minio.ObjectInfo{}, minio.ErrorResponse{Code: "NoSuchKey"}
+	}
+	return obj, nil
+}
+
+func (s *watchStore) remove(ctx context.Context, bucket, key string) (string, error) {
+	s.removed = append(s.removed, key)
+	return "", nil
+}
+
+// createdEvent 返回对象在 t 时写入的通知
+func createdEvent(key string, size int64, etag string, t time.Time) notification.Event {
+	var e notification.Event
+	e.EventTime = t.Format(time.RFC3339Nano)
+	e.S3.Object.Key = key
+	e.S3.Object.Size = size
+	e.S3.Object.ETag = etag
+	return e
+}
+
+func TestWatchHandle(t *testing.T) {
+	now := time.Now()
+	written := now.Add(-2 * time.Hour)
+	tests := []struct {
+		name string
+		// event 为通知中的对象，current 为到期后重新查询到的对象，为空时对象已被删除
+		event   notification.Event
+		current *minio.ObjectInfo
+		setup   func(cfg *config.Config)
+		want    []string
+		// pending 为处理后仍在等待的对象数
+		pending int
+	}{
+		{
+			name:    "deleted after minObjectAge",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: written},
+			want:    []string{"uploads/a.exe"},
+		},
+		{
+			name:  "no matching rule",
+			event: createdEvent("uploads/a.txt", 10, "e1", written),
+		},
+		// 通知刚刚写入的对象，未达到 safety.minObjectAge 时等待
+		{
+			name:    "newer than minObjectAge",
+			event:   createdEvent("uploads/a.exe", 10, "e1", now),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: now},
+			pending: 1,
+		},
+		// 通知中的时间早于对象的修改时间，重新查询后按修改时间重新排队
+		{
+			name:    "modified later than event",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: now},
+			pending: 1,
+		},
+		{
+			name:    "overwritten",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e2", LastModified: written},
+		},
+		{
+			name:  "already gone",
+			event: createdEvent("uploads/a.exe", 10, "e1", written),
+		},
+		{
+			name:    "unreplicated",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: written, ReplicationStatus: "PENDING"},
+			setup:   func(cfg *config.Config) { cfg.Cleanup.SkipUnreplicated = true },
+		},
+		{
+			name:    "kept by filter",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: written},
+			setup: func(cfg *config.Config) {
+				cfg.Filters = []rules.Filter{rules.FilterFunc(func(rules.ObjectInfo) rules.Decision { return rules.Keep })}
+			},
+		},
+		{
+			name:    "vetoed by preDelete",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: written},
+			setup: func(cfg *config.Config) {
+				cfg.Hooks.PreDelete = config.HookConfig{Command: []string{"false"}, Timeout: time.Minute}
+			},
+		},
+		{
+			name:    "dry run",
+			event:   createdEvent("uploads/a.exe", 10, "e1", written),
+			current: &minio.ObjectInfo{Key: "uploads/a.exe", Size: 10, ETag: "e1", LastModified: written},
+			setup:   func(cfg *config.Config) { cfg.Cleanup.DryRun = true },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Watch.Rules = []config.WatchRule{{Prefix: "uploads/", Suffixes: []string{".exe"}}}
+			if tt.setup != nil {
+				tt.setup(cfg)
+			}
+			store := &watchStore{current: make(map[string]minio.ObjectInfo)}
+			if tt.current != nil {
+				store.current[tt.current.Key] = *tt.current
+			}
+			ctx, abort := context.WithCancelCause(context.Background())
+			defer abort(nil)
+			w, err := newWatcher(Target{cfg: cfg, store: store}, nil, abort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.handle(tt.event)
+			w.flush(ctx, now)
+			w.close(ctx)
+			if !slices.Equal(store.removed, tt.want) {
+				t.Errorf("removed = %q, want %q", store.removed, tt.want)
+			}
+			if len(w.pending) != tt.pending {
+				t.Errorf("pending = %d, want %d", len(w.pending), tt.pending)
+			}
+		})
+	}
+}
+
+func TestWatchSchedule(t *testing.T) {
+	base := time.Now()
+	w := &watcher{}
+	for _, d := range []time.Duration{3, 1, 2, 1} {
+		w.schedule(candidate{obj: minio.ObjectInfo{Key: d.String()}}, base.Add(d*time.Minute))
+	}
+	var got []string
+	for _, p := range w.pending {
+		got = append(got, p.c.obj.Key)
+	}
+	// 到期时间相同的对象按加入的顺序排列
+	if want := []string{"1ns", "1ns", "2ns", "3ns"}; !slices.Equal(got, want) {
+		t.Errorf("pending = %q, want %q", got, want)
+	}
+}
+
+func TestValidateWatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(cfg *config.Config)
+		want  bool
+	}{
+		{name: "watch only", setup: func(*config.Config) {}},
+		{name: "with approval", setup: func(cfg *config.Config) { cfg.Approval.URL = "http://approval" }, want: true},
+		{name: "with canary", setup: func(cfg *config.Config) { cfg.Canary.Size = 10 }, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(rules.Rule{MaxAge: rules.Retention(rules.Day)})
+			cfg.Minio.Endpoint = "localhost:9000"
+			cfg.Watch.Rules = []config.WatchRule{{Prefix: "uploads/"}}
+			tt.setup(cfg)
+			if got := slices.Contains(validateConfig(cfg), tr(cfg, msgWatchApproval)); got != tt.want {
+				t.Errorf("validateConfig() reports watch with approval or canary = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
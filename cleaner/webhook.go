@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// Webhook 事件类型
+const (
+	webhookEventRunStart = "runStart" // 运行开始
+	webhookEventRunEnd   = "runEnd"   // 运行结束
+	webhookEventBatch    = "batch"    // 一批对象删除完成
+)
+
+// webhookPayload 为回调请求的 JSON 内容
+type webhookPayload struct {
+	Event     string            `json:"event"`
+	Bucket    string            `json:"bucket"`
+	Timestamp time.Time         `json:"timestamp"`
+	DryRun    bool              `json:"dryRun"`
+	Files     int64             `json:"files,omitempty"`
+	Bytes     int64             `json:"bytes,omitempty"`
+	Objects   []manifestEntry   `json:"objects,omitempty"`
+	Report    *report.RunReport `json:"report,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+type webhookRequest struct {
+	hook    *config.WebhookConfig
+	payload webhookPayload
+}
+
+// webhookDispatcher 在后台按顺序发送回调，避免阻塞删除工作协程
+type webhookDispatcher struct {
+	cfg   *config.Config
+	queue chan webhookRequest
+	done  chan struct{}
+
+	mu      sync.Mutex
+	batches [][]manifestEntry
+}
+
+// newWebhookDispatcher 创建回调分发器，未配置任何回调时返回 nil
+func newWebhookDispatcher(cfg *config.Config) *webhookDispatcher {
+	if len(cfg.Notify.Webhooks) == 0 {
+		return nil
+	}
+	d := &webhookDispatcher{
+		cfg:     cfg,
+		queue:   make(chan webhookRequest, 64),
+		done:    make(chan struct{}),
+		batches: make([][]manifestEntry, len(cfg.Notify.Webhooks)),
+	}
+	go d.loop()
+	return d
+}
+
+func (d *webhookDispatcher) loop() {
+	defer close(d.done)
+	for req := range d.queue {
+		if err := postWebhook(req.hook, req.payload); err != nil {
+			slog.Error(tr(d.cfg, msgWebhookFailed, req.hook.URL, req.payload.Event, err),
+				"bucket", req.payload.Bucket, "action", "webhook", "error", err)
+		}
+	}
+}
+
+func (d *webhookDispatcher) payload(event string) webhookPayload {
+	return webhookPayload{
+		Event:     event,
+		Bucket:    d.cfg.Minio.Bucket,
+		Timestamp: time.Now(),
+		DryRun:    d.cfg.Cleanup.DryRun,
+	}
+}
+
+func (d *webhookDispatcher) emit(event string, build func(p *webhookPayload)) {
+	for i := range d.cfg.Notify.Webhooks {
+		hook := &d.cfg.Notify.Webhooks[i]
+		if !hook.Wants(event) {
+			continue
+		}
+		p := d.payload(event)
+		if build != nil {
+			build(&p)
+		}
+		d.queue <- webhookRequest{hook: hook, payload: p}
+	}
+}
+
+// start 发送运行开始事件
+func (d *webhookDispatcher) start() {
+	d.emit(webhookEventRunStart, nil)
+}
+
+// deleted 记录一个已删除对象，攒满一批后发送 batch 事件
+func (d *webhookDispatcher) deleted(e manifestEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.cfg.Notify.Webhooks {
+		hook := &d.cfg.Notify.Webhooks[i]
+		if !hook.Wants(webhookEventBatch) {
+			continue
+		}
+		d.batches[i] = append(d.batches[i], e)
+		size := hook.BatchSize
+		if size <= 0 {
+			size = config.DefaultWebhookBatchSize
+		}
+		if len(d.batches[i]) >= size {
+			d.flushLocked(i)
+		}
+	}
+}
+
+func (d *webhookDispatcher) flushLocked(i int) {
+	batch := d.batches[i]
+	if len(batch) == 0 {
+		return
+	}
+	d.batches[i] = nil
+	p := d.payload(webhookEventBatch)
+	p.Objects = batch
+	p.Files = int64(len(batch))
+	for _, e := range batch {
+		p.Bytes += e.Size
+	}
+	d.queue <- webhookRequest{hook: &d.cfg.Notify.Webhooks[i], payload: p}
+}
+
+// finish 发送剩余未满一批的对象和运行结束事件，并等待所有回调发送完成
+func (d *webhookDispatcher) finish(report *report.RunReport, runErr error) {
+	d.mu.Lock()
+	for i := range d.batches {
+		d.flushLocked(i)
+	}
+	d.mu.Unlock()
+
+	d.emit(webhookEventRunEnd, func(p *webhookPayload) {
+		p.Report = report
+		if report != nil {
+			p.Files = report.DeletedFiles
+			p.Bytes = report.DeletedBytes
+		}
+		if runErr != nil {
+			p.Error = runErr.Error()
+		}
+	})
+	close(d.queue)
+	<-d.done
+}
+
+func postWebhook(hook *config.WebhookConfig, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := notifyHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
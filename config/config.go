@@ -0,0 +1,428 @@
+// Package config 定义配置文件的结构，负责读取、解析（YAML、JSON、TOML 和 sops 加密文件）、合并 profile、
+// 应用命令行参数和环境变量的覆盖，以及为未配置的项设置默认值
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	rand "math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Config 为清理程序的全部配置，与配置文件的结构相同
+type Config struct {
+	Minio    MinioConfig   `yaml:"minio"`    // MinIO 连接配置
+	Clusters []MinioConfig `yaml:"clusters"` // 多个 MinIO 集群，配置后代替 minio
+	Cleanup  struct {
+		MaxAge              rules.Retention `yaml:"maxAge"`              // 文件最大保留时间，如 30d、12h，不带单位时按天计算
+		MinSize             rules.ByteSize  `yaml:"minSize"`             // 文件最小大小，如 500MB、1.5GiB，不带单位时按字节计算
+		DryRun              bool            `yaml:"dryRun"`              // 是否仅预览不实际删除
+		Workers             int             `yaml:"workers"`             // 并发工作协程数
+		ParallelTargets     int             `yaml:"parallelTargets"`     // 同时清理的目标（集群上的存储桶）数，默认逐个清理
+		Listers             int             `yaml:"listers"`             // 并发列举协程数，大于 1 时按顶级前缀分片并发列举
+		MaxDeletesPerSecond float64         `yaml:"maxDeletesPerSecond"` // 每秒最多删除的对象数，0 表示不限制
+		MaxBandwidth        rules.ByteSize  `yaml:"maxBandwidth"`        // 所有对象传输合计每秒最多读写的字节数，如 50MB，0 表示不限制
+		MaxWorkerBandwidth  rules.ByteSize  `yaml:"maxWorkerBandwidth"`  // 每个工作协程（同一时间只进行一个传输）每秒最多读写的字节数，0 表示不限制
+		AutoTune            bool            `yaml:"autoTune"`            // 是否根据删除延迟和错误率自动调整并发数，workers 为上限
+		MaxErrors           int             `yaml:"maxErrors"`           // 删除错误数超过该值时中止运行，0 表示不限制
+		MaxErrorRate        float64         `yaml:"maxErrorRate"`        // 删除错误比例超过该值时中止运行，0 表示不限制
+		MaxRuntime          time.Duration   `yaml:"maxRuntime"`          // 单次运行的最长时间，超过后停止并汇总已完成的部分，0 表示不限制
+		MaxAPICalls         int64           `yaml:"maxAPICalls"`         // 单次运行最多发出的 API 请求数，超过后中止运行，0 表示不限制
+		VerifyBeforeDelete  bool            `yaml:"verifyBeforeDelete"`  // 是否在删除前重新查询对象，跳过列举后被覆盖写入的对象
+		SkipUnreplicated    bool            `yaml:"skipUnreplicated"`    // 是否跳过复制状态为 PENDING 或 FAILED、尚未复制到目标站点的对象
+		LogFile             string          `yaml:"logFile"`             // 日志文件路径
+		LogFormat           string          `yaml:"logFormat"`           // 日志格式：text 或 json
+		LogLevel            string          `yaml:"logLevel"`            // 日志级别：debug、info、warn 或 error
+		Language            string          `yaml:"language"`            // 日志语言：zh 或 en
+		Timezone            Timezone        `yaml:"timezone"`            // 计算阈值时间和解析分区日期使用的时区，如 Asia/Shanghai，默认服务器本地时区
+		ProtectedBuckets    []string        `yaml:"protectedBuckets"`    // 任何集群上都不清理的存储桶，优先于 bucket、buckets 和 bucketPattern
+	}
+	Safety         SafetyConfig         `yaml:"safety"`         // 防止误删的安全限制
+	Canary         CanaryConfig         `yaml:"canary"`         // 先删除少量随机对象的金丝雀删除
+	Verification   VerificationConfig   `yaml:"verification"`   // 运行结束后复查已删除和未清理的对象
+	KillSwitch     KillSwitchConfig     `yaml:"killSwitch"`     // 紧急停止开关
+	FilterPlugin   FilterPluginConfig   `yaml:"filterPlugin"`   // 由外部命令或 HTTP 服务决定是否删除的过滤插件
+	Hooks          HooksConfig          `yaml:"hooks"`          // 每批对象删除前后执行的钩子
+	Approval       ApprovalConfig       `yaml:"approval"`       // 大规模删除的外部审批
+	Watch          WatchConfig          `yaml:"watch"`          // 守护模式下按存储桶通知实时删除新写入的对象
+	Events         EventsConfig         `yaml:"events"`         // 将删除事件发布到 Kafka 或 NATS
+	Syslog         SyslogConfig         `yaml:"syslog"`         // syslog 日志输出
+	Retry          RetryConfig          `yaml:"retry"`          // 对象操作失败时的重试策略
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker"` // endpoint 不可用时暂停删除的熔断器
+	Timeouts       TimeoutConfig        `yaml:"timeouts"`       // MinIO 请求超时
+	Inventory      InventoryConfig      `yaml:"inventory"`      // 代替实时列举的 S3 Inventory 清单
+	Cost           CostConfig           `yaml:"cost"`           // 估算节省的存储费用所用的存储单价
+	Presets        PresetsConfig        `yaml:"presets"`        // 集群或存储桶通过 preset 选择的集成预设
+	Rules          []rules.Rule         `yaml:"rules"`          // 清理规则，为空时使用 cleanup 中的 maxAge 和 minSize
+	RulesDir       string               `yaml:"rulesDir"`       // 规则文件目录，其中的规则按文件名顺序追加到 rules 之后
+	Filters        []rules.Filter       `yaml:"-"`              // 作为库使用时由调用方设置的过滤器，对符合规则的对象依次判断，配置文件中无法设置
+	Report         struct {
+		SummaryFile    string `yaml:"summaryFile"`    // 汇总报告本地文件路径，支持 {time} 占位符
+		SummaryObject  string `yaml:"summaryObject"`  // 汇总报告在存储桶中的对象名，支持 {time} 占位符
+		PlanObject     string `yaml:"planObject"`     // 删除前上传的计划删除清单的对象名，支持 {time} 占位符
+		PlanBucket     string `yaml:"planBucket"`     // 计划删除清单上传到的存储桶，为空时为清理的存储桶
+		ManifestFile   string `yaml:"manifestFile"`   // 已删除对象清单文件路径，支持 {time} 占位符
+		ManifestFormat string `yaml:"manifestFormat"` // 清单格式：csv 或 jsonl，为空时按扩展名判断
+		HTMLFile       string `yaml:"htmlFile"`       // HTML 报告文件路径，支持 {time} 占位符
+		CandidatesFile string `yaml:"candidatesFile"` // 预览模式下待清理对象列表文件路径，供 diff 命令使用
+		AnalyzeFile    string `yaml:"analyzeFile"`    // analyze 命令输出的 JSON 报告文件路径，支持 {time} 占位符
+		PrefixDepth    int    `yaml:"prefixDepth"`    // 汇总报告中按前缀分组统计的目录层级，默认 1
+	}
+	Audit   AuditConfig `yaml:"audit"` // 审计日志
+	History struct {
+		File string `yaml:"file"` // 运行历史数据库文件路径，为空则不记录
+	}
+	Notify struct {
+		Email    EmailConfig     `yaml:"email"`    // 邮件通知
+		Chat     []ChatConfig    `yaml:"chat"`     // 聊天平台 Webhook 通知
+		Webhooks []WebhookConfig `yaml:"webhooks"` // 通用 HTTP 回调
+		Sentry   SentryConfig    `yaml:"sentry"`   // Sentry 错误上报
+	}
+	Daemon struct {
+		Interval     time.Duration `yaml:"interval"`     // 两次清理之间的间隔
+		HealthAddr   string        `yaml:"healthAddr"`   // 健康检查服务监听地址，为空则不启用
+		StallTimeout time.Duration `yaml:"stallTimeout"` // 清理过程无进展超过该时长则判定为不健康
+		// MaxConcurrentRuns 为同时进行的运行数上限，定时运行、gRPC 启动的运行和 REST 接口提交的任务共用，默认 1
+		MaxConcurrentRuns int           `yaml:"maxConcurrentRuns"`
+		GRPC              GRPCConfig    `yaml:"grpc"` // 启动、查询、取消运行和订阅进度的 gRPC 控制服务
+		API               JobsAPIConfig `yaml:"api"`  // 提交临时清理任务的 REST 接口
+	}
+	Debug    DebugConfig          `yaml:"debug"`    // 性能分析
+	Profiles map[string]yaml.Node `yaml:"profiles"` // 命名的配置组合，通过 -profile 选择后合并到顶层配置
+
+	// rulesDirLoaded 表示 rulesDir 中的规则已追加到 Rules，检查配置时不再重复读取
+	rulesDirLoaded bool
+}
+
+// RulesDirLoaded 返回 rulesDir 中的规则是否已由 Load 追加到 Rules
+func (cfg *Config) RulesDirLoaded() bool {
+	return cfg.rulesDirLoaded
+}
+
+// Load 读取配置文件，configPath 为空时不读取文件。profile 不为空时将 profiles 中的同名配置合并到顶层配置。
+// overrides 为命令行参数和环境变量对配置项的覆盖，优先于配置文件和 profile
+func Load(configPath, profile string, overrides ...Override) (*Config, error) {
+	cfg := &Config{}
+
+	var data []byte
+	var err error
+	if configPath != "" {
+		if data, err = os.ReadFile(configPath); err != nil {
+			return nil, errors.New(tr(msgConfigReadFailed, err))
+		}
+	}
+	doc, err := ParseFile(configPath, data)
+	if err != nil {
+		return nil, errors.New(tr(msgConfigParseFailed, err))
+	}
+	if profile != "" {
+		if err := ApplyProfile(doc, profile); err != nil {
+			return nil, err
+		}
+	}
+	if len(overrides) > 0 {
+		if err := applyOverrides(doc, overrides); err != nil {
+			return nil, err
+		}
+	}
+
+	// 严格解析，拼写错误的配置项会被忽略而取默认值 0，可能导致清理掉不该清理的文件
+	if err := Decode(doc, cfg, true); err != nil {
+		return nil, errors.New(tr(msgConfigDecode, strings.Join(DecodeErrors(err), "\n  - ")))
+	}
+	// 配置了 clusters 时 minio 不生效，对 minio 的覆盖会被忽略
+	if len(cfg.Clusters) > 0 {
+		for _, o := range overrides {
+			if strings.HasPrefix(o.Path, "minio.") {
+				return nil, errors.New(tr(msgOverrideClusters, o.Path))
+			}
+		}
+	}
+	if cfg.RulesDir != "" {
+		rules, problems := LoadRulesDir(cfg.RulesDir)
+		if len(problems) > 0 {
+			return nil, errors.New(strings.Join(problems, "; "))
+		}
+		cfg.Rules = append(cfg.Rules, rules...)
+		cfg.rulesDirLoaded = true
+	}
+	SetDefaults(cfg)
+	return cfg, nil
+}
+
+// Clone 返回配置的副本：切片另行复制，为副本设置默认值或追加规则不会修改原配置
+func (cfg *Config) Clone() *Config {
+	c := *cfg
+	c.Clusters = slices.Clone(cfg.Clusters)
+	c.Rules = slices.Clone(cfg.Rules)
+	c.Filters = slices.Clone(cfg.Filters)
+	c.Daemon.API.Tenants = slices.Clone(cfg.Daemon.API.Tenants)
+	return &c
+}
+
+// SetDefaults 为未配置的项设置默认值
+func SetDefaults(cfg *Config) {
+	if cfg.Report.PrefixDepth <= 0 {
+		cfg.Report.PrefixDepth = 1
+	}
+	if cfg.Safety.MaxDeletePercent == 0 {
+		cfg.Safety.MaxDeletePercent = defaultMaxDeletePercent
+	}
+	if cfg.Safety.MinObjectAge <= 0 {
+		cfg.Safety.MinObjectAge = defaultMinObjectAge
+	}
+	if cfg.Verification.Sample == 0 {
+		cfg.Verification.Sample = defaultVerificationSample
+	}
+	if cfg.KillSwitch.Interval <= 0 {
+		cfg.KillSwitch.Interval = defaultKillSwitchInterval
+	}
+	if cfg.FilterPlugin.Timeout <= 0 {
+		cfg.FilterPlugin.Timeout = defaultPluginTimeout
+	}
+	if cfg.Hooks.BatchSize <= 0 {
+		cfg.Hooks.BatchSize = defaultHookBatchSize
+	}
+	for _, h := range []*HookConfig{&cfg.Hooks.PreDelete, &cfg.Hooks.PostDelete} {
+		if h.Timeout <= 0 {
+			h.Timeout = defaultHookTimeout
+		}
+	}
+	if cfg.Events.BatchSize <= 0 {
+		cfg.Events.BatchSize = defaultEventsBatchSize
+	}
+	if cfg.Events.Timeout <= 0 {
+		cfg.Events.Timeout = defaultEventsTimeout
+	}
+	if cfg.Approval.Timeout <= 0 {
+		cfg.Approval.Timeout = defaultApprovalTimeout
+	}
+	if cfg.Approval.PollInterval <= 0 {
+		cfg.Approval.PollInterval = defaultApprovalPollInterval
+	}
+	if cfg.Approval.MaxObjects <= 0 {
+		cfg.Approval.MaxObjects = defaultApprovalMaxObjects
+	}
+
+	// 重试默认值
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry.MaxAttempts = 3
+	}
+	if cfg.Retry.InitialBackoff <= 0 {
+		cfg.Retry.InitialBackoff = 200 * time.Millisecond
+	}
+	if cfg.Retry.MaxBackoff <= 0 {
+		cfg.Retry.MaxBackoff = 10 * time.Second
+	}
+
+	// 请求超时默认值
+	if cfg.Timeouts.List <= 0 {
+		cfg.Timeouts.List = time.Minute
+	}
+	if cfg.Timeouts.Stat <= 0 {
+		cfg.Timeouts.Stat = 5 * time.Second
+	}
+	if cfg.Timeouts.Delete <= 0 {
+		cfg.Timeouts.Delete = 30 * time.Second
+	}
+
+	if cfg.Inventory.MaxAge <= 0 {
+		cfg.Inventory.MaxAge = defaultInventoryMaxAge
+	}
+
+	// 集成预设默认值
+	if cfg.Presets.Harbor.UploadMaxAge <= 0 {
+		cfg.Presets.Harbor.UploadMaxAge = defaultHarborUploadMaxAge
+	}
+	if cfg.Presets.Registry.UploadMaxAge <= 0 {
+		cfg.Presets.Registry.UploadMaxAge = defaultHarborUploadMaxAge
+	}
+	if cfg.Presets.Registry.BlobMaxAge <= 0 {
+		cfg.Presets.Registry.BlobMaxAge = defaultRegistryBlobMaxAge
+	}
+	if cfg.Presets.Dedup.MaxAge <= 0 {
+		cfg.Presets.Dedup.MaxAge = defaultDedupMaxAge
+	}
+	if cfg.Presets.Velero.KeepLatest <= 0 {
+		cfg.Presets.Velero.KeepLatest = defaultVeleroKeepLatest
+	}
+	if cfg.Presets.Velero.MaxAge <= 0 {
+		cfg.Presets.Velero.MaxAge = defaultVeleroMaxAge
+	}
+	for _, ci := range []*CIPresetConfig{&cfg.Presets.GitLab, &cfg.Presets.Jenkins} {
+		if ci.KeepLatest <= 0 {
+			ci.KeepLatest = 1
+		}
+		if ci.MaxAge <= 0 {
+			ci.MaxAge = defaultCIMaxAge
+		}
+	}
+
+	if cfg.CircuitBreaker.ProbeInterval <= 0 {
+		cfg.CircuitBreaker.ProbeInterval = 30 * time.Second
+	}
+
+	// 守护模式默认值
+	if cfg.Daemon.Interval <= 0 {
+		cfg.Daemon.Interval = 24 * time.Hour
+	}
+	if cfg.Daemon.StallTimeout == 0 {
+		cfg.Daemon.StallTimeout = 10 * time.Minute
+	}
+	if cfg.Daemon.MaxConcurrentRuns <= 0 {
+		cfg.Daemon.MaxConcurrentRuns = 1
+	}
+	if cfg.Daemon.API.QueueSize <= 0 {
+		cfg.Daemon.API.QueueSize = defaultJobsQueueSize
+	}
+	for i := range cfg.Daemon.API.Tenants {
+		if t := &cfg.Daemon.API.Tenants[i]; t.MaxConcurrent <= 0 {
+			t.MaxConcurrent = 1
+		}
+	}
+}
+
+// CircuitBreakerConfig 为熔断器配置：endpoint 连续出现连接或认证错误时暂停删除，
+// 定期探测 endpoint，恢复后自动继续
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failureThreshold"` // 连续失败多少次后暂停删除，0 表示不启用
+	ProbeInterval    time.Duration `yaml:"probeInterval"`    // 暂停期间探测 endpoint 的间隔
+}
+
+// CostConfig 为存储单价，配置后汇总报告和通知中估算清理每月节省的存储费用
+type CostConfig struct {
+	Currency        string     `yaml:"currency"`        // 货币单位，仅用于展示，默认 USD
+	PricePerGBMonth float64    `yaml:"pricePerGBMonth"` // 每 GB 每月的存储单价
+	Tiers           []CostTier `yaml:"tiers"`           // 阶梯单价，按存储桶中的数据量分档，与 pricePerGBMonth 只能配置一个
+
+	Requests RequestCostConfig `yaml:"requests"` // 请求单价，配置后估算运行发出的 API 请求的费用
+}
+
+// RequestCostConfig 为每 1000 次请求的价格
+type RequestCostConfig struct {
+	List   float64 `yaml:"list"`   // LIST 请求的价格
+	Get    float64 `yaml:"get"`    // GET 和 HEAD 请求的价格
+	Delete float64 `yaml:"delete"` // DELETE 请求的价格，AWS S3 不收费
+	Other  float64 `yaml:"other"`  // PUT、POST 等其他请求的价格
+}
+
+// Enabled 返回是否配置了请求单价
+func (r *RequestCostConfig) Enabled() bool {
+	return r.List > 0 || r.Get > 0 || r.Delete > 0 || r.Other > 0
+}
+
+// CostTier 为阶梯单价中的一档
+type CostTier struct {
+	UpTo            rules.ByteSize `yaml:"upTo"`            // 该档的数据量上限（含之前各档），如 50TB，最后一档可以不设置
+	PricePerGBMonth float64        `yaml:"pricePerGBMonth"` // 该档每 GB 每月的存储单价
+}
+
+// DefaultCurrency 为 cost.currency 的默认值
+const DefaultCurrency = "USD"
+
+// Enabled 返回是否配置了存储单价
+func (c *CostConfig) Enabled() bool {
+	return c.PricePerGBMonth > 0 || len(c.Tiers) > 0
+}
+
+// Monthly 返回存储 size 字节每月的费用。数据量超过所有档的上限时，超出部分按最后一档计算
+func (c *CostConfig) Monthly(size int64) float64 {
+	gb := func(n int64) float64 { return float64(n) / 1024 / 1024 / 1024 }
+	if len(c.Tiers) == 0 {
+		return gb(size) * c.PricePerGBMonth
+	}
+	var cost float64
+	var lower int64
+	for i, t := range c.Tiers {
+		upper := int64(t.UpTo)
+		if upper <= 0 || i == len(c.Tiers)-1 || size < upper {
+			upper = max(size, lower)
+		}
+		cost += gb(upper-lower) * t.PricePerGBMonth
+		if upper >= size {
+			break
+		}
+		lower = upper
+	}
+	return cost
+}
+
+// GroupsObjects 返回是否按组清理对象：有规则设置了 groupDepth 或 partition，或有集群、存储桶选择了 velero 预设
+func (cfg *Config) GroupsObjects(rs []rules.Rule) bool {
+	if rules.HasGroups(rs) {
+		return true
+	}
+	return slices.ContainsFunc(cfg.AllClusters(), func(m MinioConfig) bool {
+		return m.Preset == "velero" || slices.ContainsFunc(m.Buckets, func(b BucketConfig) bool { return b.Preset == "velero" })
+	})
+}
+
+const (
+	// defaultInventoryMaxAge 为 inventory.maxAge 的默认值，每天生成的清单晚一次也可以使用
+	defaultInventoryMaxAge = rules.Retention(2 * rules.Day)
+)
+
+// InventoryConfig 为作为列举来源的 S3 Inventory 清单。配置后读取最近一次生成的清单代替实时列举存储桶，
+// 数亿对象的存储桶无需逐页调用 ListObjects
+type InventoryConfig struct {
+	Bucket string          `yaml:"bucket"` // 清单所在的存储桶（S3 Inventory 的目标存储桶），为空时为被清理的存储桶
+	Prefix string          `yaml:"prefix"` // 清单的前缀 <目标前缀>/<源存储桶>/<清单配置 ID>/，支持 {cluster}、{bucket} 占位符，为空则不使用清单
+	MaxAge rules.Retention `yaml:"maxAge"` // 最近一次清单早于该时间时不清理，默认 2d
+}
+
+// Enabled 返回是否使用清单代替实时列举
+func (c *InventoryConfig) Enabled() bool {
+	return c.Prefix != ""
+}
+
+// DebugConfig 为性能分析相关配置
+type DebugConfig struct {
+	PprofAddress string `yaml:"pprofAddress"` // pprof 调试接口监听地址，如 localhost:6060，为空则不启用
+	Bench        bool   `yaml:"bench"`        // 基准模式：运行结束后分别输出列举、筛选和执行阶段的吞吐量
+}
+
+// Hash 计算生效配置的 SHA-256 摘要，用于区分不同配置下的运行结果
+func Hash(cfg *Config) string {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// RetryConfig 为对象操作的重试配置
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"maxAttempts"`    // 最多尝试次数（含首次），1 表示不重试
+	InitialBackoff time.Duration `yaml:"initialBackoff"` // 第一次重试前的最长等待时间
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`     // 单次重试等待时间上限
+}
+
+// Backoff 返回第 attempt 次重试前的等待时间：上限按指数增长，实际值在 0 到上限之间随机（full jitter）
+func (r *RetryConfig) Backoff(attempt int) time.Duration {
+	ceiling := r.InitialBackoff
+	for i := 1; i < attempt && ceiling < r.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+	if ceiling > r.MaxBackoff {
+		ceiling = r.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// TimeoutConfig 为各类 MinIO 请求的超时配置，0 表示不限制
+type TimeoutConfig struct {
+	List   time.Duration `yaml:"list"`   // 等待下一批列举结果的最长时间
+	Stat   time.Duration `yaml:"stat"`   // 查询类请求（检查存储桶是否存在等）的超时
+	Delete time.Duration `yaml:"delete"` // 单次删除请求的超时
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Extensions 为支持的配置文件扩展名
+var Extensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// ParseFile 按扩展名解析 YAML、JSON 或 TOML 格式的配置文件，返回 YAML 文档节点。
+// 各格式使用相同的配置项，之后的 profile 合并、覆盖和解析与格式无关，错误中的行号仍对应原文件。
+// sops 加密的配置文件在这里解密
+func ParseFile(path string, data []byte) (*yaml.Node, error) {
+	doc, err := ParseData(path, data)
+	if err != nil || !isSOPSEncrypted(doc) {
+		return doc, err
+	}
+	plaintext, err := decryptSOPS(path, data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseData(path, plaintext)
+}
+
+// ParseData 按 path 的扩展名解析配置文件的内容
+func ParseData(path string, data []byte) (*yaml.Node, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSONNode(data)
+	case ".toml":
+		return tomlConfigNode(data)
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Decode 将配置文件的文档节点解析到 out，strict 为 true 时未知的配置项视为错误。
+// 取值和未知配置项的问题以 yaml.TypeError 返回，每条说明都带有行号
+func Decode(doc *yaml.Node, out any, strict bool) error {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	var problems []string
+	if strict {
+		problems = unknownKeys(doc.Content[0], reflect.TypeOf(out).Elem())
+	}
+	if err := doc.Decode(out); err != nil {
+		var terr *yaml.TypeError
+		if !errors.As(err, &terr) {
+			return err
+		}
+		problems = append(problems, terr.Errors...)
+	}
+	if len(problems) > 0 {
+		return &yaml.TypeError{Errors: problems}
+	}
+	return nil
+}
+
+// JSONNode 将 JSON 配置文件转换为 YAML 文档节点并保留行号。
+// 不直接按 YAML 解析，因为配置管理系统生成的 JSON 可能包含 YAML 不支持的转义（如 \/）
+func JSONNode(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	lineAt := func(offset int64) int {
+		return 1 + bytes.Count(data[:offset], []byte("\n"))
+	}
+
+	var value func() (*yaml.Node, error)
+	value = func() (*yaml.Node, error) {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		line := lineAt(dec.InputOffset())
+		switch v := tok.(type) {
+		case json.Delim:
+			n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+			if v == '{' {
+				n.Kind, n.Tag = yaml.MappingNode, "!!map"
+			}
+			for dec.More() {
+				if n.Kind == yaml.MappingNode {
+					key, err := dec.Token()
+					if err != nil {
+						return nil, err
+					}
+					n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str",
+						Value: key.(string), Line: lineAt(dec.InputOffset())})
+				}
+				item, err := value()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, item)
+			}
+			// 读取结束的 } 或 ]
+			_, err := dec.Token()
+			return n, err
+		case string:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v, Line: line}, nil
+		case json.Number:
+			tag := "!!int"
+			if strings.ContainsAny(v.String(), ".eE") {
+				tag = "!!float"
+			}
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String(), Line: line}, nil
+		case bool:
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v), Line: line}, nil
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null", Line: line}, nil
+	}
+
+	root, err := value()
+	if errors.Is(err, io.EOF) {
+		return &yaml.Node{Kind: yaml.DocumentNode}, nil
+	}
+	if err != nil {
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			// Offset 为已读取的字节数，包含出错的字符
+			return nil, errors.New(tr(msgJSONSyntax, lineAt(max(serr.Offset-1, 0)), err))
+		}
+		return nil, errors.New(tr(msgJSONSyntax, lineAt(dec.InputOffset()), err))
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Content: []*yaml.Node{root}}, nil
+}
+
+// unknownFieldPattern 匹配 yaml 严格解析时未知配置项的错误，类型名可能是很长的匿名结构体，不直接展示
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type `)
+
+// DecodeErrors 将 yaml 解析错误逐条转换为易读的说明
+func DecodeErrors(err error) []string {
+	var terr *yaml.TypeError
+	if !errors.As(err, &terr) {
+		return []string{tr(msgValidateSyntax, err)}
+	}
+	problems := make([]string, 0, len(terr.Errors))
+	for _, e := range terr.Errors {
+		if m := unknownFieldPattern.FindStringSubmatch(e); m != nil {
+			problems = append(problems, tr(msgValidateUnknownKey, m[1], m[2]))
+			continue
+		}
+		problems = append(problems, e)
+	}
+	return problems
+}
+
+// unknownKeys 按 yaml 标签检查 node 中的未知配置项，返回带行号的说明。
+// profiles 以 yaml.Node 保存，严格解析配置文件时不会检查其中的配置项
+func unknownKeys(n *yaml.Node, t reflect.Type) []string {
+	n = resolveAlias(n)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var problems []string
+	switch {
+	case t == reflect.TypeOf(yaml.Node{}):
+	case t.Kind() == reflect.Struct && n.Kind == yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Value == "<<" {
+				continue
+			}
+			f, ok := yamlField(t, key.Value)
+			if !ok {
+				problems = append(problems, tr(msgValidateUnknownKey, strconv.Itoa(key.Line), key.Value))
+				continue
+			}
+			problems = append(problems, unknownKeys(n.Content[i+1], f.Type)...)
+		}
+	case t.Kind() == reflect.Slice && n.Kind == yaml.SequenceNode:
+		for _, c := range n.Content {
+			problems = append(problems, unknownKeys(c, t.Elem())...)
+		}
+	case t.Kind() == reflect.Map && n.Kind == yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			problems = append(problems, unknownKeys(n.Content[i], t.Elem())...)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+	minio "github.com/minio/minio-go/v7"
+)
+
+// GRPCConfig 为守护模式下的 gRPC 控制服务，接口定义见 proto/minio_cleaner/v1/control.proto。
+// 服务可以启动删除对象的运行，token 和 clientCAFile 至少需要配置一个
+type GRPCConfig struct {
+	Addr         string `yaml:"addr"`         // 监听地址，如 :9090，为空则不启用
+	Token        string `yaml:"token"`        // 调用方需在 authorization 元数据中携带 Bearer 令牌
+	CertFile     string `yaml:"certFile"`     // TLS 证书文件，与 keyFile 都为空时不加密
+	KeyFile      string `yaml:"keyFile"`      // TLS 私钥文件
+	ClientCAFile string `yaml:"clientCAFile"` // 签发客户端证书的 CA 文件，配置后调用方需提供该 CA 签发的证书（mTLS）
+}
+
+// JobsAPIConfig 为守护模式下提交临时清理任务的 REST 接口
+type JobsAPIConfig struct {
+	Addr      string       `yaml:"addr"`      // 监听地址，如 :8090，为空则不启用
+	Tenants   []JobsTenant `yaml:"tenants"`   // 可以提交任务的团队，启用时至少配置一个
+	CertFile  string       `yaml:"certFile"`  // TLS 证书文件，与 keyFile 都为空时使用 HTTP
+	KeyFile   string       `yaml:"keyFile"`   // TLS 私钥文件
+	QueueSize int          `yaml:"queueSize"` // 每个团队排队等待执行的任务数上限，默认 20
+}
+
+// JobsTenant 为一个可以提交任务的团队。团队只能清理 buckets 中列出的存储桶，只能查询和取消自己提交的任务，
+// 各团队的任务轮流执行，并分别限制同时执行的任务数和删除速率
+type JobsTenant struct {
+	Team                string   `yaml:"team"`                // 团队名称，记录在任务和日志中
+	Token               string   `yaml:"token"`               // 请求需在 Authorization 头中携带 Bearer 令牌
+	Buckets             []string `yaml:"buckets"`             // 团队可以清理的存储桶，可写成 集群名/存储桶 限定集群，必填
+	MaxConcurrent       int      `yaml:"maxConcurrent"`       // 该团队同时执行的任务数上限，默认 1
+	MaxDeletesPerSecond float64  `yaml:"maxDeletesPerSecond"` // 该团队所有任务合计每秒最多删除的对象数，0 表示不限制
+	AccessKeyID         string   `yaml:"accessKeyId"`         // 执行该团队的任务使用的访问密钥 ID，必填
+	SecretAccessKey     string   `yaml:"secretAccessKey"`     // 执行该团队的任务使用的访问密钥
+	AccessKeyIDFile     string   `yaml:"accessKeyIdFile"`     // 从文件读取访问密钥 ID
+	SecretAccessKeyFile string   `yaml:"secretAccessKeyFile"` // 从文件读取访问密钥
+}
+
+// HasCreds 返回团队是否配置了自己的凭证
+func (t *JobsTenant) HasCreds() bool {
+	return t.AccessKeyID != "" || t.AccessKeyIDFile != ""
+}
+
+// Allows 返回团队是否可以清理集群 cluster 上的存储桶 bucket
+func (t *JobsTenant) Allows(cluster, bucket string) bool {
+	return slices.Contains(t.Buckets, bucket) || slices.Contains(t.Buckets, cluster+"/"+bucket)
+}
+
+// defaultJobsQueueSize 为 daemon.api.queueSize 的默认值
+const defaultJobsQueueSize = 20
+
+// WatchConfig 为事件驱动的实时清理：守护模式下订阅存储桶的对象创建通知，新写入的对象符合 watch.rules 时
+// 在达到 safety.minObjectAge 后删除，作为周期清理的补充，如尽快删除不允许上传的文件类型。
+// 只支持 MinIO，Azure、GCS 和 AWS S3 不提供该通知接口
+type WatchConfig struct {
+	Rules []WatchRule `yaml:"rules"` // 实时清理规则，为空时不订阅通知
+}
+
+// WatchRule 为一条实时清理规则，新写入的对象同时满足前缀、后缀和大小条件时删除。
+// 实时清理规则只用于新写入的对象，不参与周期清理；对象同样要达到 safety.minObjectAge 才删除
+type WatchRule struct {
+	Name     string         `yaml:"name"`     // 规则名称，用于日志
+	Prefix   string         `yaml:"prefix"`   // 对象前缀，为空则匹配所有对象
+	Suffixes []string       `yaml:"suffixes"` // 对象名后缀，如 .exe、.iso，不区分大小写，为空则不限制
+	MinSize  rules.ByteSize `yaml:"minSize"`  // 文件最小大小，如 500MB，为 0 则不限制
+}
+
+// Match 返回对象是否符合实时清理规则
+func (r *WatchRule) Match(obj minio.ObjectInfo) bool {
+	if !strings.HasPrefix(obj.Key, r.Prefix) || obj.Size < int64(r.MinSize) {
+		return false
+	}
+	if len(r.Suffixes) == 0 {
+		return true
+	}
+	key := strings.ToLower(obj.Key)
+	for _, s := range r.Suffixes {
+		if strings.HasSuffix(key, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
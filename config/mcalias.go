@@ -0,0 +1,108 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// mcAlias 为 MinIO 客户端（mc）配置中的一个别名
+type mcAlias struct {
+	URL          string `json:"url"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+	SessionToken string `json:"sessionToken"`
+	// Path 为存储桶寻址方式：auto、on（路径方式）或 off（虚拟主机方式）
+	Path string `json:"path"`
+}
+
+// mcConfigPath 返回 mc 配置文件的路径：MC_CONFIG_DIR 中的 config.json，默认为 ~/.mc/config.json
+func mcConfigPath() string {
+	if dir := os.Getenv("MC_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".mc", "config.json")
+}
+
+// lookupMCAlias 按 mc 的规则查找别名：环境变量 MC_HOST_<别名>（如 https://<访问密钥 ID>:<访问密钥>@minio.example.com）优先，
+// 否则读取 mc 配置文件。每次调用都重新读取，别名的密钥更新后下次运行即生效
+func lookupMCAlias(name string) (*mcAlias, error) {
+	if env := os.Getenv("MC_HOST_" + name); env != "" {
+		u, err := url.Parse(env)
+		if err != nil {
+			return nil, errors.New(tr(msgAliasURL, name, "MC_HOST_"+name))
+		}
+		a := &mcAlias{URL: u.Scheme + "://" + u.Host}
+		if u.User != nil {
+			a.AccessKey = u.User.Username()
+			a.SecretKey, _ = u.User.Password()
+		}
+		return a, nil
+	}
+	path := mcConfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New(tr(msgAliasFailed, path, err))
+	}
+	// 旧版 mc 的配置文件（version 9 及以前）将别名保存在 hosts 中
+	var f struct {
+		Aliases map[string]*mcAlias `json:"aliases"`
+		Hosts   map[string]*mcAlias `json:"hosts"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.New(tr(msgAliasFailed, path, err))
+	}
+	a := f.Aliases[name]
+	if a == nil {
+		a = f.Hosts[name]
+	}
+	if a == nil {
+		return nil, errors.New(tr(msgAliasNotFound, path, name))
+	}
+	return a, nil
+}
+
+// ResolveAlias 按 endpointAlias 从 mc 的配置补全连接配置：endpoint 为空时使用别名的地址和协议，
+// addressing 为空时按别名的寻址方式设置，name 为空时使用别名。别名的密钥在没有配置其他凭证时使用，
+// 只保存在未导出的字段中，不会出现在审计日志的配置和配置摘要中
+func (m *MinioConfig) ResolveAlias() error {
+	if m.EndpointAlias == "" {
+		return nil
+	}
+	a, err := lookupMCAlias(m.EndpointAlias)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(a.URL)
+	if err != nil || u.Host == "" {
+		return errors.New(tr(msgAliasURL, m.EndpointAlias, a.URL))
+	}
+	if m.Endpoint == "" {
+		m.Endpoint = u.Host
+		m.UseSSL = u.Scheme == "https"
+	}
+	if m.Addressing == "" {
+		switch a.Path {
+		case "on":
+			m.Addressing = "path"
+		case "off":
+			m.Addressing = "virtualHost"
+		}
+	}
+	if m.Name == "" {
+		m.Name = m.EndpointAlias
+	}
+	m.alias = a
+	return nil
+}
+
+// AliasCredentials 返回 endpointAlias 解析出的 mc 别名中的访问密钥，未配置别名或别名中没有访问密钥时返回 false
+func (m *MinioConfig) AliasCredentials() (accessKey, secretKey, sessionToken string, ok bool) {
+	if m.alias == nil || m.alias.AccessKey == "" {
+		return "", "", "", false
+	}
+	return m.alias.AccessKey, m.alias.SecretKey, m.alias.SessionToken, true
+}
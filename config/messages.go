@@ -0,0 +1,139 @@
+package config
+
+import (
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgValidateSyntax     msgID = "validate.syntax"
+	msgValidateUnknownKey msgID = "validate.unknownKey"
+	msgRulesDirFailed     msgID = "config.rulesDirFailed"
+	msgRulesFileProblem   msgID = "config.rulesFile"
+	msgJSONSyntax         msgID = "config.jsonSyntax"
+	msgTOMLSyntax         msgID = "config.tomlSyntax"
+	msgSOPSDecrypt        msgID = "sops.decrypt"
+	msgSOPSFormat         msgID = "sops.format"
+	msgOverrideUnknown    msgID = "override.unknown"
+	msgOverrideValue      msgID = "override.value"
+	msgOverrideIndex      msgID = "override.index"
+	msgOverrideNotObject  msgID = "override.notObject"
+	msgOverrideClusters   msgID = "override.clusters"
+	msgProfileUnknown     msgID = "profile.unknown"
+	msgProfileNotObject   msgID = "profile.notObject"
+	msgCILayoutGroups     msgID = "ci.layoutGroups"
+	msgAliasFailed        msgID = "alias.failed"
+	msgAliasNotFound      msgID = "alias.notFound"
+	msgAliasURL           msgID = "alias.url"
+	msgBadTimezone        msgID = "config.badTimezone"
+	msgConfigReadFailed   msgID = "config.readFailed"
+	msgConfigParseFailed  msgID = "config.parseFailed"
+	msgConfigDecode       msgID = "config.decode"
+	msgFlagEndpoint       msgID = "flag.endpoint"
+	msgFlagBucket         msgID = "flag.bucket"
+	msgFlagMaxAge         msgID = "flag.maxAge"
+	msgFlagMinSize        msgID = "flag.minSize"
+	msgFlagDryRun         msgID = "flag.dryRun"
+	msgFlagWorkers        msgID = "flag.workers"
+	msgFlagListers        msgID = "flag.listers"
+	msgFlagParallel       msgID = "flag.parallelTargets"
+	msgFlagDeleteRate     msgID = "flag.deleteRate"
+	msgFlagMaxErrors      msgID = "flag.maxErrors"
+	msgFlagMaxErrorRate   msgID = "flag.maxErrorRate"
+	msgFlagMaxRuntime     msgID = "flag.maxRuntime"
+	msgFlagLogFile        msgID = "flag.logFile"
+	msgFlagLogFormat      msgID = "flag.logFormat"
+	msgFlagLanguage       msgID = "flag.language"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = i18n.Catalogs[msgID]{
+	"zh": {
+		msgValidateSyntax:     "无法解析配置文件: %v",
+		msgValidateUnknownKey: "第 %s 行: 未知的配置项 %s，请检查拼写和大小写",
+		msgRulesDirFailed:     "读取规则文件失败: %s: %v",
+		msgRulesFileProblem:   "规则文件 %s: %s",
+		msgJSONSyntax:         "JSON 第 %d 行: %v",
+		msgTOMLSyntax:         "TOML 第 %d 行: %s",
+		msgSOPSDecrypt:        "解密 sops 加密的配置文件失败（age 私钥通过环境变量 SOPS_AGE_KEY 或 SOPS_AGE_KEY_FILE 提供）: %v",
+		msgSOPSFormat:         "sops 不支持 TOML 格式，请使用 YAML 或 JSON 格式的加密配置文件",
+		msgOverrideUnknown:    "未知的配置项: %s",
+		msgOverrideValue:      "无法覆盖配置项 %s: %v",
+		msgOverrideIndex:      "列表序号 %s 超出范围，列表共有 %d 项",
+		msgOverrideNotObject:  "%s 的上一级配置项不是对象或列表",
+		msgOverrideClusters:   "配置了 clusters 时不能覆盖 %s，请使用 -set clusters.<序号>.<配置项>",
+		msgProfileUnknown:     "配置文件中没有名为 %q 的 profile，可用的 profile: %s",
+		msgProfileNotObject:   "profile %q 应为对象，包含要覆盖的配置项",
+		msgCILayoutGroups:     "需要包含命名分组 branch 和 build",
+		msgAliasFailed:        "无法读取 mc 配置文件 %s: %v",
+		msgAliasNotFound:      "mc 配置文件 %s 中没有别名 %s",
+		msgAliasURL:           "mc 别名 %s 的地址 %q 无效",
+		msgBadTimezone:        "无效的时区: %q，应为 IANA 时区名称，如 Asia/Shanghai、UTC",
+		msgConfigReadFailed:   "读取配置文件失败: %v",
+		msgConfigParseFailed:  "解析配置文件失败: %v",
+		msgConfigDecode:       "解析配置文件失败:\n  - %s",
+		msgFlagEndpoint:       "MinIO 服务器地址（覆盖 minio.endpoint）",
+		msgFlagBucket:         "只清理该存储桶（覆盖 minio.bucket，并忽略 minio.buckets 和 minio.bucketPattern）",
+		msgFlagMaxAge:         "文件最大保留时间，如 30d、12h，不带单位时按天计算（覆盖 cleanup.maxAge）",
+		msgFlagMinSize:        "文件最小大小，如 500MB，不带单位时按字节计算（覆盖 cleanup.minSize）",
+		msgFlagDryRun:         "仅预览不实际删除，-dry-run=false 表示实际删除（覆盖 cleanup.dryRun）",
+		msgFlagWorkers:        "并发工作协程数（覆盖 cleanup.workers）",
+		msgFlagListers:        "并发列举协程数（覆盖 cleanup.listers）",
+		msgFlagParallel:       "同时清理的目标数（覆盖 cleanup.parallelTargets）",
+		msgFlagDeleteRate:     "每秒最多删除的对象数（覆盖 cleanup.maxDeletesPerSecond）",
+		msgFlagMaxErrors:      "删除错误数超过该值时中止运行（覆盖 cleanup.maxErrors）",
+		msgFlagMaxErrorRate:   "删除错误比例超过该值时中止运行（覆盖 cleanup.maxErrorRate）",
+		msgFlagMaxRuntime:     "单次运行的最长时间，如 2h（覆盖 cleanup.maxRuntime）",
+		msgFlagLogFile:        "日志文件路径（覆盖 cleanup.logFile）",
+		msgFlagLogFormat:      "日志格式：text 或 json（覆盖 cleanup.logFormat）",
+		msgFlagLanguage:       "日志语言：zh 或 en（覆盖 cleanup.language）",
+	},
+	"en": {
+		msgValidateSyntax:     "Cannot parse the config file: %v",
+		msgValidateUnknownKey: "line %s: unknown key %s, check the spelling and case",
+		msgRulesDirFailed:     "Failed to read rules file: %s: %v",
+		msgRulesFileProblem:   "Rules file %s: %s",
+		msgJSONSyntax:         "JSON line %d: %v",
+		msgTOMLSyntax:         "TOML line %d: %s",
+		msgSOPSDecrypt:        "Failed to decrypt the sops encrypted config file (provide an age key via SOPS_AGE_KEY or SOPS_AGE_KEY_FILE): %v",
+		msgSOPSFormat:         "sops does not support TOML, use a YAML or JSON encrypted config file",
+		msgOverrideUnknown:    "Unknown config key: %s",
+		msgOverrideValue:      "Cannot override config key %s: %v",
+		msgOverrideIndex:      "List index %s is out of range, the list has %d items",
+		msgOverrideNotObject:  "The parent of %s is not an object or a list",
+		msgOverrideClusters:   "Cannot override %s when clusters is configured, use -set clusters.<index>.<key>",
+		msgProfileUnknown:     "No profile named %q in the config file, available profiles: %s",
+		msgProfileNotObject:   "Profile %q must be a mapping of config keys to override",
+		msgCILayoutGroups:     "it must contain the named groups branch and build",
+		msgAliasFailed:        "failed to read mc config file %s: %v",
+		msgAliasNotFound:      "mc config file %s has no alias %s",
+		msgAliasURL:           "mc alias %s has an invalid URL %q",
+		msgBadTimezone:        "Invalid timezone: %q, expected an IANA time zone name such as Asia/Shanghai or UTC",
+		msgConfigReadFailed:   "Failed to read config file: %v",
+		msgConfigParseFailed:  "Failed to parse config file: %v",
+		msgConfigDecode:       "Failed to parse config file:\n  - %s",
+		msgFlagEndpoint:       "MinIO server address (overrides minio.endpoint)",
+		msgFlagBucket:         "Only clean up this bucket (overrides minio.bucket and ignores minio.buckets and minio.bucketPattern)",
+		msgFlagMaxAge:         "Maximum object age, e.g. 30d or 12h, in days without a unit (overrides cleanup.maxAge)",
+		msgFlagMinSize:        "Minimum object size, e.g. 500MB, in bytes without a unit (overrides cleanup.minSize)",
+		msgFlagDryRun:         "Only preview without deleting, -dry-run=false deletes (overrides cleanup.dryRun)",
+		msgFlagWorkers:        "Number of concurrent workers (overrides cleanup.workers)",
+		msgFlagListers:        "Number of concurrent listers (overrides cleanup.listers)",
+		msgFlagParallel:       "Number of targets cleaned up at the same time (overrides cleanup.parallelTargets)",
+		msgFlagDeleteRate:     "Maximum deletions per second (overrides cleanup.maxDeletesPerSecond)",
+		msgFlagMaxErrors:      "Abort the run when delete errors exceed this number (overrides cleanup.maxErrors)",
+		msgFlagMaxErrorRate:   "Abort the run when the delete error ratio exceeds this value (overrides cleanup.maxErrorRate)",
+		msgFlagMaxRuntime:     "Maximum duration of a run, e.g. 2h (overrides cleanup.maxRuntime)",
+		msgFlagLogFile:        "Log file path (overrides cleanup.logFile)",
+		msgFlagLogFormat:      "Log format: text or json (overrides cleanup.logFormat)",
+		msgFlagLanguage:       "Log language: zh or en (overrides cleanup.language)",
+	},
+}
+
+// tr 按命令行程序设置的语言格式化消息，缺失的翻译回退到中文。
+// 读取配置文件时还不知道配置的语言，错误信息都使用命令行程序设置的语言
+func tr(id msgID, args ...any) string {
+	return catalogs.Format("", id, args...)
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// AzureConfig 为 Azure Blob 存储的额外配置。账户名和账户密钥分别使用 accessKeyId 和 secretAccessKey
+type AzureConfig struct {
+	SASToken string `yaml:"sasToken"` // 共享访问签名（SAS），配置后不使用账户密钥
+}
+
+// TransportConfig 为连接 MinIO 的 HTTP 连接池和超时配置，未配置的项使用 MinIO 客户端的默认值
+type TransportConfig struct {
+	MaxIdleConns          int           `yaml:"maxIdleConns"`          // 所有主机的空闲连接总数上限
+	MaxIdleConnsPerHost   int           `yaml:"maxIdleConnsPerHost"`   // 每个主机保留的空闲连接数，默认不少于并发列举和删除的协程数
+	MaxConnsPerHost       int           `yaml:"maxConnsPerHost"`       // 每个主机的连接总数上限，0 表示不限制
+	IdleConnTimeout       time.Duration `yaml:"idleConnTimeout"`       // 空闲连接保留时间
+	DialTimeout           time.Duration `yaml:"dialTimeout"`           // 建立 TCP 连接的超时
+	KeepAlive             time.Duration `yaml:"keepAlive"`             // TCP keep-alive 探测间隔，负数表示关闭
+	TLSHandshakeTimeout   time.Duration `yaml:"tlsHandshakeTimeout"`   // TLS 握手超时
+	ResponseHeaderTimeout time.Duration `yaml:"responseHeaderTimeout"` // 发送请求后等待响应头的超时
+	ExpectContinueTimeout time.Duration `yaml:"expectContinueTimeout"` // 等待 100-continue 响应的超时
+	DisableKeepAlives     bool          `yaml:"disableKeepAlives"`     // 是否禁用连接复用
+	Proxy                 string        `yaml:"proxy"`                 // 代理地址，支持 http、https、socks5 和 socks5h，为空时使用 HTTP_PROXY 等环境变量，direct 表示不使用代理
+}
+
+// TLSConfig 为连接 MinIO 的 TLS 配置，仅在 useSSL 为 true 时生效
+type TLSConfig struct {
+	CAFile             string `yaml:"caFile"`             // 额外信任的 CA 证书文件（PEM），用于内部 CA 签发的证书
+	CertFile           string `yaml:"certFile"`           // 双向 TLS 使用的客户端证书文件（PEM）
+	KeyFile            string `yaml:"keyFile"`            // 双向 TLS 使用的客户端私钥文件（PEM）
+	ServerName         string `yaml:"serverName"`         // 校验证书时使用的服务器名称，默认为 endpoint 中的主机名
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"` // 是否跳过服务器证书校验，仅用于测试
+}
+
+// GCSConfig 为 Google Cloud Storage 的额外配置
+type GCSConfig struct {
+	CredentialsFile string `yaml:"credentialsFile"` // 服务账号密钥或 gcloud 应用默认凭证文件，默认取 GOOGLE_APPLICATION_CREDENTIALS
+	Project         string `yaml:"project"`         // 项目 ID，仅用于按 bucketPattern 列举存储桶，默认取凭证文件中的项目或 GOOGLE_CLOUD_PROJECT
+}
+
+// STSConfig 为通过 STS 获取临时凭证的配置。临时凭证在有效期过去 80% 时自动续期，
+// 运行时间超过凭证有效期也不会中断
+type STSConfig struct {
+	Type            string        `yaml:"type"`            // 凭证类型：assumeRole 或 webIdentity，为空则不使用 STS
+	Endpoint        string        `yaml:"endpoint"`        // STS 服务地址，如 https://sts.amazonaws.com，默认为 MinIO 服务器地址
+	RoleARN         string        `yaml:"roleArn"`         // 要扮演的角色，webIdentity 默认取 AWS_ROLE_ARN
+	RoleSessionName string        `yaml:"roleSessionName"` // 会话名称，仅用于 assumeRole
+	ExternalID      string        `yaml:"externalId"`      // 外部 ID，仅用于 assumeRole
+	Policy          string        `yaml:"policy"`          // 进一步限制临时凭证权限的策略（JSON）
+	Region          string        `yaml:"region"`          // STS 服务所在区域，仅用于 assumeRole，默认与 minio.region 相同
+	Duration        time.Duration `yaml:"duration"`        // 临时凭证有效期，默认 1h
+	TokenFile       string        `yaml:"tokenFile"`       // webIdentity 使用的令牌文件，默认取 AWS_WEB_IDENTITY_TOKEN_FILE，未设置时为 Kubernetes 服务账号令牌
+}
+
+// MinioConfig 为一个 MinIO 集群的连接配置
+type MinioConfig struct {
+	Name                string          `yaml:"name"`                // 集群名称，用于日志、报告和路径占位符，默认为 endpoint
+	Type                string          `yaml:"type"`                // 存储类型：s3、azure 或 gcs，默认 s3
+	Endpoint            string          `yaml:"endpoint"`            // MinIO 服务器地址
+	EndpointAlias       string          `yaml:"endpointAlias"`       // mc 的别名，从 ~/.mc/config.json 读取服务器地址和访问密钥
+	AccessKeyID         string          `yaml:"accessKeyId"`         // 访问密钥 ID，为空时从环境变量、凭证文件或 IAM 角色获取
+	SecretAccessKey     string          `yaml:"secretAccessKey"`     // 访问密钥
+	AccessKeyIDFile     string          `yaml:"accessKeyIdFile"`     // 从文件读取访问密钥 ID，每次运行开始时重新读取
+	SecretAccessKeyFile string          `yaml:"secretAccessKeyFile"` // 从文件读取访问密钥，每次运行开始时重新读取
+	CredentialsFile     string          `yaml:"credentialsFile"`     // AWS 凭证文件路径，默认 ~/.aws/credentials
+	Profile             string          `yaml:"profile"`             // AWS 凭证文件中的 profile，默认 AWS_PROFILE 或 default
+	IAMEndpoint         string          `yaml:"iamEndpoint"`         // 获取 IAM 角色凭证的地址，默认按运行环境自动选择
+	STS                 STSConfig       `yaml:"sts"`                 // 通过 STS 获取临时凭证
+	Vault               VaultConfig     `yaml:"vault"`               // 从 Vault 读取凭证
+	UseSSL              bool            `yaml:"useSSL"`
+	Region              string          `yaml:"region"`             // 存储桶所在区域，如 us-east-1，为空时自动查询
+	Addressing          string          `yaml:"addressing"`         // 存储桶寻址方式：auto、path 或 virtualHost，默认 auto
+	Bucket              string          `yaml:"bucket"`             // 要清理的存储桶
+	Buckets             []BucketConfig  `yaml:"buckets"`            // 要清理的多个存储桶，与 bucket 合并
+	BucketPattern       string          `yaml:"bucketPattern"`      // 按名称匹配要清理的存储桶（正则表达式，需完整匹配），与 bucket 和 buckets 合并
+	ExcludeBuckets      []string        `yaml:"excludeBuckets"`     // 不清理的存储桶，即使在 bucket、buckets 中配置或匹配 bucketPattern
+	RemoveEmptyBuckets  bool            `yaml:"removeEmptyBuckets"` // 按 bucketPattern 发现的存储桶清理后没有剩余对象时删除存储桶本身，bucket 和 buckets 中配置的存储桶不删除
+	Preset              string          `yaml:"preset"`             // 集成预设，如 harbor，只清理预设认定为垃圾的对象
+	Transport           TransportConfig `yaml:"transport"`          // HTTP 连接池和超时
+	TLS                 TLSConfig       `yaml:"tls"`                // TLS 证书校验和客户端证书
+	Azure               AzureConfig     `yaml:"azure"`              // Azure Blob 存储的额外配置
+	GCS                 GCSConfig       `yaml:"gcs"`                // GCS 的额外配置
+	AdminAPI            bool            `yaml:"adminAPI"`           // 是否通过 MinIO 管理接口按存储桶大小排序清理目标，并记录集群清理前后的剩余空间
+
+	// alias 为 endpointAlias 解析出的 mc 别名，由 resolveAlias 设置
+	alias *mcAlias
+}
+
+// BucketConfig 为 buckets 中的一个存储桶。可以只写存储桶名称，
+// 也可以写成包含 name 和凭证的对象，为该存储桶单独指定访问密钥
+type BucketConfig struct {
+	Name                string `yaml:"name"`                // 存储桶名称
+	AccessKeyID         string `yaml:"accessKeyId"`         // 访问该存储桶使用的访问密钥 ID，为空则使用集群的凭证
+	SecretAccessKey     string `yaml:"secretAccessKey"`     // 访问该存储桶使用的访问密钥
+	AccessKeyIDFile     string `yaml:"accessKeyIdFile"`     // 从文件读取访问该存储桶使用的访问密钥 ID
+	SecretAccessKeyFile string `yaml:"secretAccessKeyFile"` // 从文件读取访问该存储桶使用的访问密钥
+	Preset              string `yaml:"preset"`              // 该存储桶使用的集成预设，为空则使用集群的 preset
+}
+
+// UnmarshalYAML 支持只写存储桶名称的简写形式
+func (b *BucketConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Name = node.Value
+		return nil
+	}
+	type plain BucketConfig
+	return node.Decode((*plain)(b))
+}
+
+// SecretsFromFile 返回是否从文件读取访问密钥
+func (m *MinioConfig) SecretsFromFile() bool {
+	return m.AccessKeyIDFile != "" || m.SecretAccessKeyFile != ""
+}
+
+// AllClusters 返回配置的全部集群，未配置 clusters 时为 minio 中的单个集群
+func (cfg *Config) AllClusters() []MinioConfig {
+	if len(cfg.Clusters) > 0 {
+		return cfg.Clusters
+	}
+	return []MinioConfig{cfg.Minio}
+}
+
+// Excluded 返回存储桶是否在集群的 excludeBuckets 或 cleanup.protectedBuckets 中，这些存储桶不会被清理
+func (cfg *Config) Excluded(cluster *MinioConfig, bucket string) bool {
+	return slices.Contains(cluster.ExcludeBuckets, bucket) || slices.Contains(cfg.Cleanup.ProtectedBuckets, bucket)
+}
+
+// DiscoversBuckets 返回是否有集群按 bucketPattern 发现存储桶
+func (cfg *Config) DiscoversBuckets() bool {
+	for _, cluster := range cfg.AllClusters() {
+		if cluster.BucketPattern != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// VaultConfig 为从 HashiCorp Vault KV 读取 MinIO 凭证的配置
+type VaultConfig struct {
+	Address         string        `yaml:"address"`         // Vault 地址，默认取 VAULT_ADDR
+	Path            string        `yaml:"path"`            // KV 路径，如 secret/data/minio-cleaner（KV v2）或 secret/minio-cleaner（KV v1），为空则不使用 Vault
+	AccessKeyField  string        `yaml:"accessKeyField"`  // 访问密钥 ID 所在字段，默认 accessKeyId
+	SecretKeyField  string        `yaml:"secretKeyField"`  // 访问密钥所在字段，默认 secretAccessKey
+	Namespace       string        `yaml:"namespace"`       // Vault 企业版命名空间
+	Token           string        `yaml:"token"`           // Vault 令牌，默认取 VAULT_TOKEN
+	TokenFile       string        `yaml:"tokenFile"`       // 从文件读取 Vault 令牌，如 Vault Agent 的 sink 文件
+	KubernetesRole  string        `yaml:"kubernetesRole"`  // 使用 Kubernetes 认证登录时的角色
+	KubernetesMount string        `yaml:"kubernetesMount"` // Kubernetes 认证的挂载路径，默认 kubernetes
+	JWTFile         string        `yaml:"jwtFile"`         // Kubernetes 认证使用的服务账号令牌，默认为 Pod 挂载的令牌
+	Refresh         time.Duration `yaml:"refresh"`         // 重新读取凭证的间隔，默认取密钥的租期，没有租期时为 1h
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// EmailConfig 为运行结果邮件通知的 SMTP 配置
+type EmailConfig struct {
+	Host           string   `yaml:"host"`           // SMTP 服务器地址，为空则不发送邮件
+	Port           int      `yaml:"port"`           // SMTP 端口，默认 587
+	Username       string   `yaml:"username"`       // SMTP 用户名
+	Password       string   `yaml:"password"`       // SMTP 密码
+	From           string   `yaml:"from"`           // 发件人
+	To             []string `yaml:"to"`             // 收件人列表
+	UseTLS         bool     `yaml:"useTLS"`         // 是否使用隐式 TLS（如 465 端口），否则在服务器支持时使用 STARTTLS
+	AttachManifest bool     `yaml:"attachManifest"` // 是否附带已删除对象清单
+	ManifestURL    string   `yaml:"manifestURL"`    // 邮件中附带的清单链接，支持 {time} 占位符
+	OnSuccess      *bool    `yaml:"onSuccess"`      // 运行成功时是否发送，默认 true
+}
+
+// AuditConfig 为审计日志的配置
+type AuditConfig struct {
+	File         string `yaml:"file"`         // 审计日志文件路径（只追加），为空则不记录
+	Bucket       string `yaml:"bucket"`       // 运行结束后上传本次审计记录的存储桶，为空则不上传
+	ObjectPrefix string `yaml:"objectPrefix"` // 上传对象名前缀
+}
+
+// 聊天通知触发的事件类型
+const (
+	ChatEventCompletion = "completion" // 运行结束
+	ChatEventThreshold  = "threshold"  // 删除数量或大小超过阈值
+	ChatEventErrorRate  = "errorRate"  // 错误率超过阈值
+)
+
+// ChatTypes 为支持的聊天平台类型
+var ChatTypes = []string{"slack", "teams", "dingtalk"}
+
+// ChatConfig 为一个聊天平台 Webhook 通知的配置
+type ChatConfig struct {
+	Type     string   `yaml:"type"`     // 平台类型：slack、teams 或 dingtalk
+	URL      string   `yaml:"url"`      // Webhook 地址
+	Secret   string   `yaml:"secret"`   // 钉钉机器人加签密钥（可选）
+	Events   []string `yaml:"events"`   // 触发的事件：completion、threshold、errorRate，默认全部
+	Template string   `yaml:"template"` // 消息模板（Go text/template），为空时使用默认摘要
+	// 以下阈值为 0 时不检查
+	DeletedFiles int64          `yaml:"deletedFiles"` // 删除文件数超过该值时触发 threshold 事件
+	DeletedBytes rules.ByteSize `yaml:"deletedBytes"` // 删除大小超过该值时触发 threshold 事件，如 100GB
+	ErrorRate    float64        `yaml:"errorRate"`    // 错误数占已处理文件数的比例超过该值时触发 errorRate 事件
+}
+
+func (c *ChatConfig) Wants(event string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultEventsBatchSize 为 events.batchSize 的默认值
+const defaultEventsBatchSize = 100
+
+// defaultEventsTimeout 为 events.timeout 的默认值
+const defaultEventsTimeout = 10 * time.Second
+
+// EventsConfig 为删除事件发布：每删除一个对象向 Kafka 或 NATS 发布一条消息，
+// 使计费、搜索索引等下游系统与存储桶保持一致。kafka 和 nats 只能配置一个，都为空时不启用
+type EventsConfig struct {
+	Kafka     KafkaConfig   `yaml:"kafka"`     // Kafka 主题
+	NATS      NATSConfig    `yaml:"nats"`      // NATS 主题
+	BatchSize int           `yaml:"batchSize"` // 每次发布的消息数，默认 100
+	Timeout   time.Duration `yaml:"timeout"`   // 单次发布的超时，默认 10s
+}
+
+func (e *EventsConfig) Enabled() bool {
+	return e.Kafka.Enabled() || e.NATS.Enabled()
+}
+
+// KafkaConfig 为删除事件发布到的 Kafka 主题，brokers 为空时不启用
+type KafkaConfig struct {
+	Brokers  []string `yaml:"brokers"`  // 引导 broker 地址（host:port），用于查询主题的分区和 leader
+	Topic    string   `yaml:"topic"`    // 发布的主题，需预先创建
+	TLS      bool     `yaml:"tls"`      // 是否使用 TLS 连接 broker
+	Username string   `yaml:"username"` // SASL/PLAIN 用户名，为空则不认证
+	Password string   `yaml:"password"` // SASL/PLAIN 密码
+}
+
+func (k *KafkaConfig) Enabled() bool {
+	return len(k.Brokers) > 0
+}
+
+// NATSConfig 为删除事件发布到的 NATS 主题，url 为空时不启用
+type NATSConfig struct {
+	URL      string `yaml:"url"`      // 服务器地址，如 nats://nats:4222，tls:// 表示使用 TLS
+	Subject  string `yaml:"subject"`  // 发布的主题，如 minio.deletions
+	Username string `yaml:"username"` // 用户名，为空则不使用用户名和密码认证
+	Password string `yaml:"password"` // 密码
+	Token    string `yaml:"token"`    // 认证令牌，与用户名和密码二选一
+	TLS      bool   `yaml:"tls"`      // 是否使用 TLS，服务器要求时自动启用
+}
+
+func (n *NATSConfig) Enabled() bool {
+	return n.URL != ""
+}
+
+// SentryConfig 为 Sentry 错误上报的配置
+type SentryConfig struct {
+	DSN         string `yaml:"dsn"`         // Sentry DSN，为空则不上报
+	Environment string `yaml:"environment"` // 环境名称，如 production
+}
+
+// SyslogConfig 为 syslog 日志输出的配置
+type SyslogConfig struct {
+	Address  string `yaml:"address"`  // syslog 服务器地址（host:port），为空则不启用
+	Network  string `yaml:"network"`  // 传输协议：udp 或 tcp，默认 udp
+	Facility string `yaml:"facility"` // 设施名称，如 user、daemon、local0，默认 user
+	AppName  string `yaml:"appName"`  // APP-NAME 字段，默认 minio-cleaner
+}
+
+// DefaultWebhookBatchSize 为未配置 batchSize 时每批包含的已删除对象数
+const DefaultWebhookBatchSize = 100
+
+// WebhookConfig 为一个通用 HTTP 回调的配置
+type WebhookConfig struct {
+	URL       string            `yaml:"url"`       // 回调地址
+	Events    []string          `yaml:"events"`    // 触发的事件：runStart、runEnd、batch，默认全部
+	Headers   map[string]string `yaml:"headers"`   // 附加的请求头，如认证信息
+	BatchSize int               `yaml:"batchSize"` // 每批包含的已删除对象数，默认 100
+}
+
+func (w *WebhookConfig) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Override 为命令行参数或环境变量对一个配置项的覆盖。path 为以 . 分隔的配置项路径，与配置文件中的写法相同，
+// 如 cleanup.maxAge；列表元素用序号表示，如 clusters.0.bucket。字符串类型的配置项直接使用 value，
+// 其他配置项的 value 按 YAML 解析，可以写列表和对象
+type Override struct {
+	Path  string
+	Value string
+}
+
+// OverrideOption 为可以通过命令行参数和环境变量覆盖的常用配置项。
+// 环境变量名为 MINIO_CLEANER_ 加上大写的参数名，- 替换为 _，如 max-age 对应 MINIO_CLEANER_MAX_AGE
+type OverrideOption struct {
+	Name, Path string
+	usage      msgID
+	BoolFlag   bool
+	EnvOnly    bool       // 只能通过环境变量设置，如访问密钥，避免出现在进程列表中
+	Also       []Override // 同时覆盖的其他配置项
+}
+
+// Usage 返回配置项的命令行参数说明
+func (o OverrideOption) Usage() string {
+	return tr(o.usage)
+}
+
+// EnvPrefix 为覆盖配置项的环境变量名前缀
+const EnvPrefix = "MINIO_CLEANER_"
+
+// OverrideOptions 为支持的全部覆盖配置项
+var OverrideOptions = []OverrideOption{
+	{Name: "endpoint", Path: "minio.endpoint", usage: msgFlagEndpoint},
+	{Name: "access-key-id", Path: "minio.accessKeyId", EnvOnly: true},
+	{Name: "secret-access-key", Path: "minio.secretAccessKey", EnvOnly: true},
+	// 临时清理其他存储桶时只清理指定的存储桶，不再清理配置文件中的 buckets 和 bucketPattern
+	{Name: "bucket", Path: "minio.bucket", usage: msgFlagBucket,
+		Also: []Override{{Path: "minio.buckets", Value: "[]"}, {Path: "minio.bucketPattern", Value: ""}}},
+	{Name: "max-age", Path: "cleanup.maxAge", usage: msgFlagMaxAge},
+	{Name: "min-size", Path: "cleanup.minSize", usage: msgFlagMinSize},
+	{Name: "dry-run", Path: "cleanup.dryRun", usage: msgFlagDryRun, BoolFlag: true},
+	{Name: "workers", Path: "cleanup.workers", usage: msgFlagWorkers},
+	{Name: "listers", Path: "cleanup.listers", usage: msgFlagListers},
+	{Name: "parallel-targets", Path: "cleanup.parallelTargets", usage: msgFlagParallel},
+	{Name: "max-deletes-per-second", Path: "cleanup.maxDeletesPerSecond", usage: msgFlagDeleteRate},
+	{Name: "max-errors", Path: "cleanup.maxErrors", usage: msgFlagMaxErrors},
+	{Name: "max-error-rate", Path: "cleanup.maxErrorRate", usage: msgFlagMaxErrorRate},
+	{Name: "max-runtime", Path: "cleanup.maxRuntime", usage: msgFlagMaxRuntime},
+	{Name: "log-file", Path: "cleanup.logFile", usage: msgFlagLogFile},
+	{Name: "log-format", Path: "cleanup.logFormat", usage: msgFlagLogFormat},
+	{Name: "language", Path: "cleanup.language", usage: msgFlagLanguage},
+}
+
+// EnvName 返回覆盖该配置项的环境变量名：MINIO_CLEANER_ 加上大写的参数名，- 替换为 _
+func (o OverrideOption) EnvName() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(o.Name, "-", "_"))
+}
+
+// EnvOverrides 返回 MINIO_CLEANER_* 环境变量对配置项的覆盖
+func EnvOverrides() []Override {
+	var overrides []Override
+	for _, o := range OverrideOptions {
+		if value, ok := os.LookupEnv(o.EnvName()); ok {
+			overrides = append(overrides, Override{Path: o.Path, Value: value})
+			overrides = append(overrides, o.Also...)
+		}
+	}
+	return overrides
+}
+
+// applyOverrides 将覆盖项写入配置文件的文档节点 doc
+func applyOverrides(doc *yaml.Node, overrides []Override) error {
+	if len(doc.Content) == 0 {
+		*doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	for _, o := range overrides {
+		t, err := configPathType(o.Path)
+		if err != nil {
+			return err
+		}
+		// 字符串类型的配置项按原样使用，访问密钥等包含 YAML 特殊字符时不会被错误解析
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: o.Value}
+		if t.Kind() != reflect.String {
+			var value yaml.Node
+			if err := yaml.Unmarshal([]byte(o.Value), &value); err != nil {
+				return errors.New(tr(msgOverrideValue, o.Path, err))
+			}
+			if len(value.Content) > 0 {
+				node = value.Content[0]
+			}
+		}
+		if err := setNode(doc.Content[0], strings.Split(o.Path, "."), node); err != nil {
+			return errors.New(tr(msgOverrideValue, o.Path, err))
+		}
+	}
+	return nil
+}
+
+// setNode 将 path 指向的配置项设置为 value，路径中不存在的对象会被创建
+func setNode(parent *yaml.Node, path []string, value *yaml.Node) error {
+	key := path[0]
+	var child **yaml.Node
+	switch parent.Kind {
+	case yaml.MappingNode:
+		for i := 0; i < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == key {
+				child = &parent.Content[i+1]
+				break
+			}
+		}
+		if child == nil {
+			parent.Content = append(parent.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: key}, &yaml.Node{Kind: yaml.MappingNode})
+			child = &parent.Content[len(parent.Content)-1]
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(parent.Content) {
+			return errors.New(tr(msgOverrideIndex, key, len(parent.Content)))
+		}
+		child = &parent.Content[i]
+	default:
+		return errors.New(tr(msgOverrideNotObject, key))
+	}
+
+	if len(path) == 1 {
+		*child = value
+		return nil
+	}
+	// 配置文件中该项为空值（如 minio: 后没有内容）时按空对象处理
+	if (*child).Kind == yaml.ScalarNode && (*child).Tag == "!!null" {
+		*child = &yaml.Node{Kind: yaml.MappingNode}
+	}
+	return setNode(*child, path[1:], value)
+}
+
+// configPathType 按 Config 的 yaml 标签查找配置项路径对应的类型，路径不存在时返回错误，避免拼写错误的覆盖项被忽略
+func configPathType(path string) (reflect.Type, error) {
+	t := reflect.TypeOf(Config{})
+	for _, key := range strings.Split(path, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := yamlField(t, key)
+			if !ok {
+				return nil, errors.New(tr(msgOverrideUnknown, path))
+			}
+			t = field.Type
+		case reflect.Slice:
+			if _, err := strconv.Atoi(key); err != nil {
+				return nil, errors.New(tr(msgOverrideUnknown, path))
+			}
+			t = t.Elem()
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, errors.New(tr(msgOverrideUnknown, path))
+		}
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return t, nil
+}
+
+// yamlField 返回 yaml 标签名为 key 的字段，没有标签的字段按 yaml 包的规则使用小写的字段名
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
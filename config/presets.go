@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// PresetsConfig 为各集成预设的配置。集群或存储桶通过 preset 选择预设后，只有预设认定为垃圾的对象才可能被清理，
+// 清理规则误配也不会删除应用仍在使用的数据
+type PresetsConfig struct {
+	Harbor   HarborPresetConfig   `yaml:"harbor"`   // Harbor 镜像仓库
+	GitLab   CIPresetConfig       `yaml:"gitlab"`   // GitLab CI 上传的制品
+	Jenkins  CIPresetConfig       `yaml:"jenkins"`  // Jenkins 制品（Artifact Manager on S3）
+	Velero   VeleroPresetConfig   `yaml:"velero"`   // Velero 备份
+	Registry RegistryPresetConfig `yaml:"registry"` // Docker Registry（distribution）
+	Dedup    DedupPresetConfig    `yaml:"dedup"`    // 跨存储桶去重，清理副本存储桶中与主存储桶内容相同的对象
+}
+
+// PresetsCoverTargets 返回是否每个集群和存储桶都选择了集成预设，此时不配置 rules 也不需要 cleanup.maxAge
+func (cfg *Config) PresetsCoverTargets() bool {
+	for _, m := range cfg.AllClusters() {
+		if m.Preset != "" {
+			continue
+		}
+		if m.Bucket != "" || m.BucketPattern != "" || len(m.Buckets) == 0 {
+			return false
+		}
+		if slices.ContainsFunc(m.Buckets, func(b BucketConfig) bool { return b.Preset == "" }) {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	// defaultCIMaxAge 为 CI 制品预设默认规则的保留时间
+	defaultCIMaxAge = rules.Retention(30 * rules.Day)
+	// DefaultGitLabLayout 匹配 CI 任务按 $CI_PROJECT_PATH/$CI_COMMIT_REF_SLUG/$CI_PIPELINE_ID/ 上传的制品
+	DefaultGitLabLayout = `^(?P<branch>.+?)/(?P<build>\d+)/`
+	// DefaultJenkinsLayout 匹配 Artifact Manager on S3 插件的布局 <任务全名>/<构建号>/artifacts|stashes/，
+	// 多分支流水线的任务全名以分支名结尾
+	DefaultJenkinsLayout = `^(?P<branch>.+?)/(?P<build>\d+)/(artifacts|stashes)/`
+)
+
+// CIPresetConfig 为 CI 制品预设。对象名按 layout 解析出分支和构建号，每个分支最近 keepLatest 次构建的制品一直保留，
+// 更早的构建超过规则的保留时间后清理；不符合 layout 的对象一律保留
+type CIPresetConfig struct {
+	Layout         string          `yaml:"layout"`         // 对象名的正则表达式，branch 分组为分支（可包含项目路径），build 分组为构建号；为空时使用预设的默认布局
+	KeepLatest     int             `yaml:"keepLatest"`     // 每个分支保留的最近构建数，默认 1
+	MaxAge         rules.Retention `yaml:"maxAge"`         // 未配置 rules 时默认规则的保留时间，默认 30d
+	StaleBranchAge rules.Retention `yaml:"staleBranchAge"` // 分支最近一次构建早于该时间时，最近的构建也按规则清理，0 表示一直保留
+}
+
+// LayoutPattern 返回 CI 制品的布局，layout 为空时为 def
+func (c *CIPresetConfig) LayoutPattern(def string) string {
+	if c.Layout != "" {
+		return c.Layout
+	}
+	return def
+}
+
+// ValidateLayout 检查 layout 能否编译且包含 branch 和 build 分组
+func (c *CIPresetConfig) ValidateLayout() error {
+	if c.Layout == "" {
+		return nil
+	}
+	re, err := regexp.Compile(c.Layout)
+	if err != nil {
+		return err
+	}
+	if re.SubexpIndex("branch") < 0 || re.SubexpIndex("build") < 0 {
+		return errors.New(tr(msgCILayoutGroups))
+	}
+	return nil
+}
+
+// DedupPresetConfig 为跨存储桶去重预设。选择该预设的存储桶作为副本存储桶，
+// 只清理内容（ETag 和大小）与主存储桶中某个对象相同的对象，主存储桶中的对象不受影响
+type DedupPresetConfig struct {
+	Primary  string          `yaml:"primary"`  // 同一集群上的主存储桶，副本存储桶中与其内容相同的对象才清理
+	MatchKey bool            `yaml:"matchKey"` // 是否要求对象名也相同，默认只比较内容，对象名不同的副本同样清理
+	MaxAge   rules.Retention `yaml:"maxAge"`   // 未配置 rules 时默认规则的保留时间，默认 1d
+}
+
+// defaultDedupMaxAge 为 presets.dedup.maxAge 的默认值，刚写入的副本可能还在被复制或校验
+const defaultDedupMaxAge = rules.Retention(rules.Day)
+
+// defaultHarborUploadMaxAge 为 presets.harbor.uploadMaxAge 的默认值，与 registry 清理上传目录的默认时间相同
+const defaultHarborUploadMaxAge = rules.Retention(7 * rules.Day)
+
+// HarborPresetConfig 为 Harbor 镜像仓库预设。镜像层（blobs）和仓库元数据只能由 Harbor 的垃圾回收删除，
+// 预设只清理 registry 中超过 uploadMaxAge 仍未完成的上传（_uploads 目录），其他对象一律保留
+type HarborPresetConfig struct {
+	RootDirectory string          `yaml:"rootDirectory"` // registry 存储的根目录，与 Harbor 的 imageChartStorage.s3.rootdirectory 相同
+	UploadMaxAge  rules.Retention `yaml:"uploadMaxAge"`  // 未完成的上传超过该时间才清理，默认 7d
+	URL           string          `yaml:"url"`           // Harbor 地址，配置后清理前查询垃圾回收状态，正在回收时不清理
+	Username      string          `yaml:"username"`      // 查询垃圾回收状态的 Harbor 用户，需有系统管理员权限
+	Password      string          `yaml:"password"`
+	PasswordFile  string          `yaml:"passwordFile"` // 从文件读取密码
+}
+
+const (
+	// defaultRegistryBlobMaxAge 为 presets.registry.blobMaxAge 的默认值
+	defaultRegistryBlobMaxAge = rules.Retention(7 * rules.Day)
+)
+
+// RegistryPresetConfig 为 Docker Registry（distribution）预设。默认只清理超过 uploadMaxAge 仍未完成的上传；
+// 开启 checkReferences 后先解析各仓库的镜像清单，只删除没有被任何清单引用的镜像层，被引用的一律保留
+type RegistryPresetConfig struct {
+	RootDirectory   string          `yaml:"rootDirectory"`   // registry 存储的根目录，与 registry 配置中的 storage.s3.rootdirectory 相同
+	UploadMaxAge    rules.Retention `yaml:"uploadMaxAge"`    // 未完成的上传超过该时间才清理，默认 7d
+	CheckReferences bool            `yaml:"checkReferences"` // 解析镜像清单后清理未被引用的 blob，未开启时 blobs 目录一律保留
+	BlobMaxAge      rules.Retention `yaml:"blobMaxAge"`      // 未配置 rules 时未被引用的 blob 的保留时间，默认 7d
+}
+
+const (
+	// defaultVeleroKeepLatest 为 presets.velero.keepLatest 的默认值
+	defaultVeleroKeepLatest = 7
+	// defaultVeleroMaxAge 为 presets.velero.maxAge 的默认值
+	defaultVeleroMaxAge = rules.Retention(30 * rules.Day)
+)
+
+// VeleroPresetConfig 为 Velero 备份预设。备份按目录整体清理：目录中的全部对象都符合规则时才删除，
+// 每个定时任务最近 keepLatest 个备份一直保留；手动创建的备份、restores 和 kopia、restic 等仓库数据一律保留
+type VeleroPresetConfig struct {
+	Prefix     string          `yaml:"prefix"`     // 备份存储位置的前缀，与 BackupStorageLocation 的 objectStorage.prefix 相同
+	KeepLatest int             `yaml:"keepLatest"` // 每个定时任务保留的最近备份数，默认 7
+	MaxAge     rules.Retention `yaml:"maxAge"`     // 未配置 rules 时默认规则的保留时间，默认 30d
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ProfileName 返回要使用的 profile，环境变量 MINIO_CLEANER_PROFILE 优先于 -profile 参数，与其他配置项的优先级一致
+func ProfileName(flagValue string) string {
+	if name, ok := os.LookupEnv(EnvPrefix + "PROFILE"); ok {
+		return name
+	}
+	return flagValue
+}
+
+// ApplyProfile 将 profiles 中名为 name 的配置合并到配置文件 doc 的顶层配置。
+// 对象按配置项逐项合并，列表和其他取值整体替换，因此 profile 中只需写与顶层配置不同的部分
+func ApplyProfile(doc *yaml.Node, name string) error {
+	profiles := ProfileNodes(doc)
+	profile, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return errors.New(tr(msgProfileUnknown, name, strings.Join(names, ", ")))
+	}
+	if profile.Kind != yaml.MappingNode {
+		return errors.New(tr(msgProfileNotObject, name))
+	}
+	mergeNode(doc.Content[0], profile)
+	return nil
+}
+
+// ProfileNodes 返回配置文件中 profiles 下的各个 profile，按名称索引
+func ProfileNodes(doc *yaml.Node) map[string]*yaml.Node {
+	profiles := make(map[string]*yaml.Node)
+	if len(doc.Content) == 0 {
+		return profiles
+	}
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return profiles
+	}
+	i := mappingIndex(root, "profiles")
+	if i < 0 {
+		return profiles
+	}
+	node := resolveAlias(root.Content[i+1])
+	if node.Kind != yaml.MappingNode {
+		return profiles
+	}
+	for j := 0; j < len(node.Content); j += 2 {
+		profiles[node.Content[j].Value] = resolveAlias(node.Content[j+1])
+	}
+	return profiles
+}
+
+// mergeNode 将 src 中的配置项合并到 dst，两边都是对象时逐项合并，否则用 src 替换 dst 中的取值
+func mergeNode(dst, src *yaml.Node) {
+	for i := 0; i < len(src.Content); i += 2 {
+		key, value := src.Content[i], resolveAlias(src.Content[i+1])
+		j := mappingIndex(dst, key.Value)
+		if j < 0 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+		existing := resolveAlias(dst.Content[j+1])
+		if existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			// 复制一份再合并，避免修改被锚点引用的对象
+			merged := *existing
+			merged.Content = slices.Clone(existing.Content)
+			mergeNode(&merged, value)
+			dst.Content[j+1] = &merged
+			continue
+		}
+		dst.Content[j+1] = value
+	}
+}
+
+// mappingIndex 返回对象中键为 key 的位置，不存在时返回 -1
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// MappingValue 返回对象中键为 key 的值，不存在时返回 nil
+func MappingValue(n *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(n, key); i >= 0 {
+		return n.Content[i+1]
+	}
+	return nil
+}
+
+// resolveAlias 返回别名（*anchor）指向的节点
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
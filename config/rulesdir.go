@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// rulesFile 为 rulesDir 中的规则文件，格式与配置文件中的 rules 相同
+type rulesFile struct {
+	Rules []rules.Rule `yaml:"rules"`
+}
+
+// LoadRulesDir 按文件名顺序严格解析 dir 中的规则文件，格式按扩展名判断，支持 .yaml、.yml、.json 和 .toml，返回全部规则和各文件中的问题。
+// 未命名的规则以文件名命名，如 team-a.yaml 中的第 2 条规则为 team-a-2，便于在报告中区分各团队的规则
+func LoadRulesDir(dir string) ([]rules.Rule, []string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, []string{tr(msgRulesDirFailed, dir, err)}
+	}
+	var rules []rules.Rule
+	var problems []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !slices.Contains(Extensions, strings.ToLower(ext)) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, tr(msgRulesDirFailed, path, err))
+			continue
+		}
+		var f rulesFile
+		doc, err := ParseFile(path, data)
+		if err == nil {
+			err = Decode(doc, &f, true)
+		}
+		if err != nil {
+			for _, p := range DecodeErrors(err) {
+				problems = append(problems, tr(msgRulesFileProblem, path, p))
+			}
+			continue
+		}
+		base := strings.TrimSuffix(e.Name(), ext)
+		for i, r := range f.Rules {
+			if r.Name == "" {
+				r.Name = fmt.Sprintf("%s-%d", base, i+1)
+			}
+			rules = append(rules, r)
+		}
+	}
+	return rules, problems
+}
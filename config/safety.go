@@ -0,0 +1,134 @@
+package config
+
+import (
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/rules"
+)
+
+// SafetyConfig 为防止误删的安全限制
+type SafetyConfig struct {
+	// MaxDeletePercent 为一次运行最多删除的对象占存储桶全部对象的百分比，默认 50，100 表示不限制。
+	// 小于 100 时每次实际删除前多列举一次存储桶，超过时不删除任何对象，需使用 -force 运行
+	MaxDeletePercent float64 `yaml:"maxDeletePercent"`
+	// MinObjectAge 为可清理对象的最短保留时间，默认 1h。比该时间新的对象不论规则如何都不清理，-force 也不例外
+	MinObjectAge rules.Retention `yaml:"minObjectAge"`
+}
+
+const (
+	// defaultMaxDeletePercent 为 safety.maxDeletePercent 的默认值。默认开启检查，以便在时钟偏差或规则写错时
+	// 删除开始前就停止运行，代价是删除前多列举一次存储桶
+	defaultMaxDeletePercent = 50
+	// defaultMinObjectAge 为 safety.minObjectAge 的默认值
+	defaultMinObjectAge = rules.Retention(time.Hour)
+)
+
+// ScansBeforeDelete 返回实际删除前是否需要先列举一次存储桶：检查删除比例、金丝雀删除、计划删除清单和外部审批都需要
+func (cfg *Config) ScansBeforeDelete() bool {
+	return cfg.Safety.MaxDeletePercent < 100 || cfg.Canary.Size > 0 || cfg.Report.PlanObject != "" || cfg.Approval.Enabled()
+}
+
+const (
+	// defaultApprovalTimeout 为 approval.timeout 的默认值
+	defaultApprovalTimeout = time.Hour
+	// defaultApprovalPollInterval 为 approval.pollInterval 的默认值
+	defaultApprovalPollInterval = 30 * time.Second
+	// defaultApprovalMaxObjects 为 approval.maxObjects 的默认值
+	defaultApprovalMaxObjects = 1000
+)
+
+// ApprovalConfig 为大规模删除的外部审批：待删除对象的总大小超过 minBytes 时，删除前将删除计划 POST 到审批服务，
+// 批准后才删除，拒绝或超时则不删除任何对象
+type ApprovalConfig struct {
+	URL          string            `yaml:"url"`          // 审批服务地址，为空时不启用
+	Headers      map[string]string `yaml:"headers"`      // 请求附加的请求头，如认证信息
+	MinBytes     rules.ByteSize    `yaml:"minBytes"`     // 待删除对象的总大小超过该值时需要审批，如 100GB，0 表示每次实际删除都需要审批
+	Timeout      time.Duration     `yaml:"timeout"`      // 等待审批结果的最长时间，默认 1h
+	PollInterval time.Duration     `yaml:"pollInterval"` // 查询审批状态的间隔，默认 30s
+	MaxObjects   int               `yaml:"maxObjects"`   // 审批请求中最多列出的对象数，默认 1000
+}
+
+func (a *ApprovalConfig) Enabled() bool {
+	return a.URL != ""
+}
+
+// CanaryConfig 为金丝雀删除配置：先删除随机抽取的少量对象，确认没有问题后再删除其余对象
+type CanaryConfig struct {
+	Size    int           `yaml:"size"`    // 金丝雀删除的对象数，0 表示不启用
+	Delay   time.Duration `yaml:"delay"`   // 金丝雀删除后等待该时长再删除其余对象
+	Confirm bool          `yaml:"confirm"` // 金丝雀删除后在终端中等待操作者确认，而不是等待 delay
+}
+
+// defaultHookBatchSize 为 hooks.batchSize 的默认值
+const defaultHookBatchSize = 100
+
+// defaultHookTimeout 为钩子 timeout 的默认值
+const defaultHookTimeout = time.Minute
+
+// HooksConfig 为删除钩子：每批对象删除前执行 preDelete，可以否决删除；删除后执行 postDelete，
+// 用于刷新 CDN 缓存、更新元数据库等
+type HooksConfig struct {
+	PreDelete  HookConfig `yaml:"preDelete"`  // 每批对象删除前执行，失败时整批保留
+	PostDelete HookConfig `yaml:"postDelete"` // 每批对象删除后执行，只包含删除成功的对象
+	BatchSize  int        `yaml:"batchSize"`  // 每批包含的对象数，默认 100
+}
+
+// HookConfig 为一个删除钩子，command 和 url 只能配置一个，都为空时不启用
+type HookConfig struct {
+	Command []string          `yaml:"command"` // 外部命令及参数，请求从标准输入读取
+	URL     string            `yaml:"url"`     // HTTP 服务地址，请求以 POST 发送
+	Headers map[string]string `yaml:"headers"` // HTTP 请求附加的请求头，如认证信息
+	Timeout time.Duration     `yaml:"timeout"` // 单次执行的超时，默认 1m
+}
+
+func (h *HookConfig) Enabled() bool {
+	return len(h.Command) > 0 || h.URL != ""
+}
+
+// KillSwitchConfig 为紧急停止开关：存储桶中存在哨兵对象或带有指定标签时不清理该存储桶，
+// 运行中出现时立即中止。值班人员无需重新部署即可停止清理
+type KillSwitchConfig struct {
+	Object   string        `yaml:"object"`   // 哨兵对象名，如 .cleaner-disabled
+	Tag      string        `yaml:"tag"`      // 存储桶标签，格式为 key=value，如 cleanup=disabled
+	Interval time.Duration `yaml:"interval"` // 运行中检查的间隔，默认 30s
+}
+
+// defaultKillSwitchInterval 为 killSwitch.interval 的默认值
+const defaultKillSwitchInterval = 30 * time.Second
+
+func (k *KillSwitchConfig) Enabled() bool {
+	return k.Object != "" || k.Tag != ""
+}
+
+// FilterPluginConfig 为外部过滤插件：符合规则的每个对象交给外部命令或 HTTP 服务，由其决定删除还是保留，
+// 用于表达配置文件无法描述的业务规则
+type FilterPluginConfig struct {
+	Command []string          `yaml:"command"` // 外部命令及参数，每个对象执行一次，对象信息从标准输入读取
+	URL     string            `yaml:"url"`     // HTTP 服务地址，每个对象以 POST 请求发送
+	Headers map[string]string `yaml:"headers"` // HTTP 请求附加的请求头，如认证信息
+	Timeout time.Duration     `yaml:"timeout"` // 单个对象的判断超时，默认 10s
+	Tags    bool              `yaml:"tags"`    // 是否查询对象标签一并发送，每个对象多一次请求
+}
+
+// defaultPluginTimeout 为 filterPlugin.timeout 的默认值
+const defaultPluginTimeout = 10 * time.Second
+
+func (f *FilterPluginConfig) Enabled() bool {
+	return len(f.Command) > 0 || f.URL != ""
+}
+
+// VerificationConfig 为运行结束后的复查配置：重新查询已删除的对象确认已不存在，查询未清理的对象确认仍然存在
+type VerificationConfig struct {
+	Enabled    bool `yaml:"enabled"`    // 是否在实际删除的运行结束后复查
+	Sample     int  `yaml:"sample"`     // 已删除对象和未清理对象各随机抽取的复查数量，默认 100
+	AllDeleted bool `yaml:"allDeleted"` // 是否复查全部已删除对象，而不是抽样
+}
+
+// defaultVerificationSample 为 verification.sample 的默认值
+const defaultVerificationSample = 100
+
+// VerifiesBeforeDelete 返回删除前是否检查对象有没有被覆盖写入。对象来自 inventory 清单时总是检查，
+// 清单生成后被删除或覆盖写入的对象不会被误删
+func (cfg *Config) VerifiesBeforeDelete() bool {
+	return cfg.Cleanup.VerifyBeforeDelete || cfg.Inventory.Enabled()
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/decrypt"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// isSOPSEncrypted 判断配置文件是否由 sops 加密，即顶层有 sops 元数据
+func isSOPSEncrypted(doc *yaml.Node) bool {
+	if len(doc.Content) == 0 {
+		return false
+	}
+	root := resolveAlias(doc.Content[0])
+	return root.Kind == yaml.MappingNode && mappingIndex(root, "sops") >= 0
+}
+
+// decryptSOPS 用 sops 的 decrypt 包解密配置文件，返回与原文件格式相同的明文。
+// 数据密钥的解密（age 私钥来自 SOPS_AGE_KEY、SOPS_AGE_KEY_FILE 或用户配置目录下的
+// sops/age/keys.txt）、ENC[...] 取值的解密和 MAC 校验均与 sops 命令行工具相同
+func decryptSOPS(path string, data []byte) ([]byte, error) {
+	var format formats.Format
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = formats.Json
+	case ".toml":
+		// sops 不支持 TOML，按 YAML 处理会得到错误的 MAC
+		return nil, errors.New(tr(msgSOPSFormat))
+	default:
+		format = formats.Yaml
+	}
+	plaintext, err := decrypt.DataWithFormat(data, format)
+	if err != nil {
+		return nil, errors.New(tr(msgSOPSDecrypt, err))
+	}
+	return plaintext, nil
+}
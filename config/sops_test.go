@@ -0,0 +1,123 @@
+package config
+
+import (
+	"cmp"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// testdata 中的 *.sops.yaml 由 sops 3.13.3 加密 sops.plain.yaml 得到，接收方为 testAgeIdentity：
+// full 加密全部取值，partial 只加密访问密钥，maconly 同时设置了 mac_only_encrypted。
+// full 同时加密给 testAgeOtherIdentity，私钥只用于测试
+const (
+	testAgeIdentity      = "AGE-SECRET-KEY-12GWPKWW8K338Z8E6RAGSRMV4XX9JL5WZP5FT9SLFDRM9XLV5029Q7JR6YL"
+	testAgeOtherIdentity = "AGE-SECRET-KEY-12772AQ2QKXYWWSHV8ZCA8MXK4KUE4Y5RLTQDMH5VVKUDTRYKZQVQ0TK7N9"
+)
+
+// setSOPSKeys 设置 sops 使用的 age 私钥，并避免读取用户目录中的私钥文件和 SSH 密钥
+func setSOPSKeys(t *testing.T, keys ...string) {
+	t.Setenv("SOPS_AGE_KEY", strings.Join(keys, "\n"))
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+}
+
+func readYAMLTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// decryptSOPSString 按文件名 path 解析并解密配置文件的内容，返回解码后的配置
+func decryptSOPSString(t *testing.T, path, data string) (any, error) {
+	t.Helper()
+	doc, err := ParseFile(path, []byte(data))
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := doc.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v, nil
+}
+
+func TestDecryptSOPS(t *testing.T) {
+	setSOPSKeys(t, testAgeOtherIdentity, testAgeIdentity)
+	var want any
+	if err := yaml.Unmarshal([]byte(readYAMLTestdata(t, "sops.plain.yaml")), &want); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"full.sops.yaml", "partial.sops.yaml", "maconly.sops.yaml", "sops.plain.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := decryptSOPSString(t, "config.yaml", readYAMLTestdata(t, name))
+			if err != nil {
+				t.Fatalf("decryptSOPS() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("decryptSOPS() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecryptSOPSErrors(t *testing.T) {
+	full := readYAMLTestdata(t, "full.sops.yaml")
+	partial := readYAMLTestdata(t, "partial.sops.yaml")
+	// swapSecrets 交换两个加密的取值，密文本身完好，但附加数据中的路径不同
+	swapSecrets := func(s string) string {
+		var doc yaml.Node
+		yaml.Unmarshal([]byte(s), &doc)
+		minio := MappingValue(doc.Content[0], "minio")
+		a, b := MappingValue(minio, "accessKeyId"), MappingValue(minio, "secretAccessKey")
+		a.Value, b.Value = b.Value, a.Value
+		out, _ := yaml.Marshal(&doc)
+		return string(out)
+	}
+	tests := []struct {
+		name string
+		// path 为配置文件名，默认为 config.yaml
+		path string
+		data string
+		keys []string
+	}{
+		{name: "wrong key", data: full, keys: []string{testAgeOtherIdentity}},
+		{name: "no key", data: full},
+		// 未加密的取值也参与 MAC 计算
+		{name: "plaintext value modified", data: strings.Replace(partial, "workers: 8", "workers: 9", 1), keys: []string{testAgeIdentity}},
+		{name: "value removed", data: strings.Replace(partial, "      maxAge: 30d\n", "", 1), keys: []string{testAgeIdentity}},
+		{name: "ciphertexts swapped", data: swapSecrets(partial), keys: []string{testAgeIdentity}},
+		{name: "lastmodified changed", data: strings.Replace(full, `lastmodified: "2026-10-15T05:59:26Z"`, `lastmodified: "2026-10-15T05:59:27Z"`, 1), keys: []string{testAgeIdentity}},
+		{name: "no recipients", data: "a: 1\nsops:\n  lastmodified: \"2026-10-15T05:59:26Z\"\n", keys: []string{testAgeIdentity}},
+		// sops 不支持 TOML
+		{name: "toml", path: "config.toml", data: "a = 1\n[sops]\nmac = \"x\"\n", keys: []string{testAgeIdentity}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSOPSKeys(t, tt.keys...)
+			if got, err := decryptSOPSString(t, cmp.Or(tt.path, "config.yaml"), tt.data); err == nil {
+				t.Errorf("decryptSOPS() = %v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestDecryptSOPSMACOnlyEncrypted(t *testing.T) {
+	// mac_only_encrypted 时未加密的取值不参与 MAC 计算，可以直接修改
+	setSOPSKeys(t, testAgeIdentity)
+	data := strings.Replace(readYAMLTestdata(t, "maconly.sops.yaml"), "workers: 8", "workers: 9", 1)
+	got, err := decryptSOPSString(t, "config.yaml", data)
+	if err != nil {
+		t.Fatalf("decryptSOPS() error = %v", err)
+	}
+	if workers := got.(map[string]any)["cleanup"].(map[string]any)["workers"]; workers != 9 {
+		t.Errorf("cleanup.workers = %v, want 9", workers)
+	}
+}
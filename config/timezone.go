@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Timezone 为配置中的 IANA 时区名称，如 Asia/Shanghai 或 UTC，为空时使用服务器本地时区
+type Timezone struct {
+	name string
+	loc  *time.Location
+}
+
+// UnmarshalYAML 解析时区名称，名称无效时返回 yaml.TypeError，与其他配置项的错误一起报告
+func (t *Timezone) UnmarshalYAML(node *yaml.Node) error {
+	v, err := LoadTimezone(node.Value)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, err)}}
+	}
+	*t = v
+	return nil
+}
+
+// LoadTimezone 按 IANA 时区名称返回时区，用于在代码中设置 cleanup.timezone，名称为空时使用服务器本地时区
+func LoadTimezone(name string) (Timezone, error) {
+	if name == "" {
+		return Timezone{}, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return Timezone{}, errors.New(tr(msgBadTimezone, name))
+	}
+	return Timezone{name: name, loc: loc}, nil
+}
+
+// MarshalYAML 输出时区名称
+func (t Timezone) MarshalYAML() (any, error) {
+	return t.name, nil
+}
+
+// String 返回时区名称，未配置时为 Local
+func (t Timezone) String() string {
+	return t.location().String()
+}
+
+// location 返回配置的时区，未配置时为服务器本地时区
+func (t Timezone) location() *time.Location {
+	if t.loc == nil {
+		return time.Local
+	}
+	return t.loc
+}
+
+// Now 返回 cleanup.timezone 时区的当前时间。运行的开始时间和各规则的阈值时间都基于它计算，
+// 整天数的保留时间按该时区的日历日计算，报告和日志中的时间也使用该时区
+func (cfg *Config) Now() time.Time {
+	if cfg.Cleanup.Timezone.loc == nil {
+		return time.Now()
+	}
+	return time.Now().In(cfg.Cleanup.Timezone.loc)
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// tomlConverter 将 BurntSushi/toml 解析出的配置转换为 YAML 节点，TOML 与 YAML 使用相同的配置项。
+// 解析库不提供键的位置，按键在文件中出现的顺序逐行向后查找，得到每个键所在的行
+type tomlConverter struct {
+	md toml.MetaData
+	// order 为每个键（含隐式定义的表）第一次出现的顺序，用于保持文件中键的顺序
+	order map[string]int
+	// lines 为每个键每次出现时所在的行，表数组的每个元素各出现一次
+	lines map[string][]int
+}
+
+// tomlConfigNode 将 TOML 配置文件转换为 YAML 文档节点，节点的行号对应 TOML 文件中的行。
+// 日期时间按字符串处理
+func tomlConfigNode(data []byte) (*yaml.Node, error) {
+	var doc map[string]any
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return nil, errors.New(tr(msgTOMLSyntax, perr.Position.Line, perr.Message))
+		}
+		return nil, err
+	}
+	c := &tomlConverter{md: md, order: map[string]int{}, lines: map[string][]int{}}
+	src := strings.Split(string(data), "\n")
+	line := 0
+	for i, key := range md.Keys() {
+		for n := 1; n <= len(key); n++ {
+			if _, ok := c.order[key[:n].String()]; !ok {
+				c.order[key[:n].String()] = i
+			}
+		}
+		// 找不到时（如键名写成带转义的字符串）沿用上一个键的行
+		for l := line; l < len(src); l++ {
+			if tomlKeyOnLine(src[l], key[len(key)-1]) {
+				line = l
+				break
+			}
+		}
+		c.lines[key.String()] = append(c.lines[key.String()], line+1)
+	}
+	root := c.table(doc, nil)
+	if len(root.Content) == 0 {
+		root.Line = 1
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Content: []*yaml.Node{root}}, nil
+}
+
+// line 返回键 key 下一次出现时所在的行，pop 为 false 时不消耗这次出现。
+// 隐式定义的表没有记录，返回 0
+func (c *tomlConverter) line(key toml.Key, pop bool) int {
+	lines := c.lines[key.String()]
+	if len(lines) == 0 {
+		return 0
+	}
+	if pop {
+		c.lines[key.String()] = lines[1:]
+	}
+	return lines[0]
+}
+
+// table 转换表，表中的键按在文件中出现的顺序排列
+func (c *tomlConverter) table(m map[string]any, path toml.Key) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return c.order[tomlChild(path, a).String()] - c.order[tomlChild(path, b).String()]
+	})
+	for _, k := range keys {
+		key := tomlChild(path, k)
+		var value *yaml.Node
+		line := 0
+		// [[表数组]] 的每个表头各记录一次，由数组元素依次消耗
+		if c.md.Type(key...) == "ArrayHash" {
+			line = c.line(key, false)
+			value = c.value(m[k], key, line)
+		} else {
+			line = c.line(key, true)
+			value = c.value(m[k], key, line)
+			if line == 0 {
+				line = value.Line
+			}
+		}
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k, Line: line}, value)
+	}
+	// 表的行号取表头所在的行，由调用方设置；隐式定义的表取第一个键所在的行
+	if len(n.Content) > 0 {
+		n.Line = n.Content[0].Line
+	}
+	return n
+}
+
+// value 转换键 key 的值，line 为键所在的行
+func (c *tomlConverter) value(v any, key toml.Key, line int) *yaml.Node {
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, Line: line}
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		n := c.table(v, key)
+		if line != 0 {
+			n.Line = line
+		}
+		return n
+	case []map[string]any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, elem := range v {
+			elemLine := c.line(key, true)
+			t := c.table(elem, key)
+			t.Line = elemLine
+			n.Content = append(n.Content, t)
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: line}
+		for _, elem := range v {
+			// 内联数组中的表没有单独记录的键，元素使用数组所在的行
+			n.Content = append(n.Content, c.value(elem, key, line))
+		}
+		return n
+	case string:
+		return scalar("!!str", v)
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(v))
+	case int64:
+		return scalar("!!int", strconv.FormatInt(v, 10))
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return scalar("!!float", ".inf")
+		case math.IsInf(v, -1):
+			return scalar("!!float", "-.inf")
+		case math.IsNaN(v):
+			return scalar("!!float", ".nan")
+		}
+		return scalar("!!float", strconv.FormatFloat(v, 'g', -1, 64))
+	case time.Time:
+		return scalar("!!str", tomlTimeString(v))
+	}
+	return scalar("!!null", "")
+}
+
+// tomlTimeString 按 TOML 中的写法格式化日期时间，本地日期、时间和日期时间不带时区
+func tomlTimeString(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// tomlKeyOnLine 判断 line 中是否定义了名为 name 的键：键名后（忽略空白）为 =、. 或 ]，
+// 不需要引号的键名前为行首、空白、[、{、, 或 .
+func tomlKeyOnLine(line, name string) bool {
+	quoted := !isTOMLBareKey(name)
+	for _, text := range []string{name, strconv.Quote(name), "'" + name + "'"} {
+		if quoted && text == name {
+			continue
+		}
+		for i := 0; ; {
+			j := strings.Index(line[i:], text)
+			if j < 0 {
+				break
+			}
+			start, end := i+j, i+j+len(text)
+			i = start + 1
+			if !quoted && start > 0 && !strings.ContainsRune(" \t[{,.", rune(line[start-1])) {
+				continue
+			}
+			rest := strings.TrimLeft(line[end:], " \t")
+			if rest != "" && strings.ContainsRune("=.]", rune(rest[0])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTOMLBareKey 判断 name 是否可以不加引号作为键名
+func isTOMLBareKey(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlChild 返回表 path 中键 k 的完整路径，不修改 path
+func tomlChild(path toml.Key, k string) toml.Key {
+	return append(slices.Clip(path), k)
+}
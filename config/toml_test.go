@@ -0,0 +1,125 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func TestTOMLConfigNode(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string // 等价的 YAML
+	}{
+		{name: "scalars", toml: "a = 1\nb = \"x\"\nc = true\nd = 1.5\ne = false # 注释\n", want: "{a: 1, b: x, c: true, d: 1.5, e: false}"},
+		{name: "tables", toml: "# 配置\n[minio]\nendpoint = \"s3:9000\"\n\n[minio.azure]\nsasToken = 'sv=1&sig=a\\b'\n[cleanup]\ndryRun = true\n",
+			want: "{minio: {endpoint: 's3:9000', azure: {sasToken: 'sv=1&sig=a\\b'}}, cleanup: {dryRun: true}}"},
+		{name: "array of tables", toml: "[[rules]]\nname = \"a\"\n[[rules]]\nname = \"b\"\nmaxAge = \"7d\"\n[rules.ttl]\ntag = \"ttl\"\n",
+			want: "{rules: [{name: a}, {name: b, maxAge: 7d, ttl: {tag: ttl}}]}"},
+		{name: "dotted keys", toml: "a.b.c = 1\na.d = 2\n[x]\ny.z = 3\n", want: "{a: {b: {c: 1}, d: 2}, x: {y: {z: 3}}}"},
+		{name: "quoted keys", toml: "\"a.b\" = 1\n'c d' = 2\nsite.\"google.com\" = true\n", want: "{'a.b': 1, 'c d': 2, site: {google.com: true}}"},
+		{name: "inline tables and arrays", toml: "a = { x = 1, y.z = \"s\" }\nb = [\n  1, # 第一个\n  2,\n]\nc = []\nd = {}\ne = [[1, 2], [\"a\"]]\n",
+			want: "{a: {x: 1, y: {z: s}}, b: [1, 2], c: [], d: {}, e: [[1, 2], [a]]}"},
+		{name: "escapes", toml: `s = "tab\there \u00e9 \U0001F600 \"q\" \\"` + "\n", want: `{s: "tab\there é 😀 \"q\" \\"}`},
+		{name: "multiline basic", toml: "s = \"\"\"\nfoo \\\n    bar\nbaz\"\"\"\n", want: `{s: "foo bar\nbaz"}`},
+		{name: "multiline literal", toml: "s = '''\nC:\\dir\n'''\nt = '''a'''''\n", want: `{s: "C:\\dir\n", t: "a''"}`},
+		{name: "integers", toml: "a = 0xff\nb = 1_000\nc = 0o17\nd = 0b11\ne = -7\nf = +3\ng = 0\n", want: "{a: 255, b: 1000, c: 15, d: 3, e: -7, f: 3, g: 0}"},
+		{name: "floats", toml: "a = 1e3\nb = -0.5\nc = 6.626e-34\nd = inf\ne = -inf\nf = 1_000.5\n",
+			want: "{a: 1000.0, b: -0.5, c: 6.626e-34, d: .inf, e: -.inf, f: 1000.5}"},
+		// 日期时间按字符串处理
+		{name: "date times", toml: "a = 2024-06-01\nb = 1979-05-27T07:32:00Z\nc = 1979-05-27 07:32:00-08:00\nd = 07:32:00\n",
+			want: "{a: '2024-06-01', b: '1979-05-27T07:32:00Z', c: '1979-05-27T07:32:00-08:00', d: '07:32:00'}"},
+		{name: "crlf", toml: "[a]\r\nb = 1\r\n", want: "{a: {b: 1}}"},
+		{name: "empty", toml: "\n# 只有注释\n", want: "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := tomlConfigNode([]byte(tt.toml))
+			if err != nil {
+				t.Fatalf("tomlConfigNode() error = %v", err)
+			}
+			var got, want any
+			if err := node.Decode(&got); err != nil {
+				t.Fatalf("decode converted node: %v", err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("tomlConfigNode() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestTOMLConfigNodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+	}{
+		{name: "duplicate key", toml: "a = 1\na = 2\n"},
+		{name: "table redefines key", toml: "a = 1\n[a]\nb = 2\n"},
+		{name: "array of tables redefines table", toml: "[a]\n[[a]]\n"},
+		{name: "two values on a line", toml: "a = 1 b = 2\n"},
+		{name: "missing value", toml: "a =\n"},
+		{name: "missing equals", toml: "a 1\n"},
+		{name: "unterminated string", toml: "a = \"abc\n"},
+		{name: "unterminated literal", toml: "a = 'abc\n"},
+		{name: "unterminated multiline", toml: "a = \"\"\"abc\n"},
+		{name: "invalid escape", toml: `a = "\q"` + "\n"},
+		{name: "invalid unicode escape", toml: `a = "\uD800"` + "\n"},
+		{name: "leading zero", toml: "a = 01\n"},
+		{name: "leading zero float", toml: "a = -01.5\n"},
+		{name: "bare word", toml: "a = yes\n"},
+		{name: "array without comma", toml: "a = [1 2]\n"},
+		{name: "unterminated table header", toml: "[a\n"},
+		{name: "unterminated inline table", toml: "a = { b = 1\n"},
+	}
+	for _, tt := range tests {
+		if node, err := tomlConfigNode([]byte(tt.toml)); err == nil {
+			var got any
+			node.Decode(&got)
+			t.Errorf("%s: tomlConfigNode() = %v, want an error", tt.name, got)
+		}
+	}
+}
+
+func TestTOMLConfigNodeLines(t *testing.T) {
+	// 节点的行号对应 TOML 文件中的行，配置校验的错误信息依赖行号。
+	// 键名在前面的值中出现时不影响查找，如第 5 行的 "prefix"
+	node, err := tomlConfigNode([]byte("[minio]\nendpoint = \"s3\"\n\n[[rules]]\nname = \"prefix\"\nmaxAge = '''\n7d'''\nprefix = \"p\"\n" +
+		"[[rules]]\nname = \"b\"\n[rules.ttl]\ntag.key = \"ttl\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := node.Content[0]
+	tests := []struct {
+		path []string
+		line int
+	}{
+		{path: []string{"minio", "endpoint"}, line: 2},
+		{path: []string{"rules"}, line: 4},
+		{path: []string{"rules", "0", "prefix"}, line: 8},
+		{path: []string{"rules", "1"}, line: 9},
+		{path: []string{"rules", "1", "name"}, line: 10},
+		{path: []string{"rules", "1", "ttl"}, line: 11},
+		// 隐式定义的表取第一个键所在的行
+		{path: []string{"rules", "1", "ttl", "tag"}, line: 12},
+	}
+	for _, tt := range tests {
+		n := root
+		for _, key := range tt.path {
+			if i, err := strconv.Atoi(key); err == nil {
+				n = n.Content[i]
+			} else {
+				n = MappingValue(n, key)
+			}
+		}
+		if n.Line != tt.line {
+			t.Errorf("%v: line %d, want %d", tt.path, n.Line, tt.line)
+		}
+	}
+}
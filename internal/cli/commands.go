@@ -0,0 +1,294 @@
+// Package cli 实现 minio-cleaner 命令行程序：子命令、命令行参数、交互式配置向导和补全脚本
+package cli
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/fjcanyue/minio-cleaner/cleaner"
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// subcommand 描述一个子命令，命令分发、补全脚本和手册页都使用这里的定义
+type subcommand struct {
+	name    string
+	summary msgID
+	// args 为手册页概要中的位置参数
+	args string
+	// define 在 fs 上定义命令的参数并返回执行命令的函数，参数解析后调用。
+	// 为 nil 时为使用清理参数的 run、diff、analyze 和 tui，参数由 defineRunFlags 定义
+	define func(fs *flag.FlagSet) func()
+}
+
+// subcommands 返回全部子命令，第一个为默认的 run
+func subcommands() []subcommand {
+	return []subcommand{
+		{name: "run", summary: msgCmdRun},
+		{name: "diff", summary: msgCmdDiff},
+		{name: "analyze", summary: msgCmdAnalyze},
+		{name: "tui", summary: msgCmdTUI},
+		{name: "history", summary: msgCmdHistory, define: cmdHistory},
+		{name: "show", summary: msgCmdShow, args: "<run-id>", define: cmdShow},
+		{name: "validate", summary: msgCmdValidate, define: cmdValidate},
+		{name: "init", summary: msgCmdInit, define: cmdInit},
+		{name: "setup", summary: msgCmdSetup, define: cmdSetup},
+		{name: "completion", summary: msgCmdCompletion, args: "bash|zsh|fish", define: cmdCompletion},
+		{name: "man", summary: msgCmdMan, define: cmdMan},
+	}
+}
+
+// findSubcommand 按名称查找子命令，不存在时返回 nil
+func findSubcommand(name string) *subcommand {
+	for _, c := range subcommands() {
+		if c.name == name {
+			return &c
+		}
+	}
+	return nil
+}
+
+// flagSet 返回定义了命令参数的 FlagSet，用于生成补全脚本和手册页
+func (c subcommand) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	if c.define == nil {
+		defineRunFlags(fs)
+	} else {
+		c.define(fs)
+	}
+	return fs
+}
+
+// runOptions 为 run、diff、analyze 和 tui 命令的参数
+type runOptions struct {
+	configPath  string
+	daemon      bool
+	verbose     bool
+	quiet       bool
+	top         int
+	pprofAddr   string
+	bench       bool
+	profile     string
+	yes         bool
+	interactive bool
+	force       bool
+	output      string
+	// overrides 为命令行参数对配置项的覆盖
+	overrides []config.Override
+}
+
+// defineRunFlags 在 fs 上定义 run、diff、analyze 和 tui 命令的参数
+func defineRunFlags(fs *flag.FlagSet) *runOptions {
+	o := &runOptions{}
+	fs.StringVar(&o.configPath, "config", "config.yaml", tr(msgFlagConfig))
+	fs.BoolVar(&o.daemon, "daemon", false, tr(msgFlagDaemon))
+	fs.BoolVar(&o.verbose, "verbose", false, tr(msgFlagVerbose))
+	fs.BoolVar(&o.quiet, "quiet", false, tr(msgFlagQuiet))
+	fs.IntVar(&o.top, "top", 20, tr(msgFlagTop))
+	fs.StringVar(&o.pprofAddr, "pprof", "", tr(msgFlagPprof))
+	fs.BoolVar(&o.bench, "bench", false, tr(msgFlagBench))
+	fs.StringVar(&o.profile, "profile", "", tr(msgFlagProfile))
+	fs.BoolVar(&o.yes, "yes", false, tr(msgFlagYes))
+	fs.BoolVar(&o.interactive, "interactive", false, tr(msgFlagInteractive))
+	fs.BoolVar(&o.force, "force", false, tr(msgFlagForce))
+	fs.StringVar(&o.output, "output", outputText, tr(msgFlagOutput))
+	registerOverrideFlags(fs, &o.overrides)
+	return o
+}
+
+// Main 为命令行程序的入口：解析子命令和命令行参数，加载配置并执行。出错时输出日志并以非零状态码退出。
+// exampleConfig 为 init 和 setup 命令生成的示例配置文件内容，由命令行程序嵌入
+func Main(example []byte) {
+	exampleConfig = example
+
+	// 第一个非选项参数为子命令，默认为 run
+	command := "run"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	// 参数说明和加载配置的错误使用命令行参数或环境变量指定的语言，配置文件中的 cleanup.language 在加载配置后生效
+	if err := i18n.SetLanguage(cliLanguage(args)); err != nil {
+		log.Fatal(err)
+	}
+	c := findSubcommand(command)
+	if c == nil {
+		log.Fatal(tr(msgUnknownCommand, command))
+	}
+	if c.define != nil {
+		fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+		run := c.define(fs)
+		fs.Parse(args)
+		run()
+		return
+	}
+
+	// 解析命令行参数
+	opts := defineRunFlags(flag.CommandLine)
+	flag.CommandLine.Parse(args)
+
+	// 加载配置文件，环境变量优先于命令行参数，命令行参数优先于配置文件。
+	// 未指定 -config 且默认的配置文件不存在时，可以只通过环境变量配置
+	path := opts.configPath
+	if _, err := os.Stat(path); os.IsNotExist(err) && len(config.EnvOverrides()) > 0 && !flagSet(flag.CommandLine, "config") {
+		path = ""
+	}
+	cfg, err := cleaner.LoadConfig(path, opts.profile, opts.overrides...)
+	if err != nil {
+		log.Fatal(tr(msgConfigLoadFailed, err))
+	}
+
+	// 设置日志语言
+	if err := i18n.SetLanguage(cfg.Cleanup.Language); err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.verbose && opts.quiet {
+		log.Fatal(tr(msgConflictVerbose))
+	}
+	if opts.top <= 0 {
+		log.Fatal(tr(msgBadTop, opts.top))
+	}
+	if opts.interactive && opts.daemon {
+		log.Fatal(tr(msgReviewDaemon))
+	}
+	if opts.interactive && !cleaner.IsTerminal(os.Stdin) {
+		log.Fatal(tr(msgReviewNoTerminal))
+	}
+	if command == "tui" && (opts.daemon || opts.interactive) {
+		log.Fatal(tr(msgTUIConflict))
+	}
+	if command == "tui" && (!cleaner.IsTerminal(os.Stdin) || !cleaner.IsTerminal(os.Stdout)) {
+		log.Fatal(tr(msgTUINoTerminal))
+	}
+	if command == "run" && cfg.Canary.Confirm && cfg.Canary.Size > 0 && !cfg.Cleanup.DryRun &&
+		(opts.daemon || !cleaner.IsTerminal(os.Stdin)) {
+		log.Fatal(tr(msgCanaryNoTerminal))
+	}
+	if err := checkOutputFormat(opts.output, command, opts.daemon); err != nil {
+		log.Fatal(err)
+	}
+	// 以 JSON 输出运行结果时，标准输出只输出结果，日志、进度条和确认提示输出到标准错误
+	logOut, objects := os.Stdout, (*cleaner.ObjectOutput)(nil)
+	if opts.output != outputText {
+		logOut = os.Stderr
+	}
+	if opts.output == outputJSONL {
+		objects = cleaner.NewObjectOutput(os.Stdout)
+	}
+
+	// 命令行参数优先于配置文件中的日志级别
+	if opts.verbose {
+		cfg.Cleanup.LogLevel = "debug"
+	} else if opts.quiet {
+		cfg.Cleanup.LogLevel = "warn"
+	}
+	if opts.pprofAddr != "" {
+		cfg.Debug.PprofAddress = opts.pprofAddr
+	}
+	if opts.bench {
+		cfg.Debug.Bench = true
+	}
+	if opts.force {
+		cfg.Safety.MaxDeletePercent = 100
+	}
+
+	// 设置日志
+	logFile, err := cleaner.SetupLogging(cfg, logOut)
+	if err != nil {
+		log.Fatal(tr(msgLogSetupFailed, err))
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	// 初始化错误上报，panic 时先上报再退出
+	if err := setupSentry(cfg); err != nil {
+		fatal(err.Error(), "error", err)
+	}
+	defer cleaner.ReportPanic()
+
+	if cfg.Debug.PprofAddress != "" {
+		startPprof(cfg.Debug.PprofAddress)
+	}
+
+	// 监听退出信号，守护模式下用于优雅停止
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 为每个集群创建 MinIO 客户端，每个存储桶为一个清理目标
+	targets, err := cleaner.Targets(ctx, cfg)
+	if err != nil {
+		fatal(err.Error(), "error", err)
+	}
+
+	if command == "diff" {
+		if err := cleaner.Diff(ctx, targets); err != nil {
+			fatal(err.Error(), "error", err)
+		}
+		return
+	}
+
+	if command == "analyze" {
+		if err := cleaner.Analyze(ctx, targets, opts.top); err != nil {
+			fatal(err.Error(), "error", err)
+		}
+		return
+	}
+
+	if opts.daemon {
+		cleaner.RunDaemon(ctx, cfg, targets, objects)
+		return
+	}
+
+	// 实际删除前由操作者确认：tui 和交互式审查时逐项选择，否则在终端中展示待删除对象的统计，输入存储桶名称确认。
+	// 全部目标都未确认时不清理，-output json 仍输出没有运行结果的文档
+	if command == "tui" {
+		targets = cleaner.BrowseTargets(ctx, targets, os.Stdin, logOut)
+	} else if opts.interactive {
+		targets = cleaner.ReviewTargets(ctx, targets, os.Stdin, logOut)
+	} else if needsConfirmation(cfg, opts.yes, cleaner.IsTerminal(os.Stdin)) {
+		targets = cleaner.ConfirmTargets(ctx, targets, os.Stdin, logOut)
+	}
+
+	report, err := cleaner.RunTargets(ctx, cfg, targets, objects)
+	if opts.output == outputJSON {
+		if werr := writeRunOutput(os.Stdout, report); werr != nil {
+			fatal(tr(msgOutputFailed, werr), "error", werr)
+		}
+	}
+	// 出现错误时以非零状态码退出，定时任务据此判断运行失败
+	failed := reportRunErrors(report)
+	if err != nil {
+		fatal(err.Error(), "error", err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// flagSet 返回命令行中是否指定了参数 name
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// needsConfirmation 判断运行前是否需要确认：实际删除且标准输入为终端（terminal），未指定 -yes
+func needsConfirmation(cfg *config.Config, yes, terminal bool) bool {
+	return !cfg.Cleanup.DryRun && !yes && terminal
+}
+
+// fatal 记录错误日志并退出程序
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
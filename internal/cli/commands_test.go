@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+func TestNeedsConfirmation(t *testing.T) {
+	tests := []struct {
+		dryRun   bool
+		yes      bool
+		terminal bool
+		want     bool
+	}{
+		{dryRun: false, yes: false, terminal: true, want: true},
+		{dryRun: true, yes: false, terminal: true},
+		{dryRun: false, yes: true, terminal: true},
+		// 标准输入不是终端时（如定时任务）无法确认，直接运行
+		{dryRun: false, yes: false, terminal: false},
+		{dryRun: true, yes: true, terminal: false},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Cleanup.DryRun = tt.dryRun
+		if got := needsConfirmation(cfg, tt.yes, tt.terminal); got != tt.want {
+			t.Errorf("needsConfirmation(dryRun=%v, yes=%v, terminal=%v) = %v, want %v", tt.dryRun, tt.yes, tt.terminal, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// completionShells 为支持生成补全脚本的 shell
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// cmdCompletion 实现 completion 命令：输出指定 shell 的补全脚本
+func cmdCompletion(fs *flag.FlagSet) func() {
+	return func() {
+		if fs.NArg() != 1 {
+			log.Fatal(tr(msgCompletionUsage))
+		}
+		var err error
+		switch fs.Arg(0) {
+		case "bash":
+			err = writeBashCompletion(os.Stdout)
+		case "zsh":
+			err = writeZshCompletion(os.Stdout)
+		case "fish":
+			err = writeFishCompletion(os.Stdout)
+		default:
+			err = errors.New(tr(msgCompletionShell, fs.Arg(0)))
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// commandFlags 返回命令的全部参数，按名称排序
+func commandFlags(c subcommand) []*flag.Flag {
+	var flags []*flag.Flag
+	c.flagSet().VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+// isBoolFlag 判断参数是否为不需要取值的布尔参数
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// isPathFlag 判断参数的取值是否为文件或目录路径，补全时提示文件名
+func isPathFlag(f *flag.Flag) bool {
+	return f.Name == "config" || strings.HasSuffix(f.Name, "-file") || f.Name == "dir"
+}
+
+// commandArgs 返回命令的位置参数的可选值，用于补全
+func commandArgs(c subcommand) []string {
+	if c.name == "completion" {
+		return completionShells
+	}
+	return nil
+}
+
+// commandNames 返回全部子命令的名称
+func commandNames() []string {
+	var names []string
+	for _, c := range subcommands() {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// writeBashCompletion 输出 bash 补全脚本，子命令只能是第一个参数，之后补全该命令的参数
+func writeBashCompletion(w io.Writer) error {
+	var pathFlags []string
+	var cases strings.Builder
+	for _, c := range subcommands() {
+		words := commandArgs(c)
+		for _, f := range commandFlags(c) {
+			words = append(words, "-"+f.Name)
+			if isPathFlag(f) && !slices.Contains(pathFlags, "-"+f.Name) {
+				pathFlags = append(pathFlags, "-"+f.Name, "--"+f.Name)
+			}
+		}
+		fmt.Fprintf(&cases, "\t%s)\n\t\twords=%q\n\t\t;;\n", c.name, strings.Join(words, " "))
+	}
+	_, err := fmt.Fprintf(w, `%s
+
+_minio_cleaner() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local cmd=run words
+	if [[ $COMP_CWORD -gt 1 && ${COMP_WORDS[1]} != -* ]]; then
+		cmd=${COMP_WORDS[1]}
+	fi
+	case $prev in
+	%s)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		return
+		;;
+	esac
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W %q -- "$cur"))
+		return
+	fi
+	case $cmd in
+%s	esac
+	COMPREPLY=($(compgen -W "$words" -- "$cur"))
+}
+
+complete -o default -F _minio_cleaner minio-cleaner
+`, tr(msgCompletionBash), strings.Join(pathFlags, "|"), strings.Join(commandNames(), " "), cases.String())
+	return err
+}
+
+// zshQuote 转义 zsh 补全说明中的特殊字符，结果用于单引号字符串
+func zshQuote(s string) string {
+	return strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+// writeZshCompletion 输出 zsh 补全脚本，可以放入 $fpath 中的 _minio-cleaner 文件，也可以直接 source
+func writeZshCompletion(w io.Writer) error {
+	var commands, cases strings.Builder
+	for _, c := range subcommands() {
+		fmt.Fprintf(&commands, "\t\t'%s:%s'\n", c.name, zshQuote(tr(c.summary)))
+		fmt.Fprintf(&cases, "\t%s)\n\t\t_arguments", c.name)
+		for _, f := range commandFlags(c) {
+			spec := "-" + f.Name + "[" + zshQuote(f.Usage) + "]"
+			if f.Name == "set" {
+				// -set 可以多次使用
+				spec = "*" + spec
+			}
+			switch {
+			case isBoolFlag(f):
+			case isPathFlag(f):
+				spec += ":path:_files"
+			default:
+				spec += ":" + f.Name + ": "
+			}
+			fmt.Fprintf(&cases, " \\\n\t\t\t'%s'", spec)
+		}
+		if args := commandArgs(c); len(args) > 0 {
+			fmt.Fprintf(&cases, " \\\n\t\t\t'1:%s:(%s)'", c.name, strings.Join(args, " "))
+		}
+		cases.WriteString("\n\t\t;;\n")
+	}
+	_, err := fmt.Fprintf(w, `#compdef minio-cleaner
+%s
+
+_minio-cleaner() {
+	local -a commands
+	commands=(
+%s	)
+	local cmd=run
+	if (( CURRENT > 2 )) && [[ ${words[2]} != -* ]]; then
+		cmd=${words[2]}
+		shift words
+		(( CURRENT-- ))
+	elif (( CURRENT == 2 )) && [[ ${words[2]} != -* ]]; then
+		_describe 'command' commands
+		return
+	fi
+	case $cmd in
+%s	esac
+}
+
+if [ "$funcstack[1]" = "_minio-cleaner" ]; then
+	_minio-cleaner "$@"
+else
+	compdef _minio-cleaner minio-cleaner
+fi
+`, tr(msgCompletionZsh), commands.String(), cases.String())
+	return err
+}
+
+// fishQuote 将 s 转换为 fish 的单引号字符串
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// writeFishCompletion 输出 fish 补全脚本
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(tr(msgCompletionFish) + "\n\n")
+	b.WriteString("complete -c minio-cleaner -f\n")
+
+	// 未指定子命令时参数属于默认的 run 命令
+	var others []string
+	for _, c := range subcommands()[1:] {
+		others = append(others, c.name)
+	}
+	for _, c := range subcommands() {
+		fmt.Fprintf(&b, "complete -c minio-cleaner -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(tr(c.summary)))
+	}
+	for _, c := range subcommands() {
+		cond := fishQuote("__fish_seen_subcommand_from " + c.name)
+		if c.name == "run" {
+			cond = fishQuote("not __fish_seen_subcommand_from " + strings.Join(others, " "))
+		}
+		b.WriteString("\n")
+		for _, f := range commandFlags(c) {
+			fmt.Fprintf(&b, "complete -c minio-cleaner -n %s -o %s -d %s", cond, f.Name, fishQuote(f.Usage))
+			switch {
+			case isBoolFlag(f):
+			case isPathFlag(f):
+				b.WriteString(" -r -F")
+			default:
+				b.WriteString(" -x")
+			}
+			b.WriteString("\n")
+		}
+		if args := commandArgs(c); len(args) > 0 {
+			fmt.Fprintf(&b, "complete -c minio-cleaner -n %s -a %s\n", cond, fishQuote(strings.Join(args, " ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/cleaner"
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+	"github.com/fjcanyue/minio-cleaner/report"
+)
+
+// loadHistoryConfig 为 history 和 show 命令加载配置并返回历史数据库路径
+func loadHistoryConfig(configPath, profile string) string {
+	cfg, err := cleaner.LoadConfig(configPath, profile)
+	if err != nil {
+		log.Fatal(tr(msgConfigLoadFailed, err))
+	}
+	if err := i18n.SetLanguage(cfg.Cleanup.Language); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.History.File == "" {
+		log.Fatal(tr(msgHistoryDisabled))
+	}
+	return cfg.History.File
+}
+
+// cmdHistory 实现 history 命令：列出历史运行记录
+func cmdHistory(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "config.yaml", tr(msgFlagConfig))
+	profile := fs.String("profile", "", tr(msgFlagProfile))
+	limit := fs.Int("limit", 20, tr(msgFlagLimit))
+	sinceStr := fs.String("since", "", tr(msgFlagSince))
+	return func() {
+		var since time.Time
+		if *sinceStr != "" {
+			var err error
+			since, err = time.ParseInLocation("2006-01-02", *sinceStr, time.Local)
+			if err != nil {
+				log.Fatal(tr(msgBadDate, *sinceStr))
+			}
+		}
+
+		path := loadHistoryConfig(*configPath, *profile)
+		records, err := report.ListHistory(path, since, *limit)
+		if err != nil {
+			log.Fatal(tr(msgHistoryReadFailed, err))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, tr(msgHistoryHeader))
+		var totalFiles, totalBytes int64
+		for _, rec := range records {
+			mode, deleted, size, errCount, duration := "-", int64(0), int64(0), int64(0), "-"
+			if r := rec.Report; r != nil {
+				mode = tr(msgHTMLDelete)
+				if r.DryRun {
+					mode = tr(msgHTMLDryRun)
+				}
+				deleted, size, errCount = r.DeletedFiles, r.DeletedBytes, r.ErrorCount
+				duration = r.EndTime.Sub(r.StartTime).Round(time.Second).String()
+			}
+			if rec.Error != "" {
+				errCount++
+			}
+			totalFiles += deleted
+			totalBytes += size
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%.2f MB\t%d\n",
+				rec.RunID, rec.StartTime.Local().Format("2006-01-02 15:04:05"), duration, rec.Bucket, mode,
+				deleted, float64(size)/1024/1024, errCount)
+		}
+		w.Flush()
+		fmt.Println(tr(msgHistoryTotal, len(records), totalFiles, float64(totalBytes)/1024/1024))
+	}
+}
+
+// cmdShow 实现 show 命令：输出一次运行的完整记录
+func cmdShow(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "config.yaml", tr(msgFlagConfig))
+	profile := fs.String("profile", "", tr(msgFlagProfile))
+	return func() {
+		if fs.NArg() != 1 {
+			log.Fatal(tr(msgShowUsage))
+		}
+
+		path := loadHistoryConfig(*configPath, *profile)
+		rec, err := report.GetHistory(path, fs.Arg(0))
+		if err != nil {
+			log.Fatal(tr(msgHistoryReadFailed, err))
+		}
+		data, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			log.Fatal(tr(msgHistoryReadFailed, err))
+		}
+		fmt.Println(string(data))
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exampleConfig 为带有全部配置项说明的示例配置，init 命令以其作为初始配置文件，默认只预览不删除。
+// 由命令行程序嵌入 config.example.yaml 并传给 Main
+var exampleConfig []byte
+
+// cmdInit 生成初始配置文件，已存在时不覆盖，除非指定 -force；-config - 输出到标准输出
+func cmdInit(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "config.yaml", tr(msgFlagInitConfig))
+	force := fs.Bool("force", false, tr(msgFlagOverwrite))
+	return func() {
+		if *configPath == "-" {
+			os.Stdout.Write(exampleConfig)
+			return
+		}
+		if err := checkConfigPath(*configPath, *force); err != nil {
+			log.Fatal(err)
+		}
+		if err := writeConfigFile(*configPath, exampleConfig, *force); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(tr(msgInitDone, *configPath))
+	}
+}
+
+// checkConfigPath 检查能否在 path 生成配置文件：示例配置包含注释，只能以 YAML 格式生成；
+// 文件已存在时只有 force 为 true 才覆盖
+func checkConfigPath(path string, force bool) error {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".json" || ext == ".toml" {
+		return errors.New(tr(msgInitFormat, path))
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return errors.New(tr(msgInitExists, path))
+	}
+	return nil
+}
+
+// writeConfigFile 写入生成的配置文件。配置文件中会填写访问密钥，只允许当前用户读写
+func writeConfigFile(path string, data []byte, force bool) error {
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, mode, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return errors.New(tr(msgInitExists, path))
+	}
+	if err != nil {
+		return errors.New(tr(msgInitFailed, err))
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.New(tr(msgInitFailed, err))
+	}
+	if err := f.Close(); err != nil {
+		return errors.New(tr(msgInitFailed, err))
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// cmdMan 实现 man 命令：在指定目录生成 minio-cleaner.1 和各子命令的手册页
+func cmdMan(fs *flag.FlagSet) func() {
+	dir := fs.String("dir", ".", tr(msgFlagManDir))
+	return func() {
+		if err := os.MkdirAll(*dir, 0755); err != nil {
+			log.Fatal(tr(msgManFailed, err))
+		}
+		pages := map[string]string{"minio-cleaner.1": mainManPage()}
+		names := []string{"minio-cleaner.1"}
+		for _, c := range subcommands()[1:] {
+			name := "minio-cleaner-" + c.name + ".1"
+			pages[name] = commandManPage(c)
+			names = append(names, name)
+		}
+		for _, name := range names {
+			path := filepath.Join(*dir, name)
+			if err := os.WriteFile(path, []byte(pages[name]), 0644); err != nil {
+				log.Fatal(tr(msgManFailed, err))
+			}
+			fmt.Println(path)
+		}
+	}
+}
+
+// manPageNames 返回各子命令手册页的文件名，run 的参数在 minio-cleaner.1 中说明
+func manPageNames() []string {
+	var names []string
+	for _, c := range subcommands()[1:] {
+		names = append(names, "minio-cleaner-"+c.name+".1")
+	}
+	return names
+}
+
+// roffEscape 转义手册页正文中的反斜杠、连字符和行首的控制字符
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeManHeader 输出手册页的标题和名称部分
+func writeManHeader(b *strings.Builder, name, summary string) {
+	fmt.Fprintf(b, ".TH %s 1 \"\" \"minio-cleaner\" \"%s\"\n", strings.ToUpper(name), tr(msgManUserCommands))
+	fmt.Fprintf(b, ".SH %s\n%s \\- %s\n", tr(msgManName), roffEscape(name), roffEscape(summary))
+}
+
+// writeManOptions 输出命令的参数说明
+func writeManOptions(b *strings.Builder, c subcommand) {
+	flags := commandFlags(c)
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(b, ".SH %s\n", tr(msgManOptions))
+	for _, f := range flags {
+		b.WriteString(".TP\n")
+		fmt.Fprintf(b, "\\fB\\-%s\\fR", roffEscape(f.Name))
+		if !isBoolFlag(f) {
+			value, _ := flag.UnquoteUsage(f)
+			fmt.Fprintf(b, " \\fI%s\\fR", roffEscape(value))
+		}
+		b.WriteString("\n" + roffEscape(f.Usage))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			b.WriteString(tr(msgManDefault, roffEscape(f.DefValue)))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// mainManPage 生成 minio-cleaner.1：命令列表、run 命令的参数和环境变量
+func mainManPage() string {
+	var b strings.Builder
+	writeManHeader(&b, "minio-cleaner", tr(msgManSummary))
+	fmt.Fprintf(&b, ".SH %s\n\\fBminio-cleaner\\fR [\\fI%s\\fR] [\\fI%s\\fR]\n", tr(msgManSynopsis), tr(msgManCommandArg), tr(msgManOptionsArg))
+	fmt.Fprintf(&b, ".SH %s\n%s\n", tr(msgManDescription), roffEscape(tr(msgManBody)))
+	fmt.Fprintf(&b, ".SH %s\n", tr(msgManCommands))
+	for _, c := range subcommands() {
+		fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(c.name), roffEscape(tr(c.summary)))
+	}
+	writeManOptions(&b, subcommands()[0])
+
+	fmt.Fprintf(&b, ".SH %s\n", tr(msgManEnvironment))
+	for _, o := range config.OverrideOptions {
+		fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(o.EnvName()), roffEscape(tr(msgManOverrides, o.Path)))
+	}
+	envs := []struct {
+		name string
+		desc msgID
+	}{
+		{config.EnvPrefix + "PROFILE", msgManEnvProfile},
+		{"SOPS_AGE_KEY", msgManEnvAgeKey},
+		{"SOPS_AGE_KEY_FILE", msgManEnvAgeKeyFile},
+	}
+	for _, e := range envs {
+		fmt.Fprintf(&b, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(e.name), roffEscape(tr(e.desc)))
+	}
+	fmt.Fprintf(&b, ".SH %s\n.TP\n\\fBconfig.yaml\\fR\n%s\n", tr(msgManFiles), roffEscape(tr(msgManConfigFile)))
+	writeManSeeAlso(&b, manPageNames())
+	return b.String()
+}
+
+// commandManPage 生成子命令的手册页
+func commandManPage(c subcommand) string {
+	var b strings.Builder
+	name := "minio-cleaner-" + c.name
+	writeManHeader(&b, name, tr(c.summary))
+	usage := "[\\fI" + tr(msgManOptionsArg) + "\\fR]"
+	if c.args != "" {
+		usage += " \\fI" + roffEscape(c.args) + "\\fR"
+	}
+	fmt.Fprintf(&b, ".SH %s\n\\fBminio\\-cleaner %s\\fR %s\n", tr(msgManSynopsis), roffEscape(c.name), usage)
+	fmt.Fprintf(&b, ".SH %s\n%s\n", tr(msgManDescription), roffEscape(tr(c.summary)))
+	writeManOptions(&b, c)
+	writeManSeeAlso(&b, []string{"minio-cleaner.1"})
+	return b.String()
+}
+
+// writeManSeeAlso 输出相关手册页
+func writeManSeeAlso(b *strings.Builder, pages []string) {
+	refs := make([]string, len(pages))
+	for i, p := range pages {
+		refs[i] = "\\fB" + roffEscape(strings.TrimSuffix(p, ".1")) + "\\fR(1)"
+	}
+	fmt.Fprintf(b, ".SH %s\n%s\n", tr(msgManSeeAlso), strings.Join(refs, ", "))
+}
@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgLogSetupFailed     msgID = "log.setupFailed"
+	msgConflictVerbose    msgID = "flag.verboseQuiet"
+	msgHTMLDryRun         msgID = "html.dryRun"
+	msgHTMLDelete         msgID = "html.delete"
+	msgHistoryReadFailed  msgID = "history.readFailed"
+	msgHistoryDisabled    msgID = "history.disabled"
+	msgHistoryHeader      msgID = "history.header"
+	msgHistoryTotal       msgID = "history.total"
+	msgBadTop             msgID = "flag.badTop"
+	msgValidateOK         msgID = "validate.ok"
+	msgValidateFailed     msgID = "validate.failed"
+	msgValidatePositive   msgID = "validate.positive"
+	msgInitExists         msgID = "init.exists"
+	msgInitFormat         msgID = "init.format"
+	msgInitFailed         msgID = "init.failed"
+	msgInitDone           msgID = "init.done"
+	msgSetupIntro         msgID = "setup.intro"
+	msgSetupEndpoint      msgID = "setup.endpoint"
+	msgSetupUseSSL        msgID = "setup.useSSL"
+	msgSetupAccessKey     msgID = "setup.accessKey"
+	msgSetupSecretKey     msgID = "setup.secretKey"
+	msgSetupConnecting    msgID = "setup.connecting"
+	msgSetupConnectFailed msgID = "setup.connectFailed"
+	msgSetupRetry         msgID = "setup.retry"
+	msgSetupBuckets       msgID = "setup.buckets"
+	msgSetupBucket        msgID = "setup.bucket"
+	msgSetupNoBucket      msgID = "setup.noBucket"
+	msgSetupMaxAge        msgID = "setup.maxAge"
+	msgSetupMinSize       msgID = "setup.minSize"
+	msgSetupDryRun        msgID = "setup.dryRun"
+	msgSetupRequired      msgID = "setup.required"
+	msgSetupYesNo         msgID = "setup.yesNo"
+	msgSetupAborted       msgID = "setup.aborted"
+	msgSetupDone          msgID = "setup.done"
+	msgCompletionUsage    msgID = "completion.usage"
+	msgCompletionShell    msgID = "completion.shell"
+	msgManFailed          msgID = "man.failed"
+	msgOutputBadFormat    msgID = "output.badFormat"
+	msgOutputCommand      msgID = "output.command"
+	msgOutputDaemon       msgID = "output.daemon"
+	msgOutputFailed       msgID = "output.failed"
+	msgReviewNoTerminal   msgID = "review.noTerminal"
+	msgReviewDaemon       msgID = "review.daemon"
+	msgTUINoTerminal      msgID = "tui.noTerminal"
+	msgTUIConflict        msgID = "tui.conflict"
+	msgOverrideSyntax     msgID = "override.syntax"
+	msgSentryInitFailed   msgID = "sentry.initFailed"
+	msgPprofListening     msgID = "pprof.listening"
+	msgPprofFailed        msgID = "pprof.failed"
+	msgRunErrorSummary    msgID = "run.errorSummary"
+	msgRunErrorMore       msgID = "run.errorMore"
+	msgCanaryNoTerminal   msgID = "canary.noTerminal"
+	msgUnknownCommand     msgID = "cli.unknownCommand"
+	msgConfigLoadFailed   msgID = "config.loadFailed"
+	msgConfigReadFailed   msgID = "config.readFailed"
+	msgBadDate            msgID = "cli.badDate"
+	msgShowUsage          msgID = "show.usage"
+	msgCmdRun             msgID = "cmd.run"
+	msgCmdDiff            msgID = "cmd.diff"
+	msgCmdAnalyze         msgID = "cmd.analyze"
+	msgCmdTUI             msgID = "cmd.tui"
+	msgCmdHistory         msgID = "cmd.history"
+	msgCmdShow            msgID = "cmd.show"
+	msgCmdValidate        msgID = "cmd.validate"
+	msgCmdInit            msgID = "cmd.init"
+	msgCmdSetup           msgID = "cmd.setup"
+	msgCmdCompletion      msgID = "cmd.completion"
+	msgCmdMan             msgID = "cmd.man"
+	msgFlagConfig         msgID = "flag.config"
+	msgFlagDaemon         msgID = "flag.daemon"
+	msgFlagVerbose        msgID = "flag.verbose"
+	msgFlagQuiet          msgID = "flag.quiet"
+	msgFlagTop            msgID = "flag.top"
+	msgFlagPprof          msgID = "flag.pprof"
+	msgFlagBench          msgID = "flag.bench"
+	msgFlagProfile        msgID = "flag.profile"
+	msgFlagYes            msgID = "flag.yes"
+	msgFlagInteractive    msgID = "flag.interactive"
+	msgFlagForce          msgID = "flag.force"
+	msgFlagOutput         msgID = "flag.output"
+	msgFlagLimit          msgID = "flag.limit"
+	msgFlagSince          msgID = "flag.since"
+	msgFlagInitConfig     msgID = "flag.initConfig"
+	msgFlagSetupConfig    msgID = "flag.setupConfig"
+	msgFlagOverwrite      msgID = "flag.overwrite"
+	msgFlagCheckProfile   msgID = "flag.checkProfile"
+	msgFlagManDir         msgID = "flag.manDir"
+	msgFlagSet            msgID = "flag.set"
+	msgManUserCommands    msgID = "man.userCommands"
+	msgManName            msgID = "man.name"
+	msgManSynopsis        msgID = "man.synopsis"
+	msgManDescription     msgID = "man.description"
+	msgManCommands        msgID = "man.commands"
+	msgManOptions         msgID = "man.options"
+	msgManEnvironment     msgID = "man.environment"
+	msgManFiles           msgID = "man.files"
+	msgManSeeAlso         msgID = "man.seeAlso"
+	msgManCommandArg      msgID = "man.commandArg"
+	msgManOptionsArg      msgID = "man.optionsArg"
+	msgManDefault         msgID = "man.default"
+	msgManSummary         msgID = "man.summary"
+	msgManBody            msgID = "man.body"
+	msgManOverrides       msgID = "man.overrides"
+	msgManEnvProfile      msgID = "man.envProfile"
+	msgManEnvAgeKey       msgID = "man.envAgeKey"
+	msgManEnvAgeKeyFile   msgID = "man.envAgeKeyFile"
+	msgManConfigFile      msgID = "man.configFile"
+	msgCompletionBash     msgID = "completion.bash"
+	msgCompletionZsh      msgID = "completion.zsh"
+	msgCompletionFish     msgID = "completion.fish"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = i18n.Catalogs[msgID]{
+	"zh": {
+		msgLogSetupFailed:     "设置日志失败: %v",
+		msgConflictVerbose:    "-verbose 和 -quiet 不能同时使用",
+		msgHTMLDryRun:         "预览",
+		msgHTMLDelete:         "删除",
+		msgHistoryReadFailed:  "读取运行历史失败: %v",
+		msgHistoryDisabled:    "未配置 history.file，运行历史未启用",
+		msgHistoryHeader:      "运行 ID\t开始时间\t耗时\t存储桶\t模式\t删除文件数\t删除大小\t错误数",
+		msgHistoryTotal:       "共 %d 次运行，删除 %d 个文件，释放 %.2f MB",
+		msgBadTop:             "-top 必须大于 0: %d",
+		msgValidateOK:         "配置文件 %s 检查通过",
+		msgValidateFailed:     "配置文件 %s 有 %d 个问题:",
+		msgValidatePositive:   "%s 必须大于 0，当前为 %v",
+		msgInitExists:         "配置文件 %s 已存在，如需覆盖请指定 -force",
+		msgInitFormat:         "init 只能生成 YAML 格式的配置文件，%s 不是 .yaml 或 .yml 文件",
+		msgInitFailed:         "生成配置文件失败: %v",
+		msgInitDone:           "已生成配置文件 %s，默认只预览不删除。请填写 minio 中的连接信息和 cleanup 中的保留策略，然后运行 validate 检查",
+		msgSetupIntro:         "将逐项询问连接信息和保留策略，直接回车使用方括号中的默认值",
+		msgSetupEndpoint:      "MinIO 服务地址（如 minio.example.com:9000）",
+		msgSetupUseSSL:        "使用 HTTPS 连接",
+		msgSetupAccessKey:     "访问密钥 ID（留空则从环境变量、凭证文件或 IAM 角色获取）",
+		msgSetupSecretKey:     "访问密钥",
+		msgSetupConnecting:    "正在连接 %s 并列举存储桶...",
+		msgSetupConnectFailed: "连接失败: %v",
+		msgSetupRetry:         "重新输入连接信息？选择否则不验证连接信息，直接生成配置文件",
+		msgSetupBuckets:       "找到 %d 个存储桶:",
+		msgSetupBucket:        "要清理的存储桶（名称或序号）",
+		msgSetupNoBucket:      "存储桶 %s 不存在或没有访问权限",
+		msgSetupMaxAge:        "文件最大保留时间（如 30d、2w、12h）",
+		msgSetupMinSize:       "文件最小大小（如 500MB，0 表示不限制）",
+		msgSetupDryRun:        "仅预览不实际删除（建议首次运行时开启）",
+		msgSetupRequired:      "不能为空",
+		msgSetupYesNo:         "请输入 y 或 n",
+		msgSetupAborted:       "输入已结束，未生成配置文件",
+		msgSetupDone:          "已生成配置文件 %s，可以先运行 validate 检查，再以预览模式运行查看清理效果",
+		msgCompletionUsage:    "用法: minio-cleaner completion bash|zsh|fish",
+		msgCompletionShell:    "不支持的 shell: %s，可选 bash、zsh 或 fish",
+		msgManFailed:          "生成手册页失败: %v",
+		msgOutputBadFormat:    "不支持的输出格式: %s，可选 text、json 或 jsonl",
+		msgOutputCommand:      "%s 命令只支持文本输出，-output json 和 jsonl 仅适用于 run 命令",
+		msgOutputDaemon:       "守护模式不会结束，不能使用 -output json，请改用 -output jsonl",
+		msgOutputFailed:       "输出运行结果失败: %v",
+		msgReviewNoTerminal:   "-interactive 需要在终端中运行",
+		msgReviewDaemon:       "-interactive 不能与 -daemon 同时使用",
+		msgTUINoTerminal:      "tui 命令需要在终端中运行",
+		msgTUIConflict:        "tui 命令不能与 -daemon 或 -interactive 同时使用",
+		msgOverrideSyntax:     "无效的 -set 参数: %s，格式应为 key=value",
+		msgSentryInitFailed:   "初始化 Sentry 失败: %v",
+		msgPprofListening:     "pprof 调试接口监听于 %s",
+		msgPprofFailed:        "pprof 调试接口启动失败: %v",
+		msgRunErrorSummary:    "集群 %s 上的存储桶 %s 运行出现 %d 个错误，其中 %d 个文件删除失败（%.2f MB）",
+		msgRunErrorMore:       "  …… 另有 %d 个错误，详见日志或汇总报告",
+		msgCanaryNoTerminal:   "canary.confirm 需要在终端中运行，且不能与 -daemon 同时使用",
+		msgUnknownCommand:     "未知的命令: %s",
+		msgConfigLoadFailed:   "加载配置失败: %v",
+		msgConfigReadFailed:   "读取配置文件失败: %v",
+		msgBadDate:            "无效的日期: %s",
+		msgShowUsage:          "用法: minio-cleaner show [-config config.yaml] [-profile name] <run-id>",
+		msgCmdRun:             "按配置清理存储桶中的过期文件（默认命令）",
+		msgCmdDiff:            "以预览模式运行，并与上一次预览的待清理对象列表比较",
+		msgCmdAnalyze:         "统计存储桶按前缀、扩展名和文件年龄的构成",
+		msgCmdTUI:             "在终端表格中浏览待清理对象，标记保留或删除后清理选中的对象",
+		msgCmdHistory:         "列出历史运行记录",
+		msgCmdShow:            "输出一次运行的完整记录",
+		msgCmdValidate:        "检查配置文件，不连接 MinIO",
+		msgCmdInit:            "生成带有全部配置项说明的初始配置文件",
+		msgCmdSetup:           "交互式生成配置文件",
+		msgCmdCompletion:      "输出 bash、zsh 或 fish 的命令补全脚本",
+		msgCmdMan:             "生成手册页",
+		msgFlagConfig:         "配置文件路径",
+		msgFlagDaemon:         "以守护模式运行，按间隔循环执行清理",
+		msgFlagVerbose:        "输出调试日志（等同于 logLevel: debug）",
+		msgFlagQuiet:          "仅输出警告和错误日志（等同于 logLevel: warn）",
+		msgFlagTop:            "analyze 命令中各排行列出的条数",
+		msgFlagPprof:          "在指定地址提供 pprof 调试接口（等同于 debug.pprofAddress）",
+		msgFlagBench:          "基准模式，分别输出列举、筛选和执行阶段的吞吐量（等同于 debug.bench: true）",
+		msgFlagProfile:        "使用配置文件 profiles 中的指定配置",
+		msgFlagYes:            "实际删除前不要求确认，用于脚本和定时任务",
+		msgFlagInteractive:    "清理前按前缀分组逐项审查待清理对象，只清理批准的对象",
+		msgFlagForce:          "删除的对象超过存储桶的 safety.maxDeletePercent 时仍然删除（等同于 safety.maxDeletePercent: 100）",
+		msgFlagOutput:         "运行结果的输出格式：text、json（运行结束后输出一个 JSON 文档）或 jsonl（每个待清理或已删除的对象一行），后两种格式下日志输出到标准错误",
+		msgFlagLimit:          "最多列出的运行记录数，0 表示不限制",
+		msgFlagSince:          "只列出该日期（YYYY-MM-DD）之后的运行记录",
+		msgFlagInitConfig:     "生成的配置文件路径，- 表示输出到标准输出",
+		msgFlagSetupConfig:    "生成的配置文件路径",
+		msgFlagOverwrite:      "覆盖已存在的配置文件",
+		msgFlagCheckProfile:   "只检查 profiles 中的指定配置，默认检查全部 profile",
+		msgFlagManDir:         "手册页的输出目录，如 /usr/local/share/man/man1",
+		msgFlagSet:            "覆盖任意配置项，格式为 key=value，key 与配置文件中的写法相同，如 cleanup.maxErrorRate=0.1，可以多次使用",
+		msgManUserCommands:    "用户命令",
+		msgManName:            "名称",
+		msgManSynopsis:        "概要",
+		msgManDescription:     "描述",
+		msgManCommands:        "命令",
+		msgManOptions:         "选项",
+		msgManEnvironment:     "环境变量",
+		msgManFiles:           "文件",
+		msgManSeeAlso:         "另请参阅",
+		msgManCommandArg:      "命令",
+		msgManOptionsArg:      "选项",
+		msgManDefault:         "（默认为 %s）",
+		msgManSummary:         "清理 MinIO 及其他 S3 兼容存储中的过期文件",
+		msgManBody:            "按配置文件中的保留时间、最小文件大小和前缀规则清理存储桶中的文件。未指定命令时执行 run。配置文件中 cleanup.dryRun 为 true 时只预览不删除。",
+		msgManOverrides:       "覆盖 %s",
+		msgManEnvProfile:      "使用配置文件 profiles 中的指定配置，优先于 -profile",
+		msgManEnvAgeKey:       "解密 sops 加密的配置文件使用的 age 私钥",
+		msgManEnvAgeKeyFile:   "age 私钥文件路径",
+		msgManConfigFile:      "默认的配置文件，可以用 minio-cleaner init 生成",
+		msgCompletionBash:     "# minio-cleaner 的 bash 补全脚本\n# 使用方法：source <(minio-cleaner completion bash)",
+		msgCompletionZsh:      "# minio-cleaner 的 zsh 补全脚本\n# 使用方法：source <(minio-cleaner completion zsh)，或保存为 $fpath 中的 _minio-cleaner 文件",
+		msgCompletionFish:     "# minio-cleaner 的 fish 补全脚本\n# 使用方法：minio-cleaner completion fish | source",
+	},
+	"en": {
+		msgLogSetupFailed:     "Failed to set up logging: %v",
+		msgConflictVerbose:    "-verbose and -quiet cannot be used together",
+		msgHTMLDryRun:         "Dry run",
+		msgHTMLDelete:         "Delete",
+		msgHistoryReadFailed:  "Failed to read run history: %v",
+		msgHistoryDisabled:    "history.file is not configured, run history is disabled",
+		msgHistoryHeader:      "RUN ID\tSTART\tDURATION\tBUCKET\tMODE\tDELETED\tSIZE\tERRORS",
+		msgHistoryTotal:       "%d runs, %d files deleted, %.2f MB reclaimed",
+		msgBadTop:             "-top must be greater than 0: %d",
+		msgValidateOK:         "Config file %s is valid",
+		msgValidateFailed:     "Config file %s has %d problem(s):",
+		msgValidatePositive:   "%s must be greater than 0, got %v",
+		msgInitExists:         "Config file %s already exists, use -force to overwrite it",
+		msgInitFormat:         "init can only generate YAML config files, %s is not a .yaml or .yml file",
+		msgInitFailed:         "Failed to write the config file: %v",
+		msgInitDone:           "Wrote config file %s, which only previews deletions by default. Fill in the minio connection settings and the cleanup retention policy, then run validate to check it",
+		msgSetupIntro:         "You will be asked for the connection settings and the retention policy, press Enter to accept the default in brackets",
+		msgSetupEndpoint:      "MinIO endpoint (e.g. minio.example.com:9000)",
+		msgSetupUseSSL:        "Use HTTPS",
+		msgSetupAccessKey:     "Access key ID (leave empty to use environment variables, a credentials file or an IAM role)",
+		msgSetupSecretKey:     "Secret access key",
+		msgSetupConnecting:    "Connecting to %s and listing buckets...",
+		msgSetupConnectFailed: "Connection failed: %v",
+		msgSetupRetry:         "Re-enter the connection settings? Choose no to write the config without verifying them",
+		msgSetupBuckets:       "Found %d bucket(s):",
+		msgSetupBucket:        "Bucket to clean up (name or number)",
+		msgSetupNoBucket:      "Bucket %s does not exist or is not accessible",
+		msgSetupMaxAge:        "Maximum object age (e.g. 30d, 2w, 12h)",
+		msgSetupMinSize:       "Minimum object size (e.g. 500MB, 0 for no limit)",
+		msgSetupDryRun:        "Only preview without deleting (recommended for the first run)",
+		msgSetupRequired:      "A value is required",
+		msgSetupYesNo:         "Please answer y or n",
+		msgSetupAborted:       "Input ended, no config file was written",
+		msgSetupDone:          "Wrote config file %s. Run validate to check it, then run in preview mode to see what would be deleted",
+		msgCompletionUsage:    "Usage: minio-cleaner completion bash|zsh|fish",
+		msgCompletionShell:    "Unsupported shell: %s, expected bash, zsh or fish",
+		msgManFailed:          "Failed to generate man pages: %v",
+		msgOutputBadFormat:    "Unsupported output format: %s, expected text, json or jsonl",
+		msgOutputCommand:      "The %s command only supports text output, -output json and jsonl apply to the run command only",
+		msgOutputDaemon:       "Daemon mode never finishes and cannot use -output json, use -output jsonl instead",
+		msgOutputFailed:       "Failed to write results: %v",
+		msgReviewNoTerminal:   "-interactive must be run from a terminal",
+		msgReviewDaemon:       "-interactive cannot be used together with -daemon",
+		msgTUINoTerminal:      "the tui command must be run in a terminal",
+		msgTUIConflict:        "the tui command cannot be combined with -daemon or -interactive",
+		msgOverrideSyntax:     "Invalid -set argument: %s, expected key=value",
+		msgSentryInitFailed:   "Failed to initialize Sentry: %v",
+		msgPprofListening:     "pprof endpoint listening on %s",
+		msgPprofFailed:        "Failed to start pprof endpoint: %v",
+		msgRunErrorSummary:    "Run on bucket %[2]s on cluster %[1]s had %[3]d errors, %[4]d files (%.2[5]f MB) failed to delete",
+		msgRunErrorMore:       "  ... and %d more errors, see the log or summary report",
+		msgCanaryNoTerminal:   "canary.confirm requires a terminal and cannot be used together with -daemon",
+		msgUnknownCommand:     "Unknown command: %s",
+		msgConfigLoadFailed:   "Failed to load config: %v",
+		msgConfigReadFailed:   "Failed to read config file: %v",
+		msgBadDate:            "Invalid date: %s",
+		msgShowUsage:          "Usage: minio-cleaner show [-config config.yaml] [-profile name] <run-id>",
+		msgCmdRun:             "Clean up expired objects in buckets according to the config (default command)",
+		msgCmdDiff:            "Run in dry-run mode and compare the pending objects with the previous dry run",
+		msgCmdAnalyze:         "Summarize bucket contents by prefix, extension and object age",
+		msgCmdTUI:             "Browse pending objects in a terminal table, mark them to keep or delete, then clean up the selected objects",
+		msgCmdHistory:         "List past runs",
+		msgCmdShow:            "Print the full record of a run",
+		msgCmdValidate:        "Check the config file without connecting to MinIO",
+		msgCmdInit:            "Generate an initial config file documenting every option",
+		msgCmdSetup:           "Generate a config file interactively",
+		msgCmdCompletion:      "Print the bash, zsh or fish completion script",
+		msgCmdMan:             "Generate man pages",
+		msgFlagConfig:         "Config file path",
+		msgFlagDaemon:         "Run as a daemon, cleaning up at every interval",
+		msgFlagVerbose:        "Output debug logs (same as logLevel: debug)",
+		msgFlagQuiet:          "Only output warning and error logs (same as logLevel: warn)",
+		msgFlagTop:            "Number of entries in each ranking of the analyze command",
+		msgFlagPprof:          "Serve the pprof debug endpoints on this address (same as debug.pprofAddress)",
+		msgFlagBench:          "Benchmark mode, report the throughput of the listing, filtering and execution stages separately (same as debug.bench: true)",
+		msgFlagProfile:        "Use the named configuration from the config file's profiles",
+		msgFlagYes:            "Do not ask for confirmation before deleting, for scripts and scheduled jobs",
+		msgFlagInteractive:    "Review pending objects group by group before cleanup and only clean up the approved ones",
+		msgFlagForce:          "Delete even when the objects to delete exceed the bucket's safety.maxDeletePercent (same as safety.maxDeletePercent: 100)",
+		msgFlagOutput:         "Output format of the run result: text, json (one JSON document after the run) or jsonl (one line per pending or deleted object); logs go to standard error for the latter two",
+		msgFlagLimit:          "Maximum number of runs to list, 0 for no limit",
+		msgFlagSince:          "Only list runs after this date (YYYY-MM-DD)",
+		msgFlagInitConfig:     "Path of the generated config file, - for standard output",
+		msgFlagSetupConfig:    "Path of the generated config file",
+		msgFlagOverwrite:      "Overwrite an existing config file",
+		msgFlagCheckProfile:   "Only check the named profile, all profiles by default",
+		msgFlagManDir:         "Output directory for the man pages, e.g. /usr/local/share/man/man1",
+		msgFlagSet:            "Override any config option as key=value, with key written as in the config file, e.g. cleanup.maxErrorRate=0.1; can be repeated",
+		msgManUserCommands:    "User Commands",
+		msgManName:            "NAME",
+		msgManSynopsis:        "SYNOPSIS",
+		msgManDescription:     "DESCRIPTION",
+		msgManCommands:        "COMMANDS",
+		msgManOptions:         "OPTIONS",
+		msgManEnvironment:     "ENVIRONMENT",
+		msgManFiles:           "FILES",
+		msgManSeeAlso:         "SEE ALSO",
+		msgManCommandArg:      "command",
+		msgManOptionsArg:      "options",
+		msgManDefault:         " (default %s)",
+		msgManSummary:         "Clean up expired objects in MinIO and other S3-compatible storage",
+		msgManBody:            "Cleans up objects in buckets by the retention, minimum size and prefix rules in the config file. Executes run when no command is given. Only previews without deleting when cleanup.dryRun is true in the config file.",
+		msgManOverrides:       "Overrides %s",
+		msgManEnvProfile:      "Use the named configuration from the config file's profiles, takes precedence over -profile",
+		msgManEnvAgeKey:       "age private key used to decrypt sops encrypted config files",
+		msgManEnvAgeKeyFile:   "Path of the age private key file",
+		msgManConfigFile:      "Default config file, can be generated with minio-cleaner init",
+		msgCompletionBash:     "# bash completion script for minio-cleaner\n# Usage: source <(minio-cleaner completion bash)",
+		msgCompletionZsh:      "# zsh completion script for minio-cleaner\n# Usage: source <(minio-cleaner completion zsh), or save it as _minio-cleaner in $fpath",
+		msgCompletionFish:     "# fish completion script for minio-cleaner\n# Usage: minio-cleaner completion fish | source",
+	},
+}
+
+// tr 按命令行程序设置的语言格式化消息，缺失的翻译回退到中文
+func tr(id msgID, args ...any) string {
+	return catalogs.Format("", id, args...)
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/fjcanyue/minio-cleaner/cleaner"
+)
+
+// -output 参数的取值：text 为默认的日志输出，json 在运行结束后输出一个 JSON 文档，
+// jsonl 每处理完一个待清理对象输出一行 JSON。后两种格式下日志和提示改为输出到标准错误
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputJSONL = "jsonl"
+)
+
+// checkOutputFormat 检查 -output 参数：diff 和 analyze 命令只支持文本输出，守护模式不会结束，不能输出 json
+func checkOutputFormat(format, command string, daemon bool) error {
+	switch format {
+	case outputText:
+		return nil
+	case outputJSON, outputJSONL:
+	default:
+		return errors.New(tr(msgOutputBadFormat, format))
+	}
+	if command != "run" {
+		return errors.New(tr(msgOutputCommand, command))
+	}
+	if format == outputJSON && daemon {
+		return errors.New(tr(msgOutputDaemon))
+	}
+	return nil
+}
+
+// writeRunOutput 输出 -output json 的结果文档
+func writeRunOutput(w io.Writer, report cleaner.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// maxSummaryErrors 为运行失败时在汇总中列出的错误信息条数
+const maxSummaryErrors = 5
+
+// reportRunErrors 为出现错误的目标输出错误汇总，包括错误数、删除失败的文件数和前几条错误信息，
+// 返回是否有目标出现错误。运行中的每个错误已单独记录日志，汇总便于在定时任务的输出末尾找到失败原因
+func reportRunErrors(report cleaner.Report) bool {
+	failed := false
+	for _, r := range report.Runs {
+		if r.RunReport == nil || r.ErrorCount == 0 {
+			continue
+		}
+		failed = true
+		run := r.RunReport
+		slog.Error(tr(msgRunErrorSummary, r.Cluster, r.Bucket, run.ErrorCount, run.FailedFiles, float64(run.FailedBytes)/1024/1024),
+			"cluster", r.Cluster, "bucket", r.Bucket, "action", "summary", "errors", run.ErrorCount, "failed", run.FailedFiles, "size", run.FailedBytes)
+		for _, msg := range run.Errors[:min(len(run.Errors), maxSummaryErrors)] {
+			slog.Error("  "+msg, "cluster", r.Cluster, "bucket", r.Bucket, "action", "summary")
+		}
+		if n := run.ErrorCount - int64(min(len(run.Errors), maxSummaryErrors)); n > 0 {
+			slog.Error(tr(msgRunErrorMore, n), "cluster", r.Cluster, "bucket", r.Bucket, "action", "summary")
+		}
+	}
+	return failed
+}
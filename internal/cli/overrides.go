@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// overrideFlag 为覆盖某个配置项的命令行参数，每次设置都按出现顺序追加到 overrides
+type overrideFlag struct {
+	path      string
+	boolFlag  bool
+	also      []config.Override // 同时覆盖的其他配置项
+	overrides *[]config.Override
+}
+
+func (f *overrideFlag) String() string { return "" }
+
+func (f *overrideFlag) Set(value string) error {
+	*f.overrides = append(*f.overrides, config.Override{Path: f.path, Value: value})
+	*f.overrides = append(*f.overrides, f.also...)
+	return nil
+}
+
+func (f *overrideFlag) IsBoolFlag() bool { return f.boolFlag }
+
+// setFlag 实现 -set key=value，可以覆盖任意配置项
+type setFlag struct {
+	overrides *[]config.Override
+}
+
+func (f *setFlag) String() string { return "" }
+
+func (f *setFlag) Set(s string) error {
+	path, value, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return errors.New(tr(msgOverrideSyntax, s))
+	}
+	*f.overrides = append(*f.overrides, config.Override{Path: path, Value: value})
+	return nil
+}
+
+// registerOverrideFlags 注册常用配置项的命令行参数和通用的 -set 参数，解析后的覆盖项追加到 overrides
+func registerOverrideFlags(fs *flag.FlagSet, overrides *[]config.Override) {
+	for _, o := range config.OverrideOptions {
+		if !o.EnvOnly {
+			fs.Var(&overrideFlag{path: o.Path, boolFlag: o.BoolFlag, also: o.Also, overrides: overrides}, o.Name, o.Usage())
+		}
+	}
+	fs.Var(&setFlag{overrides: overrides}, "set", tr(msgFlagSet))
+}
+
+// cliLanguage 在解析命令行参数之前返回 MINIO_CLEANER_LANGUAGE 环境变量或 -language、
+// -set cleanup.language=... 参数指定的消息语言，环境变量优先，都未指定时返回空字符串
+func cliLanguage(args []string) string {
+	if lang, ok := os.LookupEnv(config.EnvPrefix + "LANGUAGE"); ok {
+		return lang
+	}
+	lang := ""
+	for i := 0; i < len(args) && args[i] != "--"; i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !ok && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "language":
+			lang = value
+		case "set":
+			if v, ok := strings.CutPrefix(value, "cleanup.language="); ok {
+				lang = v
+			}
+		}
+	}
+	return lang
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+func TestCLILanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  string
+		want string
+	}{
+		{name: "none", args: []string{"-config", "c.yaml"}, want: ""},
+		{name: "flag", args: []string{"-language", "en"}, want: "en"},
+		{name: "flag with equals", args: []string{"--language=en", "-dry-run"}, want: "en"},
+		{name: "set", args: []string{"-set", "cleanup.language=en"}, want: "en"},
+		{name: "set other key", args: []string{"-set=cleanup.workers=4"}, want: ""},
+		// 与 flag 包相同，后出现的参数生效
+		{name: "last wins", args: []string{"-language", "en", "-language=zh"}, want: "zh"},
+		{name: "after terminator", args: []string{"--", "-language", "en"}, want: ""},
+		// 环境变量优先于命令行参数
+		{name: "env", args: []string{"-language", "zh"}, env: "en", want: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv(config.EnvPrefix+"LANGUAGE", tt.env)
+			}
+			if got := cliLanguage(tt.args); got != tt.want {
+				t.Errorf("cliLanguage(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+	sentry "github.com/getsentry/sentry-go"
+)
+
+// setupSentry 按配置初始化 Sentry 客户端，未配置 DSN 时不做任何事
+func setupSentry(cfg *config.Config) error {
+	if cfg.Notify.Sentry.DSN == "" {
+		return nil
+	}
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         cfg.Notify.Sentry.DSN,
+		Environment: cfg.Notify.Sentry.Environment,
+	})
+	if err != nil {
+		return errors.New(tr(msgSentryInitFailed, err))
+	}
+	sentry.ConfigureScope(func(scope *sentry.Scope) {
+		scope.SetTag("bucket", cfg.Minio.Bucket)
+		scope.SetTag("endpoint", cfg.Minio.Endpoint)
+		scope.SetTag("dryRun", strconv.FormatBool(cfg.Cleanup.DryRun))
+	})
+	return nil
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/cleaner"
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/rules"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// setupTimeout 为 setup 向导连接 MinIO 列举存储桶的超时时间
+const setupTimeout = 30 * time.Second
+
+// setupPrompter 从标准输入逐项读取 setup 向导的回答
+type setupPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask 显示提示并读取一行回答，直接回车时返回默认值 def。
+// check 不为空时检查回答，不通过则显示原因并重新询问
+func (p *setupPrompter) ask(label, def string, check func(string) error) string {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			// 输入已结束（如 Ctrl-D 或标准输入不是终端），不生成不完整的配置文件
+			fmt.Fprintln(p.out)
+			log.Fatal(tr(msgSetupAborted))
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if check == nil {
+			return answer
+		}
+		if err := check(answer); err != nil {
+			fmt.Fprintln(p.out, "  "+err.Error())
+			continue
+		}
+		return answer
+	}
+}
+
+// confirm 询问是或否，直接回车时返回默认值 def
+func (p *setupPrompter) confirm(label string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	var result bool
+	p.ask(label+" ("+hint+")", "", func(s string) error {
+		switch strings.ToLower(s) {
+		case "":
+			result = def
+		case "y", "yes":
+			result = true
+		case "n", "no":
+			result = false
+		default:
+			return errors.New(tr(msgSetupYesNo))
+		}
+		return nil
+	})
+	return result
+}
+
+// required 检查回答不为空
+func required(s string) error {
+	if s == "" {
+		return errors.New(tr(msgSetupRequired))
+	}
+	return nil
+}
+
+// cmdSetup 交互式生成配置文件：询问连接信息并通过列举存储桶验证，选择存储桶和保留策略后，
+// 将回答填入与 init 命令相同的示例配置，其余配置项保留默认值和说明
+func cmdSetup(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "config.yaml", tr(msgFlagSetupConfig))
+	force := fs.Bool("force", false, tr(msgFlagOverwrite))
+	return func() {
+		// 在询问之前检查，避免回答完所有问题后才发现无法写入
+		if err := checkConfigPath(*configPath, *force); err != nil {
+			log.Fatal(err)
+		}
+		doc := &yaml.Node{}
+		if err := yaml.Unmarshal(exampleConfig, doc); err != nil {
+			log.Fatal(tr(msgInitFailed, err))
+		}
+		root := doc.Content[0]
+		minioNode := config.MappingValue(root, "minio")
+		cleanupNode := config.MappingValue(root, "cleanup")
+
+		p := &setupPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		fmt.Println(tr(msgSetupIntro))
+
+		cfg := &config.Config{}
+		var buckets []string
+		for {
+			endpoint := p.ask(tr(msgSetupEndpoint), cfg.Minio.Endpoint, required)
+			// 允许直接粘贴带协议的地址，协议决定是否使用 HTTPS
+			useSSL := true
+			if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+				endpoint, useSSL = rest, false
+			} else if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+				endpoint = rest
+			}
+			cfg.Minio.Endpoint = strings.TrimSuffix(endpoint, "/")
+			cfg.Minio.UseSSL = p.confirm(tr(msgSetupUseSSL), useSSL)
+			cfg.Minio.AccessKeyID = p.ask(tr(msgSetupAccessKey), cfg.Minio.AccessKeyID, nil)
+			if cfg.Minio.AccessKeyID != "" {
+				cfg.Minio.SecretAccessKey = p.ask(tr(msgSetupSecretKey), "", required)
+			} else {
+				cfg.Minio.SecretAccessKey = ""
+			}
+
+			fmt.Println(tr(msgSetupConnecting, cfg.Minio.Endpoint))
+			names, err := setupListBuckets(cfg)
+			if err == nil {
+				buckets = names
+				break
+			}
+			fmt.Println(tr(msgSetupConnectFailed, err))
+			if !p.confirm(tr(msgSetupRetry), true) {
+				// 不验证连接信息，存储桶名称也无法检查
+				buckets = nil
+				break
+			}
+		}
+
+		if buckets != nil {
+			fmt.Println(tr(msgSetupBuckets, len(buckets)))
+			for i, name := range buckets {
+				fmt.Printf("  %d) %s\n", i+1, name)
+			}
+		}
+		bucket := p.ask(tr(msgSetupBucket), "", func(s string) error {
+			if err := required(s); err != nil || len(buckets) == 0 {
+				return err
+			}
+			if n, err := strconv.Atoi(s); err == nil && n >= 1 && n <= len(buckets) {
+				return nil
+			}
+			if !slices.Contains(buckets, s) {
+				return errors.New(tr(msgSetupNoBucket, s))
+			}
+			return nil
+		})
+		if n, err := strconv.Atoi(bucket); err == nil && n >= 1 && n <= len(buckets) {
+			bucket = buckets[n-1]
+		}
+
+		// 保留策略的默认值取示例配置中的取值
+		maxAge := p.ask(tr(msgSetupMaxAge), config.MappingValue(cleanupNode, "maxAge").Value, func(s string) error {
+			r, err := rules.ParseRetention(s)
+			if err == nil && r <= 0 {
+				err = errors.New(tr(msgValidatePositive, "cleanup.maxAge", s))
+			}
+			return err
+		})
+		minSize := p.ask(tr(msgSetupMinSize), config.MappingValue(cleanupNode, "minSize").Value, func(s string) error {
+			_, err := rules.ParseByteSize(s)
+			return err
+		})
+		dryRun := p.confirm(tr(msgSetupDryRun), true)
+
+		data, err := replaceValues(exampleConfig, []valueReplacement{
+			{config.MappingValue(minioNode, "endpoint"), cfg.Minio.Endpoint},
+			{config.MappingValue(minioNode, "useSSL"), cfg.Minio.UseSSL},
+			{config.MappingValue(minioNode, "accessKeyId"), cfg.Minio.AccessKeyID},
+			{config.MappingValue(minioNode, "secretAccessKey"), cfg.Minio.SecretAccessKey},
+			{config.MappingValue(minioNode, "bucket"), bucket},
+			{config.MappingValue(cleanupNode, "maxAge"), maxAge},
+			{config.MappingValue(cleanupNode, "minSize"), minSize},
+			{config.MappingValue(cleanupNode, "dryRun"), dryRun},
+		})
+		if err != nil {
+			log.Fatal(tr(msgInitFailed, err))
+		}
+		if err := writeConfigFile(*configPath, data, *force); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(tr(msgSetupDone, *configPath))
+	}
+}
+
+// setupListBuckets 按向导中填写的连接信息创建客户端并列举存储桶，用于验证地址和访问密钥
+func setupListBuckets(cfg *config.Config) ([]string, error) {
+	config.SetDefaults(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+	return cleaner.ListBuckets(ctx, cfg)
+}
+
+// valueReplacement 为示例配置中要替换的取值
+type valueReplacement struct {
+	node  *yaml.Node
+	value any
+}
+
+// replaceValues 在示例配置原文中逐行替换取值，保留原有的格式、空行和其后的说明注释。
+// 示例配置中要替换的都是单行取值，取值之后只有以 " #" 开头的注释
+func replaceValues(src []byte, replacements []valueReplacement) ([]byte, error) {
+	lines := strings.Split(string(src), "\n")
+	for _, r := range replacements {
+		value, err := yaml.Marshal(r.value)
+		if err != nil {
+			return nil, err
+		}
+		line := lines[r.node.Line-1]
+		start := r.node.Column - 1
+		end := len(line)
+		if i := strings.Index(line[start:], " #"); i >= 0 {
+			end = start + len(strings.TrimRight(line[start:start+i], " "))
+		}
+		lines[r.node.Line-1] = line[:start] + strings.TrimSuffix(string(value), "\n") + line[end:]
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fjcanyue/minio-cleaner/cleaner"
+	"github.com/fjcanyue/minio-cleaner/config"
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// cmdValidate 实现 validate 命令：严格解析配置文件并检查取值，不连接 MinIO。
+// 配置了 profiles 时检查合并了各个 profile 后的配置。发现问题时逐条列出并以非零状态码退出
+func cmdValidate(fs *flag.FlagSet) func() {
+	configPath := fs.String("config", "config.yaml", tr(msgFlagConfig))
+	profile := fs.String("profile", "", tr(msgFlagCheckProfile))
+	return func() {
+		problems, err := cleaner.ValidateFile(*configPath, *profile)
+		if err != nil {
+			log.Fatal(tr(msgConfigReadFailed, err))
+		}
+		// 结果与问题的描述一样使用配置文件中的 cleanup.language，无法解析的语言已列在问题中
+		if cfg, err := config.Load(*configPath, config.ProfileName(*profile)); err == nil {
+			i18n.SetLanguage(cfg.Cleanup.Language)
+		}
+		if len(problems) == 0 {
+			fmt.Println(tr(msgValidateOK, *configPath))
+			return
+		}
+		fmt.Println(tr(msgValidateFailed, *configPath, len(problems)))
+		for _, p := range problems {
+			fmt.Println("  - " + p)
+		}
+		os.Exit(1)
+	}
+}
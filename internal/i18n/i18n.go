@@ -0,0 +1,60 @@
+// Package i18n 管理日志和命令行输出的消息语言。各包各自维护消息目录，
+// 通过 Catalogs.Format 按每次运行配置的语言或命令行程序设置的语言格式化消息
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Catalogs 为各语言的消息目录，键为语言，值为消息 ID 到格式串的映射
+type Catalogs[ID ~string] map[string]map[ID]string
+
+// Format 按语言 lang 格式化消息，lang 为空时使用 SetLanguage 设置的语言（默认中文），缺失的翻译回退到中文
+func (c Catalogs[ID]) Format(lang string, id ID, args ...any) string {
+	base := baseLanguage(lang)
+	if base == "" {
+		base, _ = language.Load().(string)
+	}
+	format, ok := c[base][id]
+	if !ok {
+		format = c["zh"][id]
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// language 为命令行程序设置的消息语言，未设置时使用中文。读写都是原子的
+var language atomic.Value
+
+// baseLanguage 去掉语言的地区部分，如 zh-CN、en_US 分别为 zh、en
+func baseLanguage(lang string) string {
+	base := strings.ToLower(lang)
+	if i := strings.IndexAny(base, "-_"); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// ParseLanguage 返回语言对应的消息目录，支持 zh、en 及 zh-CN、en_US 等带地区的写法
+func ParseLanguage(lang string) (string, error) {
+	base := baseLanguage(lang)
+	if _, ok := catalogs[base]; !ok {
+		return "", errors.New(tr(msgBadLanguage, lang))
+	}
+	return base, nil
+}
+
+// SetLanguage 设置命令行程序的消息语言，lang 为空时不改变
+func SetLanguage(lang string) error {
+	if lang == "" {
+		return nil
+	}
+	base, err := ParseLanguage(lang)
+	if err != nil {
+		return err
+	}
+	language.Store(base)
+	return nil
+}
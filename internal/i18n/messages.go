@@ -0,0 +1,23 @@
+package i18n
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgBadLanguage msgID = "config.badLanguage"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = Catalogs[msgID]{
+	"zh": {
+		msgBadLanguage: "不支持的语言: %s",
+	},
+	"en": {
+		msgBadLanguage: "unsupported language: %s",
+	},
+}
+
+// tr 按命令行程序设置的语言格式化消息，缺失的翻译回退到中文
+func tr(id msgID, args ...any) string {
+	return catalogs.Format("", id, args...)
+}
@@ -0,0 +1,708 @@
+// minio-cleaner 守护模式的 gRPC 控制服务，配置 daemon.grpc.addr 后启用。
+// 同时进行的运行数不超过 daemon.maxConcurrentRuns，定时运行和通过 StartRun 启动的运行共用状态。
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: minio_cleaner/v1/control.proto
+
+package cleanerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StartRunRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 以预览模式运行，只列出待清理对象。未设置时为 true，需要明确设置为 false 才删除对象；
+	// 配置文件中 cleanup.dryRun 为 true 时总是预览
+	DryRun *bool `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3,oneof" json:"dry_run,omitempty"`
+	// 只清理这些存储桶，每项写成 <集群名称>/<存储桶>，为空时清理全部目标
+	Buckets       []string `protobuf:"bytes,2,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRunRequest) Reset() {
+	*x = StartRunRequest{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRunRequest) ProtoMessage() {}
+
+func (x *StartRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRunRequest.ProtoReflect.Descriptor instead.
+func (*StartRunRequest) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StartRunRequest) GetDryRun() bool {
+	if x != nil && x.DryRun != nil {
+		return *x.DryRun
+	}
+	return false
+}
+
+func (x *StartRunRequest) GetBuckets() []string {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+type StartRunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Run           *RunStatus             `protobuf:"bytes,1,opt,name=run,proto3" json:"run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRunResponse) Reset() {
+	*x = StartRunResponse{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRunResponse) ProtoMessage() {}
+
+func (x *StartRunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRunResponse.ProtoReflect.Descriptor instead.
+func (*StartRunResponse) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StartRunResponse) GetRun() *RunStatus {
+	if x != nil {
+		return x.Run
+	}
+	return nil
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetStatusRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type CancelRunRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRunRequest) Reset() {
+	*x = CancelRunRequest{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRunRequest) ProtoMessage() {}
+
+func (x *CancelRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRunRequest.ProtoReflect.Descriptor instead.
+func (*CancelRunRequest) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CancelRunRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type StreamEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StreamEventsRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type RunStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	RunId string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	// queued、running、succeeded、failed 或 canceled
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// schedule（守护模式定时运行）、grpc 或 api（通过 REST 接口提交的任务）
+	Trigger string `protobuf:"bytes,3,opt,name=trigger,proto3" json:"trigger,omitempty"`
+	DryRun  bool   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Unix 毫秒时间戳，运行未开始时 started_at 为 0，未结束时 finished_at 为 0
+	StartedAt      int64 `protobuf:"varint,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt     int64 `protobuf:"varint,6,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	ProcessedFiles int64 `protobuf:"varint,7,opt,name=processed_files,json=processedFiles,proto3" json:"processed_files,omitempty"`
+	// 预览模式下符合规则的文件数
+	MatchedFiles int64  `protobuf:"varint,8,opt,name=matched_files,json=matchedFiles,proto3" json:"matched_files,omitempty"`
+	DeletedFiles int64  `protobuf:"varint,9,opt,name=deleted_files,json=deletedFiles,proto3" json:"deleted_files,omitempty"`
+	DeletedBytes int64  `protobuf:"varint,10,opt,name=deleted_bytes,json=deletedBytes,proto3" json:"deleted_bytes,omitempty"`
+	FailedFiles  int64  `protobuf:"varint,11,opt,name=failed_files,json=failedFiles,proto3" json:"failed_files,omitempty"`
+	Error        string `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	// 运行结束后的结果文档，与 run -output json 相同
+	ReportJson    string `protobuf:"bytes,13,opt,name=report_json,json=reportJson,proto3" json:"report_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunStatus) Reset() {
+	*x = RunStatus{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStatus) ProtoMessage() {}
+
+func (x *RunStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStatus.ProtoReflect.Descriptor instead.
+func (*RunStatus) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RunStatus) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *RunStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *RunStatus) GetTrigger() string {
+	if x != nil {
+		return x.Trigger
+	}
+	return ""
+}
+
+func (x *RunStatus) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *RunStatus) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *RunStatus) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return 0
+}
+
+func (x *RunStatus) GetProcessedFiles() int64 {
+	if x != nil {
+		return x.ProcessedFiles
+	}
+	return 0
+}
+
+func (x *RunStatus) GetMatchedFiles() int64 {
+	if x != nil {
+		return x.MatchedFiles
+	}
+	return 0
+}
+
+func (x *RunStatus) GetDeletedFiles() int64 {
+	if x != nil {
+		return x.DeletedFiles
+	}
+	return 0
+}
+
+func (x *RunStatus) GetDeletedBytes() int64 {
+	if x != nil {
+		return x.DeletedBytes
+	}
+	return 0
+}
+
+func (x *RunStatus) GetFailedFiles() int64 {
+	if x != nil {
+		return x.FailedFiles
+	}
+	return 0
+}
+
+func (x *RunStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *RunStatus) GetReportJson() string {
+	if x != nil {
+		return x.ReportJson
+	}
+	return ""
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// progress、object 或 finished
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Unix 毫秒时间戳
+	Time int64 `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	// progress 和 finished 事件的运行状态
+	Status *RunStatus `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// object 事件处理的对象
+	Object        *ObjectEvent `protobuf:"bytes,4,opt,name=object,proto3" json:"object,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Event) GetStatus() *RunStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *Event) GetObject() *ObjectEvent {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+// ObjectEvent 与 -output jsonl 输出的一行相同
+type ObjectEvent struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Cluster      string                 `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Bucket       string                 `protobuf:"bytes,2,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Key          string                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Size         int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	LastModified int64                  `protobuf:"varint,5,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	VersionId    string                 `protobuf:"bytes,6,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	Rule         string                 `protobuf:"bytes,7,opt,name=rule,proto3" json:"rule,omitempty"`
+	// match（预览模式）或 delete
+	Action        string `protobuf:"bytes,8,opt,name=action,proto3" json:"action,omitempty"`
+	Error         string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectEvent) Reset() {
+	*x = ObjectEvent{}
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectEvent) ProtoMessage() {}
+
+func (x *ObjectEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_minio_cleaner_v1_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectEvent.ProtoReflect.Descriptor instead.
+func (*ObjectEvent) Descriptor() ([]byte, []int) {
+	return file_minio_cleaner_v1_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ObjectEvent) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ObjectEvent) GetLastModified() int64 {
+	if x != nil {
+		return x.LastModified
+	}
+	return 0
+}
+
+func (x *ObjectEvent) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ObjectEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_minio_cleaner_v1_control_proto protoreflect.FileDescriptor
+
+const file_minio_cleaner_v1_control_proto_rawDesc = "" +
+	"\n" +
+	"\x1eminio_cleaner/v1/control.proto\x12\x10minio_cleaner.v1\"U\n" +
+	"\x0fStartRunRequest\x12\x1c\n" +
+	"\adry_run\x18\x01 \x01(\bH\x00R\x06dryRun\x88\x01\x01\x12\x18\n" +
+	"\abuckets\x18\x02 \x03(\tR\abucketsB\n" +
+	"\n" +
+	"\b_dry_run\"A\n" +
+	"\x10StartRunResponse\x12-\n" +
+	"\x03run\x18\x01 \x01(\v2\x1b.minio_cleaner.v1.RunStatusR\x03run\")\n" +
+	"\x10GetStatusRequest\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\")\n" +
+	"\x10CancelRunRequest\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\",\n" +
+	"\x13StreamEventsRequest\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\x9d\x03\n" +
+	"\tRunStatus\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x18\n" +
+	"\atrigger\x18\x03 \x01(\tR\atrigger\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\x03R\tstartedAt\x12\x1f\n" +
+	"\vfinished_at\x18\x06 \x01(\x03R\n" +
+	"finishedAt\x12'\n" +
+	"\x0fprocessed_files\x18\a \x01(\x03R\x0eprocessedFiles\x12#\n" +
+	"\rmatched_files\x18\b \x01(\x03R\fmatchedFiles\x12#\n" +
+	"\rdeleted_files\x18\t \x01(\x03R\fdeletedFiles\x12#\n" +
+	"\rdeleted_bytes\x18\n" +
+	" \x01(\x03R\fdeletedBytes\x12!\n" +
+	"\ffailed_files\x18\v \x01(\x03R\vfailedFiles\x12\x14\n" +
+	"\x05error\x18\f \x01(\tR\x05error\x12\x1f\n" +
+	"\vreport_json\x18\r \x01(\tR\n" +
+	"reportJson\"\x9b\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\x03R\x04time\x123\n" +
+	"\x06status\x18\x03 \x01(\v2\x1b.minio_cleaner.v1.RunStatusR\x06status\x125\n" +
+	"\x06object\x18\x04 \x01(\v2\x1d.minio_cleaner.v1.ObjectEventR\x06object\"\xeb\x01\n" +
+	"\vObjectEvent\x12\x18\n" +
+	"\acluster\x18\x01 \x01(\tR\acluster\x12\x16\n" +
+	"\x06bucket\x18\x02 \x01(\tR\x06bucket\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12#\n" +
+	"\rlast_modified\x18\x05 \x01(\x03R\flastModified\x12\x1d\n" +
+	"\n" +
+	"version_id\x18\x06 \x01(\tR\tversionId\x12\x12\n" +
+	"\x04rule\x18\a \x01(\tR\x04rule\x12\x16\n" +
+	"\x06action\x18\b \x01(\tR\x06action\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error2\xca\x02\n" +
+	"\aControl\x12Q\n" +
+	"\bStartRun\x12!.minio_cleaner.v1.StartRunRequest\x1a\".minio_cleaner.v1.StartRunResponse\x12L\n" +
+	"\tGetStatus\x12\".minio_cleaner.v1.GetStatusRequest\x1a\x1b.minio_cleaner.v1.RunStatus\x12L\n" +
+	"\tCancelRun\x12\".minio_cleaner.v1.CancelRunRequest\x1a\x1b.minio_cleaner.v1.RunStatus\x12P\n" +
+	"\fStreamEvents\x12%.minio_cleaner.v1.StreamEventsRequest\x1a\x17.minio_cleaner.v1.Event0\x01BDZBgithub.com/fjcanyue/minio-cleaner/proto/minio_cleaner/v1;cleanerv1b\x06proto3"
+
+var (
+	file_minio_cleaner_v1_control_proto_rawDescOnce sync.Once
+	file_minio_cleaner_v1_control_proto_rawDescData []byte
+)
+
+func file_minio_cleaner_v1_control_proto_rawDescGZIP() []byte {
+	file_minio_cleaner_v1_control_proto_rawDescOnce.Do(func() {
+		file_minio_cleaner_v1_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_minio_cleaner_v1_control_proto_rawDesc), len(file_minio_cleaner_v1_control_proto_rawDesc)))
+	})
+	return file_minio_cleaner_v1_control_proto_rawDescData
+}
+
+var file_minio_cleaner_v1_control_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_minio_cleaner_v1_control_proto_goTypes = []any{
+	(*StartRunRequest)(nil),     // 0: minio_cleaner.v1.StartRunRequest
+	(*StartRunResponse)(nil),    // 1: minio_cleaner.v1.StartRunResponse
+	(*GetStatusRequest)(nil),    // 2: minio_cleaner.v1.GetStatusRequest
+	(*CancelRunRequest)(nil),    // 3: minio_cleaner.v1.CancelRunRequest
+	(*StreamEventsRequest)(nil), // 4: minio_cleaner.v1.StreamEventsRequest
+	(*RunStatus)(nil),           // 5: minio_cleaner.v1.RunStatus
+	(*Event)(nil),               // 6: minio_cleaner.v1.Event
+	(*ObjectEvent)(nil),         // 7: minio_cleaner.v1.ObjectEvent
+}
+var file_minio_cleaner_v1_control_proto_depIdxs = []int32{
+	5, // 0: minio_cleaner.v1.StartRunResponse.run:type_name -> minio_cleaner.v1.RunStatus
+	5, // 1: minio_cleaner.v1.Event.status:type_name -> minio_cleaner.v1.RunStatus
+	7, // 2: minio_cleaner.v1.Event.object:type_name -> minio_cleaner.v1.ObjectEvent
+	0, // 3: minio_cleaner.v1.Control.StartRun:input_type -> minio_cleaner.v1.StartRunRequest
+	2, // 4: minio_cleaner.v1.Control.GetStatus:input_type -> minio_cleaner.v1.GetStatusRequest
+	3, // 5: minio_cleaner.v1.Control.CancelRun:input_type -> minio_cleaner.v1.CancelRunRequest
+	4, // 6: minio_cleaner.v1.Control.StreamEvents:input_type -> minio_cleaner.v1.StreamEventsRequest
+	1, // 7: minio_cleaner.v1.Control.StartRun:output_type -> minio_cleaner.v1.StartRunResponse
+	5, // 8: minio_cleaner.v1.Control.GetStatus:output_type -> minio_cleaner.v1.RunStatus
+	5, // 9: minio_cleaner.v1.Control.CancelRun:output_type -> minio_cleaner.v1.RunStatus
+	6, // 10: minio_cleaner.v1.Control.StreamEvents:output_type -> minio_cleaner.v1.Event
+	7, // [7:11] is the sub-list for method output_type
+	3, // [3:7] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_minio_cleaner_v1_control_proto_init() }
+func file_minio_cleaner_v1_control_proto_init() {
+	if File_minio_cleaner_v1_control_proto != nil {
+		return
+	}
+	file_minio_cleaner_v1_control_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_minio_cleaner_v1_control_proto_rawDesc), len(file_minio_cleaner_v1_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_minio_cleaner_v1_control_proto_goTypes,
+		DependencyIndexes: file_minio_cleaner_v1_control_proto_depIdxs,
+		MessageInfos:      file_minio_cleaner_v1_control_proto_msgTypes,
+	}.Build()
+	File_minio_cleaner_v1_control_proto = out.File
+	file_minio_cleaner_v1_control_proto_goTypes = nil
+	file_minio_cleaner_v1_control_proto_depIdxs = nil
+}
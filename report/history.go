@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// historyBucket 为历史数据库中保存运行记录的 bbolt bucket 名称
+var historyBucket = []byte("runs")
+
+// HistoryRecord 为历史数据库中的一次运行记录
+type HistoryRecord struct {
+	RunID     string     `json:"runId"`
+	StartTime time.Time  `json:"startTime"`
+	Bucket    string     `json:"bucket"`
+	Config    any        `json:"config"`
+	Report    *RunReport `json:"report,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+func openHistory(path string, readOnly bool) (*bolt.DB, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+}
+
+func SaveHistory(path string, rec HistoryRecord) error {
+	db, err := openHistory(path, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	// 运行 ID 以开始时间开头，按键排序即按时间排序
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(historyBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(rec.RunID), data)
+	})
+}
+
+// ListHistory 按时间从新到旧返回不早于 since 的运行记录，limit 为 0 时不限制数量
+func ListHistory(path string, since time.Time, limit int) ([]HistoryRecord, error) {
+	db, err := openHistory(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var records []HistoryRecord
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var rec HistoryRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			if rec.StartTime.Before(since) {
+				break
+			}
+			records = append(records, rec)
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+func GetHistory(path, runID string) (*HistoryRecord, error) {
+	db, err := openHistory(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var rec *HistoryRecord
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(runID))
+		if v == nil {
+			return nil
+		}
+		rec = &HistoryRecord{}
+		return json.Unmarshal(v, rec)
+	})
+	if err == nil && rec == nil {
+		err = errors.New(tr("", msgHistoryNotFound, runID))
+	}
+	return rec, err
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"tr": func(id string, args ...any) string { return tr("", msgID(id), args...) },
+	"size": func(n int64) string {
+		return fmt.Sprintf("%.2f MB", float64(n)/1024/1024)
+	},
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"percent": func(n int64, ages []Age) float64 {
+		max := MaxAgeBytes(ages)
+		if max == 0 {
+			return 0
+		}
+		return float64(n) / float64(max) * 100
+	},
+	"prefix": func(p string) string {
+		if p == "" {
+			return "/"
+		}
+		return p
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{tr "html.title"}} - {{.Bucket}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "Microsoft YaHei", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 10px; text-align: left; }
+th { background: #f2f2f2; }
+td.num { text-align: right; }
+.bar { background: #4a90d9; height: 1em; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>{{tr "html.title"}} - {{.Bucket}}</h1>
+
+<h2>{{tr "html.summary"}}</h2>
+<table>
+<tr><th>{{tr "html.startTime"}}</th><td>{{time .StartTime}}</td></tr>
+<tr><th>{{tr "html.endTime"}}</th><td>{{time .EndTime}}</td></tr>
+<tr><th>{{tr "html.mode"}}</th><td>{{if .DryRun}}{{tr "html.dryRun"}}{{else}}{{tr "html.delete"}}{{end}}</td></tr>
+<tr><th>{{tr "html.totalFiles"}}</th><td class="num">{{.TotalFiles}}</td></tr>
+<tr><th>{{tr "html.deletedFiles"}}</th><td class="num">{{.DeletedFiles}}</td></tr>
+<tr><th>{{tr "html.deletedBytes"}}</th><td class="num">{{size .DeletedBytes}}</td></tr>
+{{with .EstimatedSavings}}<tr><th>{{tr "html.savings"}}</th><td class="num">{{printf "%.2f" .Monthly}} {{.Currency}}</td></tr>
+{{end}}<tr><th>{{tr "html.errorCount"}}</th><td class="num">{{.ErrorCount}}</td></tr>
+</table>
+
+<h2>{{tr "html.rules"}}</h2>
+<table>
+<tr><th>{{tr "html.rule"}}</th><th>{{tr "html.prefix"}}</th><th>{{tr "html.matchedFiles"}}</th><th>{{tr "html.matchedBytes"}}</th><th>{{tr "html.deletedFiles"}}</th><th>{{tr "html.deletedBytes"}}</th></tr>
+{{range .Rules}}<tr><td>{{.Name}}</td><td>{{prefix .Prefix}}</td><td class="num">{{.MatchedFiles}}</td><td class="num">{{size .MatchedBytes}}</td><td class="num">{{.DeletedFiles}}</td><td class="num">{{size .DeletedBytes}}</td></tr>
+{{end}}</table>
+
+<h2>{{tr "html.prefixes"}}</h2>
+{{if .Prefixes}}<table>
+<tr><th>{{tr "html.prefix"}}</th><th>{{tr "html.scannedFiles"}}</th><th>{{tr "html.scannedBytes"}}</th><th>{{tr "html.matchedFiles"}}</th><th>{{tr "html.matchedBytes"}}</th><th>{{tr "html.deletedFiles"}}</th><th>{{tr "html.deletedBytes"}}</th></tr>
+{{range .Prefixes}}<tr><td>{{prefix .Prefix}}</td><td class="num">{{.ScannedFiles}}</td><td class="num">{{size .ScannedBytes}}</td><td class="num">{{.MatchedFiles}}</td><td class="num">{{size .MatchedBytes}}</td><td class="num">{{.DeletedFiles}}</td><td class="num">{{size .DeletedBytes}}</td></tr>
+{{end}}</table>{{else}}<p>{{tr "html.none"}}</p>{{end}}
+
+<h2>{{tr "html.topPrefixes"}}</h2>
+{{if .TopPrefixes}}<table>
+<tr><th>{{tr "html.prefix"}}</th><th>{{tr "html.files"}}</th><th>{{tr "html.bytes"}}</th></tr>
+{{range .TopPrefixes}}<tr><td>{{prefix .Prefix}}</td><td class="num">{{.Files}}</td><td class="num">{{size .Bytes}}</td></tr>
+{{end}}</table>{{else}}<p>{{tr "html.none"}}</p>{{end}}
+
+<h2>{{tr "html.largestObjects"}}</h2>
+{{if .LargestObjects}}<table>
+<tr><th>{{tr "html.key"}}</th><th>{{tr "html.bytes"}}</th><th>{{tr "html.lastModified"}}</th><th>{{tr "html.rule"}}</th></tr>
+{{range .LargestObjects}}<tr><td>{{.Key}}</td><td class="num">{{size .Size}}</td><td>{{time .LastModified}}</td><td>{{.Rule}}</td></tr>
+{{end}}</table>{{else}}<p>{{tr "html.none"}}</p>{{end}}
+
+{{if .AgeHistogram}}<h2>{{tr "html.ageHistogram"}}</h2>
+<table>
+<tr><th>{{tr "html.age"}}</th><th>{{tr "html.files"}}</th><th>{{tr "html.bytes"}}</th><th style="width: 300px"></th></tr>
+{{$ages := .AgeHistogram}}{{range .AgeHistogram}}<tr><td>{{.Age}}</td><td class="num">{{.Files}}</td><td class="num">{{size .Bytes}}</td><td><div class="bar" style="width: {{percent .Bytes $ages | printf "%.1f"}}%"></div></td></tr>
+{{end}}</table>
+{{end}}
+<h2>{{tr "html.errors"}}</h2>
+{{if .Errors}}<ul>
+{{range .Errors}}<li class="error">{{.}}</li>
+{{end}}</ul>{{else}}<p>{{tr "html.none"}}</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML 将运行汇总渲染为便于人工阅读的 HTML 报告，lang 为报告使用的语言
+func WriteHTML(path string, report *RunReport, lang string) error {
+	t, err := htmlReportTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(template.FuncMap{
+		"tr": func(id string, args ...any) string { return tr(lang, msgID(id), args...) },
+	})
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, report); err != nil {
+		return err
+	}
+	return WriteFile(path, buf.Bytes())
+}
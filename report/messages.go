@@ -0,0 +1,27 @@
+package report
+
+import (
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgHistoryNotFound msgID = "history.notFound"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = i18n.Catalogs[msgID]{
+	"zh": {
+		msgHistoryNotFound: "未找到运行记录: %s",
+	},
+	"en": {
+		msgHistoryNotFound: "Run not found: %s",
+	},
+}
+
+// tr 按语言 lang 格式化消息，lang 为空时使用命令行程序设置的语言，缺失的翻译回退到中文
+func tr(lang string, id msgID, args ...any) string {
+	return catalogs.Format(lang, id, args...)
+}
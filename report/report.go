@@ -0,0 +1,175 @@
+// Package report 定义每次运行的汇总报告，负责写入 JSON 和 HTML 报告以及运行历史数据库
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fjcanyue/minio-cleaner/config"
+)
+
+// RunReport 是每次运行结束后输出的机器可读汇总
+type RunReport struct {
+	RunID          string    `json:"runId"`
+	StartTime      time.Time `json:"startTime"`
+	EndTime        time.Time `json:"endTime"`
+	ConfigHash     string    `json:"configHash"`
+	Cluster        string    `json:"cluster,omitempty"` // 配置了 clusters 时为集群名称
+	Bucket         string    `json:"bucket"`
+	DryRun         bool      `json:"dryRun"`
+	TotalFiles     int64     `json:"totalFiles"`
+	ProcessedFiles int64     `json:"processedFiles"`
+	DeletedFiles   int64     `json:"deletedFiles"`
+	DeletedBytes   int64     `json:"deletedBytes"`
+	FailedFiles    int64     `json:"failedFiles"` // 删除失败的文件数
+	FailedBytes    int64     `json:"failedBytes"`
+	ChangedFiles   int64     `json:"changedFiles,omitempty"`      // 删除前发现已被覆盖写入而跳过的文件数
+	Unreplicated   int64     `json:"unreplicatedFiles,omitempty"` // 尚未复制到目标站点而跳过的文件数
+	PluginKept     int64     `json:"pluginKeptFiles,omitempty"`   // 过滤插件决定保留的文件数
+	HookVetoed     int64     `json:"hookVetoedFiles,omitempty"`   // 删除前钩子否决删除的文件数
+	TTLKept        int64     `json:"ttlKeptFiles,omitempty"`      // 超过 maxAge 但对象自带的过期时间未到而保留的文件数
+	ErrorCount     int64     `json:"errorCount"`
+	Retries        int64     `json:"retries"`
+	TimedOut       bool      `json:"timedOut,omitempty"` // 运行超过 cleanup.maxRuntime 而提前停止
+	Errors         []string  `json:"errors"`
+	ManifestFile   string    `json:"manifestFile,omitempty"`
+	PlanObject     string    `json:"planObject,omitempty"` // 计划删除清单的存储桶和对象名
+	CandidatesFile string    `json:"candidatesFile,omitempty"`
+	Rules          []Rule    `json:"rules"`
+
+	// FreeSpaceBefore 和 FreeSpaceAfter 为开启 adminAPI 时清理前后集群所有磁盘的剩余空间（字节）
+	FreeSpaceBefore int64 `json:"freeSpaceBefore,omitempty"`
+	FreeSpaceAfter  int64 `json:"freeSpaceAfter,omitempty"`
+
+	// BucketRemoved 为开启 removeEmptyBuckets 时存储桶清理后为空而被删除。存储桶在写入汇总报告之后才删除，
+	// 该字段只出现在运行返回的结果中，如 -output json、通知和接口
+	BucketRemoved bool `json:"bucketRemoved,omitempty"`
+
+	// APICalls 为运行发出的各类 API 请求数，EstimatedAPICalls 为预览模式下估算的实际删除时的请求数
+	APICalls          APICalls  `json:"apiCalls"`
+	EstimatedAPICalls *APICalls `json:"estimatedApiCalls,omitempty"`
+
+	// EstimatedSavings 为配置了 cost 时估算的每月节省的存储费用，预览模式下按待清理的对象估算
+	EstimatedSavings *Cost `json:"estimatedSavings,omitempty"`
+
+	// Filters 为集成预设和作为库使用时 Config.Filters 中各过滤器的统计
+	Filters []Filter `json:"filters,omitempty"`
+
+	// Verification 为开启 verification 时运行结束后的复查结果
+	Verification *Verification `json:"verification,omitempty"`
+
+	// 以下统计在预览模式下为待清理对象，否则为已删除对象
+	TopPrefixes    []Prefix `json:"topPrefixes"`
+	LargestObjects []Object `json:"largestObjects"`
+
+	// Prefixes 为按 report.prefixDepth 层前缀分组的扫描、匹配和删除统计，按扫描字节数从大到小排列
+	Prefixes []PrefixBreakdown `json:"prefixes"`
+
+	// AgeHistogram 为预览模式下所有已处理对象按年龄区间的分布，用于选择合适的 maxAge
+	AgeHistogram []Age `json:"ageHistogram,omitempty"`
+}
+
+// Prefix 是单个顶级前缀的清理汇总
+type Prefix struct {
+	Prefix string `json:"prefix"`
+	Files  int64  `json:"files"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// PrefixBreakdown 是单个前缀下的扫描、匹配和删除统计
+type PrefixBreakdown struct {
+	Prefix       string `json:"prefix"`
+	ScannedFiles int64  `json:"scannedFiles"`
+	ScannedBytes int64  `json:"scannedBytes"`
+	MatchedFiles int64  `json:"matchedFiles"`
+	MatchedBytes int64  `json:"matchedBytes"`
+	DeletedFiles int64  `json:"deletedFiles"`
+	DeletedBytes int64  `json:"deletedBytes"`
+}
+
+// Object 描述报告中列出的单个对象
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	Rule         string    `json:"rule"`
+}
+
+// Rule 是单条规则的汇总
+type Rule struct {
+	Name         string `json:"name"`
+	Prefix       string `json:"prefix"`
+	MatchedFiles int64  `json:"matchedFiles"`
+	MatchedBytes int64  `json:"matchedBytes"`
+	DeletedFiles int64  `json:"deletedFiles"`
+	DeletedBytes int64  `json:"deletedBytes"`
+}
+
+// WriteFile 写入文件，并自动创建所在目录
+func WriteFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// MaxAgeBytes 返回各年龄区间中最大的字节数
+func MaxAgeBytes(ages []Age) int64 {
+	var max int64
+	for _, a := range ages {
+		if a.Bytes > max {
+			max = a.Bytes
+		}
+	}
+	return max
+}
+
+// Age 是单个年龄区间的文件数和字节数
+type Age struct {
+	Age   string `json:"age"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+}
+
+// APICalls 是各类 API 请求的次数
+type APICalls struct {
+	List   int64   `json:"list"`
+	Head   int64   `json:"head"`
+	Get    int64   `json:"get"`
+	Delete int64   `json:"delete"`
+	Other  int64   `json:"other"`
+	Total  int64   `json:"total"`
+	Cost   float64 `json:"cost,omitempty"` // 配置了 cost.requests 时的请求费用
+}
+
+// Priced 按 cost.requests 计算请求费用
+func (r APICalls) Priced(prices *config.RequestCostConfig) APICalls {
+	r.Cost = (float64(r.List)*prices.List + float64(r.Head+r.Get)*prices.Get +
+		float64(r.Delete)*prices.Delete + float64(r.Other)*prices.Other) / 1000
+	return r
+}
+
+// Cost 是按存储单价估算的每月节省的存储费用
+type Cost struct {
+	Bytes    int64   `json:"bytes"`    // 预览模式下为待清理对象的字节数，否则为已删除对象的字节数
+	Monthly  float64 `json:"monthly"`  // 每月节省的存储费用
+	Currency string  `json:"currency"` // 货币单位
+}
+
+// Filter 为汇总报告中一个过滤器的统计
+type Filter struct {
+	Name      string `json:"name"`
+	Evaluated int64  `json:"evaluated"` // 交给该过滤器判断的文件数
+	KeptFiles int64  `json:"keptFiles"` // 该过滤器决定保留的文件数
+	KeptBytes int64  `json:"keptBytes"`
+}
+
+// Verification 为复查结果，记录在汇总报告的 verification 字段中
+type Verification struct {
+	DeletedChecked int      `json:"deletedChecked"` // 复查的已删除对象数
+	KeptChecked    int      `json:"keptChecked"`    // 复查的未清理对象数
+	StillExists    []string `json:"stillExists"`    // 已删除但仍然存在的对象
+	Missing        []string `json:"missing"`        // 未清理但已不存在的对象
+	Failed         int      `json:"failed"`         // 查询失败、无法确认的对象数
+}
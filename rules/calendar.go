@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Period 为日历周期，规则的 keepLastOf 按它保留每个周期中最新的对象
+type Period string
+
+const (
+	PeriodDay     Period = "day"
+	PeriodWeek    Period = "week"
+	PeriodMonth   Period = "month"
+	PeriodQuarter Period = "quarter"
+	PeriodYear    Period = "year"
+)
+
+// Periods 为 keepLastOf 可选的日历周期
+var Periods = []Period{PeriodDay, PeriodWeek, PeriodMonth, PeriodQuarter, PeriodYear}
+
+// UnmarshalYAML 解析日历周期，取值无效时返回 yaml.TypeError，与其他配置项的错误一起报告
+func (p *Period) UnmarshalYAML(node *yaml.Node) error {
+	v := Period(strings.ToLower(node.Value))
+	if v != "" && !slices.Contains(Periods, v) {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, tr("", msgBadPeriod, node.Value))}}
+	}
+	*p = v
+	return nil
+}
+
+// Start 返回 t 所在周期的开始时间，周期的边界按 t 的时区计算，每周从周一开始
+func (p Period) Start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch p {
+	case PeriodDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case PeriodWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case PeriodQuarter:
+		m = (m-1)/3*3 + 1
+	case PeriodYear:
+		m = time.January
+	}
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// GFSConfig 为规则的 GFS（祖父-父-子）轮换保留：保留最近 daily 天、weekly 周、monthly 月中每天、每周、每月最新的对象
+type GFSConfig struct {
+	Daily   int `yaml:"daily"`   // 保留最近多少天每天最新的对象
+	Weekly  int `yaml:"weekly"`  // 保留最近多少周每周最新的对象，每周从周一开始
+	Monthly int `yaml:"monthly"` // 保留最近多少个月每月最新的对象
+}
+
+// Enabled 返回是否配置了 GFS 保留
+func (g *GFSConfig) Enabled() bool {
+	return g.Daily > 0 || g.Weekly > 0 || g.Monthly > 0
+}
+
+// HasCalendar 返回是否有规则设置了 keepLastOf 或 gfs
+func HasCalendar(rules []Rule) bool {
+	return slices.ContainsFunc(rules, func(r Rule) bool { return r.KeepLastOf != "" || r.GFS.Enabled() })
+}
@@ -0,0 +1,37 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, shanghai) }
+	// 2024-05-15 为周三
+	wed := time.Date(2024, 5, 15, 15, 4, 5, 0, shanghai)
+	tests := []struct {
+		p    Period
+		t    time.Time
+		want time.Time
+	}{
+		{p: PeriodDay, t: wed, want: date(2024, 5, 15)},
+		{p: PeriodWeek, t: wed, want: date(2024, 5, 13)},
+		{p: PeriodWeek, t: time.Date(2024, 5, 19, 23, 59, 0, 0, shanghai), want: date(2024, 5, 13)},
+		{p: PeriodWeek, t: date(2024, 5, 13), want: date(2024, 5, 13)},
+		{p: PeriodWeek, t: time.Date(2024, 1, 2, 8, 0, 0, 0, shanghai), want: date(2024, 1, 1)},
+		{p: PeriodWeek, t: time.Date(2023, 1, 1, 8, 0, 0, 0, shanghai), want: date(2022, 12, 26)},
+		{p: PeriodMonth, t: wed, want: date(2024, 5, 1)},
+		{p: PeriodQuarter, t: wed, want: date(2024, 4, 1)},
+		{p: PeriodQuarter, t: time.Date(2024, 12, 31, 23, 0, 0, 0, shanghai), want: date(2024, 10, 1)},
+		{p: PeriodYear, t: wed, want: date(2024, 1, 1)},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Start(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s.start(%v) = %v, want %v", tt.p, tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	minio "github.com/minio/minio-go/v7"
+)
+
+// ObjectInfo 为列举得到的对象信息，交给过滤器判断
+type ObjectInfo = minio.ObjectInfo
+
+// Decision 为过滤器对一个对象的判断
+type Decision int
+
+const (
+	// Abstain 表示过滤器对该对象不表态，由其他过滤器决定
+	Abstain Decision = iota
+	// Keep 表示保留对象，不删除
+	Keep
+	// Delete 表示同意删除对象
+	Delete
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Keep:
+		return "keep"
+	case Delete:
+		return "delete"
+	}
+	return "abstain"
+}
+
+// Filter 判断符合清理规则的对象是否可以删除。过滤器只能阻止删除：Config.Filters 中任一过滤器返回 Keep 时保留对象，
+// 返回 Delete 或 Abstain 时交给下一个过滤器；不符合清理规则的对象不会交给过滤器。
+// Match 会被多个协程并发调用，且在判断对象组是否完整、删除前检查、确认提示等统计中同样会被调用
+type Filter interface {
+	Match(obj ObjectInfo) Decision
+}
+
+// FilterFunc 将函数转换为 Filter
+type FilterFunc func(obj ObjectInfo) Decision
+
+func (f FilterFunc) Match(obj ObjectInfo) Decision { return f(obj) }
+
+// Chain 依次询问各过滤器，采用第一个不是 Abstain 的判断；全部为 Abstain 时返回 Abstain
+func Chain(filters ...Filter) Filter {
+	return FilterFunc(func(obj ObjectInfo) Decision {
+		for _, f := range filters {
+			if d := f.Match(obj); d != Abstain {
+				return d
+			}
+		}
+		return Abstain
+	})
+}
+
+// All 在任一过滤器返回 Keep 时返回 Keep，否则有过滤器返回 Delete 时返回 Delete；全部为 Abstain 时返回 Abstain
+func All(filters ...Filter) Filter {
+	return FilterFunc(func(obj ObjectInfo) Decision {
+		result := Abstain
+		for _, f := range filters {
+			switch f.Match(obj) {
+			case Keep:
+				return Keep
+			case Delete:
+				result = Delete
+			}
+		}
+		return result
+	})
+}
+
+// Any 在任一过滤器返回 Delete 时返回 Delete，否则有过滤器返回 Keep 时返回 Keep；全部为 Abstain 时返回 Abstain
+func Any(filters ...Filter) Filter {
+	return FilterFunc(func(obj ObjectInfo) Decision {
+		result := Abstain
+		for _, f := range filters {
+			switch f.Match(obj) {
+			case Delete:
+				return Delete
+			case Keep:
+				result = Keep
+			}
+		}
+		return result
+	})
+}
+
+// Named 为过滤器指定名称，用于日志和汇总报告中的 filters 统计。未命名的过滤器按顺序称为 filter1、filter2……
+func Named(name string, f Filter) Filter {
+	return NamedFilter{Name: name, Filter: f}
+}
+
+type NamedFilter struct {
+	Name string
+	Filter
+}
@@ -0,0 +1,38 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+)
+
+// Groups 返回按规则划分对象组的函数：规则配置了 partition 时对象所属的组为其所在的分区目录，
+// 否则为规则前缀之后的前 groupDepth 级目录；规则未设置两者或对象不在相应目录之下时不属于任何组
+func Groups(rules []*Compiled) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		_, r := Match(rules, key)
+		if r == nil {
+			return "", false
+		}
+		if r.Layout != nil {
+			dir, _, ok := r.PartitionOf(key)
+			return dir, ok
+		}
+		if r.GroupDepth <= 0 {
+			return "", false
+		}
+		end := len(r.Prefix)
+		for range r.GroupDepth {
+			i := strings.IndexByte(key[end:], '/')
+			if i < 0 {
+				return "", false
+			}
+			end += i + 1
+		}
+		return key[:end], true
+	}
+}
+
+// HasGroups 返回是否有规则设置了 groupDepth 或 partition
+func HasGroups(rules []Rule) bool {
+	return slices.ContainsFunc(rules, func(r Rule) bool { return r.GroupDepth > 0 || r.Partition != "" })
+}
@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"github.com/fjcanyue/minio-cleaner/internal/i18n"
+)
+
+// msgID 标识消息目录中的一条消息
+type msgID string
+
+const (
+	msgValidatePositive    msgID = "validate.positive"
+	msgBadRetention        msgID = "config.badRetention"
+	msgRetentionTooLong    msgID = "config.retentionTooLong"
+	msgBadByteSize         msgID = "config.badByteSize"
+	msgPartitionInvalid    msgID = "partition.invalid"
+	msgPartitionSegment    msgID = "partition.segment"
+	msgPartitionYear       msgID = "partition.year"
+	msgPartitionGroupDepth msgID = "partition.groupDepth"
+	msgBadPeriod           msgID = "config.badPeriod"
+	msgTTLInvalid          msgID = "ttl.invalid"
+	msgBadUntil            msgID = "config.badUntil"
+)
+
+// catalogs 为各语言的消息目录
+var catalogs = i18n.Catalogs[msgID]{
+	"zh": {
+		msgValidatePositive:    "%s 必须大于 0，当前为 %v",
+		msgBadRetention:        "无效的保留时间: %q，应为不带单位的天数或带单位的时长，如 30d、2w、12h、90m",
+		msgRetentionTooLong:    "保留时间 %q 过长，最长约为 106751 天（292 年）",
+		msgBadByteSize:         "无效的大小: %q，应为字节数或带单位的大小，如 500MB、1.5GiB",
+		msgPartitionInvalid:    "rules[%d].partition %q 无效: %v",
+		msgPartitionSegment:    "%q 需要写成 <分区名>=<日期格式>，如 dt=2006-01-02",
+		msgPartitionYear:       "日期格式需要包含年份 2006",
+		msgPartitionGroupDepth: "rules[%d] 不能同时配置 partition 和 groupDepth，分区本身即为整体清理的单位",
+		msgBadPeriod:           "无效的日历周期: %q，应为 day、week、month、quarter 或 year",
+		msgTTLInvalid:          "无效的过期时间 %q，应为保留时间（如 90d）或日期（如 2025-07-01）",
+		msgBadUntil:            "无效的截止时间: %q，应为日期（如 2025-03-31）或 RFC 3339 时间",
+	},
+	"en": {
+		msgValidatePositive:    "%s must be greater than 0, got %v",
+		msgBadRetention:        "Invalid retention: %q, expected a number of days or a duration such as 30d, 2w, 12h or 90m",
+		msgRetentionTooLong:    "Retention %q is too long, the maximum is about 106751 days (292 years)",
+		msgBadByteSize:         "Invalid size: %q, expected a number of bytes or a size such as 500MB or 1.5GiB",
+		msgPartitionInvalid:    "rules[%d].partition %q is invalid: %v",
+		msgPartitionSegment:    "%q must be written as <name>=<date layout>, e.g. dt=2006-01-02",
+		msgPartitionYear:       "the date layout must contain the year 2006",
+		msgPartitionGroupDepth: "rules[%d] cannot set both partition and groupDepth, each partition is already deleted as a whole",
+		msgBadPeriod:           "Invalid calendar period: %q, expected day, week, month, quarter or year",
+		msgTTLInvalid:          "invalid expiry %q, expected a retention such as 90d or a date such as 2025-07-01",
+		msgBadUntil:            "Invalid date: %q, expected a date such as 2025-03-31 or an RFC 3339 time",
+	},
+}
+
+// tr 按语言 lang 格式化消息，lang 为空时使用命令行程序设置的语言。
+// 解析配置文件时还不知道配置的语言，产生的错误使用命令行程序设置的语言
+func tr(lang string, id msgID, args ...any) string {
+	return catalogs.Format(lang, id, args...)
+}
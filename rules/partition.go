@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"cmp"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// partitionLayout 为规则的 partition 配置，描述对象路径中 Hive 风格的日期分区，如 dt=2024-01-05 或 year=2024/month=01/day=05
+type partitionLayout struct {
+	// names 为各级分区名
+	names []string
+	// layout 为各级日期格式以 / 连接后的 Go 时间格式，与各级分区值以 / 连接后的字符串对应
+	layout string
+	// loc 为解析分区日期的时区，为 nil 时按 UTC 解析
+	loc *time.Location
+}
+
+// ParsePartition 解析 partition 配置 <分区名>=<Go 时间格式>，多级分区用 / 分隔。时间格式需要包含年份 2006。
+// 错误信息使用语言 lang，为空时使用命令行程序设置的语言
+func ParsePartition(s, lang string) (*partitionLayout, error) {
+	l := &partitionLayout{}
+	var layouts []string
+	for _, seg := range strings.Split(strings.Trim(s, "/"), "/") {
+		name, layout, ok := strings.Cut(seg, "=")
+		if !ok || name == "" || layout == "" {
+			return nil, errors.New(tr(lang, msgPartitionSegment, seg))
+		}
+		l.names = append(l.names, name)
+		layouts = append(layouts, layout)
+	}
+	l.layout = strings.Join(layouts, "/")
+	if !strings.Contains(l.layout, "2006") {
+		return nil, errors.New(tr(lang, msgPartitionYear))
+	}
+	return l, nil
+}
+
+// find 在 key 的目录中查找第一个日期可以解析的分区，返回分区目录（含结尾的 /）在 key 中的结束位置和分区的开始时间。
+// 分区值按 Hive 的方式转义（如 %3A），解析前先还原；时间按 cleanup.timezone 解析，未配置时按 UTC 解析
+func (l *partitionLayout) find(key string) (int, time.Time, bool) {
+	if len(l.names) == 0 {
+		return 0, time.Time{}, false
+	}
+	// 最后一段为文件名，不作为分区
+	dirs := strings.Split(key, "/")
+	dirs = dirs[:len(dirs)-1]
+	pos := 0
+	for i := 0; i+len(l.names) <= len(dirs); i++ {
+		if t, ok := l.parse(dirs[i : i+len(l.names)]); ok {
+			return pos + len(strings.Join(dirs[i:i+len(l.names)], "/")) + 1, t, true
+		}
+		pos += len(dirs[i]) + 1
+	}
+	return 0, time.Time{}, false
+}
+
+// parse 解析与各级分区名对应的目录，目录名不符合或日期无法解析时返回 false
+func (l *partitionLayout) parse(dirs []string) (time.Time, bool) {
+	values := make([]string, len(dirs))
+	for i, name := range l.names {
+		v, ok := strings.CutPrefix(dirs[i], name+"=")
+		if !ok {
+			return time.Time{}, false
+		}
+		if u, err := url.PathUnescape(v); err == nil {
+			v = u
+		}
+		values[i] = v
+	}
+	t, err := time.ParseInLocation(l.layout, strings.Join(values, "/"), cmp.Or(l.loc, time.UTC))
+	return t, err == nil
+}
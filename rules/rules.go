@@ -0,0 +1,295 @@
+// Package rules 定义清理规则：保留期限、分区布局、日历周期保留、TTL 和过滤器，
+// 并判断对象应由哪条规则处理、是否应被清理
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Rule 描述一条清理规则，按前缀匹配对象
+type Rule struct {
+	Name    string    `yaml:"name"`    // 规则名称，用于日志和报告
+	Prefix  string    `yaml:"prefix"`  // 对象前缀，为空则匹配所有对象
+	MaxAge  Retention `yaml:"maxAge"`  // 文件最大保留时间，如 30d、12h，不带单位时按天计算
+	MinSize ByteSize  `yaml:"minSize"` // 文件最小大小，如 500MB、1.5GiB，不带单位时按字节计算
+	// GroupDepth 大于 0 时，前缀之后的前 GroupDepth 级目录作为原子的清理单位（如 Thanos 的块目录）：
+	// 目录中的对象全部符合规则时整个目录删除，否则整个目录保留
+	GroupDepth int `yaml:"groupDepth"`
+	// Partition 为对象路径中的日期分区，写法为 <分区名>=<Go 时间格式>，如 dt=2006-01-02，多级分区用 / 分隔。
+	// 配置后按分区日期而不是修改时间判断 maxAge，每个分区作为原子的清理单位，不在分区中的对象不清理
+	Partition string `yaml:"partition"`
+	// KeepLastOf 为 day、week、month、quarter 或 year 时，每个日历周期中最新的对象即使超过 maxAge 也保留，
+	// 如月末的财务导出，或将高频快照稀疏为每天一份
+	KeepLastOf Period `yaml:"keepLastOf"`
+	// GFS 为 GFS 轮换保留，最近若干天、周、月中每个周期最新的对象即使超过 maxAge 也保留
+	GFS GFSConfig `yaml:"gfs"`
+	// TTL 为对象自带的过期时间，配置后对象标签或元数据中的过期时间优先于 maxAge
+	TTL TTLConfig `yaml:"ttl"`
+	// SuspendUntil 为暂停清理的截止日期，如审计期间的 2025-03-31，之前匹配该规则的对象都不清理，
+	// 规则和统计保留，到期后自动恢复
+	SuspendUntil UntilDate `yaml:"suspendUntil"`
+}
+
+// Retention 为文件最大保留时间。配置中可以写带单位的时长，如 30d、2w、12h、90m、1d12h，
+// 不带单位的整数按天计算，与只支持天数的旧配置兼容。在代码中构造配置时直接转换，如 Retention(30 * 24 * time.Hour)
+type Retention time.Duration
+
+const Day = 24 * time.Hour
+
+// maxRetention 为保留时间的上限（约 292 年），更长的取值会使 time.Duration 溢出
+const maxRetention = time.Duration(math.MaxInt64)
+
+// retentionPattern 匹配保留时间中的一段数值和单位
+var retentionPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)(w|d|h|m|s)`)
+
+var retentionUnits = map[string]time.Duration{
+	"w": 7 * Day,
+	"d": Day,
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// ParseRetention 解析保留时间，不带单位的整数按天计算。负数和超过 maxRetention 的取值无效，
+// 在相乘之前检查，避免溢出后得到负数或很短的保留时间
+func ParseRetention(s string) (Retention, error) {
+	s = strings.TrimSpace(s)
+	if days, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if days < 0 {
+			return 0, errors.New(tr("", msgBadRetention, s))
+		}
+		if days > int64(maxRetention/Day) {
+			return 0, errors.New(tr("", msgRetentionTooLong, s))
+		}
+		return Retention(time.Duration(days) * Day), nil
+	}
+	if s == "" {
+		return 0, errors.New(tr("", msgBadRetention, s))
+	}
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		m := retentionPattern.FindStringSubmatchIndex(rest)
+		if m == nil || m[0] != 0 {
+			return 0, errors.New(tr("", msgBadRetention, s))
+		}
+		n, _ := strconv.ParseFloat(rest[m[2]:m[3]], 64)
+		v := n * float64(retentionUnits[rest[m[4]:m[5]]])
+		if v >= float64(maxRetention) || time.Duration(v) > maxRetention-total {
+			return 0, errors.New(tr("", msgRetentionTooLong, s))
+		}
+		total += time.Duration(v)
+		rest = rest[m[1]:]
+	}
+	return Retention(total), nil
+}
+
+// UnmarshalYAML 解析配置中的保留时间，取值无效时返回 yaml.TypeError，与其他配置项的错误一起报告
+func (r *Retention) UnmarshalYAML(node *yaml.Node) error {
+	v, err := ParseRetention(node.Value)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, err)}}
+	}
+	*r = v
+	return nil
+}
+
+// MarshalYAML 整天数输出为整数，与旧配置的写法一致，配置摘要不会因升级而变化
+func (r Retention) MarshalYAML() (any, error) {
+	if time.Duration(r)%Day == 0 {
+		return int64(time.Duration(r) / Day), nil
+	}
+	return r.String(), nil
+}
+
+// String 返回便于阅读的保留时间，整天数显示为 30d 的形式
+func (r Retention) String() string {
+	d := time.Duration(r)
+	if d%Day == 0 {
+		return strconv.FormatInt(int64(d/Day), 10) + "d"
+	}
+	return d.String()
+}
+
+// Before 返回保留时间之前的时间点。整天数按日历日计算，与夏令时切换无关
+func (r Retention) Before(now time.Time) time.Time {
+	d := time.Duration(r)
+	if d%Day == 0 {
+		return now.AddDate(0, 0, -int(d/Day))
+	}
+	// 去掉单调时钟读数，与 AddDate 的结果一样只包含墙上时间，日志中不会输出 m=...
+	return now.Add(-d).Round(0)
+}
+
+// ByteSize 为配置中的字节数。可以写带单位的大小，如 500MB、1.5GiB、64k，单位不区分大小写，
+// 按 1024 进制计算（KB 与 KiB 相同）；不带单位的整数按字节计算，与旧配置兼容。在代码中构造配置时直接转换，如 ByteSize(500 << 20)
+type ByteSize int64
+
+// byteSizePattern 匹配带单位的大小，数值和单位之间可以有空格
+var byteSizePattern = regexp.MustCompile(`^(?i)(\d+(?:\.\d+)?)\s*([kmgtp]?)(i?b)?$`)
+
+// ParseByteSize 解析带单位的大小
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ByteSize(n), nil
+	}
+	m := byteSizePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, errors.New(tr("", msgBadByteSize, s))
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	exp := 0
+	if m[2] != "" {
+		exp = strings.Index("kmgtp", strings.ToLower(m[2])) + 1
+	}
+	return ByteSize(math.Round(n * math.Pow(1024, float64(exp)))), nil
+}
+
+// UnmarshalYAML 解析配置中的大小，取值无效时返回 yaml.TypeError，与其他配置项的错误一起报告
+func (b *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	v, err := ParseByteSize(node.Value)
+	if err != nil {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, err)}}
+	}
+	*b = v
+	return nil
+}
+
+// MarshalYAML 输出字节数，与旧配置的写法一致，配置摘要不会因升级而变化
+func (b ByteSize) MarshalYAML() (any, error) {
+	return int64(b), nil
+}
+
+// DefaultName 为未配置 rules 时由 cleanup 配置生成的默认规则名称
+const DefaultName = "default"
+
+// Compiled 是运行时使用的规则，附带计算好的时间阈值
+type Compiled struct {
+	Rule
+	Threshold time.Time
+	// Floor 为 safety.minObjectAge 对应的时间，修改时间晚于该时间的对象不论规则如何都不清理
+	Floor time.Time
+	// Layout 为解析后的 Partition，未配置时为 nil
+	Layout *partitionLayout
+	// Suspended 为规则在 suspendUntil 之前暂停清理
+	Suspended bool
+}
+
+// PartitionOf 返回对象所在的分区目录（以 / 结尾）和分区的开始时间，对象不在分区中时返回 false
+func (r *Compiled) PartitionOf(key string) (string, time.Time, bool) {
+	end, t, ok := r.Layout.find(key[len(r.Prefix):])
+	if !ok {
+		return "", time.Time{}, false
+	}
+	return key[:len(r.Prefix)+end], t, true
+}
+
+// Check 检查规则中会使 Compile 无法按配置生效的问题：保留时间不大于 0、无法解析的 partition、
+// partition 与 groupDepth 同时配置。加载配置和提交任务时已检查，运行开始时再检查一次，
+// 避免未经检查的配置静默地不清理或误清理对象。错误信息使用语言 lang
+func Check(rules []Rule, lang string) error {
+	for i, r := range rules {
+		if r.MaxAge <= 0 {
+			return errors.New(tr(lang, msgValidatePositive, fmt.Sprintf("rules[%d].maxAge", i), r.MaxAge))
+		}
+		if r.Partition == "" {
+			continue
+		}
+		if _, err := ParsePartition(r.Partition, lang); err != nil {
+			return errors.New(tr(lang, msgPartitionInvalid, i, r.Partition, err))
+		}
+		if r.GroupDepth > 0 {
+			return errors.New(tr(lang, msgPartitionGroupDepth, i))
+		}
+	}
+	return nil
+}
+
+// Compile 计算各规则在 now 时的阈值时间，minObjectAge 为 safety.minObjectAge
+func Compile(rules []Rule, now time.Time, minObjectAge Retention) []*Compiled {
+	compiled := make([]*Compiled, 0, len(rules))
+	for _, r := range rules {
+		c := &Compiled{
+			Rule:      r,
+			Threshold: r.MaxAge.Before(now),
+			Floor:     minObjectAge.Before(now),
+		}
+		if r.Partition != "" {
+			// 无法解析的 partition 在加载配置、提交任务和运行开始时（checkRules）都会报错，这里不会出现；
+			// 仍按不清理任何对象处理
+			c.Layout, _ = ParsePartition(r.Partition, "")
+			if c.Layout == nil {
+				c.Layout = &partitionLayout{}
+			}
+			// 配置了 cleanup.timezone 时 now 使用该时区，分区日期也按该时区解析
+			if loc := now.Location(); loc != time.Local {
+				c.Layout.loc = loc
+			}
+		}
+		if r.SuspendUntil != "" {
+			c.Suspended = now.Before(r.SuspendUntil.end(now.Location()))
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+// Match 按顺序返回第一条前缀匹配对象的规则及其序号，没有匹配时返回 -1 和 nil
+func Match(rules []*Compiled, key string) (int, *Compiled) {
+	for i, r := range rules {
+		if strings.HasPrefix(key, r.Prefix) {
+			return i, r
+		}
+	}
+	return -1, nil
+}
+
+// 对象不需要清理的原因，与日志中的 reason 字段一致
+const (
+	SkipNoRule  = "noRule"
+	SkipMinSize = "minSize"
+	SkipMaxAge  = "maxAge"
+	// SkipMinObjectAge 为对象符合规则，但比 safety.minObjectAge 新
+	SkipMinObjectAge = "minObjectAge"
+	// SkipNoPartition 为规则配置了 partition，但对象不在日期分区中
+	SkipNoPartition = "noPartition"
+)
+
+// Select 查找对象适用的规则并检查大小和时间，对象需要清理时原因为空。
+// 规则配置了 partition 时按分区日期检查 maxAge，safety.minObjectAge 仍按修改时间检查
+func Select(rules []*Compiled, obj minio.ObjectInfo) (int, *Compiled, string) {
+	idx, rule := Match(rules, obj.Key)
+	switch {
+	case rule == nil:
+		return -1, nil, SkipNoRule
+	case rule.Suspended:
+		return idx, rule, SkipSuspended
+	case obj.Size < int64(rule.MinSize):
+		return idx, rule, SkipMinSize
+	}
+	age := obj.LastModified
+	if rule.Layout != nil {
+		_, t, ok := rule.PartitionOf(obj.Key)
+		if !ok {
+			return idx, rule, SkipNoPartition
+		}
+		age = t
+	}
+	switch {
+	case age.After(rule.Threshold):
+		return idx, rule, SkipMaxAge
+	case obj.LastModified.After(rule.Floor):
+		return idx, rule, SkipMinObjectAge
+	}
+	return idx, rule, ""
+}
@@ -0,0 +1,209 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30", want: 30 * Day},
+		{in: " 7 ", want: 7 * Day},
+		{in: "30d", want: 30 * Day},
+		{in: "2w", want: 14 * Day},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "90m", want: 90 * time.Minute},
+		{in: "45s", want: 45 * time.Second},
+		{in: "1d12h", want: 36 * time.Hour},
+		{in: "1.5d", want: 36 * time.Hour},
+		{in: "", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "30x", wantErr: true},
+		{in: "30d junk", wantErr: true},
+		{in: "-3d", wantErr: true},
+		{in: "0", want: 0},
+		{in: "-1", wantErr: true},
+		{in: "106751", want: 106751 * Day},
+		// 超过约 292 年的取值溢出 time.Duration，在相乘之前拒绝
+		{in: "106752", wantErr: true},
+		{in: "110000d", wantErr: true},
+		{in: "300000w", wantErr: true},
+		{in: "106751d106751d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRetention(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && time.Duration(got) != tt.want {
+			t.Errorf("parseRetention(%q) = %v, want %v", tt.in, time.Duration(got), tt.want)
+		}
+	}
+}
+
+func TestRetentionString(t *testing.T) {
+	tests := []struct {
+		in   Retention
+		want string
+	}{
+		{in: Retention(30 * Day), want: "30d"},
+		{in: Retention(36 * time.Hour), want: "36h0m0s"},
+		{in: Retention(90 * time.Minute), want: "1h30m0s"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Retention(%v).String() = %q, want %q", time.Duration(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestRetentionBefore(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-03-10 为美国夏令时开始的日期，当天只有 23 小时
+	now := time.Date(2024, 3, 11, 12, 0, 0, 0, ny)
+	tests := []struct {
+		r    Retention
+		want time.Time
+	}{
+		{r: Retention(Day), want: time.Date(2024, 3, 10, 12, 0, 0, 0, ny)},
+		{r: Retention(2 * Day), want: time.Date(2024, 3, 9, 12, 0, 0, 0, ny)},
+		{r: Retention(30 * Day), want: time.Date(2024, 2, 10, 12, 0, 0, 0, ny)},
+		{r: Retention(36 * time.Hour), want: now.Add(-36 * time.Hour)},
+		{r: Retention(90 * time.Minute), want: time.Date(2024, 3, 11, 10, 30, 0, 0, ny)},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Before(now); !got.Equal(tt.want) {
+			t.Errorf("Retention(%v).before(%v) = %v, want %v", tt.r, now, got, tt.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "1024", want: 1024},
+		{in: "0", want: 0},
+		{in: "64k", want: 64 << 10},
+		{in: "500MB", want: 500 << 20},
+		{in: "500mib", want: 500 << 20},
+		{in: "1.5GiB", want: 3 << 29},
+		{in: "2 TB", want: 2 << 40},
+		{in: "1p", want: 1 << 50},
+		{in: "10b", want: 10},
+		{in: "", wantErr: true},
+		{in: "MB", wantErr: true},
+		{in: "5XB", wantErr: true},
+		{in: "1.5", want: 2},
+		{in: "-1MB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseByteSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && int64(got) != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSelectRule(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	rules := Compile([]Rule{
+		{Name: "logs-big", Prefix: "logs/big/", MaxAge: Retention(Day)},
+		{Name: "logs", Prefix: "logs/", MaxAge: Retention(30 * Day), MinSize: ByteSize(100)},
+		{Name: "audit", Prefix: "audit/", MaxAge: Retention(Day), SuspendUntil: "2024-06-30"},
+		{Name: "events", Prefix: "events/", MaxAge: Retention(7 * Day), Partition: "dt=2006-01-02"},
+		{Name: "tmp", Prefix: "tmp/", MaxAge: Retention(time.Minute)},
+	}, now, Retention(time.Hour))
+
+	tests := []struct {
+		name       string
+		key        string
+		age        time.Duration
+		size       int64
+		wantRule   string
+		wantReason string
+	}{
+		{name: "no rule", key: "other/a", age: 365 * Day, size: 1000, wantReason: SkipNoRule},
+		{name: "expired", key: "logs/a", age: 31 * Day, size: 1000, wantRule: "logs"},
+		{name: "too new", key: "logs/a", age: 29 * Day, size: 1000, wantRule: "logs", wantReason: SkipMaxAge},
+		{name: "exactly at threshold", key: "logs/a", age: 30 * Day, size: 1000, wantRule: "logs"},
+		{name: "too small", key: "logs/a", age: 31 * Day, size: 99, wantRule: "logs", wantReason: SkipMinSize},
+		{name: "first matching prefix wins", key: "logs/big/a", age: 2 * Day, size: 1, wantRule: "logs-big"},
+		{name: "suspended", key: "audit/a", age: 365 * Day, size: 1000, wantRule: "audit", wantReason: SkipSuspended},
+		{name: "partition date expired", key: "events/dt=2024-06-01/a.parquet", age: time.Hour + time.Minute, size: 1, wantRule: "events"},
+		{name: "partition date recent", key: "events/dt=2024-06-14/a.parquet", age: 365 * Day, size: 1, wantRule: "events", wantReason: SkipMaxAge},
+		{name: "not in a partition", key: "events/a.parquet", age: 365 * Day, size: 1, wantRule: "events", wantReason: SkipNoPartition},
+		{name: "partition date expired but object new", key: "events/dt=2024-06-01/a.parquet", age: time.Minute, size: 1, wantRule: "events", wantReason: SkipMinObjectAge},
+		{name: "newer than minObjectAge", key: "tmp/a", age: 30 * time.Minute, size: 1, wantRule: "tmp", wantReason: SkipMinObjectAge},
+		{name: "older than minObjectAge", key: "tmp/a", age: 2 * time.Hour, size: 1, wantRule: "tmp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rule, reason := Select(rules, ObjectInfo{Key: tt.key, LastModified: now.Add(-tt.age), Size: tt.size})
+			var name string
+			if rule != nil {
+				name = rule.Name
+			}
+			if name != tt.wantRule || reason != tt.wantReason {
+				t.Errorf("selectRule(%s) = %q, %q, want %q, %q", tt.key, name, reason, tt.wantRule, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCompileRulesSuspendUntil(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		until UntilDate
+		now   time.Time
+		want  bool
+	}{
+		// 只写日期时暂停到当天结束
+		{until: "2024-06-30", now: time.Date(2024, 6, 30, 23, 59, 0, 0, shanghai), want: true},
+		{until: "2024-06-30", now: time.Date(2024, 7, 1, 0, 0, 0, 0, shanghai), want: false},
+		{until: "2024-06-30T12:00:00+08:00", now: time.Date(2024, 6, 30, 11, 0, 0, 0, shanghai), want: true},
+		{until: "2024-06-30T12:00:00+08:00", now: time.Date(2024, 6, 30, 13, 0, 0, 0, shanghai), want: false},
+	}
+	for _, tt := range tests {
+		rules := Compile([]Rule{{MaxAge: Retention(Day), SuspendUntil: tt.until}}, tt.now, 0)
+		if got := rules[0].Suspended; got != tt.want {
+			t.Errorf("suspendUntil %s at %v: suspended = %v, want %v", tt.until, tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "valid", rule: Rule{MaxAge: Retention(Day)}},
+		{name: "zero maxAge", rule: Rule{}, wantErr: true},
+		{name: "invalid partition", rule: Rule{MaxAge: Retention(Day), Partition: "dt"}, wantErr: true},
+		{name: "partition without year", rule: Rule{MaxAge: Retention(Day), Partition: "dt=01-02"}, wantErr: true},
+		{name: "partition with groupDepth", rule: Rule{MaxAge: Retention(Day), Partition: "dt=2006-01-02", GroupDepth: 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := Check([]Rule{tt.rule}, ""); (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkRules() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
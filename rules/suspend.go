@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// SkipSuspended 为对象匹配的规则在 suspendUntil 之前暂停清理
+const SkipSuspended = "suspended"
+
+// UntilDate 为规则暂停清理的截止时间，可以写日期（如 2025-03-31，包括当天）或 RFC 3339 时间
+type UntilDate string
+
+// UnmarshalYAML 检查截止时间的格式，取值无效时返回 yaml.TypeError，与其他配置项的错误一起报告
+func (u *UntilDate) UnmarshalYAML(node *yaml.Node) error {
+	v := UntilDate(node.Value)
+	if !v.Valid() {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, tr("", msgBadUntil, node.Value))}}
+	}
+	*u = v
+	return nil
+}
+
+// Valid 返回截止时间是否为空、日期或 RFC 3339 时间
+func (u UntilDate) Valid() bool {
+	if u == "" {
+		return true
+	}
+	if _, err := time.Parse(time.DateOnly, string(u)); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, string(u))
+	return err == nil
+}
+
+// end 返回暂停结束的时间。只写日期时暂停到该日期结束，即次日零点，日期按 loc（cleanup.timezone）解析
+func (u UntilDate) end(loc *time.Location) time.Time {
+	if t, err := time.ParseInLocation(time.DateOnly, string(u), loc); err == nil {
+		return t.AddDate(0, 0, 1)
+	}
+	t, _ := time.Parse(time.RFC3339, string(u))
+	return t
+}
@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SkipTTL 为对象自带的过期时间还没有到
+const SkipTTL = "ttl"
+
+// TTLConfig 为对象自带的过期时间：生产者在对象的标签或用户元数据中写入保留时间（如 90d，从修改时间起算）
+// 或过期时间（如 2025-07-01），配置后优先于规则的 maxAge，没有该标签和元数据的对象仍按 maxAge 判断
+type TTLConfig struct {
+	Tag      string `yaml:"tag"`      // 保存过期时间的对象标签名，如 ttl
+	Metadata string `yaml:"metadata"` // 保存过期时间的用户元数据名，不含 x-amz-meta- 前缀，如 expires
+}
+
+// Enabled 返回是否从对象读取过期时间
+func (t *TTLConfig) Enabled() bool {
+	return t.Tag != "" || t.Metadata != ""
+}
+
+// HasTTL 返回是否有规则从对象读取过期时间
+func HasTTL(rules []*Compiled) bool {
+	return slices.ContainsFunc(rules, func(r *Compiled) bool { return r.TTL.Enabled() })
+}
+
+// ParseExpiry 解析对象的过期时间。值为保留时间时从对象的修改时间起算；为日期时按 loc（cleanup.timezone）解析，
+// 当天零点过期；也可以写 RFC 3339 格式的时间。为 0、负数或过长（溢出）的保留时间无效，对象因此保留，
+// 而不是在写入时即已过期。错误信息使用语言 lang
+func ParseExpiry(value string, lastModified time.Time, loc *time.Location, lang string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if r, err := ParseRetention(value); err == nil {
+		d := time.Duration(r)
+		if d <= 0 {
+			return time.Time{}, errors.New(tr(lang, msgTTLInvalid, value))
+		}
+		if d%Day == 0 {
+			return lastModified.In(loc).AddDate(0, 0, int(d/Day)), nil
+		}
+		return lastModified.Add(d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New(tr(lang, msgTTLInvalid, value))
+}
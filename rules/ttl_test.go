@@ -0,0 +1,47 @@
+package rules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpiry(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 上海时间 2024-01-31 18:00
+	modified := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "90d", want: time.Date(2024, 4, 30, 18, 0, 0, 0, shanghai)},
+		{value: "1d", want: time.Date(2024, 2, 1, 18, 0, 0, 0, shanghai)},
+		{value: "30", want: time.Date(2024, 3, 1, 18, 0, 0, 0, shanghai)},
+		{value: "12h", want: modified.Add(12 * time.Hour)},
+		{value: " 2w ", want: time.Date(2024, 2, 14, 18, 0, 0, 0, shanghai)},
+		{value: "2025-07-01", want: time.Date(2025, 7, 1, 0, 0, 0, 0, shanghai)},
+		{value: "2025-07-01T08:00:00Z", want: time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)},
+		{value: "", wantErr: true},
+		{value: "soon", wantErr: true},
+		// 为 0、负数或溢出的保留时间无效，对象保留，而不是立即过期或过期时间回绕到过去
+		{value: "0", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "110000d", wantErr: true},
+		{value: "300000w", wantErr: true},
+		{value: "2025/07/01", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExpiry(tt.value, modified, shanghai, "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseExpiry(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parseExpiry(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}